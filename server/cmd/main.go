@@ -1,15 +1,22 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"tamis-server/internal/api"
 	"tamis-server/internal/config"
 	"tamis-server/internal/database"
 	"tamis-server/internal/middleware"
+	"tamis-server/internal/models"
 	"tamis-server/internal/repository"
 	"tamis-server/internal/services"
 	"tamis-server/internal/utils"
+	"time"
 )
 
 func main() {
@@ -47,12 +54,46 @@ func main() {
 	userRepo := repository.NewUserRepository(db)
 	accountRepo := repository.NewAccountRepository(db)
 	emailRepo := repository.NewEmailRepository(db)
+	syncStateRepo := repository.NewSyncStateRepository(db)
+	backfillRepo := repository.NewBackfillRepository(db)
+	settingsRepo := repository.NewSettingsRepository(db)
+	jobRepo := repository.NewJobRepository(db)
+	outboxRepo := repository.NewOutboxRepository(db)
+	unsubscribeRepo := repository.NewUnsubscribeRepository(db)
+	savedSearchRepo := repository.NewSavedSearchRepository(db)
+	actionBatchRepo := repository.NewActionBatchRepository(db)
+	cleanupRuleRepo := repository.NewCleanupRuleRepository(db)
+	retentionRepo := repository.NewRetentionRepository(db)
+	classifierRepo := repository.NewClassifierRepository(db)
 
 	// Initialiser les services avec sécurité renforcée
+	eventBroker := services.NewEventBroker()
 	authService := services.NewAuthService(userRepo, logger, cfg.JWT.Secret)
-	accountService := services.NewAccountService(accountRepo, logger, cfg.Encryption.Key)
-	mailService := services.NewMailService(emailRepo, accountService, logger)
+	accountService := services.NewAccountService(accountRepo, eventBroker, logger, cfg.Encryption.Key)
 	oauth2Service := utils.NewOAuth2Service(cfg, logger)
+	tokenManager := services.NewTokenManager(accountService, oauth2Service, logger)
+	settingsService := services.NewSettingsService(settingsRepo, accountService, cfg.Scheduler, logger)
+	mailService := services.NewMailService(emailRepo, syncStateRepo, outboxRepo, actionBatchRepo, accountService, settingsService, tokenManager, services.NewActionConfirmer(cfg.JWT.Secret), eventBroker, logger)
+	backfillService := services.NewBackfillService(backfillRepo, mailService, accountService, logger)
+	mailService.OnProviderIDsReset(backfillService.RestartBackfill)
+	duplicateService := services.NewDuplicateService(emailRepo, accountService, settingsService, mailService, logger)
+	unsubscribeService := services.NewUnsubscribeService(unsubscribeRepo, emailRepo, mailService, accountService, logger)
+	mailService.OnNewEmails(unsubscribeService.ArchiveFromUnsubscribedLists)
+	senderService := services.NewSenderService(emailRepo, accountService, mailService, logger)
+	jobService := services.NewJobService(jobRepo, logger)
+	jobService.RegisterHandler(models.JobTypeSync, mailService.RunSyncJob)
+	classifierService := services.NewClassifierService(classifierRepo, emailRepo, accountService, jobService, logger)
+	jobService.RegisterHandler(models.JobTypeClassifier, classifierService.RunRetrainJob)
+	mailService.OnEmailAction(classifierService.Learn)
+	mailService.OnNewEmails(classifierService.ScoreNewEmails)
+	bulkActionService := services.NewBulkActionService(savedSearchRepo, emailRepo, accountService, mailService, jobService, eventBroker, logger)
+	jobService.RegisterHandler(models.JobTypeBulkAction, bulkActionService.RunBulkActionJob)
+	ruleService := services.NewRuleService(cleanupRuleRepo, emailRepo, accountService, mailService, jobService, eventBroker, logger)
+	jobService.RegisterHandler(models.JobTypeRules, ruleService.RunRulesJob)
+	mailService.OnNewEmails(ruleService.ApplyToNewEmails)
+	outboxService := services.NewOutboxService(outboxRepo, mailService, eventBroker, logger)
+	trashService := services.NewTrashService(emailRepo, actionBatchRepo, outboxRepo, accountService, settingsService, mailService, logger)
+	retentionService := services.NewRetentionService(retentionRepo, emailRepo, accountService, mailService, eventBroker, logger)
 
 	// Initialiser les middlewares
 	authMiddleware := middleware.NewAuthMiddleware(userRepo, authService, logger)
@@ -61,7 +102,7 @@ func main() {
 	mux := http.NewServeMux()
 
 	// Enregistrer toutes les routes avec les nouveaux services
-	api.RegisterRoutes(mux, cfg, logger, authService, authMiddleware, accountService, mailService, oauth2Service)
+	api.RegisterRoutes(mux, cfg, logger, authService, authMiddleware, accountService, mailService, duplicateService, unsubscribeService, senderService, bulkActionService, backfillService, settingsService, jobService, outboxService, trashService, ruleService, retentionService, classifierService, eventBroker, oauth2Service)
 
 	// Démarrer le serveur
 	addr := ":" + cfg.Server.Port
@@ -74,8 +115,61 @@ func main() {
 		fmt.Fprintf(w, "<h1>Bienvenue sur Tamis API 🧹🚀</h1><p>Le serveur tourne sur le port %s</p><ul><li>Auth: /api/auth/</li><li>Accounts: /api/accounts/</li><li>Mails: /api/mails/</li></ul>", cfg.Server.Port)
 	})
 
-	err = http.ListenAndServe(addr, mux)
-	if err != nil {
-		logger.Fatal(fmt.Sprintf("Server failed to start: %v", err))
+	// Arrêt propre sur SIGINT / SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Jobs asynchrones (les jobs interrompus par un redémarrage sont repris)
+	if err := jobService.Start(ctx); err != nil {
+		logger.Fatal(fmt.Sprintf("Failed to start job workers: %v", err))
+	}
+
+	// Actions provider différées (réessais avec backoff)
+	if err := outboxService.Start(ctx); err != nil {
+		logger.Fatal(fmt.Sprintf("Failed to start outbox worker: %v", err))
+	}
+
+	// Reprendre les imports complets interrompus par un redémarrage
+	if err := backfillService.Start(ctx); err != nil {
+		logger.Error(fmt.Sprintf("Failed to resume backfills: %v", err))
+	}
+
+	// Purge automatique de la corbeille Tamis
+	trashService.Start(ctx)
+
+	// Politiques de rétention (exécution périodique)
+	retentionService.Start(ctx)
+
+	// Synchronisation automatique en arrière-plan
+	scheduler := services.NewSyncScheduler(mailService, accountService, settingsRepo, cfg.Scheduler, logger)
+	if cfg.Scheduler.Enabled {
+		scheduler.Start(ctx)
+	}
+
+	server := &http.Server{Addr: addr, Handler: mux}
+	// Shutdown n'interrompt pas les requêtes longues : fermer les flux SSE pour qu'ils se terminent
+	server.RegisterOnShutdown(eventBroker.Close)
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Fatal(fmt.Sprintf("Server failed to start: %v", err))
+		}
+	}()
+
+	<-ctx.Done()
+	logger.Info("Shutting down Tamis Server...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Error(fmt.Sprintf("Server shutdown failed: %v", err))
 	}
+	scheduler.Stop()
+	jobService.Stop()
+	outboxService.Stop()
+	trashService.Stop()
+	retentionService.Stop()
+	backfillService.Stop()
+
+	logger.Info("Server stopped")
 }
@@ -124,6 +124,58 @@ func DeleteAccountHandler(accountService *services.AccountService, logger *utils
 	}
 }
 
+// BackfillHandler - Lancer (POST) ou suivre (GET) l'import complet d'un compte
+func BackfillHandler(backfillService *services.BackfillService, logger *utils.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodPost {
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+		if !ok || user == nil {
+			utils.WriteError(w, http.StatusUnauthorized, "User not found")
+			return
+		}
+
+		accountIDStr := r.URL.Query().Get("account_id")
+		if accountIDStr == "" {
+			utils.WriteError(w, http.StatusBadRequest, "Account ID is required")
+			return
+		}
+
+		accountID, err := strconv.Atoi(accountIDStr)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "Invalid account ID")
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			job, err := backfillService.GetBackfillStatus(user.ID, accountID)
+			if err != nil {
+				utils.WriteError(w, http.StatusNotFound, err.Error())
+				return
+			}
+
+			utils.WriteSuccess(w, job, "Backfill status retrieved successfully")
+			return
+		}
+
+		// Paramètre optionnel : restart=true pour repartir de zéro
+		restart := r.URL.Query().Get("restart") == "true"
+
+		job, err := backfillService.StartBackfill(user.ID, accountID, restart)
+		if err != nil {
+			logger.Error("Failed to start backfill for account " + accountIDStr + ": " + err.Error())
+			utils.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		logger.Info("Backfill started for account " + accountIDStr + " by user " + strconv.Itoa(user.ID))
+		utils.WriteSuccess(w, job, "Backfill started successfully")
+	}
+}
+
 // isValidProvider - Vérifier si le provider est supporté
 func isValidProvider(provider models.EmailProvider) bool {
 	validProviders := []models.EmailProvider{
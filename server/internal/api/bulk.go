@@ -0,0 +1,131 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"tamis-server/internal/middleware"
+	"tamis-server/internal/models"
+	"tamis-server/internal/services"
+	"tamis-server/internal/utils"
+)
+
+// BulkActionHandler - Action sur tous les emails d'un filtre : aperçu (preview) ou lancement du job
+func BulkActionHandler(bulkActionService *services.BulkActionService, logger *utils.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+		if !ok || user == nil {
+			utils.WriteError(w, http.StatusUnauthorized, "User not found")
+			return
+		}
+
+		var req models.BulkActionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "Invalid JSON format")
+			return
+		}
+
+		if req.Action == "" {
+			utils.WriteError(w, http.StatusBadRequest, "Action is required")
+			return
+		}
+
+		if req.Preview {
+			preview, err := bulkActionService.Preview(user.ID, &req)
+			if err != nil {
+				utils.WriteError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			utils.WriteSuccess(w, preview, "Bulk action preview computed")
+			return
+		}
+
+		job, err := bulkActionService.Start(user.ID, &req)
+		if err != nil {
+			if errors.Is(err, services.ErrBulkActionRunning) {
+				utils.WriteError(w, http.StatusConflict, err.Error()+" (job "+job.ID+")")
+				return
+			}
+			logger.Error("Failed to start bulk action for user " + strconv.Itoa(user.ID) + ": " + err.Error())
+			utils.WriteError(w, actionErrorStatus(err), err.Error())
+			return
+		}
+
+		logger.Info("Bulk action job " + job.ID + " queued for user " + strconv.Itoa(user.ID))
+		utils.WriteSuccess(w, job, "Bulk action started")
+	}
+}
+
+// SavedSearchesHandler - Lister (GET) ou enregistrer (POST) les recherches de l'utilisateur
+func SavedSearchesHandler(bulkActionService *services.BulkActionService, logger *utils.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+		if !ok || user == nil {
+			utils.WriteError(w, http.StatusUnauthorized, "User not found")
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			searches, err := bulkActionService.GetSavedSearches(user.ID)
+			if err != nil {
+				logger.Error("Failed to get saved searches for user " + strconv.Itoa(user.ID) + ": " + err.Error())
+				utils.WriteError(w, http.StatusInternalServerError, "Failed to retrieve saved searches")
+				return
+			}
+			utils.WriteSuccess(w, searches, "Saved searches retrieved successfully")
+
+		case http.MethodPost:
+			var req models.SavedSearchRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				utils.WriteError(w, http.StatusBadRequest, "Invalid JSON format")
+				return
+			}
+
+			search, err := bulkActionService.SaveSearch(user.ID, &req)
+			if err != nil {
+				utils.WriteError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			utils.WriteSuccess(w, search, "Search saved successfully")
+
+		default:
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	}
+}
+
+// DeleteSavedSearchHandler - Supprimer une recherche enregistrée
+func DeleteSavedSearchHandler(bulkActionService *services.BulkActionService, logger *utils.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+		if !ok || user == nil {
+			utils.WriteError(w, http.StatusUnauthorized, "User not found")
+			return
+		}
+
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "Invalid saved search ID")
+			return
+		}
+
+		if err := bulkActionService.DeleteSearch(user.ID, id); err != nil {
+			utils.WriteError(w, http.StatusNotFound, err.Error())
+			return
+		}
+
+		utils.WriteSuccess(w, nil, "Saved search deleted successfully")
+	}
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"tamis-server/internal/middleware"
+	"tamis-server/internal/models"
+	"tamis-server/internal/services"
+	"tamis-server/internal/utils"
+)
+
+// ClassifierHandler - État du classifieur (GET) ou oubli de tout l'apprentissage (DELETE)
+func ClassifierHandler(classifierService *services.ClassifierService, logger *utils.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+		if !ok || user == nil {
+			utils.WriteError(w, http.StatusUnauthorized, "User not found")
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			stats, err := classifierService.GetStats(user.ID)
+			if err != nil {
+				logger.Error("Failed to get classifier stats for user " + strconv.Itoa(user.ID) + ": " + err.Error())
+				utils.WriteError(w, http.StatusInternalServerError, "Failed to retrieve classifier state")
+				return
+			}
+			utils.WriteSuccess(w, stats, "Classifier state retrieved successfully")
+
+		case http.MethodDelete:
+			if err := classifierService.Reset(user.ID); err != nil {
+				logger.Error("Failed to reset classifier for user " + strconv.Itoa(user.ID) + ": " + err.Error())
+				utils.WriteError(w, http.StatusInternalServerError, "Failed to reset classifier")
+				return
+			}
+			utils.WriteSuccess(w, nil, "Classifier reset successfully")
+
+		default:
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	}
+}
+
+// RetrainClassifierHandler - Reconstruire le modèle et réévaluer tous les emails (job en arrière-plan)
+func RetrainClassifierHandler(classifierService *services.ClassifierService, logger *utils.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+		if !ok || user == nil {
+			utils.WriteError(w, http.StatusUnauthorized, "User not found")
+			return
+		}
+
+		job, err := classifierService.Retrain(user.ID)
+		if err != nil {
+			logger.Error("Failed to enqueue classifier retrain for user " + strconv.Itoa(user.ID) + ": " + err.Error())
+			utils.WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		utils.WriteSuccess(w, job, "Classifier retrain started")
+	}
+}
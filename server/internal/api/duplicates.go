@@ -0,0 +1,102 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"tamis-server/internal/middleware"
+	"tamis-server/internal/models"
+	"tamis-server/internal/services"
+	"tamis-server/internal/utils"
+)
+
+// DuplicatesHandler - Lister les doublons de tous les comptes (?primary_account_id=&keep=oldest|newest)
+func DuplicatesHandler(duplicateService *services.DuplicateService, logger *utils.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+		if !ok || user == nil {
+			utils.WriteError(w, http.StatusUnauthorized, "User not found")
+			return
+		}
+
+		var primaryAccountID *int
+		if param := r.URL.Query().Get("primary_account_id"); param != "" {
+			id, err := strconv.Atoi(param)
+			if err != nil || id < 0 {
+				utils.WriteError(w, http.StatusBadRequest, "Invalid primary_account_id")
+				return
+			}
+			primaryAccountID = &id
+		}
+
+		var keep *models.DuplicateKeepStrategy
+		if param := r.URL.Query().Get("keep"); param != "" {
+			strategy := models.DuplicateKeepStrategy(param)
+			if !strategy.IsValid() {
+				utils.WriteError(w, http.StatusBadRequest, "Invalid keep strategy")
+				return
+			}
+			keep = &strategy
+		}
+
+		report, err := duplicateService.FindDuplicates(user.ID, primaryAccountID, keep)
+		if err != nil {
+			logger.Error("Failed to find duplicates for user " + strconv.Itoa(user.ID) + ": " + err.Error())
+			utils.WriteError(w, http.StatusInternalServerError, "Failed to find duplicates")
+			return
+		}
+
+		utils.WriteSuccess(w, report, "Duplicates retrieved successfully")
+	}
+}
+
+// DeleteDuplicatesHandler - Supprimer les doublons en conservant une copie par groupe
+func DeleteDuplicatesHandler(duplicateService *services.DuplicateService, logger *utils.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+		if !ok || user == nil {
+			utils.WriteError(w, http.StatusUnauthorized, "User not found")
+			return
+		}
+
+		var req models.DeleteDuplicatesRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "Invalid JSON format")
+			return
+		}
+
+		if req.Keep != nil && !req.Keep.IsValid() {
+			utils.WriteError(w, http.StatusBadRequest, "Invalid keep strategy")
+			return
+		}
+
+		if req.DryRun {
+			preview, err := duplicateService.PreviewDuplicateDeletion(user.ID, &req)
+			if err != nil {
+				utils.WriteError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			utils.WriteSuccess(w, preview, "Duplicate deletion preview computed")
+			return
+		}
+
+		result, err := duplicateService.DeleteDuplicates(user.ID, &req)
+		if err != nil {
+			logger.Error("Failed to delete duplicates for user " + strconv.Itoa(user.ID) + ": " + err.Error())
+			utils.WriteError(w, actionErrorStatus(err), err.Error())
+			return
+		}
+
+		utils.WriteSuccess(w, result, "Duplicates deleted successfully")
+	}
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"tamis-server/internal/middleware"
+	"tamis-server/internal/models"
+	"tamis-server/internal/services"
+	"tamis-server/internal/utils"
+	"time"
+)
+
+// eventsHeartbeat - Commentaire envoyé périodiquement pour garder la connexion ouverte derrière les proxies
+const eventsHeartbeat = 25 * time.Second
+
+// EventsHandler - Flux Server-Sent Events des événements de l'utilisateur
+func EventsHandler(eventBroker *services.EventBroker, logger *utils.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+		if !ok || user == nil {
+			utils.WriteError(w, http.StatusUnauthorized, "User not found")
+			return
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			utils.WriteError(w, http.StatusInternalServerError, "Streaming not supported")
+			return
+		}
+
+		// Reprise après reconnexion : en-tête standard, ou paramètre pour les clients qui ne peuvent pas le définir
+		lastEventID := r.Header.Get("Last-Event-ID")
+		if lastEventID == "" {
+			lastEventID = r.URL.Query().Get("last_event_id")
+		}
+
+		events, missed, unsubscribe := eventBroker.Subscribe(user.ID, lastEventID)
+		defer unsubscribe()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		fmt.Fprint(w, "retry: 5000\n\n")
+		for _, event := range missed {
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+
+		logger.Info("Event stream opened for user " + strconv.Itoa(user.ID))
+
+		heartbeat := time.NewTicker(eventsHeartbeat)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				logger.Info("Event stream closed for user " + strconv.Itoa(user.ID))
+				return
+			case event, open := <-events:
+				if !open {
+					// Connexion jugée trop lente par le broker : le client se reconnecte avec Last-Event-ID
+					return
+				}
+				if err := writeEvent(w, event); err != nil {
+					return
+				}
+				flusher.Flush()
+			case <-heartbeat.C:
+				if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+					return
+				}
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+// writeEvent - Écrire un événement au format SSE
+func writeEvent(w http.ResponseWriter, event *models.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"net/http"
+	"tamis-server/internal/middleware"
+	"tamis-server/internal/models"
+	"tamis-server/internal/services"
+	"tamis-server/internal/utils"
+)
+
+// GetJobHandler - Consulter l'état et la progression d'un job
+func GetJobHandler(jobService *services.JobService, logger *utils.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+		if !ok || user == nil {
+			utils.WriteError(w, http.StatusUnauthorized, "User not found")
+			return
+		}
+
+		jobID := r.PathValue("id")
+		if jobID == "" {
+			utils.WriteError(w, http.StatusBadRequest, "Job ID is required")
+			return
+		}
+
+		job, err := jobService.GetJob(user.ID, jobID)
+		if err != nil {
+			utils.WriteError(w, http.StatusNotFound, "Job not found")
+			return
+		}
+
+		utils.WriteSuccess(w, job, "Job retrieved successfully")
+	}
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"tamis-server/internal/middleware"
@@ -75,11 +76,22 @@ func MailActionHandler(mailService *services.MailService, logger *utils.Logger)
 			return
 		}
 
+		// Aperçu : emails concernés et jeton de confirmation
+		if req.DryRun {
+			preview, err := mailService.PreviewEmailAction(user.ID, &req)
+			if err != nil {
+				utils.WriteError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			utils.WriteSuccess(w, preview, "Action preview computed")
+			return
+		}
+
 		// Exécuter l'action
 		result, err := mailService.ExecuteEmailAction(user.ID, &req)
 		if err != nil {
 			logger.Error("Failed to execute mail action for user " + strconv.Itoa(user.ID) + ": " + err.Error())
-			utils.WriteError(w, http.StatusBadRequest, err.Error())
+			utils.WriteError(w, actionErrorStatus(err), err.Error())
 			return
 		}
 
@@ -88,8 +100,8 @@ func MailActionHandler(mailService *services.MailService, logger *utils.Logger)
 	}
 }
 
-// syncMailsHandler - Synchroniser les mails depuis les serveurs (force refresh)
-func SyncMailsHandler(mailService *services.MailService, logger *utils.Logger) http.HandlerFunc {
+// syncMailsHandler - Lancer une synchronisation en arrière-plan (force refresh) et renvoyer le job
+func SyncMailsHandler(jobService *services.JobService, logger *utils.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
@@ -105,15 +117,28 @@ func SyncMailsHandler(mailService *services.MailService, logger *utils.Logger) h
 		// Paramètre optionnel pour forcer la synchronisation complète
 		forceSync := r.URL.Query().Get("force") == "true"
 
-		result, err := mailService.SyncUserEmails(user.ID, forceSync)
+		job, err := jobService.Enqueue(user.ID, models.JobTypeSync, &models.SyncJobParams{Force: forceSync})
 		if err != nil {
-			logger.Error("Failed to sync emails for user " + strconv.Itoa(user.ID) + ": " + err.Error())
+			logger.Error("Failed to enqueue sync for user " + strconv.Itoa(user.ID) + ": " + err.Error())
 			utils.WriteError(w, http.StatusInternalServerError, err.Error())
 			return
 		}
 
-		logger.Info("Email sync completed for user " + strconv.Itoa(user.ID) + " - Synced: " + strconv.Itoa(result.SyncedCount))
-		utils.WriteSuccess(w, result, "Email synchronization completed")
+		// Enqueue renvoie le job déjà actif : une synchronisation simple ne remplace pas une synchronisation forcée
+		var active models.SyncJobParams
+		if len(job.Params) > 0 {
+			if err := json.Unmarshal(job.Params, &active); err != nil {
+				utils.WriteError(w, http.StatusInternalServerError, "invalid sync job params: "+err.Error())
+				return
+			}
+		}
+		if forceSync && !active.Force {
+			utils.WriteError(w, http.StatusConflict, "a sync without force is already running (job "+job.ID+")")
+			return
+		}
+
+		logger.Info("Email sync job " + job.ID + " queued for user " + strconv.Itoa(user.ID))
+		utils.WriteSuccess(w, job, "Email synchronization started")
 	}
 }
 
@@ -126,6 +151,18 @@ func buildEmailFilter(r *http.Request) *models.EmailFilter {
 		filter.Provider = models.EmailProvider(provider)
 	}
 
+	// Compte
+	if accountIDStr := r.URL.Query().Get("account_id"); accountIDStr != "" {
+		if accountID, err := strconv.Atoi(accountIDStr); err == nil && accountID > 0 {
+			filter.AccountID = accountID
+		}
+	}
+
+	// Catégorie (newsletter, notification, personal)
+	if category := r.URL.Query().Get("category"); category != "" {
+		filter.Category = models.EmailCategory(category)
+	}
+
 	// From
 	filter.From = r.URL.Query().Get("from")
 
@@ -144,6 +181,18 @@ func buildEmailFilter(r *http.Request) *models.EmailFilter {
 		filter.IsSpam = &isSpam
 	}
 
+	// Score indésirable du classifieur (0 à 1)
+	if minStr := r.URL.Query().Get("min_junk_score"); minStr != "" {
+		if minScore, err := strconv.ParseFloat(minStr, 64); err == nil {
+			filter.MinJunkScore = &minScore
+		}
+	}
+	if maxStr := r.URL.Query().Get("max_junk_score"); maxStr != "" {
+		if maxScore, err := strconv.ParseFloat(maxStr, 64); err == nil {
+			filter.MaxJunkScore = &maxScore
+		}
+	}
+
 	// Pagination
 	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
 		if limit, err := strconv.Atoi(limitStr); err == nil && limit > 0 {
@@ -162,3 +211,17 @@ func buildEmailFilter(r *http.Request) *models.EmailFilter {
 
 	return filter
 }
+
+// actionErrorStatus - Code HTTP d'une action refusée (jeton de confirmation absent ou invalide)
+func actionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrConfirmationRequired):
+		return http.StatusPreconditionRequired
+	case errors.Is(err, services.ErrConfirmationInvalid),
+		errors.Is(err, services.ErrConfirmationExpired),
+		errors.Is(err, services.ErrConfirmationMismatch):
+		return http.StatusPreconditionFailed
+	default:
+		return http.StatusBadRequest
+	}
+}
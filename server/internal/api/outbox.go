@@ -0,0 +1,94 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"tamis-server/internal/middleware"
+	"tamis-server/internal/models"
+	"tamis-server/internal/services"
+	"tamis-server/internal/utils"
+)
+
+// ListOutboxHandler - Lister les actions provider en attente ou en échec (?status=pending,failed)
+func ListOutboxHandler(outboxService *services.OutboxService, logger *utils.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+		if !ok || user == nil {
+			utils.WriteError(w, http.StatusUnauthorized, "User not found")
+			return
+		}
+
+		var statuses []models.OutboxStatus
+		if param := r.URL.Query().Get("status"); param != "" {
+			for _, status := range strings.Split(param, ",") {
+				statuses = append(statuses, models.OutboxStatus(strings.TrimSpace(status)))
+			}
+		}
+
+		ops, err := outboxService.ListOperations(user.ID, statuses)
+		if err != nil {
+			if strings.HasPrefix(err.Error(), "invalid outbox status") {
+				utils.WriteError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			logger.Error("Failed to list outbox for user " + strconv.Itoa(user.ID) + ": " + err.Error())
+			utils.WriteError(w, http.StatusInternalServerError, "Failed to retrieve outbox")
+			return
+		}
+
+		utils.WriteSuccess(w, ops, "Outbox retrieved successfully")
+	}
+}
+
+// RetryOutboxHandler - Relancer des actions en échec ou annulées
+func RetryOutboxHandler(outboxService *services.OutboxService, logger *utils.Logger) http.HandlerFunc {
+	return outboxOperationsHandler(logger, "retried", outboxService.RetryOperations)
+}
+
+// CancelOutboxHandler - Annuler des actions en attente ou en échec
+func CancelOutboxHandler(outboxService *services.OutboxService, logger *utils.Logger) http.HandlerFunc {
+	return outboxOperationsHandler(logger, "cancelled", outboxService.CancelOperations)
+}
+
+// outboxOperationsHandler - Appliquer une opération de gestion à une sélection d'actions de l'outbox
+func outboxOperationsHandler(logger *utils.Logger, verb string, apply func(userID int, ids []int64) (int, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+		if !ok || user == nil {
+			utils.WriteError(w, http.StatusUnauthorized, "User not found")
+			return
+		}
+
+		var req models.OutboxOperationsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "Invalid JSON format")
+			return
+		}
+
+		if len(req.IDs) == 0 {
+			utils.WriteError(w, http.StatusBadRequest, "At least one operation ID is required")
+			return
+		}
+
+		count, err := apply(user.ID, req.IDs)
+		if err != nil {
+			logger.Error("Failed to update outbox for user " + strconv.Itoa(user.ID) + ": " + err.Error())
+			utils.WriteError(w, http.StatusInternalServerError, "Failed to update outbox")
+			return
+		}
+
+		utils.WriteSuccess(w, map[string]int{verb: count}, "Outbox operations "+verb)
+	}
+}
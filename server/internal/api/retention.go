@@ -0,0 +1,160 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"tamis-server/internal/middleware"
+	"tamis-server/internal/models"
+	"tamis-server/internal/services"
+	"tamis-server/internal/utils"
+)
+
+// RetentionPoliciesHandler - Lister (GET) ou créer (POST) les politiques de rétention de l'utilisateur
+func RetentionPoliciesHandler(retentionService *services.RetentionService, logger *utils.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+		if !ok || user == nil {
+			utils.WriteError(w, http.StatusUnauthorized, "User not found")
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			policies, err := retentionService.GetPolicies(user.ID)
+			if err != nil {
+				logger.Error("Failed to get retention policies for user " + strconv.Itoa(user.ID) + ": " + err.Error())
+				utils.WriteError(w, http.StatusInternalServerError, "Failed to retrieve retention policies")
+				return
+			}
+			utils.WriteSuccess(w, policies, "Retention policies retrieved successfully")
+
+		case http.MethodPost:
+			var req models.RetentionPolicyRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				utils.WriteError(w, http.StatusBadRequest, "Invalid JSON format")
+				return
+			}
+
+			policy, err := retentionService.CreatePolicy(user.ID, &req)
+			if err != nil {
+				utils.WriteError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			utils.WriteSuccess(w, policy, "Retention policy created successfully")
+
+		default:
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	}
+}
+
+// RetentionPolicyHandler - Remplacer (PUT) ou supprimer (DELETE) une politique de rétention
+func RetentionPolicyHandler(retentionService *services.RetentionService, logger *utils.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+		if !ok || user == nil {
+			utils.WriteError(w, http.StatusUnauthorized, "User not found")
+			return
+		}
+
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "Invalid retention policy ID")
+			return
+		}
+
+		switch r.Method {
+		case http.MethodPut:
+			var req models.RetentionPolicyRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				utils.WriteError(w, http.StatusBadRequest, "Invalid JSON format")
+				return
+			}
+
+			policy, err := retentionService.UpdatePolicy(user.ID, id, &req)
+			if err != nil {
+				status := http.StatusBadRequest
+				if strings.Contains(err.Error(), "not found") {
+					status = http.StatusNotFound
+				}
+				utils.WriteError(w, status, err.Error())
+				return
+			}
+			utils.WriteSuccess(w, policy, "Retention policy updated successfully")
+
+		case http.MethodDelete:
+			if err := retentionService.DeletePolicy(user.ID, id); err != nil {
+				utils.WriteError(w, http.StatusNotFound, err.Error())
+				return
+			}
+			utils.WriteSuccess(w, nil, "Retention policy deleted successfully")
+
+		default:
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	}
+}
+
+// RetentionRunsHandler - Dernières exécutions des politiques de rétention (?limit=)
+func RetentionRunsHandler(retentionService *services.RetentionService, logger *utils.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+		if !ok || user == nil {
+			utils.WriteError(w, http.StatusUnauthorized, "User not found")
+			return
+		}
+
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+		runs, err := retentionService.GetRuns(user.ID, limit)
+		if err != nil {
+			logger.Error("Failed to get retention runs for user " + strconv.Itoa(user.ID) + ": " + err.Error())
+			utils.WriteError(w, http.StatusInternalServerError, "Failed to retrieve retention runs")
+			return
+		}
+
+		utils.WriteSuccess(w, runs, "Retention runs retrieved successfully")
+	}
+}
+
+// RetentionRunHandler - Exécution d'une politique et journal des emails concernés
+func RetentionRunHandler(retentionService *services.RetentionService, logger *utils.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+		if !ok || user == nil {
+			utils.WriteError(w, http.StatusUnauthorized, "User not found")
+			return
+		}
+
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "Invalid retention run ID")
+			return
+		}
+
+		run, err := retentionService.GetRun(user.ID, id)
+		if err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				utils.WriteError(w, http.StatusNotFound, err.Error())
+				return
+			}
+			logger.Error("Failed to get retention run for user " + strconv.Itoa(user.ID) + ": " + err.Error())
+			utils.WriteError(w, http.StatusInternalServerError, "Failed to retrieve retention run")
+			return
+		}
+
+		utils.WriteSuccess(w, run, "Retention run retrieved successfully")
+	}
+}
@@ -19,22 +19,71 @@ func RegisterRoutes(
 	authMiddleware *middleware.AuthMiddleware,
 	accountService *services.AccountService,
 	mailService *services.MailService,
+	duplicateService *services.DuplicateService,
+	unsubscribeService *services.UnsubscribeService,
+	senderService *services.SenderService,
+	bulkActionService *services.BulkActionService,
+	backfillService *services.BackfillService,
+	settingsService *services.SettingsService,
+	jobService *services.JobService,
+	outboxService *services.OutboxService,
+	trashService *services.TrashService,
+	ruleService *services.RuleService,
+	retentionService *services.RetentionService,
+	classifierService *services.ClassifierService,
+	eventBroker *services.EventBroker,
 	oauth2Service *utils.OAuth2Service,
 ) {
 	// Routes d'authentification (publiques)
 	registerAuthRoutes(mux, authService, logger)
 
 	// Routes d'API générales
-	registerAPIRoutes(mux, cfg, logger, authMiddleware)
+	registerAPIRoutes(mux, cfg, logger, authMiddleware, settingsService)
 
 	// Routes de gestion des comptes email (protégées)
-	registerAccountRoutes(mux, authMiddleware, accountService, logger)
+	registerAccountRoutes(mux, authMiddleware, accountService, backfillService, logger)
 
 	// Routes OAuth2 (protégées)
 	registerOAuthRoutes(mux, authMiddleware, oauth2Service, accountService, logger)
 
 	// Routes de gestion des emails (protégées)
-	registerMailRoutes(mux, authMiddleware, mailService, logger)
+	registerMailRoutes(mux, authMiddleware, mailService, duplicateService, jobService, logger)
+
+	// Classement des expéditeurs et actions par expéditeur (protégées)
+	registerSenderRoutes(mux, authMiddleware, senderService, logger)
+
+	// Actions par filtre et recherches enregistrées (protégées)
+	registerBulkRoutes(mux, authMiddleware, bulkActionService, logger)
+
+	// Désinscription des listes de diffusion (protégées)
+	mux.Handle("/api/unsubscriptions",
+		authMiddleware.CORS(
+			authMiddleware.RequireAuth(http.HandlerFunc(UnsubscribeHandler(unsubscribeService, logger))),
+		))
+
+	// Suivi des jobs en arrière-plan (protégées)
+	registerJobRoutes(mux, authMiddleware, jobService, logger)
+
+	// Actions provider différées (protégées)
+	registerOutboxRoutes(mux, authMiddleware, outboxService, logger)
+
+	// Corbeille Tamis et annulation des actions (protégées)
+	registerTrashRoutes(mux, authMiddleware, trashService, logger)
+
+	// Règles de nettoyage (protégées)
+	registerRuleRoutes(mux, authMiddleware, ruleService, logger)
+
+	// Politiques de rétention (protégées)
+	registerRetentionRoutes(mux, authMiddleware, retentionService, logger)
+
+	// Classifieur d'emails indésirables (protégées)
+	registerClassifierRoutes(mux, authMiddleware, classifierService, logger)
+
+	// Événements temps réel (protégées)
+	mux.Handle("/api/events",
+		authMiddleware.CORS(
+			authMiddleware.RequireAuth(http.HandlerFunc(EventsHandler(eventBroker, logger))),
+		))
 }
 
 // registerAuthRoutes - Routes d'authentification
@@ -45,7 +94,7 @@ func registerAuthRoutes(mux *http.ServeMux, authService *services.AuthService, l
 }
 
 // registerAPIRoutes - Routes de l'API (protégées et publiques)
-func registerAPIRoutes(mux *http.ServeMux, cfg *config.Config, logger *utils.Logger, authMiddleware *middleware.AuthMiddleware) {
+func registerAPIRoutes(mux *http.ServeMux, cfg *config.Config, logger *utils.Logger, authMiddleware *middleware.AuthMiddleware, settingsService *services.SettingsService) {
 	// Route de santé (publique)
 	mux.HandleFunc("/api/health", corsMiddleware(healthHandler(cfg, logger)))
 
@@ -54,10 +103,16 @@ func registerAPIRoutes(mux *http.ServeMux, cfg *config.Config, logger *utils.Log
 		authMiddleware.CORS(
 			authMiddleware.RequireAuth(http.HandlerFunc(meHandler(logger))),
 		))
+
+	// Préférences utilisateur (intervalle de synchronisation automatique)
+	mux.Handle("/api/user/settings",
+		authMiddleware.CORS(
+			authMiddleware.RequireAuth(http.HandlerFunc(SettingsHandler(settingsService, logger))),
+		))
 }
 
 // registerAccountRoutes - Routes de gestion des comptes email
-func registerAccountRoutes(mux *http.ServeMux, authMiddleware *middleware.AuthMiddleware, accountService *services.AccountService, logger *utils.Logger) {
+func registerAccountRoutes(mux *http.ServeMux, authMiddleware *middleware.AuthMiddleware, accountService *services.AccountService, backfillService *services.BackfillService, logger *utils.Logger) {
 	// Ajouter un compte email
 	mux.Handle("/api/accounts/add",
 		authMiddleware.CORS(
@@ -75,6 +130,12 @@ func registerAccountRoutes(mux *http.ServeMux, authMiddleware *middleware.AuthMi
 		authMiddleware.CORS(
 			authMiddleware.RequireAuth(http.HandlerFunc(DeleteAccountHandler(accountService, logger))),
 		))
+
+	// Import complet d'une boîte mail (lancement et progression)
+	mux.Handle("/api/accounts/backfill",
+		authMiddleware.CORS(
+			authMiddleware.RequireAuth(http.HandlerFunc(BackfillHandler(backfillService, logger))),
+		))
 }
 
 // registerOAuthRoutes - Routes OAuth2
@@ -88,6 +149,24 @@ func registerOAuthRoutes(mux *http.ServeMux, authMiddleware *middleware.AuthMidd
 	// Callback OAuth Google (public)
 	mux.HandleFunc("/api/oauth/google/callback", corsMiddleware(handlers.GoogleOAuthCallbackHandler(oauth2Service, accountService, logger)))
 
+	// Initier OAuth Outlook / Microsoft 365
+	mux.Handle("/api/oauth/outlook/initiate",
+		authMiddleware.CORS(
+			authMiddleware.RequireAuth(http.HandlerFunc(handlers.InitiateOutlookOAuthHandler(oauth2Service, logger))),
+		))
+
+	// Callback OAuth Outlook (public)
+	mux.HandleFunc("/api/oauth/outlook/callback", corsMiddleware(handlers.OutlookOAuthCallbackHandler(oauth2Service, accountService, logger)))
+
+	// Initier OAuth Yahoo
+	mux.Handle("/api/oauth/yahoo/initiate",
+		authMiddleware.CORS(
+			authMiddleware.RequireAuth(http.HandlerFunc(handlers.InitiateYahooOAuthHandler(oauth2Service, logger))),
+		))
+
+	// Callback OAuth Yahoo (public)
+	mux.HandleFunc("/api/oauth/yahoo/callback", corsMiddleware(handlers.YahooOAuthCallbackHandler(oauth2Service, accountService, logger)))
+
 	// Finaliser l'ajout du compte
 	mux.Handle("/api/oauth/complete",
 		authMiddleware.CORS(
@@ -96,7 +175,7 @@ func registerOAuthRoutes(mux *http.ServeMux, authMiddleware *middleware.AuthMidd
 }
 
 // registerMailRoutes - Routes de gestion des emails
-func registerMailRoutes(mux *http.ServeMux, authMiddleware *middleware.AuthMiddleware, mailService *services.MailService, logger *utils.Logger) {
+func registerMailRoutes(mux *http.ServeMux, authMiddleware *middleware.AuthMiddleware, mailService *services.MailService, duplicateService *services.DuplicateService, jobService *services.JobService, logger *utils.Logger) {
 	// Lister tous les emails consolidés
 	mux.Handle("/api/mails",
 		authMiddleware.CORS(
@@ -109,10 +188,175 @@ func registerMailRoutes(mux *http.ServeMux, authMiddleware *middleware.AuthMiddl
 			authMiddleware.RequireAuth(http.HandlerFunc(MailActionHandler(mailService, logger))),
 		))
 
-	// Synchroniser les emails
+	// Synchroniser les emails (job asynchrone)
 	mux.Handle("/api/mails/sync",
 		authMiddleware.CORS(
-			authMiddleware.RequireAuth(http.HandlerFunc(SyncMailsHandler(mailService, logger))),
+			authMiddleware.RequireAuth(http.HandlerFunc(SyncMailsHandler(jobService, logger))),
+		))
+
+	// Doublons sur l'ensemble des comptes
+	mux.Handle("/api/mails/duplicates",
+		authMiddleware.CORS(
+			authMiddleware.RequireAuth(http.HandlerFunc(DuplicatesHandler(duplicateService, logger))),
+		))
+
+	// Supprimer les doublons (une copie conservée par groupe)
+	mux.Handle("/api/mails/duplicates/delete",
+		authMiddleware.CORS(
+			authMiddleware.RequireAuth(http.HandlerFunc(DeleteDuplicatesHandler(duplicateService, logger))),
+		))
+}
+
+// registerSenderRoutes - Routes du classement des expéditeurs
+func registerSenderRoutes(mux *http.ServeMux, authMiddleware *middleware.AuthMiddleware, senderService *services.SenderService, logger *utils.Logger) {
+	// Volume de mail par expéditeur ou par domaine
+	mux.Handle("/api/senders",
+		authMiddleware.CORS(
+			authMiddleware.RequireAuth(http.HandlerFunc(SendersHandler(senderService, logger))),
+		))
+
+	// Action sur tout le mail d'un expéditeur
+	mux.Handle("/api/senders/action",
+		authMiddleware.CORS(
+			authMiddleware.RequireAuth(http.HandlerFunc(SenderActionHandler(senderService, logger))),
+		))
+}
+
+// registerBulkRoutes - Routes des actions par filtre et des recherches enregistrées
+func registerBulkRoutes(mux *http.ServeMux, authMiddleware *middleware.AuthMiddleware, bulkActionService *services.BulkActionService, logger *utils.Logger) {
+	// Aperçu ou lancement d'une action sur tous les emails d'un filtre
+	mux.Handle("/api/mails/bulk-action",
+		authMiddleware.CORS(
+			authMiddleware.RequireAuth(http.HandlerFunc(BulkActionHandler(bulkActionService, logger))),
+		))
+
+	// Recherches enregistrées
+	mux.Handle("/api/searches",
+		authMiddleware.CORS(
+			authMiddleware.RequireAuth(http.HandlerFunc(SavedSearchesHandler(bulkActionService, logger))),
+		))
+
+	mux.Handle("/api/searches/{id}",
+		authMiddleware.CORS(
+			authMiddleware.RequireAuth(http.HandlerFunc(DeleteSavedSearchHandler(bulkActionService, logger))),
+		))
+}
+
+// registerTrashRoutes - Routes de la corbeille et de l'annulation des actions
+func registerTrashRoutes(mux *http.ServeMux, authMiddleware *middleware.AuthMiddleware, trashService *services.TrashService, logger *utils.Logger) {
+	// Emails supprimés via Tamis
+	mux.Handle("/api/trash",
+		authMiddleware.CORS(
+			authMiddleware.RequireAuth(http.HandlerFunc(TrashHandler(trashService, logger))),
+		))
+
+	// Sortir des emails de la corbeille
+	mux.Handle("/api/trash/restore",
+		authMiddleware.CORS(
+			authMiddleware.RequireAuth(http.HandlerFunc(RestoreTrashHandler(trashService, logger))),
+		))
+
+	// Annuler une action à partir de son handle
+	mux.Handle("/api/actions/{id}/undo",
+		authMiddleware.CORS(
+			authMiddleware.RequireAuth(http.HandlerFunc(UndoActionHandler(trashService, logger))),
+		))
+}
+
+// registerRuleRoutes - Routes des règles de nettoyage
+func registerRuleRoutes(mux *http.ServeMux, authMiddleware *middleware.AuthMiddleware, ruleService *services.RuleService, logger *utils.Logger) {
+	mux.Handle("/api/rules",
+		authMiddleware.CORS(
+			authMiddleware.RequireAuth(http.HandlerFunc(RulesHandler(ruleService, logger))),
+		))
+
+	// Aperçu ou application des règles aux emails existants
+	mux.Handle("/api/rules/run",
+		authMiddleware.CORS(
+			authMiddleware.RequireAuth(http.HandlerFunc(RunRulesHandler(ruleService, logger))),
+		))
+
+	// Import et export au format Sieve
+	mux.Handle("/api/rules/sieve/import",
+		authMiddleware.CORS(
+			authMiddleware.RequireAuth(http.HandlerFunc(ImportSieveHandler(ruleService, logger))),
+		))
+
+	mux.Handle("/api/rules/sieve/export",
+		authMiddleware.CORS(
+			authMiddleware.RequireAuth(http.HandlerFunc(ExportSieveHandler(ruleService, logger))),
+		))
+
+	mux.Handle("/api/rules/{id}",
+		authMiddleware.CORS(
+			authMiddleware.RequireAuth(http.HandlerFunc(RuleHandler(ruleService, logger))),
+		))
+}
+
+// registerRetentionRoutes - Routes des politiques de rétention et de leur journal
+func registerRetentionRoutes(mux *http.ServeMux, authMiddleware *middleware.AuthMiddleware, retentionService *services.RetentionService, logger *utils.Logger) {
+	mux.Handle("/api/retention/policies",
+		authMiddleware.CORS(
+			authMiddleware.RequireAuth(http.HandlerFunc(RetentionPoliciesHandler(retentionService, logger))),
+		))
+
+	mux.Handle("/api/retention/policies/{id}",
+		authMiddleware.CORS(
+			authMiddleware.RequireAuth(http.HandlerFunc(RetentionPolicyHandler(retentionService, logger))),
+		))
+
+	// Résumés des exécutions et emails concernés
+	mux.Handle("/api/retention/runs",
+		authMiddleware.CORS(
+			authMiddleware.RequireAuth(http.HandlerFunc(RetentionRunsHandler(retentionService, logger))),
+		))
+
+	mux.Handle("/api/retention/runs/{id}",
+		authMiddleware.CORS(
+			authMiddleware.RequireAuth(http.HandlerFunc(RetentionRunHandler(retentionService, logger))),
+		))
+}
+
+// registerClassifierRoutes - Routes du classifieur d'emails indésirables
+func registerClassifierRoutes(mux *http.ServeMux, authMiddleware *middleware.AuthMiddleware, classifierService *services.ClassifierService, logger *utils.Logger) {
+	mux.Handle("/api/classifier",
+		authMiddleware.CORS(
+			authMiddleware.RequireAuth(http.HandlerFunc(ClassifierHandler(classifierService, logger))),
+		))
+
+	mux.Handle("/api/classifier/retrain",
+		authMiddleware.CORS(
+			authMiddleware.RequireAuth(http.HandlerFunc(RetrainClassifierHandler(classifierService, logger))),
+		))
+}
+
+// registerJobRoutes - Routes de suivi des jobs
+func registerJobRoutes(mux *http.ServeMux, authMiddleware *middleware.AuthMiddleware, jobService *services.JobService, logger *utils.Logger) {
+	// État et progression d'un job
+	mux.Handle("/api/jobs/{id}",
+		authMiddleware.CORS(
+			authMiddleware.RequireAuth(http.HandlerFunc(GetJobHandler(jobService, logger))),
+		))
+}
+
+// registerOutboxRoutes - Routes de suivi des actions provider différées
+func registerOutboxRoutes(mux *http.ServeMux, authMiddleware *middleware.AuthMiddleware, outboxService *services.OutboxService, logger *utils.Logger) {
+	// Actions en attente ou en échec
+	mux.Handle("/api/outbox",
+		authMiddleware.CORS(
+			authMiddleware.RequireAuth(http.HandlerFunc(ListOutboxHandler(outboxService, logger))),
+		))
+
+	// Relancer des actions
+	mux.Handle("/api/outbox/retry",
+		authMiddleware.CORS(
+			authMiddleware.RequireAuth(http.HandlerFunc(RetryOutboxHandler(outboxService, logger))),
+		))
+
+	// Annuler des actions
+	mux.Handle("/api/outbox/cancel",
+		authMiddleware.CORS(
+			authMiddleware.RequireAuth(http.HandlerFunc(CancelOutboxHandler(outboxService, logger))),
 		))
 }
 
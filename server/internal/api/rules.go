@@ -0,0 +1,216 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"tamis-server/internal/middleware"
+	"tamis-server/internal/models"
+	"tamis-server/internal/services"
+	"tamis-server/internal/utils"
+)
+
+// RulesHandler - Lister (GET) ou créer (POST) les règles de nettoyage de l'utilisateur
+func RulesHandler(ruleService *services.RuleService, logger *utils.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+		if !ok || user == nil {
+			utils.WriteError(w, http.StatusUnauthorized, "User not found")
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			rules, err := ruleService.GetRules(user.ID)
+			if err != nil {
+				logger.Error("Failed to get cleanup rules for user " + strconv.Itoa(user.ID) + ": " + err.Error())
+				utils.WriteError(w, http.StatusInternalServerError, "Failed to retrieve cleanup rules")
+				return
+			}
+			utils.WriteSuccess(w, rules, "Cleanup rules retrieved successfully")
+
+		case http.MethodPost:
+			var req models.CleanupRuleRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				utils.WriteError(w, http.StatusBadRequest, "Invalid JSON format")
+				return
+			}
+
+			rule, err := ruleService.CreateRule(user.ID, &req)
+			if err != nil {
+				utils.WriteError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			utils.WriteSuccess(w, rule, "Cleanup rule created successfully")
+
+		default:
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	}
+}
+
+// RuleHandler - Remplacer (PUT) ou supprimer (DELETE) une règle de nettoyage
+func RuleHandler(ruleService *services.RuleService, logger *utils.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+		if !ok || user == nil {
+			utils.WriteError(w, http.StatusUnauthorized, "User not found")
+			return
+		}
+
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "Invalid cleanup rule ID")
+			return
+		}
+
+		switch r.Method {
+		case http.MethodPut:
+			var req models.CleanupRuleRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				utils.WriteError(w, http.StatusBadRequest, "Invalid JSON format")
+				return
+			}
+
+			rule, err := ruleService.UpdateRule(user.ID, id, &req)
+			if err != nil {
+				status := http.StatusBadRequest
+				if strings.Contains(err.Error(), "not found") {
+					status = http.StatusNotFound
+				}
+				utils.WriteError(w, status, err.Error())
+				return
+			}
+			utils.WriteSuccess(w, rule, "Cleanup rule updated successfully")
+
+		case http.MethodDelete:
+			if err := ruleService.DeleteRule(user.ID, id); err != nil {
+				utils.WriteError(w, http.StatusNotFound, err.Error())
+				return
+			}
+			utils.WriteSuccess(w, nil, "Cleanup rule deleted successfully")
+
+		default:
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	}
+}
+
+// RunRulesHandler - Appliquer les règles aux emails existants : aperçu (dry_run) ou lancement du job
+func RunRulesHandler(ruleService *services.RuleService, logger *utils.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+		if !ok || user == nil {
+			utils.WriteError(w, http.StatusUnauthorized, "User not found")
+			return
+		}
+
+		var req models.RuleRunRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "Invalid JSON format")
+			return
+		}
+
+		if req.DryRun {
+			preview, err := ruleService.Preview(user.ID, &req)
+			if err != nil {
+				utils.WriteError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			utils.WriteSuccess(w, preview, "Cleanup rules preview computed")
+			return
+		}
+
+		job, err := ruleService.Start(user.ID, &req)
+		if err != nil {
+			if errors.Is(err, services.ErrRulesRunning) {
+				utils.WriteError(w, http.StatusConflict, err.Error()+" (job "+job.ID+")")
+				return
+			}
+			utils.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		logger.Info("Cleanup rules job " + job.ID + " queued for user " + strconv.Itoa(user.ID))
+		utils.WriteSuccess(w, job, "Cleanup rules started")
+	}
+}
+
+// ImportSieveHandler - Convertir un script Sieve en règles de nettoyage (dry_run : sans enregistrer)
+func ImportSieveHandler(ruleService *services.RuleService, logger *utils.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+		if !ok || user == nil {
+			utils.WriteError(w, http.StatusUnauthorized, "User not found")
+			return
+		}
+
+		var req models.SieveImportRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "Invalid JSON format")
+			return
+		}
+		if strings.TrimSpace(req.Script) == "" {
+			utils.WriteError(w, http.StatusBadRequest, "Sieve script is required")
+			return
+		}
+
+		result, err := ruleService.ImportSieve(user.ID, &req)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if req.DryRun {
+			utils.WriteSuccess(w, result, "Sieve script converted")
+			return
+		}
+		logger.Info("Sieve script imported for user " + strconv.Itoa(user.ID))
+		utils.WriteSuccess(w, result, "Sieve script imported successfully")
+	}
+}
+
+// ExportSieveHandler - Script Sieve équivalent aux règles actives (account_id facultatif)
+func ExportSieveHandler(ruleService *services.RuleService, logger *utils.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+		if !ok || user == nil {
+			utils.WriteError(w, http.StatusUnauthorized, "User not found")
+			return
+		}
+
+		accountID := 0
+		if accountIDStr := r.URL.Query().Get("account_id"); accountIDStr != "" {
+			id, err := strconv.Atoi(accountIDStr)
+			if err != nil {
+				utils.WriteError(w, http.StatusBadRequest, "Invalid account ID")
+				return
+			}
+			accountID = id
+		}
+
+		export, err := ruleService.ExportSieve(user.ID, accountID)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.WriteSuccess(w, export, "Sieve script exported successfully")
+	}
+}
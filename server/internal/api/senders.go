@@ -0,0 +1,109 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"tamis-server/internal/middleware"
+	"tamis-server/internal/models"
+	"tamis-server/internal/services"
+	"tamis-server/internal/utils"
+)
+
+// SendersHandler - Classement des expéditeurs (?group_by=sender|domain&account_id=&sort=&order=asc|desc&limit=&offset=)
+func SendersHandler(senderService *services.SenderService, logger *utils.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+		if !ok || user == nil {
+			utils.WriteError(w, http.StatusUnauthorized, "User not found")
+			return
+		}
+
+		params := r.URL.Query()
+		query := &models.SenderQuery{
+			GroupBy:   models.SenderGroupBy(params.Get("group_by")),
+			Sort:      models.SenderSort(params.Get("sort")),
+			Ascending: params.Get("order") == "asc",
+		}
+
+		if accountIDStr := params.Get("account_id"); accountIDStr != "" {
+			accountID, err := strconv.Atoi(accountIDStr)
+			if err != nil {
+				utils.WriteError(w, http.StatusBadRequest, "Invalid account ID")
+				return
+			}
+			query.AccountID = accountID
+		}
+		if limit, err := strconv.Atoi(params.Get("limit")); err == nil && limit > 0 {
+			query.Limit = limit
+		}
+		if offset, err := strconv.Atoi(params.Get("offset")); err == nil && offset >= 0 {
+			query.Offset = offset
+		}
+
+		page, err := senderService.GetLeaderboard(user.ID, query)
+		if err != nil {
+			logger.Error("Failed to build sender leaderboard for user " + strconv.Itoa(user.ID) + ": " + err.Error())
+			utils.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		utils.WriteSuccess(w, page, "Senders retrieved successfully")
+	}
+}
+
+// SenderActionHandler - Appliquer une action à tout le mail d'un expéditeur ou d'un domaine
+func SenderActionHandler(senderService *services.SenderService, logger *utils.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+		if !ok || user == nil {
+			utils.WriteError(w, http.StatusUnauthorized, "User not found")
+			return
+		}
+
+		var req models.SenderActionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "Invalid JSON format")
+			return
+		}
+
+		if req.Key == "" {
+			utils.WriteError(w, http.StatusBadRequest, "Sender key is required")
+			return
+		}
+
+		if req.Action == "" {
+			utils.WriteError(w, http.StatusBadRequest, "Action is required")
+			return
+		}
+
+		if req.DryRun {
+			preview, err := senderService.PreviewSenderAction(user.ID, &req)
+			if err != nil {
+				utils.WriteError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			utils.WriteSuccess(w, preview, "Action preview computed")
+			return
+		}
+
+		result, err := senderService.ApplySenderAction(user.ID, &req)
+		if err != nil {
+			logger.Error("Failed to apply sender action for user " + strconv.Itoa(user.ID) + ": " + err.Error())
+			utils.WriteError(w, actionErrorStatus(err), err.Error())
+			return
+		}
+
+		utils.WriteSuccess(w, result, "Action executed successfully")
+	}
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"tamis-server/internal/middleware"
+	"tamis-server/internal/models"
+	"tamis-server/internal/services"
+	"tamis-server/internal/utils"
+)
+
+// SettingsHandler - Lire (GET) ou modifier (PUT) les préférences de l'utilisateur
+func SettingsHandler(settingsService *services.SettingsService, logger *utils.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodPut {
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+		if !ok || user == nil {
+			utils.WriteError(w, http.StatusUnauthorized, "User not found")
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			settings, err := settingsService.GetSettings(user.ID)
+			if err != nil {
+				logger.Error("Failed to retrieve settings for user " + strconv.Itoa(user.ID) + ": " + err.Error())
+				utils.WriteError(w, http.StatusInternalServerError, "Failed to retrieve settings")
+				return
+			}
+
+			utils.WriteSuccess(w, settings, "Settings retrieved successfully")
+			return
+		}
+
+		var req models.UpdateSettingsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "Invalid JSON format")
+			return
+		}
+
+		settings, err := settingsService.UpdateSettings(user.ID, &req)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		utils.WriteSuccess(w, settings, "Settings updated successfully")
+	}
+}
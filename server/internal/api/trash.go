@@ -0,0 +1,106 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"tamis-server/internal/middleware"
+	"tamis-server/internal/models"
+	"tamis-server/internal/services"
+	"tamis-server/internal/utils"
+)
+
+// TrashHandler - Lister la corbeille Tamis (?limit=&offset=)
+func TrashHandler(trashService *services.TrashService, logger *utils.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+		if !ok || user == nil {
+			utils.WriteError(w, http.StatusUnauthorized, "User not found")
+			return
+		}
+
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+		page, err := trashService.ListTrash(user.ID, limit, offset)
+		if err != nil {
+			logger.Error("Failed to list trash for user " + strconv.Itoa(user.ID) + ": " + err.Error())
+			utils.WriteError(w, http.StatusInternalServerError, "Failed to retrieve trash")
+			return
+		}
+
+		utils.WriteSuccess(w, page, "Trash retrieved successfully")
+	}
+}
+
+// RestoreTrashHandler - Restaurer des emails de la corbeille
+func RestoreTrashHandler(trashService *services.TrashService, logger *utils.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+		if !ok || user == nil {
+			utils.WriteError(w, http.StatusUnauthorized, "User not found")
+			return
+		}
+
+		var req models.RestoreRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "Invalid JSON format")
+			return
+		}
+
+		if len(req.EmailIDs) == 0 {
+			utils.WriteError(w, http.StatusBadRequest, "Email IDs are required")
+			return
+		}
+
+		result, err := trashService.Restore(user.ID, req.EmailIDs)
+		if err != nil {
+			logger.Error("Failed to restore emails for user " + strconv.Itoa(user.ID) + ": " + err.Error())
+			utils.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		utils.WriteSuccess(w, result, "Emails restored successfully")
+	}
+}
+
+// UndoActionHandler - Annuler toute une action à partir de son handle
+func UndoActionHandler(trashService *services.TrashService, logger *utils.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+		if !ok || user == nil {
+			utils.WriteError(w, http.StatusUnauthorized, "User not found")
+			return
+		}
+
+		batchID := r.PathValue("id")
+		if batchID == "" {
+			utils.WriteError(w, http.StatusBadRequest, "Undo handle is required")
+			return
+		}
+
+		result, err := trashService.Undo(user.ID, batchID)
+		if err != nil {
+			logger.Error("Failed to undo action " + batchID + " for user " + strconv.Itoa(user.ID) + ": " + err.Error())
+			utils.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		utils.WriteSuccess(w, result, "Action undone successfully")
+	}
+}
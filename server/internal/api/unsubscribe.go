@@ -0,0 +1,59 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"tamis-server/internal/middleware"
+	"tamis-server/internal/models"
+	"tamis-server/internal/services"
+	"tamis-server/internal/utils"
+)
+
+// UnsubscribeHandler - Lister les désinscriptions (GET) ou se désinscrire d'une liste (POST)
+func UnsubscribeHandler(unsubscribeService *services.UnsubscribeService, logger *utils.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodPost {
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+		if !ok || user == nil {
+			utils.WriteError(w, http.StatusUnauthorized, "User not found")
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			unsubscriptions, err := unsubscribeService.GetUnsubscriptions(user.ID)
+			if err != nil {
+				logger.Error("Failed to retrieve unsubscriptions for user " + strconv.Itoa(user.ID) + ": " + err.Error())
+				utils.WriteError(w, http.StatusInternalServerError, "Failed to retrieve unsubscriptions")
+				return
+			}
+
+			utils.WriteSuccess(w, unsubscriptions, "Unsubscriptions retrieved successfully")
+			return
+		}
+
+		var req models.UnsubscribeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "Invalid JSON format")
+			return
+		}
+
+		if req.EmailID == "" && req.Sender == "" {
+			utils.WriteError(w, http.StatusBadRequest, "Email ID or sender is required")
+			return
+		}
+
+		result, err := unsubscribeService.Unsubscribe(user.ID, &req)
+		if err != nil {
+			logger.Error("Failed to unsubscribe for user " + strconv.Itoa(user.ID) + ": " + err.Error())
+			utils.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		utils.WriteSuccess(w, result, "Unsubscribe "+string(result.Unsubscription.Status))
+	}
+}
@@ -3,6 +3,8 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"time"
 )
 
 type Config struct {
@@ -12,6 +14,7 @@ type Config struct {
 	JWT        JWTConfig
 	Encryption EncryptionConfig
 	OAuth2     OAuth2Config
+	Scheduler  SchedulerConfig
 }
 
 type ServerConfig struct {
@@ -41,6 +44,16 @@ type EncryptionConfig struct {
 	Key string // Clé AES-256 pour chiffrer les tokens OAuth2
 }
 
+// SchedulerConfig - Synchronisation automatique en arrière-plan
+type SchedulerConfig struct {
+	Enabled         bool
+	TickInterval    time.Duration // Fréquence de recherche des comptes à synchroniser
+	DefaultInterval time.Duration // Intervalle utilisé quand l'utilisateur n'en a pas défini
+	MinInterval     time.Duration // Intervalle minimal accepté dans les préférences utilisateur
+	Jitter          time.Duration // Décalage aléatoire maximal ajouté à chaque échéance
+	Workers         int           // Nombre de synchronisations simultanées
+}
+
 type OAuth2Config struct {
 	Gmail struct {
 		ClientID     string
@@ -83,6 +96,14 @@ func Load() *Config {
 		Encryption: EncryptionConfig{
 			Key: getEnv("ENCRYPTION_KEY", "tamis-super-secret-encryption-key-32-bytes"),
 		},
+		Scheduler: SchedulerConfig{
+			Enabled:         getEnvBool("SYNC_SCHEDULER_ENABLED", true),
+			TickInterval:    getEnvDuration("SYNC_SCHEDULER_TICK", time.Minute),
+			DefaultInterval: getEnvDuration("SYNC_DEFAULT_INTERVAL", 15*time.Minute),
+			MinInterval:     getEnvDuration("SYNC_MIN_INTERVAL", 5*time.Minute),
+			Jitter:          getEnvDuration("SYNC_JITTER", 2*time.Minute),
+			Workers:         getEnvInt("SYNC_WORKERS", 4),
+		},
 		OAuth2: OAuth2Config{
 			Gmail: struct {
 				ClientID     string
@@ -123,6 +144,30 @@ func getEnv(key, defaultValue string) string {
 	return value
 }
 
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
 func (c *Config) GetDSN() string {
 	return fmt.Sprintf(
 		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
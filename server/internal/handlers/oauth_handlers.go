@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"net/http"
+	"net/url"
 	"tamis-server/internal/middleware"
 	"tamis-server/internal/models"
 	"tamis-server/internal/services"
@@ -121,7 +122,8 @@ func CompleteAccountSetupHandler(
 
 		// Structure pour recevoir le code d'autorisation
 		var req struct {
-			Code string `json:"code"`
+			Code     string               `json:"code"`
+			Provider models.EmailProvider `json:"provider"`
 		}
 
 		if err := utils.DecodeJSON(r, &req); err != nil {
@@ -129,39 +131,231 @@ func CompleteAccountSetupHandler(
 			return
 		}
 
-		// Échanger le code contre des tokens
-		tokens, err := oauth2Service.ExchangeCodeForTokens(req.Code)
+		// Gmail par défaut pour les clients existants
+		if req.Provider == "" {
+			req.Provider = models.ProviderGmail
+		}
+
+		// Échanger le code contre des tokens et récupérer le profil
+		tokens, email, name, err := exchangeProviderCode(oauth2Service, req.Provider, req.Code)
 		if err != nil {
-			logger.Error("Failed to exchange code: " + err.Error())
+			logger.Error(fmt.Sprintf("Failed to complete %s OAuth: %v", req.Provider, err))
 			utils.WriteError(w, http.StatusInternalServerError, "Failed to exchange authorization code")
 			return
 		}
 
-		// Récupérer les informations utilisateur
+		// Créer la requête de compte
+		accountReq := &models.CreateEmailAccountRequest{
+			Provider:    req.Provider,
+			Email:       email,
+			DisplayName: name,
+		}
+
+		// Ajouter le compte avec les vrais tokens OAuth2
+		account, err := accountService.AddAccountWithTokens(user.ID, accountReq, tokens)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Failed to add %s account: %v", req.Provider, err))
+			utils.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		logger.Info(fmt.Sprintf("%s account added for user %d: %s", req.Provider, user.ID, email))
+		utils.WriteSuccess(w, account, "Email account added successfully")
+	}
+}
+
+// exchangeProviderCode - Échanger un code OAuth2 et récupérer l'email et le nom du compte
+func exchangeProviderCode(oauth2Service *utils.OAuth2Service, provider models.EmailProvider, code string) (*models.OAuth2Token, string, string, error) {
+	switch provider {
+	case models.ProviderGmail:
+		tokens, err := oauth2Service.ExchangeCodeForTokens(code)
+		if err != nil {
+			return nil, "", "", err
+		}
 		userInfo, err := oauth2Service.GetUserInfo(tokens.AccessToken)
+		if err != nil {
+			return nil, "", "", err
+		}
+		return tokens, userInfo.Email, userInfo.Name, nil
+
+	case models.ProviderOutlook:
+		tokens, err := oauth2Service.ExchangeOutlookCode(code)
+		if err != nil {
+			return nil, "", "", err
+		}
+		userInfo, err := oauth2Service.GetOutlookUserInfo(tokens.AccessToken)
+		if err != nil {
+			return nil, "", "", err
+		}
+		return tokens, userInfo.Email(), userInfo.DisplayName, nil
+
+	case models.ProviderYahoo:
+		tokens, err := oauth2Service.ExchangeYahooCode(code)
+		if err != nil {
+			return nil, "", "", err
+		}
+		userInfo, err := oauth2Service.GetYahooUserInfo(tokens.AccessToken)
+		if err != nil {
+			return nil, "", "", err
+		}
+		return tokens, userInfo.Email, userInfo.Name, nil
+
+	default:
+		return nil, "", "", fmt.Errorf("unsupported oauth provider: %s", provider)
+	}
+}
+
+// InitiateOutlookOAuthHandler - Initier le flux OAuth Microsoft
+func InitiateOutlookOAuthHandler(oauth2Service *utils.OAuth2Service, logger *utils.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+		if !ok || user == nil {
+			utils.WriteError(w, http.StatusUnauthorized, "User not found")
+			return
+		}
+
+		state := generateSecureState()
+		authURL := oauth2Service.GetOutlookAuthURL(state)
+
+		utils.WriteSuccess(w, map[string]string{
+			"auth_url": authURL,
+			"state":    state,
+		}, "Outlook OAuth URL generated")
+	}
+}
+
+// OutlookOAuthCallbackHandler - Callback après autorisation Microsoft
+func OutlookOAuthCallbackHandler(
+	oauth2Service *utils.OAuth2Service,
+	accountService *services.AccountService,
+	logger *utils.Logger,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		code := r.URL.Query().Get("code")
+		state := r.URL.Query().Get("state")
+		errorParam := r.URL.Query().Get("error")
+
+		if errorParam != "" {
+			logger.Error("OAuth error: " + errorParam + " - " + r.URL.Query().Get("error_description"))
+			utils.WriteError(w, http.StatusBadRequest, "OAuth authorization failed")
+			return
+		}
+
+		if code == "" {
+			utils.WriteError(w, http.StatusBadRequest, "Authorization code missing")
+			return
+		}
+
+		if state == "" {
+			utils.WriteError(w, http.StatusBadRequest, "Invalid state parameter")
+			return
+		}
+
+		tokens, err := oauth2Service.ExchangeOutlookCode(code)
+		if err != nil {
+			logger.Error("Failed to exchange code for tokens: " + err.Error())
+			utils.WriteError(w, http.StatusInternalServerError, "Failed to exchange authorization code")
+			return
+		}
+
+		userInfo, err := oauth2Service.GetOutlookUserInfo(tokens.AccessToken)
 		if err != nil {
 			logger.Error("Failed to get user info: " + err.Error())
 			utils.WriteError(w, http.StatusInternalServerError, "Failed to get user information")
 			return
 		}
 
-		// Créer la requête de compte
-		accountReq := &models.CreateEmailAccountRequest{
-			Provider:    models.ProviderGmail,
-			Email:       userInfo.Email,
-			DisplayName: userInfo.Name,
+		redirectURL := fmt.Sprintf("http://localhost:3001/oauth/callback?email=%s&name=%s&provider=outlook",
+			url.QueryEscape(userInfo.Email()), url.QueryEscape(userInfo.DisplayName))
+
+		http.Redirect(w, r, redirectURL, http.StatusTemporaryRedirect)
+	}
+}
+
+// InitiateYahooOAuthHandler - Initier le flux OAuth Yahoo
+func InitiateYahooOAuthHandler(oauth2Service *utils.OAuth2Service, logger *utils.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
 		}
 
-		// Ajouter le compte avec les vrais tokens OAuth2
-		account, err := accountService.AddAccountWithTokens(user.ID, accountReq, tokens)
+		user, ok := r.Context().Value(middleware.UserContextKey).(*models.User)
+		if !ok || user == nil {
+			utils.WriteError(w, http.StatusUnauthorized, "User not found")
+			return
+		}
+
+		state := generateSecureState()
+		authURL := oauth2Service.GetYahooAuthURL(state)
+
+		utils.WriteSuccess(w, map[string]string{
+			"auth_url": authURL,
+			"state":    state,
+		}, "Yahoo OAuth URL generated")
+	}
+}
+
+// YahooOAuthCallbackHandler - Callback après autorisation Yahoo
+func YahooOAuthCallbackHandler(
+	oauth2Service *utils.OAuth2Service,
+	accountService *services.AccountService,
+	logger *utils.Logger,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			utils.WriteError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		code := r.URL.Query().Get("code")
+		state := r.URL.Query().Get("state")
+		errorParam := r.URL.Query().Get("error")
+
+		if errorParam != "" {
+			logger.Error("OAuth error: " + errorParam)
+			utils.WriteError(w, http.StatusBadRequest, "OAuth authorization failed")
+			return
+		}
+
+		if code == "" {
+			utils.WriteError(w, http.StatusBadRequest, "Authorization code missing")
+			return
+		}
+
+		if state == "" {
+			utils.WriteError(w, http.StatusBadRequest, "Invalid state parameter")
+			return
+		}
+
+		tokens, err := oauth2Service.ExchangeYahooCode(code)
 		if err != nil {
-			logger.Error("Failed to add Gmail account: " + err.Error())
-			utils.WriteError(w, http.StatusBadRequest, err.Error())
+			logger.Error("Failed to exchange code for tokens: " + err.Error())
+			utils.WriteError(w, http.StatusInternalServerError, "Failed to exchange authorization code")
+			return
+		}
+
+		userInfo, err := oauth2Service.GetYahooUserInfo(tokens.AccessToken)
+		if err != nil {
+			logger.Error("Failed to get user info: " + err.Error())
+			utils.WriteError(w, http.StatusInternalServerError, "Failed to get user information")
 			return
 		}
 
-		logger.Info(fmt.Sprintf("Gmail account added for user %d: %s", user.ID, userInfo.Email))
-		utils.WriteSuccess(w, account, "Gmail account added successfully")
+		redirectURL := fmt.Sprintf("http://localhost:3001/oauth/callback?email=%s&name=%s&provider=yahoo",
+			url.QueryEscape(userInfo.Email), url.QueryEscape(userInfo.Name))
+
+		http.Redirect(w, r, redirectURL, http.StatusTemporaryRedirect)
 	}
 }
 
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_emails_account_provider_id;
+ALTER TABLE emails DROP COLUMN IF EXISTS provider_id;
+ALTER TABLE email_accounts DROP COLUMN IF EXISTS imap_port;
+ALTER TABLE email_accounts DROP COLUMN IF EXISTS imap_host;
+ALTER TABLE email_accounts DROP COLUMN IF EXISTS auth_type;
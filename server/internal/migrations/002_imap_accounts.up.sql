@@ -0,0 +1,9 @@
+-- IMAP connection settings for non-OAuth accounts
+ALTER TABLE email_accounts ADD COLUMN IF NOT EXISTS auth_type VARCHAR(20) DEFAULT 'oauth2';
+ALTER TABLE email_accounts ADD COLUMN IF NOT EXISTS imap_host VARCHAR(255);
+ALTER TABLE email_accounts ADD COLUMN IF NOT EXISTS imap_port INTEGER;
+
+-- Provider-side identifier (IMAP UID, Gmail message id...)
+ALTER TABLE emails ADD COLUMN IF NOT EXISTS provider_id VARCHAR(255);
+
+CREATE INDEX IF NOT EXISTS idx_emails_account_provider_id ON emails(account_id, provider_id);
@@ -0,0 +1 @@
+ALTER TABLE email_accounts DROP COLUMN IF EXISTS inactive_reason;
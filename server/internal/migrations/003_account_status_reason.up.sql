@@ -0,0 +1,2 @@
+-- Reason an account was deactivated (revoked token...)
+ALTER TABLE email_accounts ADD COLUMN IF NOT EXISTS inactive_reason TEXT;
@@ -0,0 +1,12 @@
+-- Per-account, per-mailbox incremental sync checkpoints
+CREATE TABLE IF NOT EXISTS sync_states (
+    account_id INTEGER REFERENCES email_accounts(id) ON DELETE CASCADE,
+    mailbox VARCHAR(255) NOT NULL,
+    uid_validity BIGINT DEFAULT 0,
+    last_uid BIGINT DEFAULT 0,
+    highest_modseq NUMERIC(20, 0) DEFAULT 0,
+    history_id VARCHAR(255),
+    delta_link TEXT,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (account_id, mailbox)
+);
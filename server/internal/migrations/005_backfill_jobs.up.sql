@@ -0,0 +1,14 @@
+-- Full-mailbox backfill progress, one job per account
+CREATE TABLE IF NOT EXISTS backfill_jobs (
+    account_id INTEGER PRIMARY KEY REFERENCES email_accounts(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'running',
+    cursor TEXT,
+    fetched INTEGER DEFAULT 0,
+    estimated_total INTEGER DEFAULT 0,
+    last_error TEXT,
+    started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_backfill_jobs_status ON backfill_jobs(status);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS user_settings;
+ALTER TABLE email_accounts DROP COLUMN IF EXISTS last_synced_at;
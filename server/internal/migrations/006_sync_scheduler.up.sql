@@ -0,0 +1,10 @@
+-- Last successful sync per account, used by the background scheduler
+ALTER TABLE email_accounts ADD COLUMN IF NOT EXISTS last_synced_at TIMESTAMP;
+
+-- Per-user preferences
+CREATE TABLE IF NOT EXISTS user_settings (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    auto_sync_enabled BOOLEAN DEFAULT true,
+    sync_interval_minutes INTEGER,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,19 @@
+-- Provider-side actions waiting to be applied (retried with backoff)
+CREATE TABLE IF NOT EXISTS action_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    account_id INTEGER REFERENCES email_accounts(id) ON DELETE CASCADE,
+    email_id VARCHAR(255) NOT NULL,
+    provider_id VARCHAR(255) NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    force BOOLEAN DEFAULT false,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER DEFAULT 0,
+    next_attempt_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_error TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_action_outbox_status_next_attempt ON action_outbox(status, next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_action_outbox_user_status ON action_outbox(user_id, status);
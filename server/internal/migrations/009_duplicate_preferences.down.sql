@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_emails_message_id;
+ALTER TABLE user_settings DROP COLUMN IF EXISTS duplicate_keep;
+ALTER TABLE user_settings DROP COLUMN IF EXISTS primary_account_id;
@@ -0,0 +1,6 @@
+-- Duplicate detection preferences
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS primary_account_id INTEGER REFERENCES email_accounts(id) ON DELETE SET NULL;
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS duplicate_keep VARCHAR(20) DEFAULT 'oldest';
+
+-- Message-ID lookups across accounts
+CREATE INDEX IF NOT EXISTS idx_emails_message_id ON emails(message_id);
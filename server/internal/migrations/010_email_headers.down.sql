@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_emails_account_category;
+ALTER TABLE emails DROP COLUMN IF EXISTS category;
+ALTER TABLE emails DROP COLUMN IF EXISTS headers;
@@ -0,0 +1,5 @@
+-- Mailing-list headers captured during sync and the derived category
+ALTER TABLE emails ADD COLUMN IF NOT EXISTS headers JSONB;
+ALTER TABLE emails ADD COLUMN IF NOT EXISTS category VARCHAR(20);
+
+CREATE INDEX IF NOT EXISTS idx_emails_account_category ON emails(account_id, category);
@@ -0,0 +1,18 @@
+-- Unsubscribe attempts, one row per sender
+CREATE TABLE IF NOT EXISTS unsubscriptions (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    account_id INTEGER REFERENCES email_accounts(id) ON DELETE CASCADE,
+    sender VARCHAR(255) NOT NULL,
+    list_id VARCHAR(255),
+    method VARCHAR(20) NOT NULL,
+    target TEXT,
+    status VARCHAR(20) NOT NULL,
+    error TEXT,
+    archive_future BOOLEAN DEFAULT false,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(user_id, sender)
+);
+
+CREATE INDEX IF NOT EXISTS idx_unsubscriptions_archive_future ON unsubscriptions(user_id) WHERE archive_future = true;
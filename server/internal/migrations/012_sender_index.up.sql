@@ -0,0 +1,2 @@
+-- Sender leaderboard and per-sender actions
+CREATE INDEX IF NOT EXISTS idx_emails_sender ON emails(account_id, LOWER(TRIM(from_address)));
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS action_batch_items;
+DROP TABLE IF EXISTS action_batches;
+ALTER TABLE user_settings DROP COLUMN IF EXISTS trash_retention_days;
+DROP INDEX IF EXISTS idx_emails_trash;
+ALTER TABLE emails DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,26 @@
+-- Tamis trash: deletion date drives the automatic purge
+ALTER TABLE emails ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+UPDATE emails SET deleted_at = updated_at WHERE is_deleted = true AND deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_emails_trash ON emails(account_id, deleted_at) WHERE is_deleted = true;
+
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS trash_retention_days INTEGER DEFAULT 30;
+
+-- Undo handles: one batch per action, with the state of each email before the action
+CREATE TABLE IF NOT EXISTS action_batches (
+    id VARCHAR(36) PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    action VARCHAR(50) NOT NULL,
+    email_count INTEGER DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    undone_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS action_batch_items (
+    batch_id VARCHAR(36) REFERENCES action_batches(id) ON DELETE CASCADE,
+    email_id VARCHAR(255) NOT NULL,
+    was_read BOOLEAN DEFAULT false,
+    PRIMARY KEY (batch_id, email_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_action_batches_expires_at ON action_batches(expires_at);
@@ -0,0 +1,5 @@
+ALTER TABLE emails DROP COLUMN IF EXISTS has_attachments;
+ALTER TABLE user_settings DROP COLUMN IF EXISTS protect_attachments_min_size;
+ALTER TABLE user_settings DROP COLUMN IF EXISTS protect_recent_days;
+ALTER TABLE user_settings DROP COLUMN IF EXISTS protect_flagged;
+ALTER TABLE user_settings DROP COLUMN IF EXISTS protected_senders;
@@ -0,0 +1,7 @@
+-- Protected-mail safeguards enforced for delete and spam actions
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS protected_senders TEXT[] DEFAULT '{}';
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS protect_flagged BOOLEAN DEFAULT true;
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS protect_recent_days INTEGER DEFAULT 0;
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS protect_attachments_min_size BIGINT DEFAULT 0;
+
+ALTER TABLE emails ADD COLUMN IF NOT EXISTS has_attachments BOOLEAN DEFAULT false;
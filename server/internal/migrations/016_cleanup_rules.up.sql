@@ -0,0 +1,16 @@
+-- User-defined cleanup rules, evaluated by priority on new and existing mail
+CREATE TABLE IF NOT EXISTS cleanup_rules (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    account_id INTEGER REFERENCES email_accounts(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    enabled BOOLEAN DEFAULT true,
+    priority INTEGER DEFAULT 0,
+    conditions JSONB NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    stop_processing BOOLEAN DEFAULT false,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_cleanup_rules_user ON cleanup_rules(user_id, priority);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS retention_run_items;
+DROP TABLE IF EXISTS retention_runs;
+DROP TABLE IF EXISTS retention_policies;
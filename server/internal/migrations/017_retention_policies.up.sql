@@ -0,0 +1,46 @@
+-- Automatic cleanup of old mail per account, optionally restricted to a label or category
+CREATE TABLE IF NOT EXISTS retention_policies (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    account_id INTEGER NOT NULL REFERENCES email_accounts(id) ON DELETE CASCADE,
+    label VARCHAR(255),
+    category VARCHAR(50),
+    older_than_days INTEGER NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    enabled BOOLEAN DEFAULT true,
+    last_run_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_retention_policies_user ON retention_policies(user_id);
+
+-- One summary per run, with the outcome of every affected message
+CREATE TABLE IF NOT EXISTS retention_runs (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    policy_id INTEGER REFERENCES retention_policies(id) ON DELETE SET NULL,
+    account_id INTEGER,
+    action VARCHAR(50) NOT NULL,
+    matched_count INTEGER DEFAULT 0,
+    success_count INTEGER DEFAULT 0,
+    failure_count INTEGER DEFAULT 0,
+    pending_count INTEGER DEFAULT 0,
+    skipped_count INTEGER DEFAULT 0,
+    error TEXT,
+    started_at TIMESTAMP NOT NULL,
+    finished_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_retention_runs_user ON retention_runs(user_id, started_at DESC);
+
+CREATE TABLE IF NOT EXISTS retention_run_items (
+    run_id BIGINT REFERENCES retention_runs(id) ON DELETE CASCADE,
+    email_id VARCHAR(255) NOT NULL,
+    from_address TEXT,
+    subject TEXT,
+    date TIMESTAMP,
+    outcome VARCHAR(20) NOT NULL,
+    reason TEXT,
+    PRIMARY KEY (run_id, email_id)
+);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS classifier_tokens;
+DROP TABLE IF EXISTS classifier_examples;
+DROP INDEX IF EXISTS idx_emails_junk_score;
+ALTER TABLE emails DROP COLUMN IF EXISTS junk_score;
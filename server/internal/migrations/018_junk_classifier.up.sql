@@ -0,0 +1,22 @@
+-- Per-user naive Bayes junk classifier trained from delete/keep/spam decisions
+ALTER TABLE emails ADD COLUMN IF NOT EXISTS junk_score REAL;
+CREATE INDEX IF NOT EXISTS idx_emails_junk_score ON emails(account_id, junk_score) WHERE junk_score IS NOT NULL;
+
+-- One training example per email: the latest decision and the tokens it was trained with
+CREATE TABLE IF NOT EXISTS classifier_examples (
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    email_id VARCHAR(255) NOT NULL,
+    is_junk BOOLEAN NOT NULL,
+    tokens TEXT[] NOT NULL DEFAULT '{}',
+    trained_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, email_id)
+);
+
+-- Token counts per class, derived from classifier_examples
+CREATE TABLE IF NOT EXISTS classifier_tokens (
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    token VARCHAR(255) NOT NULL,
+    junk_count INTEGER NOT NULL DEFAULT 0,
+    keep_count INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, token)
+);
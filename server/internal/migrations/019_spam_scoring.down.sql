@@ -0,0 +1,4 @@
+ALTER TABLE action_batch_items DROP COLUMN IF EXISTS was_spam;
+ALTER TABLE emails DROP COLUMN IF EXISTS spam_verdict;
+ALTER TABLE emails DROP COLUMN IF EXISTS spam_signals;
+ALTER TABLE emails DROP COLUMN IF EXISTS spam_score;
@@ -0,0 +1,7 @@
+-- Local header-based spam scoring and the user's own spam verdict (overrides provider and score on sync)
+ALTER TABLE emails ADD COLUMN IF NOT EXISTS spam_score INTEGER DEFAULT 0;
+ALTER TABLE emails ADD COLUMN IF NOT EXISTS spam_signals TEXT[] DEFAULT '{}';
+ALTER TABLE emails ADD COLUMN IF NOT EXISTS spam_verdict BOOLEAN;
+
+-- Spam state before a mark_spam / mark_not_spam action, so undo only reverts emails that actually changed
+ALTER TABLE action_batch_items ADD COLUMN IF NOT EXISTS was_spam BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1 @@
+ALTER TABLE action_batch_items DROP COLUMN IF EXISTS outbox_id;
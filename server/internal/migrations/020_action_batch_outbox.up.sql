@@ -0,0 +1,2 @@
+-- Outbox operation created for each undo item, so undo only cancels the operations of its own batch
+ALTER TABLE action_batch_items ADD COLUMN IF NOT EXISTS outbox_id BIGINT;
@@ -0,0 +1,11 @@
+-- At most one pending or running job per user and type, enforced even under concurrent requests
+UPDATE jobs SET status = 'failed', error = 'superseded by a newer active job of the same type', completed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE status IN ('pending', 'running')
+  AND id NOT IN (
+      SELECT DISTINCT ON (user_id, type) id
+      FROM jobs
+      WHERE status IN ('pending', 'running')
+      ORDER BY user_id, type, created_at DESC
+  );
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_jobs_active_user_type ON jobs(user_id, type) WHERE status IN ('pending', 'running');
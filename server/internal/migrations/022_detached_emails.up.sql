@@ -0,0 +1,2 @@
+-- Emails whose provider id was invalidated (UIDVALIDITY change) are re-matched by Message-ID on resync
+CREATE INDEX IF NOT EXISTS idx_emails_detached ON emails(account_id, message_id) WHERE provider_id IS NULL;
@@ -0,0 +1 @@
+-- Deletion dates cannot be told apart from Tamis trash dates: nothing to undo
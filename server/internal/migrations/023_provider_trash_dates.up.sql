@@ -0,0 +1,2 @@
+-- Mail trashed on the provider side was stored without a deletion date and escaped the automatic purge
+UPDATE emails SET deleted_at = updated_at WHERE is_deleted = true AND deleted_at IS NULL;
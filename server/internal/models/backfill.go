@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// BackfillStatus - État d'un import complet de boîte mail
+type BackfillStatus string
+
+const (
+	BackfillRunning   BackfillStatus = "running"
+	BackfillCompleted BackfillStatus = "completed"
+	BackfillFailed    BackfillStatus = "failed"
+)
+
+// BackfillJob - Progression de l'import complet d'un compte
+type BackfillJob struct {
+	AccountID      int            `json:"account_id" db:"account_id"`
+	Status         BackfillStatus `json:"status" db:"status"`
+	Cursor         string         `json:"-" db:"cursor"` // Position opaque propre au provider
+	Fetched        int            `json:"fetched" db:"fetched"`
+	EstimatedTotal int            `json:"estimated_total" db:"estimated_total"`
+	LastError      string         `json:"last_error,omitempty" db:"last_error"`
+	StartedAt      time.Time      `json:"started_at" db:"started_at"`
+	UpdatedAt      time.Time      `json:"updated_at" db:"updated_at"`
+	CompletedAt    *time.Time     `json:"completed_at,omitempty" db:"completed_at"`
+}
+
+// EmailPage - Page de messages renvoyée par un client lors d'un backfill
+type EmailPage struct {
+	Emails         []*Email
+	NextCursor     string // Vide quand la boîte a été entièrement parcourue
+	EstimatedTotal int    // Nombre total de messages estimé par le provider
+}
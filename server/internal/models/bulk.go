@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+// BulkActionRequest - Action sur tous les emails correspondant à un filtre (ou à une recherche enregistrée)
+type BulkActionRequest struct {
+	Filter        *EmailFilter `json:"filter,omitempty"`
+	SavedSearchID int          `json:"saved_search_id,omitempty"`
+	Action        EmailAction  `json:"action"`
+	Force         bool         `json:"force,omitempty"`
+	Preview       bool         `json:"preview,omitempty"` // Aperçu et jeton de confirmation, sans rien modifier
+
+	ConfirmationToken  string `json:"confirmation_token,omitempty"` // Requis pour une suppression définitive
+	OverrideProtection bool   `json:"override_protection,omitempty"`
+}
+
+// BulkActionPreview - Emails concernés par une action groupée
+type BulkActionPreview struct {
+	Filter *EmailFilter `json:"filter"`
+	ActionPreview
+}
+
+// BulkActionParams - Paramètres d'un job bulk_action (filtre figé au moment de la demande)
+type BulkActionParams struct {
+	Filter *EmailFilter `json:"filter"`
+	Action EmailAction  `json:"action"`
+	Force  bool         `json:"force,omitempty"`
+
+	OverrideProtection bool `json:"override_protection,omitempty"`
+
+	// Action irréversible : emails couverts par le jeton de confirmation, seuls traités par le job
+	// (un email arrivé depuis l'aperçu n'est pas supprimé sans confirmation) ; null hors confirmation
+	EmailIDs []string `json:"email_ids"`
+}
+
+// BulkActionProgress - Avancement d'un job bulk_action
+type BulkActionProgress struct {
+	MatchedCount int    `json:"matched_count"` // Estimation au démarrage
+	Processed    int    `json:"processed"`
+	SuccessCount int    `json:"success_count"`
+	FailureCount int    `json:"failure_count"`
+	PendingCount int    `json:"pending_count"`
+	SkippedCount int    `json:"skipped_count"`     // Emails protégés
+	LastID       string `json:"last_id,omitempty"` // Curseur de reprise après redémarrage
+	UndoID       string `json:"undo_id,omitempty"` // Handle pour annuler tout le job
+}
+
+// SavedSearch - Filtre enregistré par l'utilisateur
+type SavedSearch struct {
+	ID        int          `json:"id" db:"id"`
+	UserID    int          `json:"-" db:"user_id"`
+	Name      string       `json:"name" db:"name"`
+	Filter    *EmailFilter `json:"filter" db:"filter"`
+	CreatedAt time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at" db:"updated_at"`
+}
+
+// SavedSearchRequest - Créer ou remplacer une recherche enregistrée
+type SavedSearchRequest struct {
+	Name   string       `json:"name" validate:"required"`
+	Filter *EmailFilter `json:"filter" validate:"required"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// ClassifierExample - Décision de l'utilisateur sur un email (indésirable ou conservé) et lexèmes appris
+type ClassifierExample struct {
+	EmailID   string    `json:"email_id" db:"email_id"`
+	IsJunk    bool      `json:"is_junk" db:"is_junk"`
+	Tokens    []string  `json:"tokens" db:"tokens"`
+	TrainedAt time.Time `json:"trained_at" db:"trained_at"`
+}
+
+// ClassifierTokenCount - Occurrences d'un lexème dans les exemples indésirables et conservés
+type ClassifierTokenCount struct {
+	Junk int `json:"junk"`
+	Keep int `json:"keep"`
+}
+
+// ClassifierStats - État du modèle d'un utilisateur
+type ClassifierStats struct {
+	JunkExamples  int        `json:"junk_examples"`
+	KeepExamples  int        `json:"keep_examples"`
+	Vocabulary    int        `json:"vocabulary"`  // Lexèmes distincts
+	JunkTokens    int        `json:"junk_tokens"` // Total des occurrences dans les exemples indésirables
+	KeepTokens    int        `json:"keep_tokens"`
+	LastTrainedAt *time.Time `json:"last_trained_at,omitempty"`
+	Ready         bool       `json:"ready"`        // Assez d'exemples de chaque classe pour calculer un score
+	MinExamples   int        `json:"min_examples"` // Exemples requis par classe
+}
+
+// ClassifierRetrainProgress - Avancement d'un job classifier_retrain
+type ClassifierRetrainProgress struct {
+	Rebuilt bool   `json:"rebuilt"` // Comptes de lexèmes reconstruits depuis les exemples
+	Scanned int    `json:"scanned"`
+	Scored  int    `json:"scored"`
+	LastID  string `json:"last_id,omitempty"` // Curseur de reprise après redémarrage
+}
@@ -0,0 +1,58 @@
+package models
+
+// DuplicateMatch - Critère ayant rapproché les copies d'un groupe
+type DuplicateMatch string
+
+const (
+	DuplicateByMessageID   DuplicateMatch = "message_id"  // Même en-tête Message-ID
+	DuplicateByFingerprint DuplicateMatch = "fingerprint" // Même sujet, expéditeur, date et taille proches
+)
+
+// DuplicateKeepStrategy - Copie conservée quand aucun compte prioritaire ne départage
+type DuplicateKeepStrategy string
+
+const (
+	KeepOldest DuplicateKeepStrategy = "oldest"
+	KeepNewest DuplicateKeepStrategy = "newest"
+)
+
+// IsValid - Stratégie connue
+func (k DuplicateKeepStrategy) IsValid() bool {
+	return k == KeepOldest || k == KeepNewest
+}
+
+// DuplicatePreferences - Règles de choix de la copie à conserver
+type DuplicatePreferences struct {
+	PrimaryAccountID int                   `json:"primary_account_id,omitempty"` // Conserver en priorité la copie de ce compte
+	Keep             DuplicateKeepStrategy `json:"keep"`
+}
+
+// DuplicateGroup - Copies d'un même message
+type DuplicateGroup struct {
+	Key              string         `json:"key"` // Identifiant stable du groupe tant que ses copies ne changent pas
+	MatchedBy        DuplicateMatch `json:"matched_by"`
+	Keep             *Email         `json:"keep"`
+	Duplicates       []*Email       `json:"duplicates"`
+	ReclaimableBytes int64          `json:"reclaimable_bytes"`
+}
+
+// DuplicateReport - Doublons de l'utilisateur sur l'ensemble de ses comptes
+type DuplicateReport struct {
+	Groups           []*DuplicateGroup    `json:"groups"`
+	DuplicateIDs     []string             `json:"duplicate_ids"` // À transmettre tel quel à l'action delete
+	DuplicateCount   int                  `json:"duplicate_count"`
+	ReclaimableBytes int64                `json:"reclaimable_bytes"`
+	Preferences      DuplicatePreferences `json:"preferences"`
+}
+
+// DeleteDuplicatesRequest - Supprimer les doublons en conservant une copie par groupe
+type DeleteDuplicatesRequest struct {
+	PrimaryAccountID *int                   `json:"primary_account_id,omitempty"`
+	Keep             *DuplicateKeepStrategy `json:"keep,omitempty"`
+	GroupKeys        []string               `json:"group_keys,omitempty"` // Vide = tous les groupes
+	Force            bool                   `json:"force,omitempty"`
+
+	DryRun             bool   `json:"dry_run,omitempty"`
+	ConfirmationToken  string `json:"confirmation_token,omitempty"`
+	OverrideProtection bool   `json:"override_protection,omitempty"`
+}
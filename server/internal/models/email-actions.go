@@ -12,23 +12,77 @@ const (
 	ActionMarkUnread EmailAction = "mark_unread"
 	ActionSpam       EmailAction = "mark_spam"
 	ActionNotSpam    EmailAction = "mark_not_spam"
+	ActionRestore    EmailAction = "restore"   // Sortir de la corbeille
+	ActionUnarchive  EmailAction = "unarchive" // Remettre dans la boîte de réception
 )
 
 // EmailActionRequest - Requête d'action sur des emails
 type EmailActionRequest struct {
-	EmailIDs []string    `json:"email_ids" validate:"required,min=1"`
-	Action   EmailAction `json:"action" validate:"required"`
-	Force    bool        `json:"force,omitempty"`
+	EmailIDs           []string    `json:"email_ids" validate:"required,min=1"`
+	Action             EmailAction `json:"action" validate:"required"`
+	Force              bool        `json:"force,omitempty"`
+	DryRun             bool        `json:"dry_run,omitempty"`             // Aperçu et jeton de confirmation, sans rien modifier
+	ConfirmationToken  string      `json:"confirmation_token,omitempty"`  // Requis pour une suppression définitive
+	OverrideProtection bool        `json:"override_protection,omitempty"` // Ignorer les règles de protection (VIP, étoilés...)
+}
+
+// IsProtectedAction - Actions soumises aux règles de protection
+func IsProtectedAction(action EmailAction) bool {
+	return action == ActionDelete || action == ActionSpam
+}
+
+// RequiresConfirmation - Action irréversible, exécutée seulement avec un jeton issu d'un aperçu
+func RequiresConfirmation(action EmailAction, force bool) bool {
+	return action == ActionDelete && force
+}
+
+// ActionPreview - Emails concernés par une action, calculé sans rien modifier
+type ActionPreview struct {
+	Action               EmailAction             `json:"action"`
+	Force                bool                    `json:"force"`
+	Count                int                     `json:"count"`
+	TotalSize            int64                   `json:"total_size"`
+	Accounts             []*ActionPreviewAccount `json:"accounts"`
+	Senders              []*ActionPreviewSender  `json:"senders"` // Principaux expéditeurs
+	SampleSubjects       []string                `json:"sample_subjects"`
+	MissingIDs           []string                `json:"missing_ids,omitempty"` // IDs demandés introuvables
+	ProtectedIDs         []string                `json:"protected_ids"`         // Exclus par les règles de protection
+	ProtectedCount       int                     `json:"protected_count"`
+	RequiresConfirmation bool                    `json:"requires_confirmation"`
+	ConfirmationToken    string                  `json:"confirmation_token"`
+	ExpiresAt            time.Time               `json:"expires_at"`
+}
+
+// ActionPreviewAccount - Emails concernés dans un compte
+type ActionPreviewAccount struct {
+	AccountID int    `json:"account_id"`
+	Email     string `json:"email"`
+	Count     int    `json:"count"`
+	Size      int64  `json:"size"`
+}
+
+// ActionPreviewSender - Emails concernés d'un expéditeur
+type ActionPreviewSender struct {
+	Sender string `json:"sender"`
+	Count  int    `json:"count"`
+	Size   int64  `json:"size"`
 }
 
 // EmailActionResult - Résultat d'une action sur des emails
 type EmailActionResult struct {
-	Action       EmailAction `json:"action"`
-	ProcessedIDs []string    `json:"processed_ids"`
-	FailedIDs    []string    `json:"failed_ids"`
-	SuccessCount int         `json:"success_count"`
-	FailureCount int         `json:"failure_count"`
-	Message      string      `json:"message,omitempty"`
+	Action       EmailAction       `json:"action"`
+	ProcessedIDs []string          `json:"processed_ids"`
+	FailedIDs    []string          `json:"failed_ids"`
+	PendingIDs   []string          `json:"pending_ids"` // Conservés dans l'outbox, réessayés en arrière-plan
+	SkippedIDs   []string          `json:"skipped_ids"` // Protégés, laissés intacts
+	SuccessCount int               `json:"success_count"`
+	FailureCount int               `json:"failure_count"`
+	PendingCount int               `json:"pending_count"`
+	SkippedCount int               `json:"skipped_count"`
+	Errors       map[string]string `json:"errors,omitempty"`       // Raison de l'échec par email
+	SkipReasons  map[string]string `json:"skip_reasons,omitempty"` // Règle de protection par email ignoré
+	Message      string            `json:"message,omitempty"`
+	UndoID       string            `json:"undo_id,omitempty"` // Handle pour annuler toute l'action
 }
 
 // EmailSyncResult - Résultat de synchronisation des emails
@@ -37,6 +91,7 @@ type EmailSyncResult struct {
 	FailedCount   int       `json:"failed_count"`
 	NewEmails     int       `json:"new_emails"`
 	UpdatedEmails int       `json:"updated_emails"`
+	DeletedEmails int       `json:"deleted_emails"`
 	Accounts      []string  `json:"accounts"`
 	LastSync      time.Time `json:"last_sync"`
 }
@@ -45,6 +100,9 @@ type EmailSyncResult struct {
 type AccountSyncResult struct {
 	NewEmails     int `json:"new_emails"`
 	UpdatedEmails int `json:"updated_emails"`
+	DeletedEmails int `json:"deleted_emails"`
+
+	Inserted []*Email `json:"-"` // Emails créés par cette synchronisation
 }
 
 // OAuth2Token - Token OAuth2
@@ -13,22 +13,38 @@ const (
 	ProviderOther   EmailProvider = "other"
 )
 
+// AccountAuthType - Méthode d'authentification auprès du serveur mail
+type AccountAuthType string
+
+const (
+	AuthTypeOAuth2   AccountAuthType = "oauth2"
+	AuthTypePassword AccountAuthType = "password"
+)
+
 type EmailAccount struct {
-	ID             int           `json:"id" db:"id"`
-	UserID         int           `json:"user_id" db:"user_id"`
-	Provider       EmailProvider `json:"provider" db:"provider"`
-	Email          string        `json:"email" db:"email"`
-	DisplayName    string        `json:"display_name" db:"display_name"`
-	AccessToken    string        `json:"-" db:"access_token"`
-	RefreshToken   string        `json:"-" db:"refresh_token"`
-	TokenExpiresAt *time.Time    `json:"-" db:"token_expires_at"`
-	IsActive       bool          `json:"is_active" db:"is_active"`
-	CreatedAt      time.Time     `json:"created_at" db:"created_at"`
-	UpdatedAt      time.Time     `json:"updated_at" db:"updated_at"`
+	ID             int             `json:"id" db:"id"`
+	UserID         int             `json:"user_id" db:"user_id"`
+	Provider       EmailProvider   `json:"provider" db:"provider"`
+	Email          string          `json:"email" db:"email"`
+	DisplayName    string          `json:"display_name" db:"display_name"`
+	AccessToken    string          `json:"-" db:"access_token"`
+	RefreshToken   string          `json:"-" db:"refresh_token"`
+	TokenExpiresAt *time.Time      `json:"-" db:"token_expires_at"`
+	AuthType       AccountAuthType `json:"auth_type" db:"auth_type"`
+	IMAPHost       string          `json:"imap_host,omitempty" db:"imap_host"`
+	IMAPPort       int             `json:"imap_port,omitempty" db:"imap_port"`
+	IsActive       bool            `json:"is_active" db:"is_active"`
+	InactiveReason string          `json:"inactive_reason,omitempty" db:"inactive_reason"`
+	LastSyncedAt   *time.Time      `json:"last_synced_at,omitempty" db:"last_synced_at"`
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at" db:"updated_at"`
 }
 
 type CreateEmailAccountRequest struct {
 	Provider    EmailProvider `json:"provider" validate:"required"`
 	Email       string        `json:"email" validate:"required,email"`
 	DisplayName string        `json:"display_name" validate:"required"`
+	IMAPHost    string        `json:"imap_host,omitempty"`
+	IMAPPort    int           `json:"imap_port,omitempty"`
+	Password    string        `json:"password,omitempty"` // Connexion LOGIN pour les comptes IMAP sans OAuth2
 }
@@ -5,32 +5,68 @@ import (
 )
 
 type Email struct {
-	ID        string    `json:"id" db:"id"`
-	AccountID int       `json:"account_id" db:"account_id"`
-	MessageID string    `json:"message_id" db:"message_id"`
-	Subject   string    `json:"subject" db:"subject"`
-	From      string    `json:"from" db:"from_address"`
-	To        []string  `json:"to" db:"to_addresses"`
-	Date      time.Time `json:"date" db:"date"`
-	Size      int64     `json:"size" db:"size"`
-	IsRead    bool      `json:"is_read" db:"is_read"`
-	IsSpam    bool      `json:"is_spam" db:"is_spam"`
-	IsDeleted bool      `json:"is_deleted" db:"is_deleted"`
-	Labels    []string  `json:"labels" db:"labels"`
+	ID         string    `json:"id" db:"id"`
+	AccountID  int       `json:"account_id" db:"account_id"`
+	MessageID  string    `json:"message_id" db:"message_id"`
+	ProviderID string    `json:"provider_id" db:"provider_id"` // Identifiant côté provider (UID IMAP, ID Gmail...)
+	Subject    string    `json:"subject" db:"subject"`
+	From       string    `json:"from" db:"from_address"`
+	To         []string  `json:"to" db:"to_addresses"`
+	Date       time.Time `json:"date" db:"date"`
+	Size       int64     `json:"size" db:"size"`
+	IsRead     bool      `json:"is_read" db:"is_read"`
+	IsSpam     bool      `json:"is_spam" db:"is_spam"`
+	IsDeleted  bool      `json:"is_deleted" db:"is_deleted"`
+	Labels     []string  `json:"labels" db:"labels"`
+
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"` // Mise à la corbeille Tamis
+
+	Headers  map[string]string `json:"headers,omitempty" db:"headers"` // En-têtes de diffusion (List-*, Precedence...) et d'authentification, clés en minuscules
+	Category EmailCategory     `json:"category,omitempty" db:"category"`
+
+	HasAttachments bool `json:"has_attachments" db:"has_attachments"`
+
+	JunkScore *float64 `json:"junk_score,omitempty" db:"junk_score"` // Probabilité d'email indésirable selon le classifieur de l'utilisateur
+
+	SpamScore   int      `json:"spam_score" db:"spam_score"`               // Score local des signaux de spam des en-têtes
+	SpamSignals []string `json:"spam_signals,omitempty" db:"spam_signals"` // Signaux ayant contribué au score
+	SpamVerdict *bool    `json:"spam_verdict,omitempty" db:"spam_verdict"` // Choix de l'utilisateur (mark_spam / mark_not_spam), prioritaire à la synchronisation
+
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// SpamScoreThreshold - Score local à partir duquel un email synchronisé est considéré comme spam
+const SpamScoreThreshold = 5
+
+// EmailCategory - Nature d'un message déduite de ses en-têtes
+type EmailCategory string
+
+const (
+	CategoryNewsletter   EmailCategory = "newsletter"   // Liste de diffusion, envoi marketing
+	CategoryNotification EmailCategory = "notification" // Message automatique (alerte, facture, no-reply...)
+	CategoryPersonal     EmailCategory = "personal"
+)
+
+// IsValid - Catégorie connue
+func (c EmailCategory) IsValid() bool {
+	return c == CategoryNewsletter || c == CategoryNotification || c == CategoryPersonal
+}
+
 type EmailFilter struct {
-	Provider EmailProvider `json:"provider,omitempty"`
-	From     string        `json:"from,omitempty"`
-	Subject  string        `json:"subject,omitempty"`
-	IsRead   *bool         `json:"is_read,omitempty"`
-	IsSpam   *bool         `json:"is_spam,omitempty"`
-	DateFrom *time.Time    `json:"date_from,omitempty"`
-	DateTo   *time.Time    `json:"date_to,omitempty"`
-	Limit    int           `json:"limit,omitempty"`
-	Offset   int           `json:"offset,omitempty"`
+	Provider     EmailProvider `json:"provider,omitempty"`
+	AccountID    int           `json:"account_id,omitempty"`
+	Category     EmailCategory `json:"category,omitempty"`
+	From         string        `json:"from,omitempty"`
+	Subject      string        `json:"subject,omitempty"`
+	IsRead       *bool         `json:"is_read,omitempty"`
+	IsSpam       *bool         `json:"is_spam,omitempty"`
+	MinJunkScore *float64      `json:"min_junk_score,omitempty"` // Score indésirable minimal (emails non évalués exclus)
+	MaxJunkScore *float64      `json:"max_junk_score,omitempty"`
+	DateFrom     *time.Time    `json:"date_from,omitempty"`
+	DateTo       *time.Time    `json:"date_to,omitempty"`
+	Limit        int           `json:"limit,omitempty"`
+	Offset       int           `json:"offset,omitempty"`
 }
 
 type DeleteEmailsRequest struct {
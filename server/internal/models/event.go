@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// EventType - Type d'événement diffusé au client en temps réel
+type EventType string
+
+const (
+	EventSyncStarted        EventType = "sync.started"
+	EventSyncProgress       EventType = "sync.progress"
+	EventSyncFinished       EventType = "sync.finished"
+	EventSyncFailed         EventType = "sync.failed"
+	EventNewMail            EventType = "mail.new"
+	EventBackfillProgress   EventType = "backfill.progress"
+	EventActionCompleted    EventType = "action.completed"
+	EventBulkActionProgress EventType = "bulk_action.progress"
+	EventRulesProgress      EventType = "rules.progress"
+	EventRetentionRun       EventType = "retention.run"
+	EventOutboxUpdated      EventType = "outbox.updated"
+	EventAccountDeactivated EventType = "account.deactivated"
+	EventStreamReset        EventType = "stream.reset" // Historique perdu : le client doit tout recharger
+)
+
+// Event - Événement adressé à un utilisateur
+type Event struct {
+	ID        string      `json:"id"`
+	UserID    int         `json:"-"`
+	Type      EventType   `json:"type"`
+	Data      interface{} `json:"data,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// JobType - Nature d'un job en arrière-plan
+type JobType string
+
+const (
+	JobTypeSync       JobType = "sync"
+	JobTypeBulkAction JobType = "bulk_action"
+	JobTypeRules      JobType = "cleanup_rules"
+	JobTypeClassifier JobType = "classifier_retrain"
+)
+
+// JobStatus - État d'un job
+type JobStatus string
+
+const (
+	JobPending   JobStatus = "pending"
+	JobRunning   JobStatus = "running"
+	JobCompleted JobStatus = "completed"
+	JobFailed    JobStatus = "failed"
+)
+
+// Job - Traitement asynchrone persisté (repris après redémarrage)
+type Job struct {
+	ID          string          `json:"id" db:"id"`
+	UserID      int             `json:"user_id" db:"user_id"`
+	Type        JobType         `json:"type" db:"type"`
+	Status      JobStatus       `json:"status" db:"status"`
+	Params      json.RawMessage `json:"params,omitempty" db:"params"`
+	Result      json.RawMessage `json:"result,omitempty" db:"result"` // Progression puis résultat final, propre au type de job
+	Error       string          `json:"error,omitempty" db:"error"`
+	CreatedAt   time.Time       `json:"created_at" db:"created_at"`
+	StartedAt   *time.Time      `json:"started_at,omitempty" db:"started_at"`
+	CompletedAt *time.Time      `json:"completed_at,omitempty" db:"completed_at"`
+	UpdatedAt   time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+// SyncJobParams - Paramètres d'un job de synchronisation
+type SyncJobParams struct {
+	Force bool `json:"force"`
+}
+
+// AccountSyncProgress - Avancement d'un compte dans un job de synchronisation
+type AccountSyncProgress struct {
+	AccountID     int       `json:"account_id"`
+	Email         string    `json:"email"`
+	Status        JobStatus `json:"status"`
+	NewEmails     int       `json:"new_emails"`
+	UpdatedEmails int       `json:"updated_emails"`
+	DeletedEmails int       `json:"deleted_emails"`
+	Error         string    `json:"error,omitempty"`
+}
+
+// SyncJobResult - Progression et résultat d'un job de synchronisation
+type SyncJobResult struct {
+	Summary  *EmailSyncResult       `json:"summary"`
+	Accounts []*AccountSyncProgress `json:"accounts"`
+}
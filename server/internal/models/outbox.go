@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// OutboxStatus - État d'une action provider en attente
+type OutboxStatus string
+
+const (
+	OutboxPending   OutboxStatus = "pending"
+	OutboxRunning   OutboxStatus = "running"
+	OutboxCompleted OutboxStatus = "completed"
+	OutboxFailed    OutboxStatus = "failed"
+	OutboxCancelled OutboxStatus = "cancelled"
+)
+
+// OutboxOperation - Action à appliquer chez le provider pour un email
+type OutboxOperation struct {
+	ID            int64        `json:"id" db:"id"`
+	UserID        int          `json:"-" db:"user_id"`
+	AccountID     int          `json:"account_id" db:"account_id"`
+	EmailID       string       `json:"email_id" db:"email_id"`
+	ProviderID    string       `json:"-" db:"provider_id"`
+	Action        EmailAction  `json:"action" db:"action"`
+	Force         bool         `json:"force,omitempty" db:"force"`
+	Status        OutboxStatus `json:"status" db:"status"`
+	Attempts      int          `json:"attempts" db:"attempts"`
+	NextAttemptAt time.Time    `json:"next_attempt_at" db:"next_attempt_at"`
+	LastError     string       `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt     time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at" db:"updated_at"`
+}
+
+// OutboxOperationsRequest - Sélection d'opérations à relancer ou annuler
+type OutboxOperationsRequest struct {
+	IDs []int64 `json:"ids" validate:"required,min=1"`
+}
@@ -0,0 +1,19 @@
+package models
+
+// ProtectionRules - Emails jamais supprimés ni classés en spam sans dérogation explicite
+type ProtectionRules struct {
+	Senders           []string `json:"senders"`             // Expéditeurs VIP : adresses ou domaines ("boss@acme.com", "acme.com")
+	ProtectFlagged    bool     `json:"protect_flagged"`     // Étoilés, suivis ou marqués importants
+	RecentDays        int      `json:"recent_days"`         // Emails reçus depuis moins de N jours ; 0 = désactivé
+	AttachmentMinSize int64    `json:"attachment_min_size"` // Emails avec pièces jointes d'au moins N octets ; 0 = désactivé
+}
+
+// ProtectionReason - Règle ayant protégé un email
+type ProtectionReason string
+
+const (
+	ProtectedVIPSender  ProtectionReason = "vip_sender"
+	ProtectedFlagged    ProtectionReason = "flagged"
+	ProtectedRecent     ProtectionReason = "recent"
+	ProtectedAttachment ProtectionReason = "attachment"
+)
@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+// RetentionPolicy - Nettoyage automatique des emails anciens d'un compte, éventuellement limité à un libellé ou une catégorie
+type RetentionPolicy struct {
+	ID            int           `json:"id" db:"id"`
+	UserID        int           `json:"-" db:"user_id"`
+	AccountID     int           `json:"account_id" db:"account_id"`
+	Label         string        `json:"label,omitempty" db:"label"`       // Libellé ciblé (vide = tous les emails)
+	Category      EmailCategory `json:"category,omitempty" db:"category"` // Catégorie ciblée (vide = toutes)
+	OlderThanDays int           `json:"older_than_days" db:"older_than_days"`
+	Action        EmailAction   `json:"action" db:"action"`
+	Enabled       bool          `json:"enabled" db:"enabled"`
+	LastRunAt     *time.Time    `json:"last_run_at,omitempty" db:"last_run_at"`
+	CreatedAt     time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at" db:"updated_at"`
+}
+
+// RetentionPolicyRequest - Créer ou remplacer une politique de rétention
+type RetentionPolicyRequest struct {
+	AccountID     int           `json:"account_id" validate:"required"`
+	Label         string        `json:"label,omitempty"`
+	Category      EmailCategory `json:"category,omitempty"`
+	OlderThanDays int           `json:"older_than_days" validate:"required"`
+	Action        EmailAction   `json:"action" validate:"required"`
+	Enabled       *bool         `json:"enabled,omitempty"` // Activée par défaut
+}
+
+// RetentionRun - Résumé d'une exécution d'une politique de rétention
+type RetentionRun struct {
+	ID           int64       `json:"id" db:"id"`
+	UserID       int         `json:"-" db:"user_id"`
+	PolicyID     int         `json:"policy_id" db:"policy_id"`
+	AccountID    int         `json:"account_id" db:"account_id"`
+	Action       EmailAction `json:"action" db:"action"`
+	MatchedCount int         `json:"matched_count" db:"matched_count"`
+	SuccessCount int         `json:"success_count" db:"success_count"`
+	FailureCount int         `json:"failure_count" db:"failure_count"`
+	PendingCount int         `json:"pending_count" db:"pending_count"`
+	SkippedCount int         `json:"skipped_count" db:"skipped_count"` // Emails protégés
+	Error        string      `json:"error,omitempty" db:"error"`
+	StartedAt    time.Time   `json:"started_at" db:"started_at"`
+	FinishedAt   *time.Time  `json:"finished_at,omitempty" db:"finished_at"`
+}
+
+// RetentionOutcome - Sort d'un email lors d'une exécution
+type RetentionOutcome string
+
+const (
+	RetentionProcessed RetentionOutcome = "processed"
+	RetentionFailed    RetentionOutcome = "failed"
+	RetentionPending   RetentionOutcome = "pending" // Action provider différée (outbox)
+	RetentionSkipped   RetentionOutcome = "skipped" // Email protégé
+)
+
+// RetentionRunItem - Email concerné par une exécution (journal conservé même après suppression de l'email)
+type RetentionRunItem struct {
+	EmailID string           `json:"email_id" db:"email_id"`
+	From    string           `json:"from" db:"from_address"`
+	Subject string           `json:"subject" db:"subject"`
+	Date    time.Time        `json:"date" db:"date"`
+	Outcome RetentionOutcome `json:"outcome" db:"outcome"`
+	Reason  string           `json:"reason,omitempty" db:"reason"` // Cause de l'échec ou règle de protection
+}
+
+// RetentionRunDetail - Exécution et journal des emails concernés
+type RetentionRunDetail struct {
+	RetentionRun
+	Items []*RetentionRunItem `json:"items"`
+}
@@ -0,0 +1,156 @@
+package models
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// RuleField - Champ d'un email évalué par une condition
+type RuleField string
+
+const (
+	RuleFieldSender         RuleField = "sender"  // Adresse de l'expéditeur
+	RuleFieldDomain         RuleField = "domain"  // Domaine de l'expéditeur
+	RuleFieldTo             RuleField = "to"      // Un des destinataires
+	RuleFieldSubject        RuleField = "subject" // Sujet
+	RuleFieldHeader         RuleField = "header"  // En-tête conservé (Header précise son nom)
+	RuleFieldLabel          RuleField = "label"   // Un des libellés
+	RuleFieldCategory       RuleField = "category"
+	RuleFieldAgeDays        RuleField = "age_days" // Ancienneté en jours
+	RuleFieldSize           RuleField = "size"     // Taille en octets
+	RuleFieldIsRead         RuleField = "is_read"
+	RuleFieldIsSpam         RuleField = "is_spam"
+	RuleFieldHasAttachments RuleField = "has_attachments"
+)
+
+// RuleOperator - Comparaison appliquée par une condition (insensible à la casse pour les textes)
+type RuleOperator string
+
+const (
+	RuleOpEquals     RuleOperator = "equals"
+	RuleOpContains   RuleOperator = "contains"
+	RuleOpStartsWith RuleOperator = "starts_with"
+	RuleOpEndsWith   RuleOperator = "ends_with"
+	RuleOpMatches    RuleOperator = "matches" // Expression régulière
+	RuleOpExists     RuleOperator = "exists"  // En-tête présent
+	RuleOpGreater    RuleOperator = "gt"
+	RuleOpLess       RuleOperator = "lt"
+)
+
+// RuleValue - Valeur comparée, acceptée en JSON comme texte, nombre ou booléen
+type RuleValue string
+
+// UnmarshalJSON - Convertir un nombre ou un booléen JSON en texte
+func (v *RuleValue) UnmarshalJSON(data []byte) error {
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	switch value := raw.(type) {
+	case nil:
+		*v = ""
+	case string:
+		*v = RuleValue(value)
+	case bool:
+		*v = RuleValue(strconv.FormatBool(value))
+	default:
+		*v = RuleValue(string(data))
+	}
+	return nil
+}
+
+// RuleCondition - Nœud de l'arbre de conditions : groupe (All = ET, Any = OU) ou comparaison sur un champ
+type RuleCondition struct {
+	All []*RuleCondition `json:"all,omitempty"`
+	Any []*RuleCondition `json:"any,omitempty"`
+
+	Field    RuleField    `json:"field,omitempty"`
+	Header   string       `json:"header,omitempty"` // Nom de l'en-tête pour le champ header
+	Operator RuleOperator `json:"operator,omitempty"`
+	Value    RuleValue    `json:"value,omitempty"`
+
+	Negate bool `json:"negate,omitempty"` // Inverser le résultat du nœud
+}
+
+// CleanupRule - Règle de nettoyage : conditions sur un email et action à appliquer
+type CleanupRule struct {
+	ID             int            `json:"id" db:"id"`
+	UserID         int            `json:"-" db:"user_id"`
+	AccountID      int            `json:"account_id,omitempty" db:"account_id"` // 0 = tous les comptes
+	Name           string         `json:"name" db:"name"`
+	Enabled        bool           `json:"enabled" db:"enabled"`
+	Priority       int            `json:"priority" db:"priority"` // Ordre d'évaluation croissant
+	Conditions     *RuleCondition `json:"conditions" db:"conditions"`
+	Action         EmailAction    `json:"action" db:"action"`
+	StopProcessing bool           `json:"stop_processing" db:"stop_processing"` // Ne plus évaluer les règles suivantes
+	CreatedAt      time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// CleanupRuleRequest - Créer ou remplacer une règle
+type CleanupRuleRequest struct {
+	Name           string         `json:"name" validate:"required"`
+	AccountID      int            `json:"account_id,omitempty"`
+	Enabled        *bool          `json:"enabled,omitempty"` // Activée par défaut
+	Priority       int            `json:"priority"`
+	Conditions     *RuleCondition `json:"conditions" validate:"required"`
+	Action         EmailAction    `json:"action" validate:"required"`
+	StopProcessing bool           `json:"stop_processing,omitempty"`
+}
+
+// RuleRunRequest - Appliquer les règles aux emails existants
+type RuleRunRequest struct {
+	AccountID int   `json:"account_id,omitempty"` // 0 = tous les comptes
+	RuleIDs   []int `json:"rule_ids,omitempty"`   // Vide = toutes les règles actives
+	DryRun    bool  `json:"dry_run,omitempty"`    // Compter les correspondances sans rien modifier
+}
+
+// RuleRunParams - Paramètres d'un job cleanup_rules
+type RuleRunParams struct {
+	AccountID int   `json:"account_id,omitempty"`
+	RuleIDs   []int `json:"rule_ids,omitempty"`
+}
+
+// RuleRunProgress - Avancement (ou aperçu) de l'application des règles
+type RuleRunProgress struct {
+	Scanned      int                 `json:"scanned"`
+	Matched      int                 `json:"matched"`       // Emails concernés par au moins une règle
+	RuleMatches  map[int]int         `json:"rule_matches"`  // Correspondances par règle
+	ActionCounts map[EmailAction]int `json:"action_counts"` // Emails par action
+	SuccessCount int                 `json:"success_count"`
+	FailureCount int                 `json:"failure_count"`
+	PendingCount int                 `json:"pending_count"`
+	SkippedCount int                 `json:"skipped_count"`     // Emails protégés
+	LastID       string              `json:"last_id,omitempty"` // Curseur de reprise après redémarrage
+}
+
+// SieveIssue - Construction Sieve ignorée à l'import, ou règle non exportable
+type SieveIssue struct {
+	Line      int    `json:"line,omitempty"`    // Ligne du script importé
+	RuleID    int    `json:"rule_id,omitempty"` // Règle non exportée
+	Construct string `json:"construct"`         // Commande, test, action ou champ concerné
+	Message   string `json:"message"`
+}
+
+// SieveImportRequest - Convertir un script Sieve en règles de nettoyage
+type SieveImportRequest struct {
+	Script    string `json:"script" validate:"required"`
+	AccountID int    `json:"account_id,omitempty"` // Restreindre les règles importées à un compte
+	DryRun    bool   `json:"dry_run,omitempty"`    // Convertir sans enregistrer
+}
+
+// SieveImportResult - Règles obtenues et constructions non prises en charge
+type SieveImportResult struct {
+	Rules       []*CleanupRule `json:"rules"`
+	Created     int            `json:"created"`
+	Unsupported []*SieveIssue  `json:"unsupported"`
+}
+
+// SieveExport - Script Sieve équivalent aux règles de l'utilisateur
+type SieveExport struct {
+	Script      string        `json:"script"`
+	Exported    int           `json:"exported"`
+	Unsupported []*SieveIssue `json:"unsupported"`
+}
@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+// SenderGroupBy - Regroupement du classement des expéditeurs
+type SenderGroupBy string
+
+const (
+	GroupBySender SenderGroupBy = "sender" // Adresse complète
+	GroupByDomain SenderGroupBy = "domain" // Domaine de l'adresse
+)
+
+// IsValid - Regroupement connu
+func (g SenderGroupBy) IsValid() bool {
+	return g == GroupBySender || g == GroupByDomain
+}
+
+// SenderSort - Critère de tri du classement
+type SenderSort string
+
+const (
+	SenderSortCount  SenderSort = "count"
+	SenderSortSize   SenderSort = "size"
+	SenderSortUnread SenderSort = "unread_ratio"
+	SenderSortNewest SenderSort = "newest"
+	SenderSortOldest SenderSort = "oldest"
+)
+
+// SenderStats - Volume de mail d'un expéditeur (ou d'un domaine)
+type SenderStats struct {
+	Key         string    `json:"key"` // Adresse ou domaine, à renvoyer tel quel à /api/senders/action
+	Count       int       `json:"count"`
+	TotalSize   int64     `json:"total_size"`
+	UnreadCount int       `json:"unread_count"`
+	UnreadRatio float64   `json:"unread_ratio"`
+	OldestDate  time.Time `json:"oldest_date"`
+	NewestDate  time.Time `json:"newest_date"`
+}
+
+// SenderQuery - Paramètres du classement des expéditeurs
+type SenderQuery struct {
+	GroupBy   SenderGroupBy `json:"group_by"`
+	AccountID int           `json:"account_id,omitempty"` // 0 = tous les comptes actifs
+	Sort      SenderSort    `json:"sort"`
+	Ascending bool          `json:"ascending,omitempty"`
+	Limit     int           `json:"limit"`
+	Offset    int           `json:"offset"`
+}
+
+// SenderPage - Page du classement
+type SenderPage struct {
+	Senders    []*SenderStats `json:"senders"`
+	TotalCount int            `json:"total_count"`
+	Query      *SenderQuery   `json:"query"`
+}
+
+// SenderActionRequest - Appliquer une action à tout le mail d'un expéditeur ou d'un domaine
+type SenderActionRequest struct {
+	GroupBy   SenderGroupBy `json:"group_by"`
+	Key       string        `json:"key" validate:"required"`
+	AccountID int           `json:"account_id,omitempty"` // 0 = tous les comptes actifs
+	Action    EmailAction   `json:"action" validate:"required"`
+	Force     bool          `json:"force,omitempty"`
+
+	DryRun             bool   `json:"dry_run,omitempty"`
+	ConfirmationToken  string `json:"confirmation_token,omitempty"`
+	OverrideProtection bool   `json:"override_protection,omitempty"`
+}
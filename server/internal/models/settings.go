@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// UserSettings - Préférences d'un utilisateur
+type UserSettings struct {
+	UserID              int  `json:"user_id" db:"user_id"`
+	AutoSyncEnabled     bool `json:"auto_sync_enabled" db:"auto_sync_enabled"`
+	SyncIntervalMinutes int  `json:"sync_interval_minutes" db:"sync_interval_minutes"` // 0 = intervalle par défaut du serveur
+
+	// Doublons : copie à conserver
+	PrimaryAccountID int                   `json:"primary_account_id" db:"primary_account_id"` // 0 = aucun compte prioritaire
+	DuplicateKeep    DuplicateKeepStrategy `json:"duplicate_keep" db:"duplicate_keep"`
+
+	// Corbeille : durée avant purge automatique
+	TrashRetentionDays int `json:"trash_retention_days" db:"trash_retention_days"`
+
+	// Emails protégés contre la suppression et le classement en spam
+	Protection ProtectionRules `json:"protection"`
+
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// UpdateSettingsRequest - Mise à jour partielle des préférences
+type UpdateSettingsRequest struct {
+	AutoSyncEnabled     *bool                  `json:"auto_sync_enabled,omitempty"`
+	SyncIntervalMinutes *int                   `json:"sync_interval_minutes,omitempty"`
+	PrimaryAccountID    *int                   `json:"primary_account_id,omitempty"`
+	DuplicateKeep       *DuplicateKeepStrategy `json:"duplicate_keep,omitempty"`
+	TrashRetentionDays  *int                   `json:"trash_retention_days,omitempty"`
+	Protection          *ProtectionRules       `json:"protection,omitempty"` // Remplace l'ensemble des règles
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// SyncState - Checkpoint de synchronisation d'un compte pour un dossier
+type SyncState struct {
+	AccountID     int       `json:"account_id" db:"account_id"`
+	Mailbox       string    `json:"mailbox" db:"mailbox"`
+	UIDValidity   uint32    `json:"uid_validity,omitempty" db:"uid_validity"`     // IMAP
+	LastUID       uint32    `json:"last_uid,omitempty" db:"last_uid"`             // IMAP
+	HighestModSeq uint64    `json:"highest_modseq,omitempty" db:"highest_modseq"` // IMAP CONDSTORE
+	HistoryID     string    `json:"history_id,omitempty" db:"history_id"`         // Gmail
+	DeltaLink     string    `json:"delta_link,omitempty" db:"delta_link"`         // Microsoft Graph
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// SyncChanges - Changements renvoyés par un client depuis le dernier checkpoint
+type SyncChanges struct {
+	Emails      []*Email   // Messages nouveaux ou modifiés (enregistrement complet)
+	FlagChanges []*Email   // Messages dont seuls ProviderID, IsRead, IsSpam et Labels sont renseignés
+	DeletedIDs  []string   // Provider IDs supprimés côté serveur
+	ResetIDs    bool       // Les provider IDs précédents ne sont plus valides (UIDVALIDITY modifiée)
+	State       *SyncState // Nouveau checkpoint à enregistrer
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// TrashPage - Emails de la corbeille Tamis, les plus récemment supprimés d'abord
+type TrashPage struct {
+	Emails        []*Email `json:"emails"`
+	TotalCount    int      `json:"total_count"`
+	RetentionDays int      `json:"retention_days"` // Purge automatique après ce délai
+}
+
+// RestoreRequest - Emails à sortir de la corbeille
+type RestoreRequest struct {
+	EmailIDs []string `json:"email_ids" validate:"required,min=1"`
+}
+
+// ActionBatch - Action annulable : un handle par action (ou par job d'action groupée)
+type ActionBatch struct {
+	ID         string      `json:"id" db:"id"`
+	UserID     int         `json:"-" db:"user_id"`
+	Action     EmailAction `json:"action" db:"action"`
+	EmailCount int         `json:"email_count" db:"email_count"`
+	CreatedAt  time.Time   `json:"created_at" db:"created_at"`
+	ExpiresAt  time.Time   `json:"expires_at" db:"expires_at"`
+	UndoneAt   *time.Time  `json:"undone_at,omitempty" db:"undone_at"`
+}
+
+// ActionBatchItem - État d'un email avant l'action, pour la rétablir
+type ActionBatchItem struct {
+	EmailID string `json:"email_id" db:"email_id"`
+	WasRead bool   `json:"was_read" db:"was_read"`
+	WasSpam bool   `json:"was_spam" db:"was_spam"`
+
+	OutboxID int64 `json:"-" db:"outbox_id"` // Opération provider créée par l'action (0 si inconnue)
+}
@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// UnsubscribeMethod - Moyen utilisé pour se désinscrire d'une liste
+type UnsubscribeMethod string
+
+const (
+	UnsubscribeOneClick UnsubscribeMethod = "one_click" // POST HTTPS RFC 8058
+	UnsubscribeMailto   UnsubscribeMethod = "mailto"    // Message envoyé depuis le compte
+	UnsubscribeLink     UnsubscribeMethod = "link"      // Page web à ouvrir par l'utilisateur
+)
+
+// UnsubscribeStatus - Résultat d'une désinscription
+type UnsubscribeStatus string
+
+const (
+	UnsubscribeSucceeded UnsubscribeStatus = "succeeded"
+	UnsubscribeFailed    UnsubscribeStatus = "failed"
+	UnsubscribeManual    UnsubscribeStatus = "manual" // Aucun moyen automatique : l'utilisateur doit ouvrir Target
+)
+
+// Unsubscription - Dernière désinscription connue pour un expéditeur
+type Unsubscription struct {
+	ID            int64             `json:"id" db:"id"`
+	UserID        int               `json:"-" db:"user_id"`
+	AccountID     int               `json:"account_id" db:"account_id"`
+	Sender        string            `json:"sender" db:"sender"`
+	ListID        string            `json:"list_id,omitempty" db:"list_id"`
+	Method        UnsubscribeMethod `json:"method" db:"method"`
+	Target        string            `json:"target" db:"target"`
+	Status        UnsubscribeStatus `json:"status" db:"status"`
+	Error         string            `json:"error,omitempty" db:"error"`
+	ArchiveFuture bool              `json:"archive_future" db:"archive_future"` // Archiver automatiquement les prochains messages de la liste
+	CreatedAt     time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at" db:"updated_at"`
+}
+
+// UnsubscribeRequest - Se désinscrire de la liste d'un email (ou du dernier email de diffusion d'un expéditeur)
+type UnsubscribeRequest struct {
+	EmailID        string `json:"email_id,omitempty"`
+	Sender         string `json:"sender,omitempty"`
+	DeleteExisting bool   `json:"delete_existing,omitempty"` // Supprimer les messages déjà reçus de cette liste
+	ArchiveFuture  bool   `json:"archive_future,omitempty"`
+}
+
+// UnsubscribeResult - Désinscription enregistrée et nettoyage éventuel
+type UnsubscribeResult struct {
+	Unsubscription *Unsubscription    `json:"unsubscription"`
+	Cleanup        *EmailActionResult `json:"cleanup,omitempty"`
+}
@@ -19,11 +19,15 @@ func NewAccountRepository(db *database.DB) *AccountRepository {
 // Create - Créer un nouveau compte email
 func (r *AccountRepository) Create(account *models.EmailAccount) (*models.EmailAccount, error) {
 	query := `
-        INSERT INTO email_accounts (user_id, provider, email, display_name, access_token, refresh_token, token_expires_at, is_active, created_at, updated_at)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+        INSERT INTO email_accounts (user_id, provider, email, display_name, access_token, refresh_token, token_expires_at, auth_type, imap_host, imap_port, is_active, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
         RETURNING id, created_at, updated_at
     `
 
+	if account.AuthType == "" {
+		account.AuthType = models.AuthTypeOAuth2
+	}
+
 	now := time.Now()
 	err := r.db.QueryRow(
 		query,
@@ -34,6 +38,9 @@ func (r *AccountRepository) Create(account *models.EmailAccount) (*models.EmailA
 		account.AccessToken,
 		account.RefreshToken,
 		account.TokenExpiresAt,
+		account.AuthType,
+		account.IMAPHost,
+		account.IMAPPort,
 		account.IsActive,
 		now,
 		now,
@@ -49,7 +56,7 @@ func (r *AccountRepository) Create(account *models.EmailAccount) (*models.EmailA
 // GetByUserID - Récupérer tous les comptes d'un utilisateur
 func (r *AccountRepository) GetByUserID(userID int) ([]*models.EmailAccount, error) {
 	query := `
-        SELECT id, user_id, provider, email, display_name, is_active, created_at, updated_at
+        SELECT id, user_id, provider, email, display_name, COALESCE(auth_type, 'oauth2'), COALESCE(imap_host, ''), COALESCE(imap_port, 0), is_active, COALESCE(inactive_reason, ''), last_synced_at, created_at, updated_at
         FROM email_accounts
         WHERE user_id = $1
         ORDER BY created_at DESC
@@ -70,7 +77,12 @@ func (r *AccountRepository) GetByUserID(userID int) ([]*models.EmailAccount, err
 			&account.Provider,
 			&account.Email,
 			&account.DisplayName,
+			&account.AuthType,
+			&account.IMAPHost,
+			&account.IMAPPort,
 			&account.IsActive,
+			&account.InactiveReason,
+			&account.LastSyncedAt,
 			&account.CreatedAt,
 			&account.UpdatedAt,
 		)
@@ -86,7 +98,8 @@ func (r *AccountRepository) GetByUserID(userID int) ([]*models.EmailAccount, err
 // GetByID - Récupérer un compte par ID (avec tokens)
 func (r *AccountRepository) GetByID(id int) (*models.EmailAccount, error) {
 	query := `
-        SELECT id, user_id, provider, email, display_name, access_token, refresh_token, token_expires_at, is_active, created_at, updated_at
+        SELECT id, user_id, provider, email, display_name, access_token, refresh_token, token_expires_at,
+               COALESCE(auth_type, 'oauth2'), COALESCE(imap_host, ''), COALESCE(imap_port, 0), is_active, COALESCE(inactive_reason, ''), last_synced_at, created_at, updated_at
         FROM email_accounts
         WHERE id = $1
     `
@@ -101,7 +114,12 @@ func (r *AccountRepository) GetByID(id int) (*models.EmailAccount, error) {
 		&account.AccessToken,
 		&account.RefreshToken,
 		&account.TokenExpiresAt,
+		&account.AuthType,
+		&account.IMAPHost,
+		&account.IMAPPort,
 		&account.IsActive,
+		&account.InactiveReason,
+		&account.LastSyncedAt,
 		&account.CreatedAt,
 		&account.UpdatedAt,
 	)
@@ -116,6 +134,47 @@ func (r *AccountRepository) GetByID(id int) (*models.EmailAccount, error) {
 	return account, nil
 }
 
+// GetActive - Récupérer tous les comptes actifs (planificateur de synchronisation)
+func (r *AccountRepository) GetActive() ([]*models.EmailAccount, error) {
+	query := `
+        SELECT id, user_id, provider, email, display_name, COALESCE(auth_type, 'oauth2'), COALESCE(imap_host, ''), COALESCE(imap_port, 0), is_active, last_synced_at, created_at, updated_at
+        FROM email_accounts
+        WHERE is_active = true
+        ORDER BY id
+    `
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query active email accounts: %w", err)
+	}
+	defer rows.Close()
+
+	var accounts []*models.EmailAccount
+	for rows.Next() {
+		account := &models.EmailAccount{}
+		err := rows.Scan(
+			&account.ID,
+			&account.UserID,
+			&account.Provider,
+			&account.Email,
+			&account.DisplayName,
+			&account.AuthType,
+			&account.IMAPHost,
+			&account.IMAPPort,
+			&account.IsActive,
+			&account.LastSyncedAt,
+			&account.CreatedAt,
+			&account.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan email account: %w", err)
+		}
+		accounts = append(accounts, account)
+	}
+
+	return accounts, rows.Err()
+}
+
 // GetByUserAndEmail - Récupérer un compte par utilisateur et email
 func (r *AccountRepository) GetByUserAndEmail(userID int, email string) (*models.EmailAccount, error) {
 	query := `
@@ -162,11 +221,15 @@ func (r *AccountRepository) UpdateTokens(id int, accessToken, refreshToken strin
 	return nil
 }
 
-// SetActive - Activer/désactiver un compte
-func (r *AccountRepository) SetActive(id int, isActive bool) error {
-	query := `UPDATE email_accounts SET is_active = $1, updated_at = $2 WHERE id = $3`
+// SetActive - Activer/désactiver un compte (reason explique une désactivation)
+func (r *AccountRepository) SetActive(id int, isActive bool, reason string) error {
+	query := `UPDATE email_accounts SET is_active = $1, inactive_reason = NULLIF($2, ''), updated_at = $3 WHERE id = $4`
+
+	if isActive {
+		reason = ""
+	}
 
-	_, err := r.db.Exec(query, isActive, time.Now(), id)
+	_, err := r.db.Exec(query, isActive, reason, time.Now(), id)
 	if err != nil {
 		return fmt.Errorf("failed to update account status: %w", err)
 	}
@@ -174,6 +237,18 @@ func (r *AccountRepository) SetActive(id int, isActive bool) error {
 	return nil
 }
 
+// UpdateLastSynced - Enregistrer la date de dernière synchronisation
+func (r *AccountRepository) UpdateLastSynced(id int, syncedAt time.Time) error {
+	query := `UPDATE email_accounts SET last_synced_at = $1 WHERE id = $2`
+
+	_, err := r.db.Exec(query, syncedAt, id)
+	if err != nil {
+		return fmt.Errorf("failed to update last sync time: %w", err)
+	}
+
+	return nil
+}
+
 // Delete - Supprimer un compte
 func (r *AccountRepository) Delete(id int) error {
 	query := `DELETE FROM email_accounts WHERE id = $1`
@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"tamis-server/internal/database"
+	"tamis-server/internal/models"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type ActionBatchRepository struct {
+	db *database.DB
+}
+
+func NewActionBatchRepository(db *database.DB) *ActionBatchRepository {
+	return &ActionBatchRepository{db: db}
+}
+
+// Create - Enregistrer un nouveau handle d'annulation
+func (r *ActionBatchRepository) Create(batch *models.ActionBatch) error {
+	query := `
+        INSERT INTO action_batches (id, user_id, action, email_count, created_at, expires_at)
+        VALUES ($1, $2, $3, 0, $4, $5)
+    `
+
+	batch.CreatedAt = time.Now()
+	_, err := r.db.Exec(query, batch.ID, batch.UserID, batch.Action, batch.CreatedAt, batch.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create action batch: %w", err)
+	}
+
+	return nil
+}
+
+// AddItems - Ajouter des emails au lot (un email déjà présent garde son état d'origine)
+func (r *ActionBatchRepository) AddItems(batchID string, items []*models.ActionBatchItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	emailIDs := make([]string, len(items))
+	wasRead := make([]bool, len(items))
+	wasSpam := make([]bool, len(items))
+	outboxIDs := make([]int64, len(items))
+	for i, item := range items {
+		emailIDs[i] = item.EmailID
+		wasRead[i] = item.WasRead
+		wasSpam[i] = item.WasSpam
+		outboxIDs[i] = item.OutboxID
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+        INSERT INTO action_batch_items (batch_id, email_id, was_read, was_spam, outbox_id)
+        SELECT $1, item.email_id, item.was_read, item.was_spam, NULLIF(item.outbox_id, 0)
+        FROM UNNEST($2::varchar[], $3::boolean[], $4::boolean[], $5::bigint[]) AS item(email_id, was_read, was_spam, outbox_id)
+        ON CONFLICT (batch_id, email_id) DO NOTHING
+    `, batchID, pq.Array(emailIDs), pq.Array(wasRead), pq.Array(wasSpam), pq.Array(outboxIDs))
+	if err != nil {
+		return fmt.Errorf("failed to add action batch items: %w", err)
+	}
+
+	_, err = tx.Exec(`
+        UPDATE action_batches
+        SET email_count = (SELECT COUNT(*) FROM action_batch_items WHERE batch_id = $1)
+        WHERE id = $1
+    `, batchID)
+	if err != nil {
+		return fmt.Errorf("failed to update action batch: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// Get - Handle d'annulation de l'utilisateur (nil si introuvable)
+func (r *ActionBatchRepository) Get(userID int, id string) (*models.ActionBatch, error) {
+	query := `
+        SELECT id, user_id, action, email_count, created_at, expires_at, undone_at
+        FROM action_batches
+        WHERE id = $1 AND user_id = $2
+    `
+
+	batch := &models.ActionBatch{}
+	err := r.db.QueryRow(query, id, userID).Scan(&batch.ID, &batch.UserID, &batch.Action,
+		&batch.EmailCount, &batch.CreatedAt, &batch.ExpiresAt, &batch.UndoneAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get action batch: %w", err)
+	}
+
+	return batch, nil
+}
+
+// GetItems - Emails du lot avec leur état d'origine
+func (r *ActionBatchRepository) GetItems(batchID string) ([]*models.ActionBatchItem, error) {
+	rows, err := r.db.Query(`SELECT email_id, was_read, was_spam, COALESCE(outbox_id, 0) FROM action_batch_items WHERE batch_id = $1`, batchID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query action batch items: %w", err)
+	}
+	defer rows.Close()
+
+	items := []*models.ActionBatchItem{}
+	for rows.Next() {
+		item := &models.ActionBatchItem{}
+		if err := rows.Scan(&item.EmailID, &item.WasRead, &item.WasSpam, &item.OutboxID); err != nil {
+			return nil, fmt.Errorf("failed to scan action batch item: %w", err)
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// MarkUndone - Réserver l'annulation (false si déjà annulé ou expiré)
+func (r *ActionBatchRepository) MarkUndone(id string, now time.Time) (bool, error) {
+	query := `UPDATE action_batches SET undone_at = $1 WHERE id = $2 AND undone_at IS NULL AND expires_at > $1`
+
+	result, err := r.db.Exec(query, now, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark action batch as undone: %w", err)
+	}
+
+	count, _ := result.RowsAffected()
+	return count > 0, nil
+}
+
+// ReleaseUndo - Rendre le handle de nouveau annulable après un échec, sans les emails déjà rétablis
+func (r *ActionBatchRepository) ReleaseUndo(id string, doneEmailIDs []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if len(doneEmailIDs) > 0 {
+		_, err = tx.Exec(`DELETE FROM action_batch_items WHERE batch_id = $1 AND email_id = ANY($2)`, id, pq.Array(doneEmailIDs))
+		if err != nil {
+			return fmt.Errorf("failed to remove action batch items: %w", err)
+		}
+	}
+
+	_, err = tx.Exec(`
+        UPDATE action_batches
+        SET undone_at = NULL, email_count = (SELECT COUNT(*) FROM action_batch_items WHERE batch_id = $1)
+        WHERE id = $1
+    `, id)
+	if err != nil {
+		return fmt.Errorf("failed to release action batch: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// PurgeExpired - Supprimer les handles expirés
+func (r *ActionBatchRepository) PurgeExpired(before time.Time) (int, error) {
+	result, err := r.db.Exec(`DELETE FROM action_batches WHERE expires_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge action batches: %w", err)
+	}
+
+	count, _ := result.RowsAffected()
+	return int(count), nil
+}
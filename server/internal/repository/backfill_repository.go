@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"tamis-server/internal/database"
+	"tamis-server/internal/models"
+	"time"
+)
+
+type BackfillRepository struct {
+	db *database.DB
+}
+
+func NewBackfillRepository(db *database.DB) *BackfillRepository {
+	return &BackfillRepository{db: db}
+}
+
+// backfillColumns - Colonnes sélectionnées pour construire un models.BackfillJob
+const backfillColumns = `account_id, status, COALESCE(cursor, ''), fetched, estimated_total, COALESCE(last_error, ''), started_at, updated_at, completed_at`
+
+// scanBackfillJob - Lire une ligne correspondant à backfillColumns
+func scanBackfillJob(row rowScanner) (*models.BackfillJob, error) {
+	job := &models.BackfillJob{}
+	err := row.Scan(
+		&job.AccountID,
+		&job.Status,
+		&job.Cursor,
+		&job.Fetched,
+		&job.EstimatedTotal,
+		&job.LastError,
+		&job.StartedAt,
+		&job.UpdatedAt,
+		&job.CompletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// Get - Récupérer le backfill d'un compte (nil si aucun backfill lancé)
+func (r *BackfillRepository) Get(accountID int) (*models.BackfillJob, error) {
+	query := `SELECT ` + backfillColumns + ` FROM backfill_jobs WHERE account_id = $1`
+
+	job, err := scanBackfillJob(r.db.QueryRow(query, accountID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get backfill job: %w", err)
+	}
+
+	return job, nil
+}
+
+// Save - Enregistrer (ou remplacer) la progression d'un backfill
+func (r *BackfillRepository) Save(job *models.BackfillJob) error {
+	query := `
+        INSERT INTO backfill_jobs (account_id, status, cursor, fetched, estimated_total, last_error, started_at, updated_at, completed_at)
+        VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8, $9)
+        ON CONFLICT (account_id) DO UPDATE
+        SET status = EXCLUDED.status, cursor = EXCLUDED.cursor, fetched = EXCLUDED.fetched,
+            estimated_total = EXCLUDED.estimated_total, last_error = EXCLUDED.last_error,
+            started_at = EXCLUDED.started_at, updated_at = EXCLUDED.updated_at, completed_at = EXCLUDED.completed_at
+    `
+
+	job.UpdatedAt = time.Now()
+	_, err := r.db.Exec(
+		query,
+		job.AccountID,
+		job.Status,
+		job.Cursor,
+		job.Fetched,
+		job.EstimatedTotal,
+		job.LastError,
+		job.StartedAt,
+		job.UpdatedAt,
+		job.CompletedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to save backfill job: %w", err)
+	}
+
+	return nil
+}
+
+// GetByStatus - Lister les backfills dans un état donné (reprise au démarrage)
+func (r *BackfillRepository) GetByStatus(status models.BackfillStatus) ([]*models.BackfillJob, error) {
+	query := `SELECT ` + backfillColumns + ` FROM backfill_jobs WHERE status = $1 ORDER BY updated_at`
+
+	rows, err := r.db.Query(query, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backfill jobs: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := []*models.BackfillJob{}
+	for rows.Next() {
+		job, err := scanBackfillJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan backfill job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, rows.Err()
+}
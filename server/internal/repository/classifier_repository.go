@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"fmt"
+	"tamis-server/internal/database"
+	"tamis-server/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// ClassifierRepository - Exemples d'apprentissage et comptes de lexèmes du classifieur de chaque utilisateur.
+// classifier_tokens se déduit entièrement de classifier_examples (voir Rebuild).
+type ClassifierRepository struct {
+	db *database.DB
+}
+
+func NewClassifierRepository(db *database.DB) *ClassifierRepository {
+	return &ClassifierRepository{db: db}
+}
+
+// GetExamples - Exemples existants pour ces emails, par ID d'email
+func (r *ClassifierRepository) GetExamples(userID int, emailIDs []string) (map[string]*models.ClassifierExample, error) {
+	examples := map[string]*models.ClassifierExample{}
+	if len(emailIDs) == 0 {
+		return examples, nil
+	}
+
+	rows, err := r.db.Query(`
+        SELECT email_id, is_junk, tokens, trained_at
+        FROM classifier_examples
+        WHERE user_id = $1 AND email_id = ANY($2)
+    `, userID, pq.Array(emailIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query classifier examples: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		example := &models.ClassifierExample{}
+		if err := rows.Scan(&example.EmailID, &example.IsJunk, pq.Array(&example.Tokens), &example.TrainedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan classifier example: %w", err)
+		}
+		examples[example.EmailID] = example
+	}
+
+	return examples, rows.Err()
+}
+
+// Train - Enregistrer des exemples (remplacent ceux des mêmes emails) et appliquer les variations de comptes
+// de lexèmes correspondantes, dans une même transaction
+func (r *ClassifierRepository) Train(userID int, examples []*models.ClassifierExample, deltas map[string]*models.ClassifierTokenCount) error {
+	if len(examples) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, example := range examples {
+		_, err := tx.Exec(`
+            INSERT INTO classifier_examples (user_id, email_id, is_junk, tokens, trained_at)
+            VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP)
+            ON CONFLICT (user_id, email_id) DO UPDATE SET
+                is_junk = EXCLUDED.is_junk, tokens = EXCLUDED.tokens, trained_at = EXCLUDED.trained_at
+        `, userID, example.EmailID, example.IsJunk, pq.Array(example.Tokens))
+		if err != nil {
+			return fmt.Errorf("failed to save classifier example: %w", err)
+		}
+	}
+
+	tokens := make([]string, 0, len(deltas))
+	junk := make([]int64, 0, len(deltas))
+	keep := make([]int64, 0, len(deltas))
+	for token, delta := range deltas {
+		if delta.Junk == 0 && delta.Keep == 0 {
+			continue
+		}
+		tokens = append(tokens, token)
+		junk = append(junk, int64(delta.Junk))
+		keep = append(keep, int64(delta.Keep))
+	}
+	if len(tokens) > 0 {
+		_, err = tx.Exec(`
+            INSERT INTO classifier_tokens (user_id, token, junk_count, keep_count)
+            SELECT $1, item.token, item.junk, item.keep
+            FROM UNNEST($2::varchar[], $3::integer[], $4::integer[]) AS item(token, junk, keep)
+            ON CONFLICT (user_id, token) DO UPDATE SET
+                junk_count = GREATEST(classifier_tokens.junk_count + EXCLUDED.junk_count, 0),
+                keep_count = GREATEST(classifier_tokens.keep_count + EXCLUDED.keep_count, 0)
+        `, userID, pq.Array(tokens), pq.Array(junk), pq.Array(keep))
+		if err != nil {
+			return fmt.Errorf("failed to update classifier tokens: %w", err)
+		}
+
+		_, err = tx.Exec(`
+            DELETE FROM classifier_tokens
+            WHERE user_id = $1 AND token = ANY($2) AND junk_count <= 0 AND keep_count <= 0
+        `, userID, pq.Array(tokens))
+		if err != nil {
+			return fmt.Errorf("failed to prune classifier tokens: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Rebuild - Recalculer les comptes de lexèmes depuis les exemples
+func (r *ClassifierRepository) Rebuild(userID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM classifier_tokens WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to clear classifier tokens: %w", err)
+	}
+
+	_, err = tx.Exec(`
+        INSERT INTO classifier_tokens (user_id, token, junk_count, keep_count)
+        SELECT $1, token, COUNT(*) FILTER (WHERE is_junk), COUNT(*) FILTER (WHERE NOT is_junk)
+        FROM classifier_examples, UNNEST(tokens) AS token
+        WHERE user_id = $1
+        GROUP BY token
+    `, userID)
+	if err != nil {
+		return fmt.Errorf("failed to rebuild classifier tokens: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// GetStats - Nombre d'exemples par classe et taille du vocabulaire
+func (r *ClassifierRepository) GetStats(userID int) (*models.ClassifierStats, error) {
+	stats := &models.ClassifierStats{}
+
+	err := r.db.QueryRow(`
+        SELECT COUNT(*) FILTER (WHERE is_junk), COUNT(*) FILTER (WHERE NOT is_junk), MAX(trained_at)
+        FROM classifier_examples
+        WHERE user_id = $1
+    `, userID).Scan(&stats.JunkExamples, &stats.KeepExamples, &stats.LastTrainedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count classifier examples: %w", err)
+	}
+
+	err = r.db.QueryRow(`
+        SELECT COUNT(*), COALESCE(SUM(junk_count), 0), COALESCE(SUM(keep_count), 0)
+        FROM classifier_tokens
+        WHERE user_id = $1
+    `, userID).Scan(&stats.Vocabulary, &stats.JunkTokens, &stats.KeepTokens)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count classifier tokens: %w", err)
+	}
+
+	return stats, nil
+}
+
+// GetTokenCounts - Comptes des lexèmes connus parmi tokens
+func (r *ClassifierRepository) GetTokenCounts(userID int, tokens []string) (map[string]*models.ClassifierTokenCount, error) {
+	counts := map[string]*models.ClassifierTokenCount{}
+	if len(tokens) == 0 {
+		return counts, nil
+	}
+
+	rows, err := r.db.Query(`
+        SELECT token, junk_count, keep_count
+        FROM classifier_tokens
+        WHERE user_id = $1 AND token = ANY($2)
+    `, userID, pq.Array(tokens))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query classifier tokens: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var token string
+		count := &models.ClassifierTokenCount{}
+		if err := rows.Scan(&token, &count.Junk, &count.Keep); err != nil {
+			return nil, fmt.Errorf("failed to scan classifier token: %w", err)
+		}
+		counts[token] = count
+	}
+
+	return counts, rows.Err()
+}
+
+// Reset - Oublier tous les exemples et lexèmes de l'utilisateur
+func (r *ClassifierRepository) Reset(userID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM classifier_tokens WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to clear classifier tokens: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM classifier_examples WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to clear classifier examples: %w", err)
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"tamis-server/internal/database"
+	"tamis-server/internal/models"
+	"time"
+)
+
+type CleanupRuleRepository struct {
+	db *database.DB
+}
+
+func NewCleanupRuleRepository(db *database.DB) *CleanupRuleRepository {
+	return &CleanupRuleRepository{db: db}
+}
+
+// cleanupRuleColumns - Colonnes sélectionnées pour construire un models.CleanupRule
+const cleanupRuleColumns = `id, user_id, COALESCE(account_id, 0), name, enabled, priority, conditions::text, action, stop_processing, created_at, updated_at`
+
+// scanCleanupRule - Lire une ligne correspondant à cleanupRuleColumns
+func scanCleanupRule(row rowScanner) (*models.CleanupRule, error) {
+	rule := &models.CleanupRule{}
+	var conditions string
+	err := row.Scan(&rule.ID, &rule.UserID, &rule.AccountID, &rule.Name, &rule.Enabled, &rule.Priority,
+		&conditions, &rule.Action, &rule.StopProcessing, &rule.CreatedAt, &rule.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(conditions), &rule.Conditions); err != nil {
+		return nil, fmt.Errorf("failed to decode cleanup rule conditions: %w", err)
+	}
+	return rule, nil
+}
+
+// Create - Enregistrer une nouvelle règle
+func (r *CleanupRuleRepository) Create(rule *models.CleanupRule) error {
+	conditions, err := json.Marshal(rule.Conditions)
+	if err != nil {
+		return fmt.Errorf("failed to encode cleanup rule conditions: %w", err)
+	}
+
+	query := `
+        INSERT INTO cleanup_rules (user_id, account_id, name, enabled, priority, conditions, action, stop_processing, created_at, updated_at)
+        VALUES ($1, NULLIF($2, 0), $3, $4, $5, $6, $7, $8, $9, $9)
+        RETURNING id, created_at, updated_at
+    `
+
+	err = r.db.QueryRow(query, rule.UserID, rule.AccountID, rule.Name, rule.Enabled, rule.Priority,
+		string(conditions), rule.Action, rule.StopProcessing, time.Now()).
+		Scan(&rule.ID, &rule.CreatedAt, &rule.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create cleanup rule: %w", err)
+	}
+
+	return nil
+}
+
+// Update - Remplacer une règle de l'utilisateur (false si introuvable)
+func (r *CleanupRuleRepository) Update(rule *models.CleanupRule) (bool, error) {
+	conditions, err := json.Marshal(rule.Conditions)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode cleanup rule conditions: %w", err)
+	}
+
+	query := `
+        UPDATE cleanup_rules
+        SET account_id = NULLIF($3, 0), name = $4, enabled = $5, priority = $6, conditions = $7,
+            action = $8, stop_processing = $9, updated_at = $10
+        WHERE id = $1 AND user_id = $2
+        RETURNING created_at, updated_at
+    `
+
+	err = r.db.QueryRow(query, rule.ID, rule.UserID, rule.AccountID, rule.Name, rule.Enabled, rule.Priority,
+		string(conditions), rule.Action, rule.StopProcessing, time.Now()).
+		Scan(&rule.CreatedAt, &rule.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to update cleanup rule: %w", err)
+	}
+
+	return true, nil
+}
+
+// Get - Règle de l'utilisateur (nil si introuvable)
+func (r *CleanupRuleRepository) Get(userID, id int) (*models.CleanupRule, error) {
+	query := `SELECT ` + cleanupRuleColumns + ` FROM cleanup_rules WHERE id = $1 AND user_id = $2`
+
+	rule, err := scanCleanupRule(r.db.QueryRow(query, id, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get cleanup rule: %w", err)
+	}
+
+	return rule, nil
+}
+
+// GetByUser - Règles de l'utilisateur dans leur ordre d'évaluation
+func (r *CleanupRuleRepository) GetByUser(userID int) ([]*models.CleanupRule, error) {
+	query := `SELECT ` + cleanupRuleColumns + ` FROM cleanup_rules WHERE user_id = $1 ORDER BY priority, id`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query cleanup rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []*models.CleanupRule{}
+	for rows.Next() {
+		rule, err := scanCleanupRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan cleanup rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
+
+// Delete - Supprimer une règle de l'utilisateur (false si introuvable)
+func (r *CleanupRuleRepository) Delete(userID, id int) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM cleanup_rules WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete cleanup rule: %w", err)
+	}
+
+	count, _ := result.RowsAffected()
+	return count > 0, nil
+}
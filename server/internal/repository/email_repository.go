@@ -2,6 +2,7 @@ package repository
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"tamis-server/internal/database"
@@ -11,6 +12,73 @@ import (
 	"github.com/lib/pq"
 )
 
+// emailColumns - Colonnes sélectionnées pour construire un models.Email
+const emailColumns = `id, account_id, message_id, COALESCE(provider_id, ''), subject, from_address, to_addresses, date, size, is_read, is_spam, is_deleted, labels, deleted_at, COALESCE(headers::text, ''), COALESCE(category, ''), has_attachments, junk_score, COALESCE(spam_score, 0), spam_signals, spam_verdict, created_at, updated_at`
+
+// rowScanner - Interface commune à *sql.Row et *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanEmail - Lire une ligne correspondant à emailColumns
+func scanEmail(row rowScanner) (*models.Email, error) {
+	email := &models.Email{}
+	var headers string
+	err := row.Scan(
+		&email.ID,
+		&email.AccountID,
+		&email.MessageID,
+		&email.ProviderID,
+		&email.Subject,
+		&email.From,
+		pq.Array(&email.To),
+		&email.Date,
+		&email.Size,
+		&email.IsRead,
+		&email.IsSpam,
+		&email.IsDeleted,
+		pq.Array(&email.Labels),
+		&email.DeletedAt,
+		&headers,
+		&email.Category,
+		&email.HasAttachments,
+		&email.JunkScore,
+		&email.SpamScore,
+		pq.Array(&email.SpamSignals),
+		&email.SpamVerdict,
+		&email.CreatedAt,
+		&email.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if headers != "" {
+		if err := json.Unmarshal([]byte(headers), &email.Headers); err != nil {
+			return nil, fmt.Errorf("failed to decode email headers: %w", err)
+		}
+	}
+	return email, nil
+}
+
+// encodeHeaders - En-têtes en JSON pour une colonne JSONB ("" si aucun)
+func encodeHeaders(headers map[string]string) string {
+	if len(headers) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(headers)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// syncedLabels - Libellés reçus du provider ($labels), en conservant le marqueur "archived" posé par Tamis
+// tant que le message n'est pas revenu dans INBOX : le provider ne connaît pas ce marqueur
+func syncedLabels(labels string) string {
+	return `(` + labels + ` || CASE WHEN 'archived' = ANY(COALESCE(emails.labels, '{}')) AND NOT 'INBOX' = ANY(COALESCE(` + labels + `, '{}'))
+        THEN '{archived}'::text[] END)`
+}
+
 type EmailRepository struct {
 	db *database.DB
 }
@@ -22,8 +90,8 @@ func NewEmailRepository(db *database.DB) *EmailRepository {
 // Create - Créer un nouvel email
 func (r *EmailRepository) Create(email *models.Email) (*models.Email, error) {
 	query := `
-        INSERT INTO emails (id, account_id, message_id, subject, from_address, to_addresses, date, size, is_read, is_spam, is_deleted, labels, created_at, updated_at)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+        INSERT INTO emails (id, account_id, message_id, provider_id, subject, from_address, to_addresses, date, size, is_read, is_spam, is_deleted, deleted_at, labels, headers, category, has_attachments, spam_score, spam_signals, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, CASE WHEN $12 THEN $19::timestamp END, $13, NULLIF($14, '')::jsonb, NULLIF($15, ''), $16, $17, $18, $19, $20)
         RETURNING created_at, updated_at
     `
 
@@ -33,6 +101,7 @@ func (r *EmailRepository) Create(email *models.Email) (*models.Email, error) {
 		email.ID,
 		email.AccountID,
 		email.MessageID,
+		email.ProviderID,
 		email.Subject,
 		email.From,
 		pq.Array(email.To),
@@ -42,6 +111,11 @@ func (r *EmailRepository) Create(email *models.Email) (*models.Email, error) {
 		email.IsSpam,
 		email.IsDeleted,
 		pq.Array(email.Labels),
+		encodeHeaders(email.Headers),
+		email.Category,
+		email.HasAttachments,
+		email.SpamScore,
+		pq.Array(email.SpamSignals),
 		now,
 		now,
 	).Scan(&email.CreatedAt, &email.UpdatedAt)
@@ -53,31 +127,222 @@ func (r *EmailRepository) Create(email *models.Email) (*models.Email, error) {
 	return email, nil
 }
 
+// Upsert - Créer un email ou mettre à jour ses données provider ; inserted indique une création,
+// changed=false si la ligne existait déjà à l'identique. Un email mis à la corbeille côté provider
+// reçoit une date de suppression (purge automatique), effacée s'il en ressort.
+func (r *EmailRepository) Upsert(email *models.Email) (inserted bool, changed bool, err error) {
+	query := `
+        INSERT INTO emails (id, account_id, message_id, provider_id, subject, from_address, to_addresses, date, size, is_read, is_spam, is_deleted, deleted_at, labels, headers, category, has_attachments, spam_score, spam_signals, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, CASE WHEN $12 THEN $19::timestamp END, $13, NULLIF($14, '')::jsonb, NULLIF($15, ''), $16, $17, $18, $19, $19)
+        ON CONFLICT (id) DO UPDATE
+        SET subject = EXCLUDED.subject, from_address = EXCLUDED.from_address, to_addresses = EXCLUDED.to_addresses,
+            date = EXCLUDED.date, size = EXCLUDED.size, is_read = EXCLUDED.is_read,
+            is_spam = COALESCE(emails.spam_verdict, EXCLUDED.is_spam),
+            is_deleted = EXCLUDED.is_deleted, deleted_at = CASE WHEN EXCLUDED.is_deleted THEN COALESCE(emails.deleted_at, EXCLUDED.deleted_at) END,
+            labels = ` + syncedLabels("EXCLUDED.labels") + `,
+            headers = COALESCE(EXCLUDED.headers, emails.headers), category = COALESCE(EXCLUDED.category, emails.category),
+            has_attachments = emails.has_attachments OR EXCLUDED.has_attachments,
+            spam_score = EXCLUDED.spam_score, spam_signals = EXCLUDED.spam_signals, updated_at = EXCLUDED.updated_at
+        WHERE (emails.subject, emails.is_read, emails.is_spam, emails.is_deleted, emails.labels, emails.category)
+              IS DISTINCT FROM (EXCLUDED.subject, EXCLUDED.is_read, COALESCE(emails.spam_verdict, EXCLUDED.is_spam), EXCLUDED.is_deleted, ` + syncedLabels("EXCLUDED.labels") + `, EXCLUDED.category)
+        RETURNING (xmax = 0), created_at, updated_at
+    `
+
+	err = r.db.QueryRow(
+		query,
+		email.ID,
+		email.AccountID,
+		email.MessageID,
+		email.ProviderID,
+		email.Subject,
+		email.From,
+		pq.Array(email.To),
+		email.Date,
+		email.Size,
+		email.IsRead,
+		email.IsSpam,
+		email.IsDeleted,
+		pq.Array(email.Labels),
+		encodeHeaders(email.Headers),
+		email.Category,
+		email.HasAttachments,
+		email.SpamScore,
+		pq.Array(email.SpamSignals),
+		time.Now(),
+	).Scan(&inserted, &email.CreatedAt, &email.UpdatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, false, nil
+		}
+		return false, false, fmt.Errorf("failed to upsert email: %w", err)
+	}
+
+	return inserted, true, nil
+}
+
+// UpdateFlagsByProviderID - Mettre à jour lu/spam/labels d'un email identifié côté provider.
+// Le choix spam de l'utilisateur prévaut ; sinon l'email reste spam si son score local l'indique.
+func (r *EmailRepository) UpdateFlagsByProviderID(accountID int, providerID string, isRead, isSpam bool, labels []string) (bool, error) {
+	query := `
+        UPDATE emails
+        SET is_read = $1, is_spam = COALESCE(spam_verdict, $2 OR COALESCE(spam_score, 0) >= $7), labels = ` + syncedLabels("$3::text[]") + `, updated_at = $4
+        WHERE account_id = $5 AND provider_id = $6
+          AND (is_read, is_spam, labels) IS DISTINCT FROM ($1, COALESCE(spam_verdict, $2 OR COALESCE(spam_score, 0) >= $7), ` + syncedLabels("$3::text[]") + `)
+    `
+
+	result, err := r.db.Exec(query, isRead, isSpam, pq.Array(labels), time.Now(), accountID, providerID, models.SpamScoreThreshold)
+	if err != nil {
+		return false, fmt.Errorf("failed to update email flags: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// GetProviderIDsByAccount - Lister les provider IDs connus d'un compte
+func (r *EmailRepository) GetProviderIDsByAccount(accountID int) ([]string, error) {
+	query := `SELECT provider_id FROM emails WHERE account_id = $1 AND provider_id IS NOT NULL AND is_deleted = false`
+
+	rows, err := r.db.Query(query, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query provider ids: %w", err)
+	}
+	defer rows.Close()
+
+	var providerIDs []string
+	for rows.Next() {
+		var providerID string
+		if err := rows.Scan(&providerID); err != nil {
+			return nil, fmt.Errorf("failed to scan provider id: %w", err)
+		}
+		providerIDs = append(providerIDs, providerID)
+	}
+
+	return providerIDs, nil
+}
+
+// DeleteByProviderIDs - Supprimer les emails disparus du serveur.
+// IMAP et Outlook signalent aussi comme disparus les messages que Tamis a lui-même sortis du dossier
+// synchronisé : ceux de la corbeille Tamis, archivés ou classés en spam par l'utilisateur sont conservés.
+func (r *EmailRepository) DeleteByProviderIDs(accountID int, providerIDs []string) (int, error) {
+	query := `
+        DELETE FROM emails
+        WHERE account_id = $1 AND provider_id = ANY($2) AND is_deleted = false
+          AND NOT 'archived' = ANY(COALESCE(labels, '{}'))
+          AND spam_verdict IS NOT TRUE
+    `
+
+	result, err := r.db.Exec(query, accountID, pq.Array(providerIDs))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete emails by provider ids: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return int(rowsAffected), nil
+}
+
+// DetachProviderIDs - Oublier les provider IDs d'un compte devenus invalides (UIDVALIDITY modifiée).
+// Les lignes sont conservées et rattachées par Message-ID lors de la resynchronisation.
+func (r *EmailRepository) DetachProviderIDs(accountID int) (int, error) {
+	query := `UPDATE emails SET provider_id = NULL, updated_at = $2 WHERE account_id = $1 AND provider_id IS NOT NULL`
+
+	result, err := r.db.Exec(query, accountID, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to detach provider ids: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return int(rowsAffected), nil
+}
+
+// ResolveSyncedID - Identifiant de la ligne à utiliser pour un email synchronisé : la ligne déjà associée
+// à ce provider ID, sinon une ligne détachée de même Message-ID (rattachée au passage), sinon defaultID.
+// Si defaultID est encore porté par une ligne détachée d'un autre message, un identifiant distinct est créé.
+func (r *EmailRepository) ResolveSyncedID(accountID int, providerID, messageID, defaultID string) (string, error) {
+	var id string
+	err := r.db.QueryRow(`SELECT id FROM emails WHERE account_id = $1 AND provider_id = $2 LIMIT 1`, accountID, providerID).Scan(&id)
+	if err == nil {
+		return id, nil
+	}
+	if err != sql.ErrNoRows {
+		return "", fmt.Errorf("failed to get email by provider id: %w", err)
+	}
+
+	if messageID != "" {
+		query := `
+            UPDATE emails SET provider_id = $3, updated_at = $4
+            WHERE id = (
+                SELECT id FROM emails
+                WHERE account_id = $1 AND message_id = $2 AND provider_id IS NULL
+                ORDER BY id
+                LIMIT 1
+            )
+            RETURNING id
+        `
+		err = r.db.QueryRow(query, accountID, messageID, providerID, time.Now()).Scan(&id)
+		if err == nil {
+			return id, nil
+		}
+		if err != sql.ErrNoRows {
+			return "", fmt.Errorf("failed to reattach email: %w", err)
+		}
+	}
+
+	var taken bool
+	if err := r.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM emails WHERE id = $1)`, defaultID).Scan(&taken); err != nil {
+		return "", fmt.Errorf("failed to check email id: %w", err)
+	}
+	if taken {
+		return fmt.Sprintf("%s-%d", defaultID, time.Now().UnixNano()), nil
+	}
+	return defaultID, nil
+}
+
+// DeleteDetached - Supprimer les emails restés détachés après un import complet : ils ont disparu du serveur.
+// Comme pour DeleteByProviderIDs, la corbeille Tamis, les emails archivés et les verdicts spam sont conservés.
+func (r *EmailRepository) DeleteDetached(accountID int) (int, error) {
+	query := `
+        DELETE FROM emails
+        WHERE account_id = $1 AND provider_id IS NULL AND is_deleted = false
+          AND NOT 'archived' = ANY(COALESCE(labels, '{}'))
+          AND spam_verdict IS NOT TRUE
+    `
+
+	result, err := r.db.Exec(query, accountID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete detached emails: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return int(rowsAffected), nil
+}
+
 // GetByID - Récupérer un email par ID
 func (r *EmailRepository) GetByID(id string) (*models.Email, error) {
 	query := `
-        SELECT id, account_id, message_id, subject, from_address, to_addresses, date, size, is_read, is_spam, is_deleted, labels, created_at, updated_at
+        SELECT ` + emailColumns + `
         FROM emails
         WHERE id = $1 AND is_deleted = false
     `
 
-	email := &models.Email{}
-	err := r.db.QueryRow(query, id).Scan(
-		&email.ID,
-		&email.AccountID,
-		&email.MessageID,
-		&email.Subject,
-		&email.From,
-		pq.Array(&email.To),
-		&email.Date,
-		&email.Size,
-		&email.IsRead,
-		&email.IsSpam,
-		&email.IsDeleted,
-		pq.Array(&email.Labels),
-		&email.CreatedAt,
-		&email.UpdatedAt,
-	)
+	email, err := scanEmail(r.db.QueryRow(query, id))
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -89,31 +354,41 @@ func (r *EmailRepository) GetByID(id string) (*models.Email, error) {
 	return email, nil
 }
 
+// GetByIDsForUser - Récupérer des emails par ID, limités aux comptes de l'utilisateur
+func (r *EmailRepository) GetByIDsForUser(userID int, ids []string) ([]*models.Email, error) {
+	query := `
+        SELECT ` + emailColumns + `
+        FROM emails
+        WHERE id = ANY($1) AND account_id IN (SELECT id FROM email_accounts WHERE user_id = $2)
+    `
+
+	rows, err := r.db.Query(query, pq.Array(ids), userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query emails by ids: %w", err)
+	}
+	defer rows.Close()
+
+	emails := []*models.Email{}
+	for rows.Next() {
+		email, err := scanEmail(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan email: %w", err)
+		}
+		emails = append(emails, email)
+	}
+
+	return emails, rows.Err()
+}
+
 // GetByMessageID - Récupérer un email par MessageID et AccountID
 func (r *EmailRepository) GetByMessageID(messageID string, accountID int) (*models.Email, error) {
 	query := `
-        SELECT id, account_id, message_id, subject, from_address, to_addresses, date, size, is_read, is_spam, is_deleted, labels, created_at, updated_at
+        SELECT ` + emailColumns + `
         FROM emails
         WHERE message_id = $1 AND account_id = $2
     `
 
-	email := &models.Email{}
-	err := r.db.QueryRow(query, messageID, accountID).Scan(
-		&email.ID,
-		&email.AccountID,
-		&email.MessageID,
-		&email.Subject,
-		&email.From,
-		pq.Array(&email.To),
-		&email.Date,
-		&email.Size,
-		&email.IsRead,
-		&email.IsSpam,
-		&email.IsDeleted,
-		pq.Array(&email.Labels),
-		&email.CreatedAt,
-		&email.UpdatedAt,
-	)
+	email, err := scanEmail(r.db.QueryRow(query, messageID, accountID))
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -125,6 +400,77 @@ func (r *EmailRepository) GetByMessageID(messageID string, accountID int) (*mode
 	return email, nil
 }
 
+// filterConditions - Conditions SQL d'un EmailFilter, numérotées à partir de argIndex
+func filterConditions(filter *models.EmailFilter, argIndex int) ([]string, []interface{}) {
+	whereConditions := []string{}
+	args := []interface{}{}
+	if filter == nil {
+		return whereConditions, args
+	}
+
+	if filter.AccountID != 0 {
+		whereConditions = append(whereConditions, fmt.Sprintf("account_id = $%d", argIndex))
+		args = append(args, filter.AccountID)
+		argIndex++
+	}
+
+	if filter.Category != "" {
+		whereConditions = append(whereConditions, fmt.Sprintf("category = $%d", argIndex))
+		args = append(args, filter.Category)
+		argIndex++
+	}
+
+	if filter.From != "" {
+		whereConditions = append(whereConditions, fmt.Sprintf("from_address ILIKE $%d", argIndex))
+		args = append(args, "%"+filter.From+"%")
+		argIndex++
+	}
+
+	if filter.Subject != "" {
+		whereConditions = append(whereConditions, fmt.Sprintf("subject ILIKE $%d", argIndex))
+		args = append(args, "%"+filter.Subject+"%")
+		argIndex++
+	}
+
+	if filter.IsRead != nil {
+		whereConditions = append(whereConditions, fmt.Sprintf("is_read = $%d", argIndex))
+		args = append(args, *filter.IsRead)
+		argIndex++
+	}
+
+	if filter.IsSpam != nil {
+		whereConditions = append(whereConditions, fmt.Sprintf("is_spam = $%d", argIndex))
+		args = append(args, *filter.IsSpam)
+		argIndex++
+	}
+
+	if filter.MinJunkScore != nil {
+		whereConditions = append(whereConditions, fmt.Sprintf("junk_score >= $%d", argIndex))
+		args = append(args, *filter.MinJunkScore)
+		argIndex++
+	}
+
+	if filter.MaxJunkScore != nil {
+		whereConditions = append(whereConditions, fmt.Sprintf("junk_score <= $%d", argIndex))
+		args = append(args, *filter.MaxJunkScore)
+		argIndex++
+	}
+
+	if filter.DateFrom != nil {
+		whereConditions = append(whereConditions, fmt.Sprintf("date >= $%d", argIndex))
+		args = append(args, *filter.DateFrom)
+		argIndex++
+	}
+
+	if filter.DateTo != nil {
+		whereConditions = append(whereConditions, fmt.Sprintf("date <= $%d", argIndex))
+		args = append(args, *filter.DateTo)
+		argIndex++
+	}
+
+	return whereConditions, args
+}
+
 // GetByAccountIDsWithFilter - Récupérer les emails avec filtres et pagination
 func (r *EmailRepository) GetByAccountIDsWithFilter(accountIDs []int, filter *models.EmailFilter) ([]*models.Email, int, error) {
 	// Construire la requête de base
@@ -135,7 +481,7 @@ func (r *EmailRepository) GetByAccountIDsWithFilter(accountIDs []int, filter *mo
 
 	countQuery := "SELECT COUNT(*) " + baseQuery
 	selectQuery := `
-        SELECT id, account_id, message_id, subject, from_address, to_addresses, date, size, is_read, is_spam, is_deleted, labels, created_at, updated_at 
+        SELECT ` + emailColumns + ` 
     ` + baseQuery
 
 	args := []interface{}{pq.Array(accountIDs)}
@@ -143,43 +489,10 @@ func (r *EmailRepository) GetByAccountIDsWithFilter(accountIDs []int, filter *mo
 	argIndex := 2
 
 	// Ajouter les filtres
-	if filter != nil {
-		if filter.From != "" {
-			whereConditions = append(whereConditions, fmt.Sprintf("from_address ILIKE $%d", argIndex))
-			args = append(args, "%"+filter.From+"%")
-			argIndex++
-		}
-
-		if filter.Subject != "" {
-			whereConditions = append(whereConditions, fmt.Sprintf("subject ILIKE $%d", argIndex))
-			args = append(args, "%"+filter.Subject+"%")
-			argIndex++
-		}
-
-		if filter.IsRead != nil {
-			whereConditions = append(whereConditions, fmt.Sprintf("is_read = $%d", argIndex))
-			args = append(args, *filter.IsRead)
-			argIndex++
-		}
-
-		if filter.IsSpam != nil {
-			whereConditions = append(whereConditions, fmt.Sprintf("is_spam = $%d", argIndex))
-			args = append(args, *filter.IsSpam)
-			argIndex++
-		}
-
-		if filter.DateFrom != nil {
-			whereConditions = append(whereConditions, fmt.Sprintf("date >= $%d", argIndex))
-			args = append(args, *filter.DateFrom)
-			argIndex++
-		}
-
-		if filter.DateTo != nil {
-			whereConditions = append(whereConditions, fmt.Sprintf("date <= $%d", argIndex))
-			args = append(args, *filter.DateTo)
-			argIndex++
-		}
-	}
+	conditions, filterArgs := filterConditions(filter, argIndex)
+	whereConditions = append(whereConditions, conditions...)
+	args = append(args, filterArgs...)
+	argIndex += len(filterArgs)
 
 	// Ajouter les conditions WHERE supplémentaires
 	if len(whereConditions) > 0 {
@@ -220,23 +533,7 @@ func (r *EmailRepository) GetByAccountIDsWithFilter(accountIDs []int, filter *mo
 
 	var emails []*models.Email
 	for rows.Next() {
-		email := &models.Email{}
-		err := rows.Scan(
-			&email.ID,
-			&email.AccountID,
-			&email.MessageID,
-			&email.Subject,
-			&email.From,
-			pq.Array(&email.To),
-			&email.Date,
-			&email.Size,
-			&email.IsRead,
-			&email.IsSpam,
-			&email.IsDeleted,
-			pq.Array(&email.Labels),
-			&email.CreatedAt,
-			&email.UpdatedAt,
-		)
+		email, err := scanEmail(rows)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan email: %w", err)
 		}
@@ -246,6 +543,130 @@ func (r *EmailRepository) GetByAccountIDsWithFilter(accountIDs []int, filter *mo
 	return emails, totalCount, nil
 }
 
+// CountByFilter - Nombre d'emails non supprimés des comptes correspondant au filtre
+func (r *EmailRepository) CountByFilter(accountIDs []int, filter *models.EmailFilter) (int, error) {
+	query := `SELECT COUNT(*) FROM emails WHERE account_id = ANY($1) AND is_deleted = false`
+	args := []interface{}{pq.Array(accountIDs)}
+
+	conditions, filterArgs := filterConditions(filter, 2)
+	if len(conditions) > 0 {
+		query += " AND " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filterArgs...)
+
+	var count int
+	if err := r.db.QueryRow(query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count emails: %w", err)
+	}
+
+	return count, nil
+}
+
+// GetIDsByFilter - IDs correspondant au filtre, par ordre d'ID à partir de afterID (parcours par lots)
+func (r *EmailRepository) GetIDsByFilter(accountIDs []int, filter *models.EmailFilter, afterID string, limit int) ([]string, error) {
+	query := `SELECT id FROM emails WHERE account_id = ANY($1) AND is_deleted = false AND id > $2`
+	args := []interface{}{pq.Array(accountIDs), afterID}
+
+	conditions, filterArgs := filterConditions(filter, 3)
+	if len(conditions) > 0 {
+		query += " AND " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filterArgs...)
+
+	query += fmt.Sprintf(" ORDER BY id LIMIT $%d", len(args)+1)
+	args = append(args, limit)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query emails by filter: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan email id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// GetRetentionCandidates - IDs des emails du compte reçus avant before et visés par la politique,
+// hors emails déjà dans l'état cible, par ordre d'ID à partir de afterID
+func (r *EmailRepository) GetRetentionCandidates(policy *models.RetentionPolicy, before time.Time, afterID string, limit int) ([]string, error) {
+	query := `SELECT id FROM emails WHERE account_id = $1 AND is_deleted = false AND date < $2 AND id > $3`
+	args := []interface{}{policy.AccountID, before, afterID}
+
+	if policy.Label != "" {
+		args = append(args, policy.Label)
+		query += fmt.Sprintf(" AND EXISTS (SELECT 1 FROM unnest(labels) AS label WHERE lower(label) = lower($%d))", len(args))
+	}
+	if policy.Category != "" {
+		args = append(args, policy.Category)
+		query += fmt.Sprintf(" AND category = $%d", len(args))
+	}
+
+	switch policy.Action {
+	case models.ActionArchive:
+		query += " AND NOT 'archived' = ANY(COALESCE(labels, '{}'))"
+	case models.ActionMarkRead:
+		query += " AND is_read = false"
+	}
+
+	args = append(args, limit)
+	query += fmt.Sprintf(" ORDER BY id LIMIT $%d", len(args))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query retention candidates: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan email id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// UpdateJunkScores - Enregistrer le score indésirable de chaque email (scores[i] pour emailIDs[i])
+func (r *EmailRepository) UpdateJunkScores(emailIDs []string, scores []float64) error {
+	if len(emailIDs) == 0 {
+		return nil
+	}
+
+	_, err := r.db.Exec(`
+        UPDATE emails SET junk_score = item.score
+        FROM UNNEST($1::varchar[], $2::real[]) AS item(id, score)
+        WHERE emails.id = item.id
+    `, pq.Array(emailIDs), pq.Array(scores))
+	if err != nil {
+		return fmt.Errorf("failed to update junk scores: %w", err)
+	}
+	return nil
+}
+
+// ClearJunkScores - Effacer les scores indésirables des emails de l'utilisateur
+func (r *EmailRepository) ClearJunkScores(userID int) error {
+	_, err := r.db.Exec(`
+        UPDATE emails SET junk_score = NULL
+        WHERE junk_score IS NOT NULL
+          AND account_id IN (SELECT id FROM email_accounts WHERE user_id = $1)
+    `, userID)
+	if err != nil {
+		return fmt.Errorf("failed to clear junk scores: %w", err)
+	}
+	return nil
+}
+
 // Update - Mettre à jour un email
 func (r *EmailRepository) Update(email *models.Email) error {
 	query := `
@@ -289,9 +710,9 @@ func (r *EmailRepository) UpdateReadStatus(emailIDs []string, isRead bool) error
 	return nil
 }
 
-// MarkAsDeleted - Marquer des emails comme supprimés (soft delete)
+// MarkAsDeleted - Marquer des emails comme supprimés (soft delete, visibles dans la corbeille)
 func (r *EmailRepository) MarkAsDeleted(emailIDs []string) error {
-	query := `UPDATE emails SET is_deleted = true, updated_at = $1 WHERE id = ANY($2)`
+	query := `UPDATE emails SET is_deleted = true, deleted_at = COALESCE(deleted_at, $1), updated_at = $1 WHERE id = ANY($2)`
 
 	_, err := r.db.Exec(query, time.Now(), pq.Array(emailIDs))
 	if err != nil {
@@ -313,12 +734,24 @@ func (r *EmailRepository) DeletePermanently(emailIDs []string) error {
 	return nil
 }
 
-// ArchiveEmails - Archiver des emails (ajouter le label "archived")
+// SetSpamVerdict - Enregistrer le choix spam de l'utilisateur (conservé lors des synchronisations suivantes)
+func (r *EmailRepository) SetSpamVerdict(emailIDs []string, isSpam bool) error {
+	query := `UPDATE emails SET is_spam = $1, spam_verdict = $1, updated_at = $2 WHERE id = ANY($3)`
+
+	_, err := r.db.Exec(query, isSpam, time.Now(), pq.Array(emailIDs))
+	if err != nil {
+		return fmt.Errorf("failed to update spam status: %w", err)
+	}
+
+	return nil
+}
+
+// ArchiveEmails - Archiver des emails (sortir de INBOX et ajouter le label "archived")
 func (r *EmailRepository) ArchiveEmails(emailIDs []string) error {
 	query := `
         UPDATE emails 
-        SET labels = array_append(labels, 'archived'), updated_at = $1 
-        WHERE id = ANY($2) AND NOT 'archived' = ANY(labels)
+        SET labels = array_append(array_remove(COALESCE(labels, '{}'), 'INBOX'), 'archived'), updated_at = $1 
+        WHERE id = ANY($2) AND NOT 'archived' = ANY(COALESCE(labels, '{}'))
     `
 
 	_, err := r.db.Exec(query, time.Now(), pq.Array(emailIDs))
@@ -329,10 +762,91 @@ func (r *EmailRepository) ArchiveEmails(emailIDs []string) error {
 	return nil
 }
 
+// UnarchiveEmails - Remettre des emails archivés dans INBOX
+func (r *EmailRepository) UnarchiveEmails(emailIDs []string) error {
+	query := `
+        UPDATE emails
+        SET labels = array_append(array_remove(COALESCE(labels, '{}'), 'archived'), 'INBOX'), updated_at = $1
+        WHERE id = ANY($2) AND NOT 'INBOX' = ANY(COALESCE(labels, '{}'))
+    `
+
+	_, err := r.db.Exec(query, time.Now(), pq.Array(emailIDs))
+	if err != nil {
+		return fmt.Errorf("failed to unarchive emails: %w", err)
+	}
+
+	return nil
+}
+
+// RestoreDeleted - Sortir des emails de la corbeille
+func (r *EmailRepository) RestoreDeleted(emailIDs []string) error {
+	query := `UPDATE emails SET is_deleted = false, deleted_at = NULL, updated_at = $1 WHERE id = ANY($2) AND is_deleted = true`
+
+	_, err := r.db.Exec(query, time.Now(), pq.Array(emailIDs))
+	if err != nil {
+		return fmt.Errorf("failed to restore emails: %w", err)
+	}
+
+	return nil
+}
+
+// GetTrash - Emails supprimés des comptes, les plus récemment supprimés d'abord
+func (r *EmailRepository) GetTrash(accountIDs []int, limit, offset int) ([]*models.Email, int, error) {
+	var total int
+	countQuery := `SELECT COUNT(*) FROM emails WHERE account_id = ANY($1) AND is_deleted = true`
+	if err := r.db.QueryRow(countQuery, pq.Array(accountIDs)).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count trash: %w", err)
+	}
+
+	query := `
+        SELECT ` + emailColumns + `
+        FROM emails
+        WHERE account_id = ANY($1) AND is_deleted = true
+        ORDER BY deleted_at DESC NULLS LAST, id
+        LIMIT $2 OFFSET $3
+    `
+
+	rows, err := r.db.Query(query, pq.Array(accountIDs), limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query trash: %w", err)
+	}
+	defer rows.Close()
+
+	emails := []*models.Email{}
+	for rows.Next() {
+		email, err := scanEmail(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan email: %w", err)
+		}
+		emails = append(emails, email)
+	}
+
+	return emails, total, rows.Err()
+}
+
+// PurgeTrash - Supprimer définitivement les emails restés dans la corbeille au-delà de la rétention
+// de leur utilisateur (defaultDays sans préférence enregistrée)
+func (r *EmailRepository) PurgeTrash(now time.Time, defaultDays int) (int, error) {
+	query := `
+        DELETE FROM emails e
+        USING email_accounts a LEFT JOIN user_settings s ON s.user_id = a.user_id
+        WHERE e.account_id = a.id AND e.is_deleted = true AND e.deleted_at IS NOT NULL
+          AND e.deleted_at < $1::timestamp - make_interval(days => COALESCE(s.trash_retention_days, $2))
+    `
+
+	result, err := r.db.Exec(query, now, defaultDays)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge trash: %w", err)
+	}
+
+	count, _ := result.RowsAffected()
+	return int(count), nil
+}
+
 // GetByAccountID - Récupérer tous les emails d'un compte
 func (r *EmailRepository) GetByAccountID(accountID int, limit, offset int) ([]*models.Email, error) {
 	query := `
-        SELECT id, account_id, message_id, subject, from_address, to_addresses, date, size, is_read, is_spam, is_deleted, labels, created_at, updated_at
+        SELECT ` + emailColumns + `
         FROM emails
         WHERE account_id = $1 AND is_deleted = false
         ORDER BY date DESC
@@ -347,23 +861,7 @@ func (r *EmailRepository) GetByAccountID(accountID int, limit, offset int) ([]*m
 
 	var emails []*models.Email
 	for rows.Next() {
-		email := &models.Email{}
-		err := rows.Scan(
-			&email.ID,
-			&email.AccountID,
-			&email.MessageID,
-			&email.Subject,
-			&email.From,
-			pq.Array(&email.To),
-			&email.Date,
-			&email.Size,
-			&email.IsRead,
-			&email.IsSpam,
-			&email.IsDeleted,
-			pq.Array(&email.Labels),
-			&email.CreatedAt,
-			&email.UpdatedAt,
-		)
+		email, err := scanEmail(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan email: %w", err)
 		}
@@ -373,6 +871,185 @@ func (r *EmailRepository) GetByAccountID(accountID int, limit, offset int) ([]*m
 	return emails, nil
 }
 
+// GetDuplicateCandidates - Emails non supprimés de plusieurs comptes, du plus ancien au plus récent, réduits
+// aux colonnes utiles au regroupement des doublons (ni en-têtes, ni destinataires, ni libellés)
+func (r *EmailRepository) GetDuplicateCandidates(accountIDs []int) ([]*models.Email, error) {
+	query := `
+        SELECT id, account_id, message_id, from_address, subject, date, size, is_spam
+        FROM emails
+        WHERE account_id = ANY($1) AND is_deleted = false
+        ORDER BY date ASC, id ASC
+    `
+
+	rows, err := r.db.Query(query, pq.Array(accountIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query duplicate candidates: %w", err)
+	}
+	defer rows.Close()
+
+	emails := []*models.Email{}
+	for rows.Next() {
+		email := &models.Email{}
+		if err := rows.Scan(&email.ID, &email.AccountID, &email.MessageID, &email.From, &email.Subject, &email.Date, &email.Size, &email.IsSpam); err != nil {
+			return nil, fmt.Errorf("failed to scan email: %w", err)
+		}
+		emails = append(emails, email)
+	}
+
+	return emails, rows.Err()
+}
+
+// GetLatestListEmail - Dernier email de diffusion (avec List-Unsubscribe) d'un expéditeur, nil si aucun
+func (r *EmailRepository) GetLatestListEmail(userID int, sender string) (*models.Email, error) {
+	query := `
+        SELECT ` + emailColumns + `
+        FROM emails
+        WHERE account_id IN (SELECT id FROM email_accounts WHERE user_id = $1)
+          AND LOWER(from_address) = LOWER($2) AND headers->>'list-unsubscribe' IS NOT NULL AND is_deleted = false
+        ORDER BY date DESC
+        LIMIT 1
+    `
+
+	email, err := scanEmail(r.db.QueryRow(query, userID, sender))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get list email: %w", err)
+	}
+
+	return email, nil
+}
+
+// GetIDsFromList - IDs des emails non supprimés d'une liste (List-Id si connu, expéditeur sinon)
+func (r *EmailRepository) GetIDsFromList(userID int, sender, listID string) ([]string, error) {
+	query := `
+        SELECT id FROM emails
+        WHERE account_id IN (SELECT id FROM email_accounts WHERE user_id = $1) AND is_deleted = false
+          AND CASE WHEN $3 <> '' THEN POSITION('<' || $3 || '>' IN LOWER(headers->>'list-id')) > 0 OR LOWER(headers->>'list-id') = $3
+                   ELSE LOWER(from_address) = LOWER($2) END
+    `
+
+	rows, err := r.db.Query(query, userID, sender, listID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query list emails: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan email id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// senderKeyExpressions - Clé de regroupement SQL par type de classement
+var senderKeyExpressions = map[models.SenderGroupBy]string{
+	models.GroupBySender: `LOWER(TRIM(from_address))`,
+	models.GroupByDomain: `LOWER(SUBSTRING(from_address FROM '@([^@>[:space:]]+)'))`,
+}
+
+// senderSortColumns - Colonnes de tri autorisées pour le classement
+var senderSortColumns = map[models.SenderSort]string{
+	models.SenderSortCount:  "count",
+	models.SenderSortSize:   "total_size",
+	models.SenderSortUnread: "unread_ratio",
+	models.SenderSortNewest: "newest_date",
+	models.SenderSortOldest: "oldest_date",
+}
+
+// AggregateSenders - Nombre, taille, non-lus et dates des emails par expéditeur ou par domaine
+func (r *EmailRepository) AggregateSenders(accountIDs []int, query *models.SenderQuery) ([]*models.SenderStats, int, error) {
+	keyExpr, ok := senderKeyExpressions[query.GroupBy]
+	if !ok {
+		return nil, 0, fmt.Errorf("invalid sender grouping: %s", query.GroupBy)
+	}
+	sortColumn, ok := senderSortColumns[query.Sort]
+	if !ok {
+		return nil, 0, fmt.Errorf("invalid sender sort: %s", query.Sort)
+	}
+	order := "DESC"
+	if query.Ascending {
+		order = "ASC"
+	}
+
+	baseQuery := `
+        FROM emails
+        WHERE account_id = ANY($1) AND is_deleted = false AND ` + keyExpr + ` <> ''
+    `
+
+	var totalCount int
+	if err := r.db.QueryRow(`SELECT COUNT(DISTINCT `+keyExpr+`) `+baseQuery, pq.Array(accountIDs)).Scan(&totalCount); err != nil {
+		return nil, 0, fmt.Errorf("failed to count senders: %w", err)
+	}
+
+	selectQuery := `
+        SELECT ` + keyExpr + ` AS key, COUNT(*) AS count, COALESCE(SUM(size), 0) AS total_size,
+               COUNT(*) FILTER (WHERE is_read = false) AS unread_count,
+               COUNT(*) FILTER (WHERE is_read = false)::float / COUNT(*) AS unread_ratio,
+               MIN(date) AS oldest_date, MAX(date) AS newest_date
+    ` + baseQuery + `
+        GROUP BY 1
+        ORDER BY ` + sortColumn + ` ` + order + `, key ASC
+        LIMIT $2 OFFSET $3
+    `
+
+	rows, err := r.db.Query(selectQuery, pq.Array(accountIDs), query.Limit, query.Offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to aggregate senders: %w", err)
+	}
+	defer rows.Close()
+
+	senders := []*models.SenderStats{}
+	for rows.Next() {
+		stats := &models.SenderStats{}
+		var oldest, newest sql.NullTime
+		if err := rows.Scan(&stats.Key, &stats.Count, &stats.TotalSize, &stats.UnreadCount, &stats.UnreadRatio, &oldest, &newest); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan sender stats: %w", err)
+		}
+		stats.OldestDate = oldest.Time
+		stats.NewestDate = newest.Time
+		senders = append(senders, stats)
+	}
+
+	return senders, totalCount, rows.Err()
+}
+
+// GetIDsBySender - IDs des emails non supprimés d'un expéditeur ou d'un domaine
+func (r *EmailRepository) GetIDsBySender(accountIDs []int, groupBy models.SenderGroupBy, key string) ([]string, error) {
+	keyExpr, ok := senderKeyExpressions[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("invalid sender grouping: %s", groupBy)
+	}
+
+	query := `
+        SELECT id FROM emails
+        WHERE account_id = ANY($1) AND is_deleted = false AND ` + keyExpr + ` = LOWER($2)
+    `
+
+	rows, err := r.db.Query(query, pq.Array(accountIDs), key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query emails by sender: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan email id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
 // DeleteByAccountID - Supprimer tous les emails d'un compte (lors de suppression du compte)
 func (r *EmailRepository) DeleteByAccountID(accountID int) error {
 	query := `DELETE FROM emails WHERE account_id = $1`
@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"tamis-server/internal/database"
+	"tamis-server/internal/models"
+	"time"
+)
+
+type JobRepository struct {
+	db *database.DB
+}
+
+func NewJobRepository(db *database.DB) *JobRepository {
+	return &JobRepository{db: db}
+}
+
+// jobColumns - Colonnes sélectionnées pour construire un models.Job
+const jobColumns = `id, user_id, type, status, params, result, COALESCE(error, ''), created_at, started_at, completed_at, updated_at`
+
+// scanJob - Lire une ligne correspondant à jobColumns
+func scanJob(row rowScanner) (*models.Job, error) {
+	job := &models.Job{}
+	var params, result []byte
+	err := row.Scan(
+		&job.ID,
+		&job.UserID,
+		&job.Type,
+		&job.Status,
+		&params,
+		&result,
+		&job.Error,
+		&job.CreatedAt,
+		&job.StartedAt,
+		&job.CompletedAt,
+		&job.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	job.Params = params
+	job.Result = result
+	return job, nil
+}
+
+// nullableJSON - Les []byte sont envoyés en bytea par lib/pq : passer le JSON en texte
+func nullableJSON(data []byte) interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+	return string(data)
+}
+
+// Create - Enregistrer un nouveau job en attente ; false si un job du même type est déjà actif pour l'utilisateur
+func (r *JobRepository) Create(job *models.Job) (bool, error) {
+	query := `
+        INSERT INTO jobs (id, user_id, type, status, params, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        ON CONFLICT (user_id, type) WHERE status IN ('pending', 'running') DO NOTHING
+    `
+
+	now := time.Now()
+	job.CreatedAt = now
+	job.UpdatedAt = now
+	result, err := r.db.Exec(query, job.ID, job.UserID, job.Type, job.Status, nullableJSON(job.Params), job.CreatedAt, job.UpdatedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to create job: %w", err)
+	}
+
+	count, _ := result.RowsAffected()
+	return count > 0, nil
+}
+
+// GetByID - Récupérer un job par ID
+func (r *JobRepository) GetByID(id string) (*models.Job, error) {
+	query := `SELECT ` + jobColumns + ` FROM jobs WHERE id = $1`
+
+	job, err := scanJob(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("job not found")
+		}
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+
+	return job, nil
+}
+
+// GetActive - Job en attente ou en cours d'un utilisateur pour un type donné (nil si aucun)
+func (r *JobRepository) GetActive(userID int, jobType models.JobType) (*models.Job, error) {
+	query := `SELECT ` + jobColumns + ` FROM jobs
+        WHERE user_id = $1 AND type = $2 AND status IN ('pending', 'running')
+        ORDER BY created_at DESC
+        LIMIT 1`
+
+	job, err := scanJob(r.db.QueryRow(query, userID, jobType))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get active job: %w", err)
+	}
+
+	return job, nil
+}
+
+// ClaimNext - Passer le plus ancien job en attente à l'état running (nil si la file est vide)
+func (r *JobRepository) ClaimNext() (*models.Job, error) {
+	query := `
+        UPDATE jobs SET status = 'running', started_at = $1, updated_at = $1
+        WHERE id = (
+            SELECT id FROM jobs WHERE status = 'pending'
+            ORDER BY created_at
+            LIMIT 1
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING ` + jobColumns
+
+	job, err := scanJob(r.db.QueryRow(query, time.Now()))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim job: %w", err)
+	}
+
+	return job, nil
+}
+
+// UpdateResult - Enregistrer la progression d'un job en cours
+func (r *JobRepository) UpdateResult(id string, result []byte) error {
+	query := `UPDATE jobs SET result = $1, updated_at = $2 WHERE id = $3`
+
+	_, err := r.db.Exec(query, nullableJSON(result), time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update job result: %w", err)
+	}
+
+	return nil
+}
+
+// Finish - Terminer un job (completed ou failed)
+func (r *JobRepository) Finish(id string, status models.JobStatus, errorMessage string) error {
+	query := `UPDATE jobs SET status = $1, error = NULLIF($2, ''), completed_at = $3, updated_at = $3 WHERE id = $4`
+
+	_, err := r.db.Exec(query, status, errorMessage, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to finish job: %w", err)
+	}
+
+	return nil
+}
+
+// Requeue - Remettre en attente un job interrompu
+func (r *JobRepository) Requeue(id string) error {
+	query := `UPDATE jobs SET status = 'pending', started_at = NULL, updated_at = $1 WHERE id = $2`
+
+	_, err := r.db.Exec(query, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to requeue job: %w", err)
+	}
+
+	return nil
+}
+
+// RequeueRunning - Remettre en attente les jobs interrompus par un arrêt du serveur
+func (r *JobRepository) RequeueRunning() (int, error) {
+	query := `UPDATE jobs SET status = 'pending', started_at = NULL, updated_at = $1 WHERE status = 'running'`
+
+	res, err := r.db.Exec(query, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to requeue running jobs: %w", err)
+	}
+
+	count, _ := res.RowsAffected()
+	return int(count), nil
+}
@@ -0,0 +1,260 @@
+package repository
+
+import (
+	"fmt"
+	"tamis-server/internal/database"
+	"tamis-server/internal/models"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type OutboxRepository struct {
+	db *database.DB
+}
+
+func NewOutboxRepository(db *database.DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// outboxColumns - Colonnes sélectionnées pour construire un models.OutboxOperation
+const outboxColumns = `id, user_id, account_id, email_id, provider_id, action, force, status, attempts, next_attempt_at, COALESCE(last_error, ''), created_at, updated_at`
+
+// scanOutboxOperation - Lire une ligne correspondant à outboxColumns
+func scanOutboxOperation(row rowScanner) (*models.OutboxOperation, error) {
+	op := &models.OutboxOperation{}
+	err := row.Scan(
+		&op.ID,
+		&op.UserID,
+		&op.AccountID,
+		&op.EmailID,
+		&op.ProviderID,
+		&op.Action,
+		&op.Force,
+		&op.Status,
+		&op.Attempts,
+		&op.NextAttemptAt,
+		&op.LastError,
+		&op.CreatedAt,
+		&op.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return op, nil
+}
+
+// queryOutbox - Exécuter une requête renvoyant des lignes outboxColumns
+func (r *OutboxRepository) queryOutbox(query string, args ...interface{}) ([]*models.OutboxOperation, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query outbox: %w", err)
+	}
+	defer rows.Close()
+
+	ops := []*models.OutboxOperation{}
+	for rows.Next() {
+		op, err := scanOutboxOperation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan outbox operation: %w", err)
+		}
+		ops = append(ops, op)
+	}
+
+	return ops, rows.Err()
+}
+
+// CreateBatch - Enregistrer des opérations en attente (dans une transaction)
+func (r *OutboxRepository) CreateBatch(ops []*models.OutboxOperation) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+        INSERT INTO action_outbox (user_id, account_id, email_id, provider_id, action, force, status, attempts, next_attempt_at, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, 0, $8, $8, $8)
+        RETURNING id
+    `
+
+	now := time.Now()
+	for _, op := range ops {
+		op.Status = models.OutboxPending
+		op.NextAttemptAt = now
+		op.CreatedAt = now
+		op.UpdatedAt = now
+
+		err := tx.QueryRow(query, op.UserID, op.AccountID, op.EmailID, op.ProviderID, op.Action, op.Force, op.Status, now).Scan(&op.ID)
+		if err != nil {
+			return fmt.Errorf("failed to create outbox operation: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit outbox operations: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimDue - Passer à l'état running les opérations dont l'échéance est passée
+func (r *OutboxRepository) ClaimDue(limit int) ([]*models.OutboxOperation, error) {
+	query := `
+        UPDATE action_outbox SET status = 'running', updated_at = $1
+        WHERE id IN (
+            SELECT id FROM action_outbox
+            WHERE status = 'pending' AND next_attempt_at <= $1
+            ORDER BY next_attempt_at
+            LIMIT $2
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING ` + outboxColumns
+
+	return r.queryOutbox(query, time.Now(), limit)
+}
+
+// Claim - Passer à l'état running des opérations précises encore en attente
+func (r *OutboxRepository) Claim(ids []int64) ([]*models.OutboxOperation, error) {
+	query := `
+        UPDATE action_outbox SET status = 'running', updated_at = $1
+        WHERE id = ANY($2) AND status = 'pending'
+        RETURNING ` + outboxColumns
+
+	return r.queryOutbox(query, time.Now(), pq.Array(ids))
+}
+
+// MarkCompleted - Opérations confirmées par le provider
+func (r *OutboxRepository) MarkCompleted(ids []int64) error {
+	query := `UPDATE action_outbox SET status = 'completed', attempts = attempts + 1, last_error = NULL, updated_at = $1 WHERE id = ANY($2)`
+
+	_, err := r.db.Exec(query, time.Now(), pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to complete outbox operations: %w", err)
+	}
+
+	return nil
+}
+
+// Reschedule - Remettre une opération en attente jusqu'à nextAttemptAt
+func (r *OutboxRepository) Reschedule(op *models.OutboxOperation) error {
+	query := `UPDATE action_outbox SET status = 'pending', attempts = $1, next_attempt_at = $2, last_error = NULLIF($3, ''), updated_at = $4 WHERE id = $5`
+
+	_, err := r.db.Exec(query, op.Attempts, op.NextAttemptAt, op.LastError, time.Now(), op.ID)
+	if err != nil {
+		return fmt.Errorf("failed to reschedule outbox operation: %w", err)
+	}
+
+	return nil
+}
+
+// MarkFailed - Abandonner une opération après une erreur définitive ou trop de tentatives
+func (r *OutboxRepository) MarkFailed(op *models.OutboxOperation) error {
+	query := `UPDATE action_outbox SET status = 'failed', attempts = $1, last_error = $2, updated_at = $3 WHERE id = $4`
+
+	_, err := r.db.Exec(query, op.Attempts, op.LastError, time.Now(), op.ID)
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox operation as failed: %w", err)
+	}
+
+	return nil
+}
+
+// GetByUser - Opérations d'un utilisateur dans les états donnés, plus récentes d'abord
+func (r *OutboxRepository) GetByUser(userID int, statuses []models.OutboxStatus, limit int) ([]*models.OutboxOperation, error) {
+	values := make([]string, len(statuses))
+	for i, status := range statuses {
+		values[i] = string(status)
+	}
+
+	query := `SELECT ` + outboxColumns + ` FROM action_outbox
+        WHERE user_id = $1 AND status = ANY($2)
+        ORDER BY created_at DESC
+        LIMIT $3`
+
+	return r.queryOutbox(query, userID, pq.Array(values), limit)
+}
+
+// Retry - Remettre en attente immédiate des opérations échouées ou annulées de l'utilisateur
+func (r *OutboxRepository) Retry(userID int, ids []int64) (int, error) {
+	query := `
+        UPDATE action_outbox SET status = 'pending', attempts = 0, next_attempt_at = $1, updated_at = $1
+        WHERE user_id = $2 AND id = ANY($3) AND status IN ('failed', 'cancelled')
+    `
+
+	result, err := r.db.Exec(query, time.Now(), userID, pq.Array(ids))
+	if err != nil {
+		return 0, fmt.Errorf("failed to retry outbox operations: %w", err)
+	}
+
+	count, _ := result.RowsAffected()
+	return int(count), nil
+}
+
+// Cancel - Annuler des opérations en attente ou échouées de l'utilisateur
+func (r *OutboxRepository) Cancel(userID int, ids []int64) (int, error) {
+	query := `
+        UPDATE action_outbox SET status = 'cancelled', updated_at = $1
+        WHERE user_id = $2 AND id = ANY($3) AND status IN ('pending', 'failed')
+    `
+
+	result, err := r.db.Exec(query, time.Now(), userID, pq.Array(ids))
+	if err != nil {
+		return 0, fmt.Errorf("failed to cancel outbox operations: %w", err)
+	}
+
+	count, _ := result.RowsAffected()
+	return int(count), nil
+}
+
+// CancelOperations - Annuler des opérations pas encore appliquées ; renvoie les emails concernés
+func (r *OutboxRepository) CancelOperations(userID int, ids []int64) ([]string, error) {
+	query := `
+        UPDATE action_outbox SET status = 'cancelled', updated_at = $1
+        WHERE user_id = $2 AND id = ANY($3) AND status IN ('pending', 'failed')
+        RETURNING email_id
+    `
+
+	rows, err := r.db.Query(query, time.Now(), userID, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel outbox operations: %w", err)
+	}
+	defer rows.Close()
+
+	emailIDs := []string{}
+	for rows.Next() {
+		var emailID string
+		if err := rows.Scan(&emailID); err != nil {
+			return nil, fmt.Errorf("failed to scan cancelled outbox operation: %w", err)
+		}
+		emailIDs = append(emailIDs, emailID)
+	}
+
+	return emailIDs, rows.Err()
+}
+
+// RequeueRunning - Remettre en attente les opérations interrompues par un arrêt du serveur
+func (r *OutboxRepository) RequeueRunning() (int, error) {
+	query := `UPDATE action_outbox SET status = 'pending', updated_at = $1 WHERE status = 'running'`
+
+	result, err := r.db.Exec(query, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to requeue outbox operations: %w", err)
+	}
+
+	count, _ := result.RowsAffected()
+	return int(count), nil
+}
+
+// PurgeCompleted - Supprimer l'historique des opérations terminées avant une date
+func (r *OutboxRepository) PurgeCompleted(before time.Time) (int, error) {
+	query := `DELETE FROM action_outbox WHERE status IN ('completed', 'cancelled') AND updated_at < $1`
+
+	result, err := r.db.Exec(query, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge outbox: %w", err)
+	}
+
+	count, _ := result.RowsAffected()
+	return int(count), nil
+}
@@ -0,0 +1,301 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"tamis-server/internal/database"
+	"tamis-server/internal/models"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type RetentionRepository struct {
+	db *database.DB
+}
+
+func NewRetentionRepository(db *database.DB) *RetentionRepository {
+	return &RetentionRepository{db: db}
+}
+
+// retentionPolicyColumns - Colonnes sélectionnées pour construire un models.RetentionPolicy
+const retentionPolicyColumns = `id, user_id, account_id, COALESCE(label, ''), COALESCE(category, ''), older_than_days, action, enabled, last_run_at, created_at, updated_at`
+
+// scanRetentionPolicy - Lire une ligne correspondant à retentionPolicyColumns
+func scanRetentionPolicy(row rowScanner) (*models.RetentionPolicy, error) {
+	policy := &models.RetentionPolicy{}
+	var lastRunAt sql.NullTime
+	err := row.Scan(&policy.ID, &policy.UserID, &policy.AccountID, &policy.Label, &policy.Category,
+		&policy.OlderThanDays, &policy.Action, &policy.Enabled, &lastRunAt, &policy.CreatedAt, &policy.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if lastRunAt.Valid {
+		policy.LastRunAt = &lastRunAt.Time
+	}
+	return policy, nil
+}
+
+// queryPolicies - Exécuter une requête retournant des politiques
+func (r *RetentionRepository) queryPolicies(query string, args ...interface{}) ([]*models.RetentionPolicy, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query retention policies: %w", err)
+	}
+	defer rows.Close()
+
+	policies := []*models.RetentionPolicy{}
+	for rows.Next() {
+		policy, err := scanRetentionPolicy(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan retention policy: %w", err)
+		}
+		policies = append(policies, policy)
+	}
+
+	return policies, rows.Err()
+}
+
+// CreatePolicy - Enregistrer une nouvelle politique
+func (r *RetentionRepository) CreatePolicy(policy *models.RetentionPolicy) error {
+	query := `
+        INSERT INTO retention_policies (user_id, account_id, label, category, older_than_days, action, enabled, created_at, updated_at)
+        VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5, $6, $7, $8, $8)
+        RETURNING id, created_at, updated_at
+    `
+
+	err := r.db.QueryRow(query, policy.UserID, policy.AccountID, policy.Label, policy.Category,
+		policy.OlderThanDays, policy.Action, policy.Enabled, time.Now()).
+		Scan(&policy.ID, &policy.CreatedAt, &policy.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create retention policy: %w", err)
+	}
+
+	return nil
+}
+
+// UpdatePolicy - Remplacer une politique de l'utilisateur (false si introuvable)
+func (r *RetentionRepository) UpdatePolicy(policy *models.RetentionPolicy) (bool, error) {
+	query := `
+        UPDATE retention_policies
+        SET account_id = $3, label = NULLIF($4, ''), category = NULLIF($5, ''), older_than_days = $6,
+            action = $7, enabled = $8, updated_at = $9
+        WHERE id = $1 AND user_id = $2
+        RETURNING last_run_at, created_at, updated_at
+    `
+
+	var lastRunAt sql.NullTime
+	err := r.db.QueryRow(query, policy.ID, policy.UserID, policy.AccountID, policy.Label, policy.Category,
+		policy.OlderThanDays, policy.Action, policy.Enabled, time.Now()).
+		Scan(&lastRunAt, &policy.CreatedAt, &policy.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to update retention policy: %w", err)
+	}
+	if lastRunAt.Valid {
+		policy.LastRunAt = &lastRunAt.Time
+	}
+
+	return true, nil
+}
+
+// GetPoliciesByUser - Politiques de l'utilisateur, par compte
+func (r *RetentionRepository) GetPoliciesByUser(userID int) ([]*models.RetentionPolicy, error) {
+	query := `SELECT ` + retentionPolicyColumns + ` FROM retention_policies WHERE user_id = $1 ORDER BY account_id, id`
+	return r.queryPolicies(query, userID)
+}
+
+// GetDuePolicies - Politiques actives jamais exécutées ou exécutées avant ranBefore
+func (r *RetentionRepository) GetDuePolicies(ranBefore time.Time) ([]*models.RetentionPolicy, error) {
+	query := `
+        SELECT ` + retentionPolicyColumns + `
+        FROM retention_policies
+        WHERE enabled = true AND (last_run_at IS NULL OR last_run_at <= $1)
+        ORDER BY user_id, id
+    `
+	return r.queryPolicies(query, ranBefore)
+}
+
+// MarkPolicyRun - Enregistrer la date de dernière exécution
+func (r *RetentionRepository) MarkPolicyRun(id int, at time.Time) error {
+	_, err := r.db.Exec(`UPDATE retention_policies SET last_run_at = $1 WHERE id = $2`, at, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark retention policy run: %w", err)
+	}
+	return nil
+}
+
+// DeletePolicy - Supprimer une politique de l'utilisateur (false si introuvable)
+func (r *RetentionRepository) DeletePolicy(userID, id int) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM retention_policies WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete retention policy: %w", err)
+	}
+
+	count, _ := result.RowsAffected()
+	return count > 0, nil
+}
+
+// retentionRunColumns - Colonnes sélectionnées pour construire un models.RetentionRun
+const retentionRunColumns = `id, user_id, COALESCE(policy_id, 0), COALESCE(account_id, 0), action, matched_count, success_count,
+    failure_count, pending_count, skipped_count, COALESCE(error, ''), started_at, finished_at`
+
+// scanRetentionRun - Lire une ligne correspondant à retentionRunColumns
+func scanRetentionRun(row rowScanner) (*models.RetentionRun, error) {
+	run := &models.RetentionRun{}
+	var finishedAt sql.NullTime
+	err := row.Scan(&run.ID, &run.UserID, &run.PolicyID, &run.AccountID, &run.Action, &run.MatchedCount, &run.SuccessCount,
+		&run.FailureCount, &run.PendingCount, &run.SkippedCount, &run.Error, &run.StartedAt, &finishedAt)
+	if err != nil {
+		return nil, err
+	}
+	if finishedAt.Valid {
+		run.FinishedAt = &finishedAt.Time
+	}
+	return run, nil
+}
+
+// CreateRun - Ouvrir une exécution
+func (r *RetentionRepository) CreateRun(run *models.RetentionRun) error {
+	query := `
+        INSERT INTO retention_runs (user_id, policy_id, account_id, action, started_at)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id
+    `
+
+	err := r.db.QueryRow(query, run.UserID, run.PolicyID, run.AccountID, run.Action, run.StartedAt).Scan(&run.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create retention run: %w", err)
+	}
+
+	return nil
+}
+
+// FinishRun - Enregistrer les compteurs finaux d'une exécution
+func (r *RetentionRepository) FinishRun(run *models.RetentionRun) error {
+	query := `
+        UPDATE retention_runs
+        SET matched_count = $2, success_count = $3, failure_count = $4, pending_count = $5, skipped_count = $6,
+            error = NULLIF($7, ''), finished_at = $8
+        WHERE id = $1
+    `
+
+	_, err := r.db.Exec(query, run.ID, run.MatchedCount, run.SuccessCount, run.FailureCount, run.PendingCount,
+		run.SkippedCount, run.Error, run.FinishedAt)
+	if err != nil {
+		return fmt.Errorf("failed to finish retention run: %w", err)
+	}
+
+	return nil
+}
+
+// AddRunItems - Journaliser les emails concernés par une exécution
+func (r *RetentionRepository) AddRunItems(runID int64, items []*models.RetentionRunItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	emailIDs := make([]string, len(items))
+	senders := make([]string, len(items))
+	subjects := make([]string, len(items))
+	dates := make([]time.Time, len(items))
+	outcomes := make([]string, len(items))
+	reasons := make([]string, len(items))
+	for i, item := range items {
+		emailIDs[i] = item.EmailID
+		senders[i] = item.From
+		subjects[i] = item.Subject
+		dates[i] = item.Date
+		outcomes[i] = string(item.Outcome)
+		reasons[i] = item.Reason
+	}
+
+	_, err := r.db.Exec(`
+        INSERT INTO retention_run_items (run_id, email_id, from_address, subject, date, outcome, reason)
+        SELECT $1, item.email_id, item.from_address, item.subject, item.date, item.outcome, NULLIF(item.reason, '')
+        FROM UNNEST($2::varchar[], $3::text[], $4::text[], $5::timestamp[], $6::varchar[], $7::text[])
+            AS item(email_id, from_address, subject, date, outcome, reason)
+        ON CONFLICT (run_id, email_id) DO NOTHING
+    `, runID, pq.Array(emailIDs), pq.Array(senders), pq.Array(subjects), pq.Array(dates), pq.Array(outcomes), pq.Array(reasons))
+	if err != nil {
+		return fmt.Errorf("failed to add retention run items: %w", err)
+	}
+
+	return nil
+}
+
+// GetRuns - Dernières exécutions de l'utilisateur
+func (r *RetentionRepository) GetRuns(userID, limit int) ([]*models.RetentionRun, error) {
+	query := `SELECT ` + retentionRunColumns + ` FROM retention_runs WHERE user_id = $1 ORDER BY started_at DESC, id DESC LIMIT $2`
+
+	rows, err := r.db.Query(query, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query retention runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := []*models.RetentionRun{}
+	for rows.Next() {
+		run, err := scanRetentionRun(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan retention run: %w", err)
+		}
+		runs = append(runs, run)
+	}
+
+	return runs, rows.Err()
+}
+
+// GetRun - Exécution de l'utilisateur (nil si introuvable)
+func (r *RetentionRepository) GetRun(userID int, id int64) (*models.RetentionRun, error) {
+	query := `SELECT ` + retentionRunColumns + ` FROM retention_runs WHERE id = $1 AND user_id = $2`
+
+	run, err := scanRetentionRun(r.db.QueryRow(query, id, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get retention run: %w", err)
+	}
+
+	return run, nil
+}
+
+// GetRunItems - Journal des emails d'une exécution
+func (r *RetentionRepository) GetRunItems(runID int64) ([]*models.RetentionRunItem, error) {
+	query := `
+        SELECT email_id, COALESCE(from_address, ''), COALESCE(subject, ''), COALESCE(date, 'epoch'::timestamp), outcome, COALESCE(reason, '')
+        FROM retention_run_items
+        WHERE run_id = $1
+        ORDER BY date, email_id
+    `
+
+	rows, err := r.db.Query(query, runID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query retention run items: %w", err)
+	}
+	defer rows.Close()
+
+	items := []*models.RetentionRunItem{}
+	for rows.Next() {
+		item := &models.RetentionRunItem{}
+		if err := rows.Scan(&item.EmailID, &item.From, &item.Subject, &item.Date, &item.Outcome, &item.Reason); err != nil {
+			return nil, fmt.Errorf("failed to scan retention run item: %w", err)
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// PurgeRuns - Supprimer les exécutions commencées avant before (et leur journal)
+func (r *RetentionRepository) PurgeRuns(before time.Time) (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM retention_runs WHERE started_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge retention runs: %w", err)
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"tamis-server/internal/database"
+	"tamis-server/internal/models"
+	"time"
+)
+
+type SavedSearchRepository struct {
+	db *database.DB
+}
+
+func NewSavedSearchRepository(db *database.DB) *SavedSearchRepository {
+	return &SavedSearchRepository{db: db}
+}
+
+// savedSearchColumns - Colonnes sélectionnées pour construire un models.SavedSearch
+const savedSearchColumns = `id, user_id, name, filter::text, created_at, updated_at`
+
+// scanSavedSearch - Lire une ligne correspondant à savedSearchColumns
+func scanSavedSearch(row rowScanner) (*models.SavedSearch, error) {
+	search := &models.SavedSearch{}
+	var filter string
+	err := row.Scan(&search.ID, &search.UserID, &search.Name, &filter, &search.CreatedAt, &search.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(filter), &search.Filter); err != nil {
+		return nil, fmt.Errorf("failed to decode saved search filter: %w", err)
+	}
+	return search, nil
+}
+
+// Save - Créer une recherche ou remplacer celle de même nom
+func (r *SavedSearchRepository) Save(search *models.SavedSearch) error {
+	filter, err := json.Marshal(search.Filter)
+	if err != nil {
+		return fmt.Errorf("failed to encode saved search filter: %w", err)
+	}
+
+	query := `
+        INSERT INTO saved_searches (user_id, name, filter, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $4)
+        ON CONFLICT (user_id, name) DO UPDATE
+        SET filter = EXCLUDED.filter, updated_at = EXCLUDED.updated_at
+        RETURNING id, created_at, updated_at
+    `
+
+	err = r.db.QueryRow(query, search.UserID, search.Name, string(filter), time.Now()).
+		Scan(&search.ID, &search.CreatedAt, &search.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save search: %w", err)
+	}
+
+	return nil
+}
+
+// Get - Recherche enregistrée de l'utilisateur (nil si introuvable)
+func (r *SavedSearchRepository) Get(userID, id int) (*models.SavedSearch, error) {
+	query := `SELECT ` + savedSearchColumns + ` FROM saved_searches WHERE id = $1 AND user_id = $2`
+
+	search, err := scanSavedSearch(r.db.QueryRow(query, id, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get saved search: %w", err)
+	}
+
+	return search, nil
+}
+
+// GetByUser - Recherches enregistrées de l'utilisateur, par nom
+func (r *SavedSearchRepository) GetByUser(userID int) ([]*models.SavedSearch, error) {
+	query := `SELECT ` + savedSearchColumns + ` FROM saved_searches WHERE user_id = $1 ORDER BY name`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query saved searches: %w", err)
+	}
+	defer rows.Close()
+
+	searches := []*models.SavedSearch{}
+	for rows.Next() {
+		search, err := scanSavedSearch(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan saved search: %w", err)
+		}
+		searches = append(searches, search)
+	}
+
+	return searches, rows.Err()
+}
+
+// Delete - Supprimer une recherche de l'utilisateur (false si introuvable)
+func (r *SavedSearchRepository) Delete(userID, id int) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM saved_searches WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete saved search: %w", err)
+	}
+
+	count, _ := result.RowsAffected()
+	return count > 0, nil
+}
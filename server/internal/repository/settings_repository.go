@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"tamis-server/internal/database"
+	"tamis-server/internal/models"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type SettingsRepository struct {
+	db *database.DB
+}
+
+func NewSettingsRepository(db *database.DB) *SettingsRepository {
+	return &SettingsRepository{db: db}
+}
+
+// settingsColumns - Colonnes sélectionnées pour construire un models.UserSettings
+const settingsColumns = `user_id, auto_sync_enabled, COALESCE(sync_interval_minutes, 0), COALESCE(primary_account_id, 0), COALESCE(duplicate_keep, 'oldest'), COALESCE(trash_retention_days, 30),
+    COALESCE(protected_senders, '{}'), COALESCE(protect_flagged, true), COALESCE(protect_recent_days, 0), COALESCE(protect_attachments_min_size, 0), updated_at`
+
+// scanSettings - Lire une ligne correspondant à settingsColumns
+func scanSettings(row rowScanner) (*models.UserSettings, error) {
+	settings := &models.UserSettings{}
+	err := row.Scan(
+		&settings.UserID,
+		&settings.AutoSyncEnabled,
+		&settings.SyncIntervalMinutes,
+		&settings.PrimaryAccountID,
+		&settings.DuplicateKeep,
+		&settings.TrashRetentionDays,
+		pq.Array(&settings.Protection.Senders),
+		&settings.Protection.ProtectFlagged,
+		&settings.Protection.RecentDays,
+		&settings.Protection.AttachmentMinSize,
+		&settings.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// Get - Récupérer les préférences d'un utilisateur (nil si jamais enregistrées)
+func (r *SettingsRepository) Get(userID int) (*models.UserSettings, error) {
+	query := `
+        SELECT ` + settingsColumns + `
+        FROM user_settings
+        WHERE user_id = $1
+    `
+
+	settings, err := scanSettings(r.db.QueryRow(query, userID))
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get user settings: %w", err)
+	}
+
+	return settings, nil
+}
+
+// GetAll - Préférences de tous les utilisateurs, indexées par utilisateur
+func (r *SettingsRepository) GetAll() (map[int]*models.UserSettings, error) {
+	query := `SELECT ` + settingsColumns + ` FROM user_settings`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query user settings: %w", err)
+	}
+	defer rows.Close()
+
+	all := map[int]*models.UserSettings{}
+	for rows.Next() {
+		settings, err := scanSettings(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user settings: %w", err)
+		}
+		all[settings.UserID] = settings
+	}
+
+	return all, rows.Err()
+}
+
+// Save - Enregistrer (ou remplacer) les préférences d'un utilisateur
+func (r *SettingsRepository) Save(settings *models.UserSettings) error {
+	query := `
+        INSERT INTO user_settings (user_id, auto_sync_enabled, sync_interval_minutes, primary_account_id, duplicate_keep, trash_retention_days,
+            protected_senders, protect_flagged, protect_recent_days, protect_attachments_min_size, updated_at)
+        VALUES ($1, $2, NULLIF($3, 0), NULLIF($4, 0), $5, $6, $7, $8, $9, $10, $11)
+        ON CONFLICT (user_id) DO UPDATE
+        SET auto_sync_enabled = EXCLUDED.auto_sync_enabled, sync_interval_minutes = EXCLUDED.sync_interval_minutes,
+            primary_account_id = EXCLUDED.primary_account_id, duplicate_keep = EXCLUDED.duplicate_keep,
+            trash_retention_days = EXCLUDED.trash_retention_days, protected_senders = EXCLUDED.protected_senders,
+            protect_flagged = EXCLUDED.protect_flagged, protect_recent_days = EXCLUDED.protect_recent_days,
+            protect_attachments_min_size = EXCLUDED.protect_attachments_min_size, updated_at = EXCLUDED.updated_at
+    `
+
+	settings.UpdatedAt = time.Now()
+	_, err := r.db.Exec(query, settings.UserID, settings.AutoSyncEnabled, settings.SyncIntervalMinutes,
+		settings.PrimaryAccountID, settings.DuplicateKeep, settings.TrashRetentionDays,
+		pq.Array(settings.Protection.Senders), settings.Protection.ProtectFlagged, settings.Protection.RecentDays,
+		settings.Protection.AttachmentMinSize, settings.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save user settings: %w", err)
+	}
+
+	return nil
+}
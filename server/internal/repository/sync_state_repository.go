@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"tamis-server/internal/database"
+	"tamis-server/internal/models"
+	"time"
+)
+
+type SyncStateRepository struct {
+	db *database.DB
+}
+
+func NewSyncStateRepository(db *database.DB) *SyncStateRepository {
+	return &SyncStateRepository{db: db}
+}
+
+// Get - Récupérer le checkpoint d'un compte (nil si aucune synchronisation précédente)
+func (r *SyncStateRepository) Get(accountID int, mailbox string) (*models.SyncState, error) {
+	query := `
+        SELECT account_id, mailbox, uid_validity, last_uid, highest_modseq, COALESCE(history_id, ''), COALESCE(delta_link, ''), updated_at
+        FROM sync_states
+        WHERE account_id = $1 AND mailbox = $2
+    `
+
+	state := &models.SyncState{}
+	err := r.db.QueryRow(query, accountID, mailbox).Scan(
+		&state.AccountID,
+		&state.Mailbox,
+		&state.UIDValidity,
+		&state.LastUID,
+		&state.HighestModSeq,
+		&state.HistoryID,
+		&state.DeltaLink,
+		&state.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get sync state: %w", err)
+	}
+
+	return state, nil
+}
+
+// Save - Enregistrer (ou remplacer) le checkpoint d'un compte
+func (r *SyncStateRepository) Save(state *models.SyncState) error {
+	query := `
+        INSERT INTO sync_states (account_id, mailbox, uid_validity, last_uid, highest_modseq, history_id, delta_link, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        ON CONFLICT (account_id, mailbox) DO UPDATE
+        SET uid_validity = EXCLUDED.uid_validity, last_uid = EXCLUDED.last_uid, highest_modseq = EXCLUDED.highest_modseq,
+            history_id = EXCLUDED.history_id, delta_link = EXCLUDED.delta_link, updated_at = EXCLUDED.updated_at
+    `
+
+	state.UpdatedAt = time.Now()
+	_, err := r.db.Exec(
+		query,
+		state.AccountID,
+		state.Mailbox,
+		state.UIDValidity,
+		state.LastUID,
+		state.HighestModSeq,
+		state.HistoryID,
+		state.DeltaLink,
+		state.UpdatedAt,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to save sync state: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteByAccountID - Supprimer les checkpoints d'un compte (resynchronisation complète)
+func (r *SyncStateRepository) DeleteByAccountID(accountID int) error {
+	query := `DELETE FROM sync_states WHERE account_id = $1`
+
+	_, err := r.db.Exec(query, accountID)
+	if err != nil {
+		return fmt.Errorf("failed to delete sync states: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"fmt"
+	"tamis-server/internal/database"
+	"tamis-server/internal/models"
+	"time"
+)
+
+type UnsubscribeRepository struct {
+	db *database.DB
+}
+
+func NewUnsubscribeRepository(db *database.DB) *UnsubscribeRepository {
+	return &UnsubscribeRepository{db: db}
+}
+
+// unsubscribeColumns - Colonnes sélectionnées pour construire un models.Unsubscription
+const unsubscribeColumns = `id, user_id, account_id, sender, COALESCE(list_id, ''), method, COALESCE(target, ''), status, COALESCE(error, ''), archive_future, created_at, updated_at`
+
+// scanUnsubscription - Lire une ligne correspondant à unsubscribeColumns
+func scanUnsubscription(row rowScanner) (*models.Unsubscription, error) {
+	u := &models.Unsubscription{}
+	err := row.Scan(
+		&u.ID,
+		&u.UserID,
+		&u.AccountID,
+		&u.Sender,
+		&u.ListID,
+		&u.Method,
+		&u.Target,
+		&u.Status,
+		&u.Error,
+		&u.ArchiveFuture,
+		&u.CreatedAt,
+		&u.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// queryUnsubscriptions - Exécuter une requête renvoyant des lignes unsubscribeColumns
+func (r *UnsubscribeRepository) queryUnsubscriptions(query string, args ...interface{}) ([]*models.Unsubscription, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query unsubscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	unsubscriptions := []*models.Unsubscription{}
+	for rows.Next() {
+		u, err := scanUnsubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan unsubscription: %w", err)
+		}
+		unsubscriptions = append(unsubscriptions, u)
+	}
+
+	return unsubscriptions, rows.Err()
+}
+
+// Save - Enregistrer le résultat d'une désinscription (remplace la précédente pour le même expéditeur)
+func (r *UnsubscribeRepository) Save(u *models.Unsubscription) error {
+	query := `
+        INSERT INTO unsubscriptions (user_id, account_id, sender, list_id, method, target, status, error, archive_future, created_at, updated_at)
+        VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, NULLIF($8, ''), $9, $10, $10)
+        ON CONFLICT (user_id, sender) DO UPDATE
+        SET account_id = EXCLUDED.account_id, list_id = EXCLUDED.list_id, method = EXCLUDED.method,
+            target = EXCLUDED.target, status = EXCLUDED.status, error = EXCLUDED.error,
+            archive_future = EXCLUDED.archive_future, updated_at = EXCLUDED.updated_at
+        RETURNING id, created_at, updated_at
+    `
+
+	err := r.db.QueryRow(query, u.UserID, u.AccountID, u.Sender, u.ListID, u.Method, u.Target, u.Status, u.Error, u.ArchiveFuture, time.Now()).
+		Scan(&u.ID, &u.CreatedAt, &u.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save unsubscription: %w", err)
+	}
+
+	return nil
+}
+
+// GetByUser - Désinscriptions de l'utilisateur, plus récentes d'abord
+func (r *UnsubscribeRepository) GetByUser(userID int) ([]*models.Unsubscription, error) {
+	query := `SELECT ` + unsubscribeColumns + ` FROM unsubscriptions WHERE user_id = $1 ORDER BY updated_at DESC`
+	return r.queryUnsubscriptions(query, userID)
+}
+
+// GetArchiveFuture - Listes dont les prochains messages doivent être archivés
+func (r *UnsubscribeRepository) GetArchiveFuture(userID int) ([]*models.Unsubscription, error) {
+	query := `SELECT ` + unsubscribeColumns + ` FROM unsubscriptions WHERE user_id = $1 AND archive_future = true`
+	return r.queryUnsubscriptions(query, userID)
+}
@@ -15,17 +15,19 @@ import (
 
 type AccountService struct {
 	accountRepo   *repository.AccountRepository
+	events        *EventBroker
 	logger        *utils.Logger
 	encryptionKey []byte // Clé de chiffrement pour les tokens
 }
 
-func NewAccountService(accountRepo *repository.AccountRepository, logger *utils.Logger, encryptionKey string) *AccountService {
+func NewAccountService(accountRepo *repository.AccountRepository, events *EventBroker, logger *utils.Logger, encryptionKey string) *AccountService {
 	// Utiliser une clé de 32 bytes pour AES-256
 	key := make([]byte, 32)
 	copy(key, []byte(encryptionKey))
 
 	return &AccountService{
 		accountRepo:   accountRepo,
+		events:        events,
 		logger:        logger,
 		encryptionKey: key,
 	}
@@ -39,6 +41,11 @@ func (s *AccountService) AddAccount(userID int, req *models.CreateEmailAccountRe
 		return nil, fmt.Errorf("account already exists for this email")
 	}
 
+	// Comptes IMAP avec mot de passe : pas de flux OAuth2
+	if req.Password != "" {
+		return s.addPasswordAccount(userID, req)
+	}
+
 	// Initialiser le flux OAuth2 selon le provider
 	oauthToken, err := s.initiateOAuth2Flow(req.Provider, req.Email)
 	if err != nil {
@@ -66,6 +73,8 @@ func (s *AccountService) AddAccount(userID int, req *models.CreateEmailAccountRe
 		AccessToken:    encryptedAccessToken,
 		RefreshToken:   encryptedRefreshToken,
 		TokenExpiresAt: &oauthToken.Expiry,
+		IMAPHost:       req.IMAPHost,
+		IMAPPort:       req.IMAPPort,
 		IsActive:       true,
 	}
 
@@ -79,6 +88,46 @@ func (s *AccountService) AddAccount(userID int, req *models.CreateEmailAccountRe
 	return createdAccount, nil
 }
 
+// addPasswordAccount - Ajouter un compte IMAP authentifié par LOGIN (mot de passe chiffré)
+func (s *AccountService) addPasswordAccount(userID int, req *models.CreateEmailAccountRequest) (*models.EmailAccount, error) {
+	if req.IMAPHost == "" {
+		return nil, fmt.Errorf("imap host is required for password accounts")
+	}
+
+	// Le mot de passe est stocké chiffré à la place de l'access token
+	encryptedPassword, err := s.encryptToken(req.Password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt password")
+	}
+
+	encryptedRefreshToken, err := s.encryptToken("")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt refresh token")
+	}
+
+	account := &models.EmailAccount{
+		UserID:       userID,
+		Provider:     req.Provider,
+		Email:        req.Email,
+		DisplayName:  req.DisplayName,
+		AccessToken:  encryptedPassword,
+		RefreshToken: encryptedRefreshToken,
+		AuthType:     models.AuthTypePassword,
+		IMAPHost:     req.IMAPHost,
+		IMAPPort:     req.IMAPPort,
+		IsActive:     true,
+	}
+
+	createdAccount, err := s.accountRepo.Create(account)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to create account in DB: %v", err))
+		return nil, fmt.Errorf("failed to save account")
+	}
+
+	s.logger.Info(fmt.Sprintf("IMAP account added successfully: %s (Host: %s)", req.Email, req.IMAPHost))
+	return createdAccount, nil
+}
+
 // GetUserAccounts - Récupérer tous les comptes de l'utilisateur
 func (s *AccountService) GetUserAccounts(userID int) ([]*models.EmailAccount, error) {
 	accounts, err := s.accountRepo.GetByUserID(userID)
@@ -95,6 +144,34 @@ func (s *AccountService) GetUserAccounts(userID int) ([]*models.EmailAccount, er
 	return accounts, nil
 }
 
+// GetUserAccount - Récupérer un compte en vérifiant qu'il appartient à l'utilisateur
+func (s *AccountService) GetUserAccount(userID, accountID int) (*models.EmailAccount, error) {
+	account, err := s.accountRepo.GetByID(accountID)
+	if err != nil {
+		return nil, fmt.Errorf("account not found")
+	}
+
+	if account.UserID != userID {
+		return nil, fmt.Errorf("unauthorized: account does not belong to user")
+	}
+
+	account.AccessToken = ""
+	account.RefreshToken = ""
+	return account, nil
+}
+
+// GetAccountByID - Récupérer un compte sans contrôle d'appartenance (usage interne uniquement)
+func (s *AccountService) GetAccountByID(accountID int) (*models.EmailAccount, error) {
+	account, err := s.accountRepo.GetByID(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	account.AccessToken = ""
+	account.RefreshToken = ""
+	return account, nil
+}
+
 // RemoveAccount - Supprimer un compte (révocation OAuth2)
 func (s *AccountService) RemoveAccount(userID, accountID int) error {
 	// Vérifier que le compte appartient à l'utilisateur
@@ -146,6 +223,55 @@ func (s *AccountService) GetDecryptedToken(accountID int) (*models.DecryptedToke
 	}, nil
 }
 
+// UpdateTokens - Chiffrer et enregistrer des tokens rafraîchis
+func (s *AccountService) UpdateTokens(accountID int, tokens *models.OAuth2Token) error {
+	encryptedAccessToken, err := s.encryptToken(tokens.AccessToken)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt access token")
+	}
+
+	encryptedRefreshToken, err := s.encryptToken(tokens.RefreshToken)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt refresh token")
+	}
+
+	expiresAt := tokens.Expiry
+	return s.accountRepo.UpdateTokens(accountID, encryptedAccessToken, encryptedRefreshToken, &expiresAt)
+}
+
+// DeactivateAccount - Désactiver un compte en conservant la raison
+func (s *AccountService) DeactivateAccount(accountID int, reason string) error {
+	if err := s.accountRepo.SetActive(accountID, false, reason); err != nil {
+		return err
+	}
+
+	s.logger.Warn(fmt.Sprintf("Account %d deactivated: %s", accountID, reason))
+
+	if account, err := s.accountRepo.GetByID(accountID); err == nil {
+		s.events.Publish(account.UserID, models.EventAccountDeactivated, map[string]interface{}{
+			"account_id": account.ID,
+			"email":      account.Email,
+			"reason":     reason,
+		})
+	}
+	return nil
+}
+
+// MarkSynced - Enregistrer la date de dernière synchronisation réussie
+func (s *AccountService) MarkSynced(accountID int, syncedAt time.Time) error {
+	return s.accountRepo.UpdateLastSynced(accountID, syncedAt)
+}
+
+// GetActiveAccounts - Tous les comptes actifs, tous utilisateurs confondus (usage interne uniquement)
+func (s *AccountService) GetActiveAccounts() ([]*models.EmailAccount, error) {
+	accounts, err := s.accountRepo.GetActive()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve active accounts: %w", err)
+	}
+
+	return accounts, nil
+}
+
 // encryptToken - Chiffrer un token avec AES-256-GCM
 func (s *AccountService) encryptToken(plaintext string) (string, error) {
 	block, err := aes.NewCipher(s.encryptionKey)
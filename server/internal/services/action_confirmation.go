@@ -0,0 +1,218 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"tamis-server/internal/models"
+	"time"
+)
+
+const (
+	// confirmationTokenTTL - Durée de validité d'un jeton de confirmation
+	confirmationTokenTTL = 10 * time.Minute
+	// previewSampleSize - Nombre de sujets donnés en exemple dans un aperçu
+	previewSampleSize = 10
+	// previewTopSenders - Nombre d'expéditeurs détaillés dans un aperçu
+	previewTopSenders = 20
+	// previewMaxProtectedIDs - Nombre d'emails protégés listés dans un aperçu (tous sont comptés)
+	previewMaxProtectedIDs = 1000
+)
+
+var (
+	ErrConfirmationRequired = errors.New("confirmation token required: run a dry run first")
+	ErrConfirmationInvalid  = errors.New("invalid confirmation token")
+	ErrConfirmationExpired  = errors.New("confirmation token expired: run a new dry run")
+	ErrConfirmationMismatch = errors.New("affected emails changed since the dry run: run a new dry run")
+)
+
+// ActionConfirmer - Jetons de confirmation signés (HMAC-SHA256) liant un utilisateur, une action et un ensemble d'emails
+type ActionConfirmer struct {
+	key []byte
+	ttl time.Duration
+}
+
+func NewActionConfirmer(secret string) *ActionConfirmer {
+	// Clé dérivée : un jeton de confirmation n'est jamais interchangeable avec un JWT
+	key := sha256.Sum256([]byte("action-confirmation:" + secret))
+	return &ActionConfirmer{key: key[:], ttl: confirmationTokenTTL}
+}
+
+// Issue - Signer un jeton pour cet utilisateur, cette action et ce condensat d'emails
+func (c *ActionConfirmer) Issue(userID int, action models.EmailAction, force bool, digest string) (string, time.Time) {
+	expiresAt := time.Now().Add(c.ttl).Truncate(time.Second)
+	payload := strings.Join([]string{
+		strconv.Itoa(userID),
+		string(action),
+		strconv.FormatBool(force),
+		digest,
+		strconv.FormatInt(expiresAt.Unix(), 10),
+	}, "|")
+
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(payload))
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(c.sign(encoded)), expiresAt
+}
+
+// Verify - Le jeton est-il intact, non expiré et émis pour exactement cette action ?
+func (c *ActionConfirmer) Verify(token string, userID int, action models.EmailAction, force bool, digest string) error {
+	if token == "" {
+		return ErrConfirmationRequired
+	}
+
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return ErrConfirmationInvalid
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, c.sign(encoded)) {
+		return ErrConfirmationInvalid
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return ErrConfirmationInvalid
+	}
+	fields := strings.Split(string(payload), "|")
+	if len(fields) != 5 {
+		return ErrConfirmationInvalid
+	}
+
+	expiresAt, err := strconv.ParseInt(fields[4], 10, 64)
+	if err != nil {
+		return ErrConfirmationInvalid
+	}
+	if time.Now().Unix() > expiresAt {
+		return ErrConfirmationExpired
+	}
+
+	if fields[0] != strconv.Itoa(userID) || fields[1] != string(action) || fields[2] != strconv.FormatBool(force) {
+		return ErrConfirmationInvalid
+	}
+	if fields[3] != digest {
+		return ErrConfirmationMismatch
+	}
+
+	return nil
+}
+
+func (c *ActionConfirmer) sign(encoded string) []byte {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
+
+// emailSetDigest - Condensat d'un ensemble d'IDs, indépendant de l'ordre et des doublons
+func emailSetDigest(ids []string) string {
+	sorted := uniqueIDs(ids)
+	sort.Strings(sorted)
+
+	sum := sha256.Sum256([]byte(strings.Join(sorted, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// actionPreviewBuilder - Agrège les emails concernés par une action (éventuellement lot par lot)
+type actionPreviewBuilder struct {
+	preview  *models.ActionPreview
+	accounts map[int]*models.ActionPreviewAccount
+	senders  map[string]*models.ActionPreviewSender
+	ids      []string
+}
+
+func newActionPreviewBuilder(action models.EmailAction, force bool) *actionPreviewBuilder {
+	return &actionPreviewBuilder{
+		preview: &models.ActionPreview{
+			Action:               action,
+			Force:                force,
+			Accounts:             []*models.ActionPreviewAccount{},
+			Senders:              []*models.ActionPreviewSender{},
+			SampleSubjects:       []string{},
+			ProtectedIDs:         []string{},
+			RequiresConfirmation: models.RequiresConfirmation(action, force),
+		},
+		accounts: map[int]*models.ActionPreviewAccount{},
+		senders:  map[string]*models.ActionPreviewSender{},
+	}
+}
+
+// add - Comptabiliser des emails
+func (b *actionPreviewBuilder) add(emails []*models.Email) {
+	for _, email := range emails {
+		b.ids = append(b.ids, email.ID)
+		b.preview.Count++
+		b.preview.TotalSize += email.Size
+
+		account := b.accounts[email.AccountID]
+		if account == nil {
+			account = &models.ActionPreviewAccount{AccountID: email.AccountID}
+			b.accounts[email.AccountID] = account
+		}
+		account.Count++
+		account.Size += email.Size
+
+		key := normalizeSender(email.From)
+		sender := b.senders[key]
+		if sender == nil {
+			sender = &models.ActionPreviewSender{Sender: key}
+			b.senders[key] = sender
+		}
+		sender.Count++
+		sender.Size += email.Size
+
+		if len(b.preview.SampleSubjects) < previewSampleSize {
+			b.preview.SampleSubjects = append(b.preview.SampleSubjects, email.Subject)
+		}
+	}
+}
+
+// protect - Comptabiliser un email exclu par les règles de protection
+func (b *actionPreviewBuilder) protect(emailID string) {
+	b.preview.ProtectedCount++
+	if len(b.preview.ProtectedIDs) < previewMaxProtectedIDs {
+		b.preview.ProtectedIDs = append(b.preview.ProtectedIDs, emailID)
+	}
+}
+
+// finish - Aperçu trié, signé pour l'utilisateur ; accountEmails renseigne l'adresse de chaque compte
+func (b *actionPreviewBuilder) finish(confirmer *ActionConfirmer, userID int, accountEmails map[int]string) *models.ActionPreview {
+	for _, account := range b.accounts {
+		account.Email = accountEmails[account.AccountID]
+		b.preview.Accounts = append(b.preview.Accounts, account)
+	}
+	sort.Slice(b.preview.Accounts, func(i, j int) bool {
+		return b.preview.Accounts[i].AccountID < b.preview.Accounts[j].AccountID
+	})
+
+	for _, sender := range b.senders {
+		b.preview.Senders = append(b.preview.Senders, sender)
+	}
+	sort.Slice(b.preview.Senders, func(i, j int) bool {
+		a, c := b.preview.Senders[i], b.preview.Senders[j]
+		if a.Count != c.Count {
+			return a.Count > c.Count
+		}
+		return a.Sender < c.Sender
+	})
+	if len(b.preview.Senders) > previewTopSenders {
+		b.preview.Senders = b.preview.Senders[:previewTopSenders]
+	}
+
+	b.preview.ConfirmationToken, b.preview.ExpiresAt = confirmer.Issue(userID, b.preview.Action, b.preview.Force, emailSetDigest(b.ids))
+	return b.preview
+}
+
+// verifyConfirmation - Contrôler le jeton d'une action irréversible portant sur ces emails
+func verifyConfirmation(confirmer *ActionConfirmer, userID int, action models.EmailAction, force bool, token string, ids []string) error {
+	if !models.RequiresConfirmation(action, force) {
+		return nil
+	}
+	if err := confirmer.Verify(token, userID, action, force, emailSetDigest(ids)); err != nil {
+		return fmt.Errorf("%s %w", action, err)
+	}
+	return nil
+}
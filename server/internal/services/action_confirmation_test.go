@@ -0,0 +1,80 @@
+package services
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"tamis-server/internal/models"
+)
+
+func TestEmailSetDigest(t *testing.T) {
+	digest := emailSetDigest([]string{"1-a", "1-b", "2-c"})
+	if got := emailSetDigest([]string{"2-c", "1-a", "1-b", "1-a"}); got != digest {
+		t.Errorf("reordered set with duplicates: digest %s, want %s", got, digest)
+	}
+	if got := emailSetDigest([]string{"1-a", "1-b"}); got == digest {
+		t.Error("smaller set has the same digest")
+	}
+	// Les IDs sont séparés : "1-a" + "1-b" ne se confond pas avec "1-a\n1-b" découpé autrement
+	if emailSetDigest([]string{"1-a1-b"}) == emailSetDigest([]string{"1-a", "1-b"}) {
+		t.Error("concatenated ids have the same digest")
+	}
+}
+
+func TestActionConfirmerVerify(t *testing.T) {
+	confirmer := NewActionConfirmer("secret")
+	ids := []string{"1-a", "1-b", "2-c"}
+	token, expiresAt := confirmer.Issue(7, models.ActionDelete, true, emailSetDigest(ids))
+	if until := time.Until(expiresAt); until <= 0 || until > confirmationTokenTTL {
+		t.Errorf("expires in %v", until)
+	}
+
+	encoded, signature, _ := strings.Cut(token, ".")
+	payload, _ := base64.RawURLEncoding.DecodeString(encoded)
+	forged := base64.RawURLEncoding.EncodeToString([]byte(strings.Replace(string(payload), "7|", "8|", 1))) + "." + signature
+	flipped := "A"
+	if signature[0] == 'A' {
+		flipped = "B"
+	}
+	tampered := encoded + "." + flipped + signature[1:]
+	otherKey, _ := NewActionConfirmer("other secret").Issue(7, models.ActionDelete, true, emailSetDigest(ids))
+
+	expiring := NewActionConfirmer("secret")
+	expiring.ttl = -2 * time.Second
+	expired, _ := expiring.Issue(7, models.ActionDelete, true, emailSetDigest(ids))
+
+	cases := []struct {
+		name   string
+		token  string
+		userID int
+		action models.EmailAction
+		force  bool
+		ids    []string
+		want   error
+	}{
+		{"valid", token, 7, models.ActionDelete, true, ids, nil},
+		{"reordered set", token, 7, models.ActionDelete, true, []string{"2-c", "1-b", "1-a"}, nil},
+		{"changed set", token, 7, models.ActionDelete, true, []string{"1-a", "1-b", "2-d"}, ErrConfirmationMismatch},
+		{"grown set", token, 7, models.ActionDelete, true, append([]string{"3-e"}, ids...), ErrConfirmationMismatch},
+		{"other user", token, 8, models.ActionDelete, true, ids, ErrConfirmationInvalid},
+		{"other action", token, 7, models.ActionSpam, true, ids, ErrConfirmationInvalid},
+		{"without force", token, 7, models.ActionDelete, false, ids, ErrConfirmationInvalid},
+		{"expired", expired, 7, models.ActionDelete, true, ids, ErrConfirmationExpired},
+		{"tampered payload", forged, 8, models.ActionDelete, true, ids, ErrConfirmationInvalid},
+		{"tampered signature", tampered, 7, models.ActionDelete, true, ids, ErrConfirmationInvalid},
+		{"other key", otherKey, 7, models.ActionDelete, true, ids, ErrConfirmationInvalid},
+		{"no signature", encoded, 7, models.ActionDelete, true, ids, ErrConfirmationInvalid},
+		{"missing", "", 7, models.ActionDelete, true, ids, ErrConfirmationRequired},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := confirmer.Verify(tc.token, tc.userID, tc.action, tc.force, emailSetDigest(tc.ids))
+			if !errors.Is(err, tc.want) {
+				t.Errorf("Verify = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,342 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"tamis-server/internal/models"
+	"tamis-server/internal/repository"
+	"tamis-server/internal/utils"
+	"time"
+)
+
+const (
+	// backfillPageSize - Nombre de messages demandés par page
+	backfillPageSize = 100
+	// backfillMaxRetries - Tentatives consécutives avant de passer le backfill en échec
+	backfillMaxRetries = 5
+	// backfillRetryDelay - Délai de base entre deux tentatives (multiplié par le numéro de tentative)
+	backfillRetryDelay = 10 * time.Second
+)
+
+// BackfillService - Import complet et reprenable des boîtes mail
+type BackfillService struct {
+	backfillRepo   *repository.BackfillRepository
+	mailService    *MailService
+	accountService *AccountService
+	logger         *utils.Logger
+
+	mu       sync.Mutex
+	running  map[int]bool                // Comptes dont le backfill tourne dans ce processus
+	restarts map[int]*models.BackfillJob // Redémarrages demandés pendant un backfill en cours
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+func NewBackfillService(backfillRepo *repository.BackfillRepository, mailService *MailService, accountService *AccountService, logger *utils.Logger) *BackfillService {
+	return &BackfillService{
+		backfillRepo:   backfillRepo,
+		mailService:    mailService,
+		accountService: accountService,
+		logger:         logger,
+		running:        make(map[int]bool),
+		restarts:       make(map[int]*models.BackfillJob),
+		ctx:            context.Background(),
+	}
+}
+
+// StartBackfill - Lancer (ou relancer) l'import complet d'un compte de l'utilisateur
+func (s *BackfillService) StartBackfill(userID, accountID int, restart bool) (*models.BackfillJob, error) {
+	account, err := s.accountService.GetUserAccount(userID, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !account.IsActive {
+		return nil, fmt.Errorf("account is inactive")
+	}
+
+	job, err := s.backfillRepo.Get(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if job != nil && job.Status == models.BackfillRunning && s.isRunning(accountID) {
+		return job, nil
+	}
+
+	// Un backfill terminé n'est relancé que sur demande explicite ; un échec reprend au dernier curseur
+	if job == nil || restart || job.Status == models.BackfillCompleted {
+		job = &models.BackfillJob{
+			AccountID: accountID,
+			StartedAt: time.Now(),
+		}
+	}
+	job.Status = models.BackfillRunning
+	job.LastError = ""
+	job.CompletedAt = nil
+
+	if err := s.backfillRepo.Save(job); err != nil {
+		return nil, err
+	}
+
+	s.launch(account, job)
+	return job, nil
+}
+
+// RestartBackfill - Reprendre l'import complet depuis le début (ProviderIDsResetHandler) : les curseurs
+// enregistrés et les emails déjà importés référencent des provider IDs invalidés
+func (s *BackfillService) RestartBackfill(account *models.EmailAccount) {
+	if !account.IsActive {
+		return
+	}
+
+	job := &models.BackfillJob{
+		AccountID: account.ID,
+		Status:    models.BackfillRunning,
+		StartedAt: time.Now(),
+	}
+
+	// Un backfill en cours reprend lui-même au début avant sa prochaine page
+	s.mu.Lock()
+	if s.running[account.ID] {
+		s.restarts[account.ID] = job
+		s.mu.Unlock()
+		return
+	}
+	s.mu.Unlock()
+
+	if err := s.backfillRepo.Save(job); err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to restart backfill for account %d: %v", account.ID, err))
+		return
+	}
+
+	s.logger.Info(fmt.Sprintf("Backfill restarted for account %d after provider ids reset", account.ID))
+	s.launch(account, job)
+}
+
+// GetBackfillStatus - Progression du backfill d'un compte de l'utilisateur
+func (s *BackfillService) GetBackfillStatus(userID, accountID int) (*models.BackfillJob, error) {
+	if _, err := s.accountService.GetUserAccount(userID, accountID); err != nil {
+		return nil, err
+	}
+
+	job, err := s.backfillRepo.Get(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if job == nil {
+		return nil, fmt.Errorf("no backfill found for account %d", accountID)
+	}
+
+	return job, nil
+}
+
+// Start - Rattacher les backfills au contexte d'arrêt et reprendre ceux interrompus par un arrêt du serveur
+func (s *BackfillService) Start(ctx context.Context) error {
+	s.mu.Lock()
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	s.mu.Unlock()
+
+	jobs, err := s.backfillRepo.GetByStatus(models.BackfillRunning)
+	if err != nil {
+		return err
+	}
+
+	for _, job := range jobs {
+		account, err := s.accountService.GetAccountByID(job.AccountID)
+		if err != nil {
+			s.logger.Error(fmt.Sprintf("Failed to resume backfill for account %d: %v", job.AccountID, err))
+			continue
+		}
+
+		s.logger.Info(fmt.Sprintf("Resuming backfill for account %d (%d fetched)", job.AccountID, job.Fetched))
+		s.launch(account, job)
+	}
+
+	return nil
+}
+
+// Stop - Interrompre les backfills en cours et attendre la fin de la page courante.
+// Ils restent "running" en base et reprennent au prochain démarrage.
+func (s *BackfillService) Stop() {
+	s.mu.Lock()
+	if s.cancel == nil {
+		s.mu.Unlock()
+		return
+	}
+	// Sous verrou : aucun launch ne peut plus ajouter de backfill une fois le contexte annulé
+	s.cancel()
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	s.logger.Info("Backfills stopped")
+}
+
+// launch - Démarrer le backfill en arrière-plan si aucun n'est déjà en cours pour ce compte
+func (s *BackfillService) launch(account *models.EmailAccount, job *models.BackfillJob) {
+	s.mu.Lock()
+	if s.running[account.ID] || s.ctx.Err() != nil {
+		s.mu.Unlock()
+		return
+	}
+	s.running[account.ID] = true
+	ctx := s.ctx
+	s.wg.Add(1)
+	s.mu.Unlock()
+
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			s.mu.Lock()
+			delete(s.running, account.ID)
+			s.mu.Unlock()
+
+			// Redémarrage demandé après la dernière page lue (fin, échec ou arrêt du serveur)
+			if restart := s.takeRestart(account.ID); restart != nil {
+				s.RestartBackfill(account)
+			}
+		}()
+
+		s.run(ctx, account, job)
+	}()
+}
+
+// isRunning - Un backfill est-il actif pour ce compte dans ce processus
+func (s *BackfillService) isRunning(accountID int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.running[accountID]
+}
+
+// run - Parcourir la boîte page par page en enregistrant le curseur après chaque page, jusqu'à l'arrêt de ctx
+func (s *BackfillService) run(ctx context.Context, account *models.EmailAccount, job *models.BackfillJob) {
+	var client PagedEmailClient
+	var clientToken string
+	defer func() {
+		if client != nil {
+			closeEmailClient(client)
+		}
+	}()
+
+	failures := 0
+	for {
+		if ctx.Err() != nil {
+			s.logger.Info(fmt.Sprintf("Backfill interrupted for account %d (%d fetched)", account.ID, job.Fetched))
+			return
+		}
+
+		if restart := s.takeRestart(account.ID); restart != nil {
+			s.logger.Info(fmt.Sprintf("Backfill restarted for account %d after provider ids reset", account.ID))
+			job = restart
+		}
+
+		// Le token peut expirer pendant un long import : recréer le client s'il a été rafraîchi
+		tokens, err := s.mailService.tokenManager.GetValidToken(account)
+		if err != nil {
+			s.fail(job, fmt.Errorf("failed to get valid token: %w", err))
+			return
+		}
+
+		if client == nil || tokens.AccessToken != clientToken {
+			if client != nil {
+				closeEmailClient(client)
+			}
+
+			emailClient, err := s.mailService.createEmailClient(account, tokens.AccessToken)
+			if err != nil {
+				s.fail(job, err)
+				return
+			}
+
+			paged, ok := emailClient.(PagedEmailClient)
+			if !ok {
+				closeEmailClient(emailClient)
+				s.fail(job, fmt.Errorf("provider %s does not support backfill", account.Provider))
+				return
+			}
+			client = paged
+			clientToken = tokens.AccessToken
+		}
+
+		page, err := client.FetchPage(job.Cursor, backfillPageSize)
+		if err != nil {
+			failures++
+			if failures >= backfillMaxRetries {
+				s.fail(job, fmt.Errorf("failed to fetch page: %w", err))
+				return
+			}
+
+			s.logger.Warn(fmt.Sprintf("Backfill page failed for account %d (attempt %d): %v", account.ID, failures, err))
+			closeEmailClient(client)
+			client = nil
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Duration(failures) * backfillRetryDelay):
+			}
+			continue
+		}
+		failures = 0
+
+		result := &models.AccountSyncResult{}
+		for _, email := range page.Emails {
+			s.mailService.saveSyncedEmail(account, email, result)
+		}
+
+		job.Fetched += len(page.Emails)
+		job.EstimatedTotal = page.EstimatedTotal
+		if job.Fetched > job.EstimatedTotal {
+			job.EstimatedTotal = job.Fetched
+		}
+		job.Cursor = page.NextCursor
+
+		if page.NextCursor == "" {
+			now := time.Now()
+			job.Status = models.BackfillCompleted
+			job.CompletedAt = &now
+		}
+
+		if err := s.backfillRepo.Save(job); err != nil {
+			s.logger.Error(fmt.Sprintf("Failed to save backfill progress for account %d: %v", account.ID, err))
+			return
+		}
+
+		snapshot := *job
+		s.mailService.events.Publish(account.UserID, models.EventBackfillProgress, &snapshot)
+
+		if job.Status == models.BackfillCompleted {
+			s.logger.Info(fmt.Sprintf("Backfill completed for account %d - %d emails", account.ID, job.Fetched))
+
+			// Toute la boîte a été relue : les emails encore détachés n'existent plus sur le serveur
+			if deleted, err := s.mailService.emailRepo.DeleteDetached(account.ID); err != nil {
+				s.logger.Error(fmt.Sprintf("Failed to delete detached emails for account %d: %v", account.ID, err))
+			} else if deleted > 0 {
+				s.logger.Info(fmt.Sprintf("Deleted %d detached emails for account %d", deleted, account.ID))
+			}
+			return
+		}
+	}
+}
+
+// takeRestart - Redémarrage demandé pour ce compte pendant le backfill en cours (nil si aucun)
+func (s *BackfillService) takeRestart(accountID int) *models.BackfillJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job := s.restarts[accountID]
+	delete(s.restarts, accountID)
+	return job
+}
+
+// fail - Passer le backfill en échec ; le curseur est conservé pour une reprise ultérieure
+func (s *BackfillService) fail(job *models.BackfillJob, err error) {
+	s.logger.Error(fmt.Sprintf("Backfill failed for account %d: %v", job.AccountID, err))
+
+	job.Status = models.BackfillFailed
+	job.LastError = err.Error()
+	if saveErr := s.backfillRepo.Save(job); saveErr != nil {
+		s.logger.Error(fmt.Sprintf("Failed to save backfill status for account %d: %v", job.AccountID, saveErr))
+	}
+}
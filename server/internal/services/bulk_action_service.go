@@ -0,0 +1,397 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"tamis-server/internal/models"
+	"tamis-server/internal/repository"
+	"tamis-server/internal/utils"
+)
+
+// bulkActionChunkSize - Nombre d'emails traités par lot dans un job bulk_action
+const bulkActionChunkSize = 500
+
+// ErrBulkActionRunning - Une autre action groupée est déjà en cours pour l'utilisateur
+var ErrBulkActionRunning = errors.New("another bulk action is already running")
+
+// BulkActionService - Actions sur tous les emails correspondant à un filtre, exécutées en job
+type BulkActionService struct {
+	savedSearchRepo *repository.SavedSearchRepository
+	emailRepo       *repository.EmailRepository
+	accountService  *AccountService
+	mailService     *MailService
+	jobService      *JobService
+	events          *EventBroker
+	logger          *utils.Logger
+}
+
+func NewBulkActionService(savedSearchRepo *repository.SavedSearchRepository, emailRepo *repository.EmailRepository, accountService *AccountService, mailService *MailService, jobService *JobService, events *EventBroker, logger *utils.Logger) *BulkActionService {
+	return &BulkActionService{
+		savedSearchRepo: savedSearchRepo,
+		emailRepo:       emailRepo,
+		accountService:  accountService,
+		mailService:     mailService,
+		jobService:      jobService,
+		events:          events,
+		logger:          logger,
+	}
+}
+
+// Preview - Résumé des emails concernés et jeton de confirmation, sans rien modifier
+func (s *BulkActionService) Preview(userID int, req *models.BulkActionRequest) (*models.BulkActionPreview, error) {
+	filter, err := s.resolveFilter(userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	accountIDs, err := s.scopeAccounts(userID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	protection, err := s.mailService.protectionFor(userID, req.Action, req.OverrideProtection)
+	if err != nil {
+		return nil, err
+	}
+
+	builder := newActionPreviewBuilder(req.Action, req.Force)
+	err = s.forEachMatch(accountIDs, filter, func(ids []string) error {
+		emails, err := s.emailRepo.GetByIDsForUser(userID, ids)
+		if err != nil {
+			return err
+		}
+		kept, protected := protection.filter(emails)
+		builder.add(kept)
+		for _, email := range emails {
+			if protected[email.ID] != "" {
+				builder.protect(email.ID)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	accountEmails, err := s.mailService.userAccountEmails(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.BulkActionPreview{
+		Filter:        filter,
+		ActionPreview: *builder.finish(s.mailService.confirmer, userID, accountEmails),
+	}, nil
+}
+
+// Start - Lancer l'action groupée en arrière-plan (un seul job bulk_action actif par utilisateur)
+func (s *BulkActionService) Start(userID int, req *models.BulkActionRequest) (*models.Job, error) {
+	filter, err := s.resolveFilter(userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Vérifier l'appartenance des comptes avant de créer le job
+	accountIDs, err := s.scopeAccounts(userID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	params := &models.BulkActionParams{Filter: filter, Action: req.Action, Force: req.Force, OverrideProtection: req.OverrideProtection}
+
+	// Action irréversible : les correspondances non protégées doivent être celles de l'aperçu,
+	// et le job est limité à ces emails
+	if models.RequiresConfirmation(req.Action, req.Force) {
+		protection, err := s.mailService.protectionFor(userID, req.Action, req.OverrideProtection)
+		if err != nil {
+			return nil, err
+		}
+
+		matched := []string{}
+		err = s.forEachMatch(accountIDs, filter, func(ids []string) error {
+			emails, err := s.emailRepo.GetByIDsForUser(userID, ids)
+			if err != nil {
+				return err
+			}
+			kept, _ := protection.filter(emails)
+			for _, email := range kept {
+				matched = append(matched, email.ID)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := verifyConfirmation(s.mailService.confirmer, userID, req.Action, req.Force, req.ConfirmationToken, matched); err != nil {
+			return nil, err
+		}
+		params.EmailIDs = matched
+	}
+
+	job, err := s.jobService.Enqueue(userID, models.JobTypeBulkAction, params)
+	if err != nil {
+		return nil, err
+	}
+
+	// Enqueue renvoie le job déjà actif : ce n'est pas forcément la même action
+	same, err := sameBulkActionParams(job.Params, params)
+	if err != nil {
+		return nil, err
+	}
+	if !same {
+		return job, ErrBulkActionRunning
+	}
+
+	s.logger.Info(fmt.Sprintf("Bulk %s requested by user %d (job %s)", req.Action, userID, job.ID))
+	return job, nil
+}
+
+// RunBulkActionJob - Exécuter un job bulk_action (JobHandler) par lots, en reprenant après le dernier lot traité
+func (s *BulkActionService) RunBulkActionJob(ctx context.Context, job *models.Job, report JobReporter) error {
+	var params models.BulkActionParams
+	if err := json.Unmarshal(job.Params, &params); err != nil {
+		return fmt.Errorf("invalid bulk action job params: %w", err)
+	}
+	if params.Filter == nil {
+		params.Filter = &models.EmailFilter{}
+	}
+
+	progress := &models.BulkActionProgress{}
+	if len(job.Result) > 0 {
+		if err := json.Unmarshal(job.Result, progress); err != nil {
+			return fmt.Errorf("invalid bulk action job progress: %w", err)
+		}
+	}
+
+	// Les comptes sont revérifiés à chaque reprise (compte supprimé ou désactivé entre-temps)
+	accountIDs, err := s.scopeAccounts(job.UserID, params.Filter)
+	if err != nil {
+		return err
+	}
+	if len(accountIDs) == 0 {
+		report(progress)
+		return nil
+	}
+
+	if progress.LastID == "" {
+		if params.EmailIDs != nil {
+			progress.MatchedCount = len(params.EmailIDs)
+		} else {
+			progress.MatchedCount, err = s.emailRepo.CountByFilter(accountIDs, params.Filter)
+			if err != nil {
+				return err
+			}
+		}
+		report(progress)
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		ids, err := s.nextBulkChunk(accountIDs, &params, progress)
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			break
+		}
+
+		// Confirmation vérifiée au lancement du job ; un seul handle d'annulation pour tout le job
+		result, err := s.mailService.executeEmailAction(job.UserID, &models.EmailActionRequest{
+			EmailIDs:           ids,
+			Action:             params.Action,
+			Force:              params.Force,
+			OverrideProtection: params.OverrideProtection,
+		}, actionOptions{confirmed: true, batchID: progress.UndoID})
+		if err != nil {
+			return err
+		}
+
+		progress.Processed += len(ids)
+		progress.SuccessCount += result.SuccessCount
+		progress.FailureCount += result.FailureCount
+		progress.PendingCount += result.PendingCount
+		progress.SkippedCount += result.SkippedCount
+		progress.LastID = ids[len(ids)-1]
+		if result.UndoID != "" {
+			progress.UndoID = result.UndoID
+		}
+
+		report(progress)
+		s.events.Publish(job.UserID, models.EventBulkActionProgress, map[string]interface{}{
+			"job_id":   job.ID,
+			"progress": *progress,
+		})
+	}
+
+	s.logger.Info(fmt.Sprintf("Bulk %s finished for user %d: %d processed, %d failed, %d pending, %d protected",
+		params.Action, job.UserID, progress.Processed, progress.FailureCount, progress.PendingCount, progress.SkippedCount))
+	return nil
+}
+
+// nextBulkChunk - Lot suivant : emails confirmés restants, sinon correspondances du filtre après le curseur
+func (s *BulkActionService) nextBulkChunk(accountIDs []int, params *models.BulkActionParams, progress *models.BulkActionProgress) ([]string, error) {
+	if params.EmailIDs == nil {
+		return s.emailRepo.GetIDsByFilter(accountIDs, params.Filter, progress.LastID, bulkActionChunkSize)
+	}
+
+	// Les emails confirmés sont traités dans l'ordre de l'aperçu : Processed sert de position
+	if progress.Processed >= len(params.EmailIDs) {
+		return nil, nil
+	}
+	end := progress.Processed + bulkActionChunkSize
+	if end > len(params.EmailIDs) {
+		end = len(params.EmailIDs)
+	}
+	return params.EmailIDs[progress.Processed:end], nil
+}
+
+// forEachMatch - Parcourir par lots les IDs des emails correspondant au filtre
+func (s *BulkActionService) forEachMatch(accountIDs []int, filter *models.EmailFilter, fn func(ids []string) error) error {
+	if len(accountIDs) == 0 {
+		return nil
+	}
+
+	afterID := ""
+	for {
+		ids, err := s.emailRepo.GetIDsByFilter(accountIDs, filter, afterID, bulkActionChunkSize)
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		if err := fn(ids); err != nil {
+			return err
+		}
+		afterID = ids[len(ids)-1]
+	}
+}
+
+// GetSavedSearches - Recherches enregistrées de l'utilisateur
+func (s *BulkActionService) GetSavedSearches(userID int) ([]*models.SavedSearch, error) {
+	return s.savedSearchRepo.GetByUser(userID)
+}
+
+// SaveSearch - Enregistrer un filtre sous un nom (remplace la recherche de même nom)
+func (s *BulkActionService) SaveSearch(userID int, req *models.SavedSearchRequest) (*models.SavedSearch, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("search name is required")
+	}
+	if req.Filter == nil {
+		return nil, fmt.Errorf("search filter is required")
+	}
+
+	filter := normalizeBulkFilter(req.Filter)
+	if filter.AccountID != 0 {
+		if _, err := s.accountService.GetUserAccount(userID, filter.AccountID); err != nil {
+			return nil, err
+		}
+	}
+
+	search := &models.SavedSearch{UserID: userID, Name: name, Filter: filter}
+	if err := s.savedSearchRepo.Save(search); err != nil {
+		return nil, err
+	}
+
+	return search, nil
+}
+
+// DeleteSearch - Supprimer une recherche enregistrée
+func (s *BulkActionService) DeleteSearch(userID, id int) error {
+	deleted, err := s.savedSearchRepo.Delete(userID, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("saved search not found")
+	}
+	return nil
+}
+
+// resolveFilter - Filtre de la requête ou de la recherche enregistrée, sans pagination
+func (s *BulkActionService) resolveFilter(userID int, req *models.BulkActionRequest) (*models.EmailFilter, error) {
+	if !isSupportedAction(req.Action) {
+		return nil, fmt.Errorf("unsupported action: %s", req.Action)
+	}
+
+	if req.SavedSearchID != 0 {
+		if req.Filter != nil {
+			return nil, fmt.Errorf("filter and saved_search_id are mutually exclusive")
+		}
+		search, err := s.savedSearchRepo.Get(userID, req.SavedSearchID)
+		if err != nil {
+			return nil, err
+		}
+		if search == nil {
+			return nil, fmt.Errorf("saved search not found")
+		}
+		return normalizeBulkFilter(search.Filter), nil
+	}
+
+	if req.Filter == nil {
+		return nil, fmt.Errorf("filter or saved_search_id is required")
+	}
+	return normalizeBulkFilter(req.Filter), nil
+}
+
+// scopeAccounts - Comptes actifs de l'utilisateur restreints par le filtre (compte vérifié, provider)
+func (s *BulkActionService) scopeAccounts(userID int, filter *models.EmailFilter) ([]int, error) {
+	if filter.AccountID != 0 {
+		account, err := s.accountService.GetUserAccount(userID, filter.AccountID)
+		if err != nil {
+			return nil, err
+		}
+		if !account.IsActive || (filter.Provider != "" && account.Provider != filter.Provider) {
+			return []int{}, nil
+		}
+		return []int{account.ID}, nil
+	}
+
+	accounts, err := s.accountService.GetUserAccounts(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user accounts: %w", err)
+	}
+
+	accountIDs := []int{}
+	for _, account := range accounts {
+		if account.IsActive && (filter.Provider == "" || account.Provider == filter.Provider) {
+			accountIDs = append(accountIDs, account.ID)
+		}
+	}
+	return accountIDs, nil
+}
+
+// normalizeBulkFilter - Copie du filtre sans pagination : l'action porte sur toutes les correspondances
+func normalizeBulkFilter(filter *models.EmailFilter) *models.EmailFilter {
+	normalized := *filter
+	normalized.Limit = 0
+	normalized.Offset = 0
+	return &normalized
+}
+
+// sameBulkActionParams - Le job actif porte-t-il sur la même action et le même filtre ?
+func sameBulkActionParams(encoded []byte, params *models.BulkActionParams) (bool, error) {
+	var active models.BulkActionParams
+	if err := json.Unmarshal(encoded, &active); err != nil {
+		return false, fmt.Errorf("invalid bulk action job params: %w", err)
+	}
+
+	// Comparer les formes JSON (l'ordre des clés JSONB n'est pas conservé)
+	activeJSON, err := json.Marshal(active)
+	if err != nil {
+		return false, err
+	}
+	requestedJSON, err := json.Marshal(params)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(activeJSON, requestedJSON), nil
+}
@@ -0,0 +1,401 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/mail"
+	"sort"
+	"strings"
+	"tamis-server/internal/models"
+	"tamis-server/internal/repository"
+	"tamis-server/internal/utils"
+	"time"
+)
+
+const (
+	// duplicateDateWindow - Écart de date maximal entre deux copies rapprochées par empreinte
+	duplicateDateWindow = 10 * time.Minute
+	// duplicateSizeTolerance - Écart de taille relatif toléré (les providers ne mesurent pas tous la taille pareil)
+	duplicateSizeTolerance = 0.1
+	// duplicateSizeSlack - Écart de taille absolu toléré pour les petits messages
+	duplicateSizeSlack = 2048
+)
+
+// DuplicateService - Détection des doublons sur l'ensemble des comptes d'un utilisateur
+type DuplicateService struct {
+	emailRepo       *repository.EmailRepository
+	accountService  *AccountService
+	settingsService *SettingsService
+	mailService     *MailService
+	logger          *utils.Logger
+}
+
+func NewDuplicateService(emailRepo *repository.EmailRepository, accountService *AccountService, settingsService *SettingsService, mailService *MailService, logger *utils.Logger) *DuplicateService {
+	return &DuplicateService{
+		emailRepo:       emailRepo,
+		accountService:  accountService,
+		settingsService: settingsService,
+		mailService:     mailService,
+		logger:          logger,
+	}
+}
+
+// FindDuplicates - Regrouper les copies d'un même message et proposer celle à conserver.
+// primaryAccountID et keep remplacent, s'ils sont fournis, les préférences enregistrées.
+func (s *DuplicateService) FindDuplicates(userID int, primaryAccountID *int, keep *models.DuplicateKeepStrategy) (*models.DuplicateReport, error) {
+	prefs, err := s.resolvePreferences(userID, primaryAccountID, keep)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.DuplicateReport{
+		Groups:       []*models.DuplicateGroup{},
+		DuplicateIDs: []string{},
+		Preferences:  prefs,
+	}
+
+	accounts, err := s.accountService.GetUserAccounts(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user accounts: %w", err)
+	}
+
+	accountIDs := make([]int, 0, len(accounts))
+	for _, account := range accounts {
+		if account.IsActive {
+			accountIDs = append(accountIDs, account.ID)
+		}
+	}
+
+	if len(accountIDs) == 0 {
+		return report, nil
+	}
+
+	emails, err := s.emailRepo.GetDuplicateCandidates(accountIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, members := range groupDuplicates(emails) {
+		group := buildDuplicateGroup(members, prefs)
+		report.Groups = append(report.Groups, group)
+		report.ReclaimableBytes += group.ReclaimableBytes
+		for _, email := range group.Duplicates {
+			report.DuplicateIDs = append(report.DuplicateIDs, email.ID)
+		}
+	}
+	report.DuplicateCount = len(report.DuplicateIDs)
+
+	// Les groupes les plus volumineux d'abord
+	sort.SliceStable(report.Groups, func(i, j int) bool {
+		return report.Groups[i].ReclaimableBytes > report.Groups[j].ReclaimableBytes
+	})
+
+	s.logger.Info(fmt.Sprintf("Found %d duplicates in %d groups for user %d", report.DuplicateCount, len(report.Groups), userID))
+	return report, nil
+}
+
+// DeleteDuplicates - Supprimer les doublons (recalculés côté serveur) en conservant une copie par groupe
+func (s *DuplicateService) DeleteDuplicates(userID int, req *models.DeleteDuplicatesRequest) (*models.EmailActionResult, error) {
+	ids, err := s.duplicateIDsToDelete(userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Une copie supprimée n'est pas un avis sur son contenu : le classifieur n'apprend pas de ces suppressions
+	return s.mailService.executeEmailAction(userID, &models.EmailActionRequest{
+		EmailIDs:           ids,
+		Action:             models.ActionDelete,
+		Force:              req.Force,
+		ConfirmationToken:  req.ConfirmationToken,
+		OverrideProtection: req.OverrideProtection,
+	}, actionOptions{automated: true})
+}
+
+// PreviewDuplicateDeletion - Aperçu (et jeton de confirmation) de la suppression des doublons
+func (s *DuplicateService) PreviewDuplicateDeletion(userID int, req *models.DeleteDuplicatesRequest) (*models.ActionPreview, error) {
+	ids, err := s.duplicateIDsToDelete(userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.mailService.PreviewEmailAction(userID, &models.EmailActionRequest{
+		EmailIDs:           ids,
+		Action:             models.ActionDelete,
+		Force:              req.Force,
+		OverrideProtection: req.OverrideProtection,
+	})
+}
+
+// duplicateIDsToDelete - Copies à supprimer, limitées aux groupes demandés
+func (s *DuplicateService) duplicateIDsToDelete(userID int, req *models.DeleteDuplicatesRequest) ([]string, error) {
+	report, err := s.FindDuplicates(userID, req.PrimaryAccountID, req.Keep)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := report.DuplicateIDs
+	if len(req.GroupKeys) > 0 {
+		selected := make(map[string]bool, len(req.GroupKeys))
+		for _, key := range req.GroupKeys {
+			selected[key] = true
+		}
+
+		ids = []string{}
+		for _, group := range report.Groups {
+			if !selected[group.Key] {
+				continue
+			}
+			for _, email := range group.Duplicates {
+				ids = append(ids, email.ID)
+			}
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("no duplicates to delete")
+	}
+
+	return ids, nil
+}
+
+// resolvePreferences - Préférences enregistrées, éventuellement remplacées pour cette requête
+func (s *DuplicateService) resolvePreferences(userID int, primaryAccountID *int, keep *models.DuplicateKeepStrategy) (models.DuplicatePreferences, error) {
+	settings, err := s.settingsService.GetSettings(userID)
+	if err != nil {
+		return models.DuplicatePreferences{}, err
+	}
+
+	prefs := models.DuplicatePreferences{
+		PrimaryAccountID: settings.PrimaryAccountID,
+		Keep:             settings.DuplicateKeep,
+	}
+
+	if primaryAccountID != nil {
+		prefs.PrimaryAccountID = *primaryAccountID
+	}
+	if keep != nil {
+		prefs.Keep = *keep
+	}
+
+	if !prefs.Keep.IsValid() {
+		return prefs, fmt.Errorf("invalid duplicate keep strategy: %s", prefs.Keep)
+	}
+
+	return prefs, nil
+}
+
+// groupDuplicates - Regrouper les emails (triés par date croissante) par Message-ID et par empreinte
+func groupDuplicates(emails []*models.Email) [][]*models.Email {
+	parent := make([]int, len(emails))
+	for i := range parent {
+		parent[i] = i
+	}
+	find := func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+	// Message-ID (normalisés) présents dans chaque groupe, par compte
+	accountMessageIDs := make([]map[int]map[string]bool, len(emails))
+	for i, email := range emails {
+		accountMessageIDs[i] = map[int]map[string]bool{
+			email.AccountID: {normalizeMessageID(email.MessageID): true},
+		}
+	}
+	union := func(a, b int) {
+		if ra, rb := find(a), find(b); ra != rb {
+			parent[rb] = ra
+			for accountID, ids := range accountMessageIDs[rb] {
+				if accountMessageIDs[ra][accountID] == nil {
+					accountMessageIDs[ra][accountID] = map[string]bool{}
+				}
+				for id := range ids {
+					accountMessageIDs[ra][accountID][id] = true
+				}
+			}
+			accountMessageIDs[rb] = nil
+		}
+	}
+	// conflicts - La fusion réunirait deux messages distincts d'un même compte
+	conflicts := func(a, b int) bool {
+		ra, rb := find(a), find(b)
+		if ra == rb {
+			return false
+		}
+		for accountID, ids := range accountMessageIDs[rb] {
+			existing := accountMessageIDs[ra][accountID]
+			if existing == nil {
+				continue
+			}
+			for id := range ids {
+				if !existing[id] {
+					return true
+				}
+			}
+			for id := range existing {
+				if !ids[id] {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	// Même Message-ID, quel que soit le compte
+	byMessageID := map[string]int{}
+	for i, email := range emails {
+		messageID := normalizeMessageID(email.MessageID)
+		if messageID == "" {
+			continue
+		}
+		if first, ok := byMessageID[messageID]; ok {
+			union(first, i)
+		} else {
+			byMessageID[messageID] = i
+		}
+	}
+
+	// Même empreinte, dates proches et tailles comparables
+	byFingerprint := map[string][]int{}
+	for i, email := range emails {
+		if fingerprint := contentFingerprint(email); fingerprint != "" {
+			byFingerprint[fingerprint] = append(byFingerprint[fingerprint], i)
+		}
+	}
+	for _, indexes := range byFingerprint {
+		for j := 1; j < len(indexes); j++ {
+			current := emails[indexes[j]]
+			for k := j - 1; k >= 0; k-- {
+				previous := emails[indexes[k]]
+				if current.Date.Sub(previous.Date) > duplicateDateWindow {
+					break
+				}
+				if similarSize(previous.Size, current.Size) && !conflicts(indexes[k], indexes[j]) {
+					union(indexes[k], indexes[j])
+					break
+				}
+			}
+		}
+	}
+
+	members := map[int][]*models.Email{}
+	roots := []int{}
+	for i, email := range emails {
+		root := find(i)
+		if _, ok := members[root]; !ok {
+			roots = append(roots, root)
+		}
+		members[root] = append(members[root], email)
+	}
+
+	groups := [][]*models.Email{}
+	for _, root := range roots {
+		if len(members[root]) > 1 {
+			groups = append(groups, members[root])
+		}
+	}
+	return groups
+}
+
+// buildDuplicateGroup - Choisir la copie à conserver selon les préférences
+func buildDuplicateGroup(members []*models.Email, prefs models.DuplicatePreferences) *models.DuplicateGroup {
+	sorted := append([]*models.Email(nil), members...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+
+		if prefs.PrimaryAccountID != 0 {
+			aPrimary, bPrimary := a.AccountID == prefs.PrimaryAccountID, b.AccountID == prefs.PrimaryAccountID
+			if aPrimary != bPrimary {
+				return aPrimary
+			}
+		}
+
+		// Ne jamais préférer la copie classée en spam
+		if a.IsSpam != b.IsSpam {
+			return !a.IsSpam
+		}
+
+		if !a.Date.Equal(b.Date) {
+			if prefs.Keep == models.KeepNewest {
+				return a.Date.After(b.Date)
+			}
+			return a.Date.Before(b.Date)
+		}
+
+		return a.ID < b.ID
+	})
+
+	group := &models.DuplicateGroup{
+		Key:        duplicateGroupKey(members),
+		MatchedBy:  models.DuplicateByMessageID,
+		Keep:       sorted[0],
+		Duplicates: sorted[1:],
+	}
+
+	messageID := normalizeMessageID(sorted[0].MessageID)
+	for _, email := range sorted[1:] {
+		group.ReclaimableBytes += email.Size
+		if messageID == "" || normalizeMessageID(email.MessageID) != messageID {
+			group.MatchedBy = models.DuplicateByFingerprint
+		}
+	}
+
+	return group
+}
+
+// duplicateGroupKey - Identifiant stable d'un groupe, dérivé des IDs de ses copies
+func duplicateGroupKey(members []*models.Email) string {
+	ids := make([]string, len(members))
+	for i, email := range members {
+		ids[i] = email.ID
+	}
+	sort.Strings(ids)
+
+	sum := sha256.Sum256([]byte(strings.Join(ids, "\n")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// normalizeMessageID - Message-ID sans chevrons ni espaces, en minuscules
+func normalizeMessageID(messageID string) string {
+	messageID = strings.TrimSpace(messageID)
+	messageID = strings.TrimPrefix(messageID, "<")
+	messageID = strings.TrimSuffix(messageID, ">")
+	return strings.ToLower(strings.TrimSpace(messageID))
+}
+
+// contentFingerprint - Sujet et adresse d'expéditeur normalisés ("" si l'expéditeur est inconnu)
+func contentFingerprint(email *models.Email) string {
+	sender := normalizeSender(email.From)
+	if sender == "" {
+		return ""
+	}
+
+	subject := strings.ToLower(strings.Join(strings.Fields(email.Subject), " "))
+	return sender + "\x00" + subject
+}
+
+// normalizeSender - Adresse email seule, en minuscules ("Nom <a@b.c>" -> "a@b.c")
+func normalizeSender(from string) string {
+	if address, err := mail.ParseAddress(from); err == nil {
+		return strings.ToLower(address.Address)
+	}
+	return strings.ToLower(strings.TrimSpace(from))
+}
+
+// similarSize - Tailles comparables à duplicateSizeTolerance près
+func similarSize(a, b int64) bool {
+	diff := a - b
+	if diff < 0 {
+		diff = -diff
+	}
+
+	largest := a
+	if b > largest {
+		largest = b
+	}
+
+	return diff <= duplicateSizeSlack || float64(diff) <= float64(largest)*duplicateSizeTolerance
+}
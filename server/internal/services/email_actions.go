@@ -0,0 +1,678 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sort"
+	"tamis-server/internal/models"
+	"time"
+)
+
+const (
+	// outboxMaxAttempts - Tentatives avant d'abandonner une action provider
+	outboxMaxAttempts = 8
+	// outboxBaseBackoff - Premier délai de réessai, doublé à chaque tentative
+	outboxBaseBackoff = 30 * time.Second
+	// outboxMaxBackoff - Délai de réessai maximal
+	outboxMaxBackoff = time.Hour
+	// undoWindow - Durée pendant laquelle une action peut être annulée
+	undoWindow = 7 * 24 * time.Hour
+)
+
+// actionOptions - Options internes d'exécution d'une action
+type actionOptions struct {
+	confirmed bool   // Confirmation déjà vérifiée par l'appelant (job lancé avec un jeton valide)
+	batchID   string // Handle d'annulation existant à compléter (lots successifs d'un même job)
+	automated bool   // Action décidée par Tamis (règle, rétention, doublon...) : pas transmise aux ActionHandler
+}
+
+// ExecuteEmailAction - Exécuter une action sur des emails, côté provider puis en base.
+// Une suppression définitive exige le jeton de confirmation d'un aperçu portant sur les mêmes emails.
+// Les emails protégés sont ignorés pour une suppression ou un classement en spam, sauf dérogation explicite.
+func (s *MailService) ExecuteEmailAction(userID int, req *models.EmailActionRequest) (*models.EmailActionResult, error) {
+	return s.executeEmailAction(userID, req, actionOptions{})
+}
+
+// PreviewEmailAction - Résumé des emails concernés et jeton de confirmation, sans rien modifier
+func (s *MailService) PreviewEmailAction(userID int, req *models.EmailActionRequest) (*models.ActionPreview, error) {
+	if !isSupportedAction(req.Action) {
+		return nil, fmt.Errorf("unsupported action: %s", req.Action)
+	}
+
+	emails, err := s.emailRepo.GetByIDsForUser(userID, uniqueIDs(req.EmailIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	protection, err := s.protectionFor(userID, req.Action, req.OverrideProtection)
+	if err != nil {
+		return nil, err
+	}
+	kept, protected := protection.filter(emails)
+
+	builder := newActionPreviewBuilder(req.Action, req.Force)
+	builder.add(kept)
+
+	found := make(map[string]bool, len(emails))
+	for _, email := range emails {
+		found[email.ID] = true
+		if protected[email.ID] != "" {
+			builder.protect(email.ID)
+		}
+	}
+	for _, id := range uniqueIDs(req.EmailIDs) {
+		if !found[id] {
+			builder.preview.MissingIDs = append(builder.preview.MissingIDs, id)
+		}
+	}
+
+	accountEmails, err := s.userAccountEmails(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return builder.finish(s.confirmer, userID, accountEmails), nil
+}
+
+// executeEmailAction - Exécution commune à ExecuteEmailAction et aux jobs d'actions groupées
+func (s *MailService) executeEmailAction(userID int, req *models.EmailActionRequest, opts actionOptions) (*models.EmailActionResult, error) {
+	if !isSupportedAction(req.Action) {
+		return nil, fmt.Errorf("unsupported action: %s", req.Action)
+	}
+
+	result := &models.EmailActionResult{
+		Action:       req.Action,
+		ProcessedIDs: []string{},
+		FailedIDs:    []string{},
+		PendingIDs:   []string{},
+		SkippedIDs:   []string{},
+		SuccessCount: 0,
+		FailureCount: 0,
+	}
+
+	// Charger uniquement les emails appartenant aux comptes de l'utilisateur
+	emails, err := s.emailRepo.GetByIDsForUser(userID, uniqueIDs(req.EmailIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	protection, err := s.protectionFor(userID, req.Action, req.OverrideProtection)
+	if err != nil {
+		return nil, err
+	}
+	kept, protected := protection.filter(emails)
+
+	found := make(map[string]bool, len(emails))
+	for _, email := range emails {
+		found[email.ID] = true
+	}
+
+	// Le jeton de confirmation porte sur les emails effectivement traités, hors emails protégés
+	groups := map[int][]*models.Email{}
+	foundIDs := make([]string, 0, len(kept))
+	for _, email := range kept {
+		groups[email.AccountID] = append(groups[email.AccountID], email)
+		foundIDs = append(foundIDs, email.ID)
+	}
+
+	if !opts.confirmed {
+		if err := verifyConfirmation(s.confirmer, userID, req.Action, req.Force, req.ConfirmationToken, foundIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, id := range uniqueIDs(req.EmailIDs) {
+		if !found[id] {
+			markActionFailed(result, id, "email not found")
+		} else if reason := protected[id]; reason != "" {
+			markActionSkipped(result, id, reason)
+		}
+	}
+
+	accountIDs := make([]int, 0, len(groups))
+	for accountID := range groups {
+		accountIDs = append(accountIDs, accountID)
+	}
+	sort.Ints(accountIDs)
+
+	outboxIDs := map[string]int64{}
+	for _, accountID := range accountIDs {
+		ops := []*models.OutboxOperation{}
+		for _, email := range groups[accountID] {
+			if email.ProviderID == "" {
+				markActionFailed(result, email.ID, "email has no provider reference, sync the account first")
+				continue
+			}
+			ops = append(ops, &models.OutboxOperation{
+				UserID:     userID,
+				AccountID:  accountID,
+				EmailID:    email.ID,
+				ProviderID: email.ProviderID,
+				Action:     req.Action,
+				Force:      req.Force,
+			})
+		}
+		if len(ops) == 0 {
+			continue
+		}
+
+		// Enregistrer l'action avant de contacter le provider : elle survit à une panne ou un redémarrage
+		if err := s.outboxRepo.CreateBatch(ops); err != nil {
+			s.logger.Error(fmt.Sprintf("Failed to record outbox operations for account %d: %v", accountID, err))
+			for _, op := range ops {
+				markActionFailed(result, op.EmailID, "failed to record action")
+			}
+			continue
+		}
+
+		ids := make([]int64, len(ops))
+		for i, op := range ops {
+			ids[i] = op.ID
+			outboxIDs[op.EmailID] = op.ID
+		}
+		claimed, err := s.outboxRepo.Claim(ids)
+		if err != nil {
+			s.logger.Error(fmt.Sprintf("Failed to claim outbox operations for account %d: %v", accountID, err))
+			claimed = nil
+		}
+
+		// Tentative immédiate sans attendre le rate limiter : le reste est traité par le worker
+		s.ProcessOutboxOperations(context.Background(), accountID, claimed, false)
+
+		claimedIDs := make(map[int64]bool, len(claimed))
+		for _, op := range claimed {
+			claimedIDs[op.ID] = true
+			recordOperationOutcome(result, op)
+		}
+		for _, op := range ops {
+			if !claimedIDs[op.ID] {
+				markActionPending(result, op.EmailID)
+			}
+		}
+	}
+
+	if isUndoableAction(req.Action, req.Force) {
+		result.UndoID = s.recordActionBatch(userID, req.Action, opts.batchID, emails, outboxIDs, result)
+	}
+
+	if !opts.automated && len(s.actionHandlers) > 0 {
+		s.notifyActionHandlers(userID, req.Action, kept, result)
+	}
+
+	s.logger.Info(fmt.Sprintf("Action %s executed for user %d - Success: %d, Failed: %d, Pending: %d, Skipped: %d",
+		req.Action, userID, result.SuccessCount, result.FailureCount, result.PendingCount, result.SkippedCount))
+
+	s.events.Publish(userID, models.EventActionCompleted, result)
+
+	return result, nil
+}
+
+// notifyActionHandlers - Transmettre aux ActionHandler les emails traités ou en attente côté provider
+func (s *MailService) notifyActionHandlers(userID int, action models.EmailAction, emails []*models.Email, result *models.EmailActionResult) {
+	accepted := make(map[string]bool, len(result.ProcessedIDs)+len(result.PendingIDs))
+	for _, id := range result.ProcessedIDs {
+		accepted[id] = true
+	}
+	for _, id := range result.PendingIDs {
+		accepted[id] = true
+	}
+
+	handled := make([]*models.Email, 0, len(accepted))
+	for _, email := range emails {
+		if accepted[email.ID] {
+			handled = append(handled, email)
+		}
+	}
+	if len(handled) == 0 {
+		return
+	}
+
+	for _, handler := range s.actionHandlers {
+		handler(userID, action, handled)
+	}
+}
+
+// ProcessOutboxOperations - Appliquer chez le provider des opérations (état running) d'un même compte.
+// wait=false : les opérations qui dépasseraient le rate limit restent en attente au lieu de bloquer.
+// Le statut de chaque opération est mis à jour en place et en base.
+func (s *MailService) ProcessOutboxOperations(ctx context.Context, accountID int, ops []*models.OutboxOperation, wait bool) {
+	if len(ops) == 0 {
+		return
+	}
+
+	account, err := s.accountService.GetAccountByID(accountID)
+	if err != nil {
+		s.failOperations(ops, "account not found")
+		return
+	}
+
+	if !account.IsActive {
+		s.failOperations(ops, "account is inactive")
+		return
+	}
+
+	limiter, ok := s.limiters[account.Provider]
+	if !ok {
+		limiter = s.limiters[models.ProviderOther]
+	}
+
+	// Restaurer ou effacer définitivement un message IMAP suppose de le retrouver par Message-ID
+	messageIDs := map[string]string{}
+	lookup := []string{}
+	for _, op := range ops {
+		if needsMessageID(op.Action, op.Force) {
+			lookup = append(lookup, op.EmailID)
+		}
+	}
+	if len(lookup) > 0 {
+		if emails, err := s.emailRepo.GetByIDsForUser(ops[0].UserID, lookup); err == nil {
+			for _, email := range emails {
+				messageIDs[email.ID] = email.MessageID
+			}
+		}
+	}
+
+	var client EmailClient
+	defer func() {
+		if client != nil {
+			closeEmailClient(client)
+		}
+	}()
+
+	completed := []*models.OutboxOperation{}
+	for i, op := range ops {
+		if wait {
+			if err := limiter.Wait(ctx); err != nil {
+				s.deferOperations(ops[i:])
+				break
+			}
+		} else if !limiter.Allow() {
+			s.deferOperations(ops[i:])
+			break
+		}
+
+		if client == nil {
+			client, err = s.clientForAccount(account)
+			if err != nil {
+				s.logger.Error(fmt.Sprintf("Failed to create client for account %d: %v", accountID, err))
+				for _, remaining := range ops[i:] {
+					s.retryOperation(remaining, err)
+				}
+				break
+			}
+		}
+
+		if err := applyClientAction(client, op.Action, op.Force, op.ProviderID, messageIDs[op.EmailID]); err != nil {
+			s.logger.Warn(fmt.Sprintf("Provider rejected %s for email %s: %v", op.Action, op.EmailID, err))
+			s.retryOperation(op, err)
+			continue
+		}
+
+		op.Status = models.OutboxCompleted
+		op.Attempts++
+		completed = append(completed, op)
+	}
+
+	s.completeOperations(completed)
+}
+
+// clientForAccount - Client provider authentifié pour un compte
+func (s *MailService) clientForAccount(account *models.EmailAccount) (EmailClient, error) {
+	tokens, err := s.tokenManager.GetValidToken(account)
+	if err != nil {
+		return nil, err
+	}
+	return s.createEmailClient(account, tokens.AccessToken)
+}
+
+// completeOperations - Refléter en base les actions confirmées puis clore les opérations
+func (s *MailService) completeOperations(ops []*models.OutboxOperation) {
+	if len(ops) == 0 {
+		return
+	}
+
+	type actionKey struct {
+		action models.EmailAction
+		force  bool
+	}
+	groups := map[actionKey][]*models.OutboxOperation{}
+	for _, op := range ops {
+		key := actionKey{op.Action, op.Force}
+		groups[key] = append(groups[key], op)
+	}
+
+	ids := make([]int64, 0, len(ops))
+	for key, group := range groups {
+		emailIDs := make([]string, len(group))
+		for i, op := range group {
+			emailIDs[i] = op.EmailID
+			ids = append(ids, op.ID)
+		}
+
+		// Le provider a appliqué l'action : la prochaine synchronisation corrigera la base en cas d'échec
+		if err := s.applyActionToDatabase(key.action, emailIDs, key.force); err != nil {
+			s.logger.Error(fmt.Sprintf("Action %s applied on provider but database update failed: %v", key.action, err))
+			for _, op := range group {
+				op.LastError = "applied on provider but database update failed"
+			}
+		}
+	}
+
+	if err := s.outboxRepo.MarkCompleted(ids); err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to complete outbox operations: %v", err))
+	}
+}
+
+// retryOperation - Reprogrammer une opération avec backoff exponentiel, ou l'abandonner
+func (s *MailService) retryOperation(op *models.OutboxOperation, cause error) {
+	op.Attempts++
+	op.LastError = cause.Error()
+
+	if !isTransientProviderError(cause) || op.Attempts >= outboxMaxAttempts {
+		op.Status = models.OutboxFailed
+		if err := s.outboxRepo.MarkFailed(op); err != nil {
+			s.logger.Error(fmt.Sprintf("Failed to update outbox operation %d: %v", op.ID, err))
+		}
+		return
+	}
+
+	op.Status = models.OutboxPending
+	op.NextAttemptAt = time.Now().Add(outboxBackoff(op.Attempts, cause))
+	if err := s.outboxRepo.Reschedule(op); err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to update outbox operation %d: %v", op.ID, err))
+	}
+}
+
+// deferOperations - Remettre en attente immédiate des opérations non tentées (rate limit, arrêt)
+func (s *MailService) deferOperations(ops []*models.OutboxOperation) {
+	for _, op := range ops {
+		op.Status = models.OutboxPending
+		op.NextAttemptAt = time.Now()
+		if err := s.outboxRepo.Reschedule(op); err != nil {
+			s.logger.Error(fmt.Sprintf("Failed to update outbox operation %d: %v", op.ID, err))
+		}
+	}
+}
+
+// failOperations - Abandonner des opérations pour une raison définitive
+func (s *MailService) failOperations(ops []*models.OutboxOperation, reason string) {
+	for _, op := range ops {
+		op.Status = models.OutboxFailed
+		op.LastError = reason
+		if err := s.outboxRepo.MarkFailed(op); err != nil {
+			s.logger.Error(fmt.Sprintf("Failed to update outbox operation %d: %v", op.ID, err))
+		}
+	}
+}
+
+// isTransientProviderError - Erreurs pour lesquelles un réessai a des chances d'aboutir
+func isTransientProviderError(err error) bool {
+	var apiErr *ProviderAPIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests ||
+			apiErr.StatusCode == http.StatusUnauthorized ||
+			apiErr.StatusCode >= http.StatusInternalServerError
+	}
+	if errors.Is(err, errIMAPNoTrashMailbox) || errors.Is(err, errIMAPNoUIDPlus) {
+		return false
+	}
+	// Erreurs réseau, IMAP, refresh de token...
+	return true
+}
+
+// outboxBackoff - Délai avant la tentative suivante (Retry-After du provider prioritaire)
+func outboxBackoff(attempts int, cause error) time.Duration {
+	delay := outboxBaseBackoff << uint(attempts-1)
+	if delay > outboxMaxBackoff || delay <= 0 {
+		delay = outboxMaxBackoff
+	}
+	delay += time.Duration(rand.Int63n(int64(delay) / 5))
+
+	var apiErr *ProviderAPIError
+	if errors.As(cause, &apiErr) && apiErr.RetryAfter > delay {
+		delay = apiErr.RetryAfter
+	}
+	return delay
+}
+
+// recordOperationOutcome - Reporter l'état d'une opération dans le résultat de l'action
+func recordOperationOutcome(result *models.EmailActionResult, op *models.OutboxOperation) {
+	switch op.Status {
+	case models.OutboxCompleted:
+		if op.LastError != "" {
+			markActionFailed(result, op.EmailID, op.LastError)
+			return
+		}
+		markActionProcessed(result, op.EmailID)
+	case models.OutboxFailed:
+		markActionFailed(result, op.EmailID, op.LastError)
+	default:
+		markActionPending(result, op.EmailID)
+	}
+}
+
+// applyClientAction - Appel provider correspondant à l'action. Une suppression forcée est définitive
+// chez le provider comme en base : elle est refusée plutôt que ramenée à un passage par la corbeille.
+func applyClientAction(client EmailClient, action models.EmailAction, force bool, providerID, messageID string) error {
+	switch action {
+	case models.ActionRestore, models.ActionUnarchive:
+		reversible, ok := client.(ReversibleEmailClient)
+		if !ok {
+			return fmt.Errorf("%s is not supported by this provider", action)
+		}
+		if action == models.ActionRestore {
+			return reversible.Restore(providerID, messageID)
+		}
+		return reversible.Unarchive(providerID, messageID)
+	case models.ActionSpam, models.ActionNotSpam:
+		spam, ok := client.(SpamEmailClient)
+		if !ok {
+			return fmt.Errorf("%s is not supported by this provider", action)
+		}
+		if action == models.ActionSpam {
+			return spam.MarkAsSpam(providerID)
+		}
+		return spam.MarkAsNotSpam(providerID, messageID)
+	case models.ActionDelete:
+		if !force {
+			return client.Delete(providerID)
+		}
+		permanent, ok := client.(PermanentDeleteEmailClient)
+		if !ok {
+			return fmt.Errorf("permanent deletion is not supported by this provider")
+		}
+		return permanent.DeletePermanently(providerID, messageID)
+	case models.ActionArchive:
+		return client.Archive(providerID)
+	case models.ActionMarkRead:
+		return client.MarkAsRead(providerID)
+	case models.ActionMarkUnread:
+		return client.MarkAsUnread(providerID)
+	default:
+		return fmt.Errorf("unsupported action: %s", action)
+	}
+}
+
+// applyActionToDatabase - Refléter en base une action confirmée par le provider
+func (s *MailService) applyActionToDatabase(action models.EmailAction, emailIDs []string, force bool) error {
+	switch action {
+	case models.ActionDelete:
+		if force {
+			// Suppression définitive
+			return s.emailRepo.DeletePermanently(emailIDs)
+		}
+		// Marquer comme supprimé (soft delete)
+		return s.emailRepo.MarkAsDeleted(emailIDs)
+	case models.ActionArchive:
+		return s.emailRepo.ArchiveEmails(emailIDs)
+	case models.ActionRestore:
+		return s.emailRepo.RestoreDeleted(emailIDs)
+	case models.ActionUnarchive:
+		return s.emailRepo.UnarchiveEmails(emailIDs)
+	case models.ActionMarkRead:
+		return s.emailRepo.UpdateReadStatus(emailIDs, true)
+	case models.ActionMarkUnread:
+		return s.emailRepo.UpdateReadStatus(emailIDs, false)
+	case models.ActionSpam:
+		return s.emailRepo.SetSpamVerdict(emailIDs, true)
+	case models.ActionNotSpam:
+		return s.emailRepo.SetSpamVerdict(emailIDs, false)
+	default:
+		return fmt.Errorf("unsupported action: %s", action)
+	}
+}
+
+// isSupportedAction - Actions exécutables par ExecuteEmailAction
+func isSupportedAction(action models.EmailAction) bool {
+	switch action {
+	case models.ActionDelete, models.ActionArchive, models.ActionMarkRead, models.ActionMarkUnread,
+		models.ActionRestore, models.ActionUnarchive, models.ActionSpam, models.ActionNotSpam:
+		return true
+	}
+	return false
+}
+
+// isUndoableAction - Actions pour lesquelles un handle d'annulation est créé
+func isUndoableAction(action models.EmailAction, force bool) bool {
+	switch action {
+	case models.ActionDelete:
+		return !force
+	case models.ActionArchive, models.ActionMarkRead, models.ActionMarkUnread, models.ActionSpam, models.ActionNotSpam:
+		return true
+	}
+	return false
+}
+
+// inverseAction - Action qui annule action
+func inverseAction(action models.EmailAction) (models.EmailAction, bool) {
+	switch action {
+	case models.ActionDelete:
+		return models.ActionRestore, true
+	case models.ActionArchive:
+		return models.ActionUnarchive, true
+	case models.ActionMarkRead:
+		return models.ActionMarkUnread, true
+	case models.ActionMarkUnread:
+		return models.ActionMarkRead, true
+	case models.ActionSpam:
+		return models.ActionNotSpam, true
+	case models.ActionNotSpam:
+		return models.ActionSpam, true
+	}
+	return "", false
+}
+
+// needsMessageID - Actions pour lesquelles le provider peut avoir besoin du Message-ID
+func needsMessageID(action models.EmailAction, force bool) bool {
+	return action == models.ActionRestore || action == models.ActionUnarchive || action == models.ActionNotSpam ||
+		(action == models.ActionDelete && force)
+}
+
+// markActionProcessed - Ajouter un email aux succès
+func markActionProcessed(result *models.EmailActionResult, emailID string) {
+	result.ProcessedIDs = append(result.ProcessedIDs, emailID)
+	result.SuccessCount++
+}
+
+// markActionFailed - Ajouter un email aux échecs avec sa raison
+func markActionFailed(result *models.EmailActionResult, emailID, reason string) {
+	if result.Errors == nil {
+		result.Errors = map[string]string{}
+	}
+	result.FailedIDs = append(result.FailedIDs, emailID)
+	result.FailureCount++
+	result.Errors[emailID] = reason
+}
+
+// markActionPending - Ajouter un email aux actions différées (outbox)
+func markActionPending(result *models.EmailActionResult, emailID string) {
+	result.PendingIDs = append(result.PendingIDs, emailID)
+	result.PendingCount++
+}
+
+// markActionSkipped - Ajouter un email protégé aux emails ignorés avec la règle concernée
+func markActionSkipped(result *models.EmailActionResult, emailID string, reason models.ProtectionReason) {
+	if result.SkipReasons == nil {
+		result.SkipReasons = map[string]string{}
+	}
+	result.SkippedIDs = append(result.SkippedIDs, emailID)
+	result.SkippedCount++
+	result.SkipReasons[emailID] = string(reason)
+}
+
+// uniqueIDs - Dédoublonner une liste d'IDs en conservant l'ordre
+func uniqueIDs(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	unique := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		unique = append(unique, id)
+	}
+	return unique
+}
+
+// userAccountEmails - Adresse de chaque compte de l'utilisateur
+func (s *MailService) userAccountEmails(userID int) (map[int]string, error) {
+	accounts, err := s.accountService.GetUserAccounts(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user accounts: %w", err)
+	}
+
+	emails := make(map[int]string, len(accounts))
+	for _, account := range accounts {
+		emails[account.ID] = account.Email
+	}
+	return emails, nil
+}
+
+// recordActionBatch - Enregistrer l'état d'origine des emails traités ou en attente dans un handle
+// d'annulation (nouveau si batchID est vide), avec l'opération outbox de chacun ; "" si l'enregistrement échoue
+func (s *MailService) recordActionBatch(userID int, action models.EmailAction, batchID string, emails []*models.Email, outboxIDs map[string]int64, result *models.EmailActionResult) string {
+	applied := make(map[string]bool, len(result.ProcessedIDs)+len(result.PendingIDs))
+	for _, id := range result.ProcessedIDs {
+		applied[id] = true
+	}
+	for _, id := range result.PendingIDs {
+		applied[id] = true
+	}
+
+	items := []*models.ActionBatchItem{}
+	for _, email := range emails {
+		if applied[email.ID] {
+			items = append(items, &models.ActionBatchItem{
+				EmailID:  email.ID,
+				WasRead:  email.IsRead,
+				WasSpam:  email.IsSpam,
+				OutboxID: outboxIDs[email.ID],
+			})
+		}
+	}
+	if len(items) == 0 {
+		return batchID
+	}
+
+	if batchID == "" {
+		id, err := newUUID()
+		if err != nil {
+			s.logger.Error(fmt.Sprintf("Failed to create undo handle for user %d: %v", userID, err))
+			return ""
+		}
+		batch := &models.ActionBatch{ID: id, UserID: userID, Action: action, ExpiresAt: time.Now().Add(undoWindow)}
+		if err := s.batchRepo.Create(batch); err != nil {
+			s.logger.Error(fmt.Sprintf("Failed to create undo handle for user %d: %v", userID, err))
+			return ""
+		}
+		batchID = batch.ID
+	}
+
+	if err := s.batchRepo.AddItems(batchID, items); err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to record undo items for batch %s: %v", batchID, err))
+	}
+	return batchID
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"tamis-server/internal/models"
+)
+
+// recordingClient - Client qui enregistre les appels provider
+type recordingClient struct {
+	calls []string
+}
+
+func (c *recordingClient) FetchRecentEmails(limit int) ([]*models.Email, error) { return nil, nil }
+func (c *recordingClient) MarkAsRead(emailID string) error                      { return c.record("read " + emailID) }
+func (c *recordingClient) MarkAsUnread(emailID string) error                    { return c.record("unread " + emailID) }
+func (c *recordingClient) Delete(emailID string) error                          { return c.record("trash " + emailID) }
+func (c *recordingClient) Archive(emailID string) error                         { return c.record("archive " + emailID) }
+
+func (c *recordingClient) record(call string) error {
+	c.calls = append(c.calls, call)
+	return nil
+}
+
+// permanentRecordingClient - Client capable de suppression définitive
+type permanentRecordingClient struct {
+	recordingClient
+}
+
+func (c *permanentRecordingClient) DeletePermanently(emailID, messageID string) error {
+	return c.record("delete " + emailID + " " + messageID)
+}
+
+func TestApplyClientActionForceDelete(t *testing.T) {
+	client := &permanentRecordingClient{}
+	if err := applyClientAction(client, models.ActionDelete, false, "p1", "<m1@example.com>"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := applyClientAction(client, models.ActionDelete, true, "p2", "<m2@example.com>"); err != nil {
+		t.Fatalf("forced delete: %v", err)
+	}
+	if got := strings.Join(client.calls, "|"); got != "trash p1|delete p2 <m2@example.com>" {
+		t.Errorf("calls = %s", got)
+	}
+
+	// Sans suppression définitive côté provider, la suppression forcée n'est pas ramenée à la corbeille
+	basic := &recordingClient{}
+	if err := applyClientAction(basic, models.ActionDelete, true, "p3", ""); err == nil {
+		t.Error("forced delete succeeded without permanent deletion support")
+	}
+	if len(basic.calls) != 0 {
+		t.Errorf("calls = %v", basic.calls)
+	}
+}
+
+func TestOutboxBackoff(t *testing.T) {
+	cases := []struct {
+		name     string
+		attempts int
+		cause    error
+		min, max time.Duration
+	}{
+		{"first retry", 1, errors.New("timeout"), 30 * time.Second, 36 * time.Second},
+		{"doubles", 3, errors.New("timeout"), 2 * time.Minute, 144 * time.Second},
+		{"capped", 8, errors.New("timeout"), time.Hour, 72 * time.Minute},
+		{"shift overflow", 80, errors.New("timeout"), time.Hour, 72 * time.Minute},
+		{"retry-after wins", 1, &ProviderAPIError{StatusCode: http.StatusTooManyRequests, RetryAfter: 2 * time.Hour}, 2 * time.Hour, 2 * time.Hour},
+		{"wrapped retry-after", 1, fmt.Errorf("modify: %w", &ProviderAPIError{StatusCode: http.StatusServiceUnavailable, RetryAfter: 10 * time.Minute}), 10 * time.Minute, 10 * time.Minute},
+		{"short retry-after ignored", 2, &ProviderAPIError{StatusCode: http.StatusTooManyRequests, RetryAfter: time.Second}, time.Minute, 72 * time.Second},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// La gigue est aléatoire : plusieurs tirages restent dans l'intervalle
+			for i := 0; i < 20; i++ {
+				if delay := outboxBackoff(tc.attempts, tc.cause); delay < tc.min || delay > tc.max {
+					t.Fatalf("backoff = %v, want in [%v, %v]", delay, tc.min, tc.max)
+				}
+			}
+		})
+	}
+}
+
+func TestIsTransientProviderError(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"rate limited", &ProviderAPIError{StatusCode: http.StatusTooManyRequests}, true},
+		{"expired token", &ProviderAPIError{StatusCode: http.StatusUnauthorized}, true},
+		{"server error", &ProviderAPIError{StatusCode: http.StatusBadGateway}, true},
+		{"wrapped server error", fmt.Errorf("trash: %w", &ProviderAPIError{StatusCode: http.StatusInternalServerError}), true},
+		{"bad request", &ProviderAPIError{StatusCode: http.StatusBadRequest}, false},
+		{"forbidden", &ProviderAPIError{StatusCode: http.StatusForbidden}, false},
+		{"wrapped not found", fmt.Errorf("trash: %w", &ProviderAPIError{StatusCode: http.StatusNotFound}), false},
+		{"imap without trash", fmt.Errorf("delete: %w", errIMAPNoTrashMailbox), false},
+		{"imap without uidplus", errIMAPNoUIDPlus, false},
+		{"network error", errors.New("connection reset by peer"), true},
+	}
+	for _, tc := range cases {
+		if got := isTransientProviderError(tc.err); got != tc.want {
+			t.Errorf("%s: isTransientProviderError = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
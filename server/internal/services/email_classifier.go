@@ -0,0 +1,100 @@
+package services
+
+import (
+	"strings"
+	"tamis-server/internal/models"
+)
+
+// listHeaderNames - En-têtes de diffusion conservés lors de la synchronisation
+var listHeaderNames = []string{
+	"List-Unsubscribe",
+	"List-Unsubscribe-Post",
+	"List-Id",
+	"Precedence",
+	"Auto-Submitted",
+	"Feedback-ID",
+	"X-Campaign",
+	"X-Mailgun-Tag",
+	"X-MC-User",
+	"X-SG-EID",
+	"X-SES-Outgoing",
+}
+
+// spamHeaderNames - En-têtes d'authentification et d'expédition conservés pour le score de spam
+var spamHeaderNames = []string{
+	"From",
+	"Reply-To",
+	"Authentication-Results",
+	"X-Spam-Flag",
+	"X-Spam-Status",
+}
+
+// capturedHeaderNames - En-têtes conservés lors de la synchronisation
+var capturedHeaderNames = append(append([]string{}, listHeaderNames...), spamHeaderNames...)
+
+// bulkMailerHeaders - En-têtes posés par les plateformes d'envoi en masse (Mailchimp, SendGrid, SES...)
+var bulkMailerHeaders = []string{"x-campaign", "x-mailgun-tag", "x-mc-user", "x-sg-eid", "x-ses-outgoing"}
+
+// automatedSenderPrefixes - Débuts d'adresse d'expéditeurs automatiques
+var automatedSenderPrefixes = []string{
+	"noreply", "no-reply", "no_reply", "donotreply", "do-not-reply", "do_not_reply",
+	"notification", "notifications", "alert", "alerts", "mailer-daemon", "postmaster", "bounce",
+}
+
+// captureHeaders - Lire les en-têtes conservés via get (nil si aucun n'est présent)
+func captureHeaders(get func(name string) string) map[string]string {
+	var headers map[string]string
+	for _, name := range capturedHeaderNames {
+		value := strings.TrimSpace(get(name))
+		if value == "" {
+			continue
+		}
+		if headers == nil {
+			headers = map[string]string{}
+		}
+		headers[strings.ToLower(name)] = value
+	}
+	return headers
+}
+
+// classifyEmail - Newsletter (liste, envoi en masse), notification (message automatique) ou personnel
+func classifyEmail(email *models.Email) models.EmailCategory {
+	headers := email.Headers
+	precedence := strings.ToLower(headers["precedence"])
+
+	if headers["list-id"] != "" || headers["list-unsubscribe"] != "" || precedence == "bulk" || precedence == "list" {
+		return models.CategoryNewsletter
+	}
+
+	for _, name := range bulkMailerHeaders {
+		if headers[name] != "" {
+			return models.CategoryNewsletter
+		}
+	}
+
+	// RFC 3834 : tout Auto-Submitted autre que "no" signale un message automatique
+	if autoSubmitted := strings.ToLower(headers["auto-submitted"]); autoSubmitted != "" && autoSubmitted != "no" {
+		return models.CategoryNotification
+	}
+
+	if precedence == "junk" || precedence == "auto_reply" || isAutomatedSender(email.From) {
+		return models.CategoryNotification
+	}
+
+	return models.CategoryPersonal
+}
+
+// isAutomatedSender - Adresse d'expéditeur typique d'un envoi automatique
+func isAutomatedSender(from string) bool {
+	local := strings.ToLower(normalizeSender(from))
+	if at := strings.LastIndex(local, "@"); at >= 0 {
+		local = local[:at]
+	}
+
+	for _, prefix := range automatedSenderPrefixes {
+		if strings.HasPrefix(local, prefix) {
+			return true
+		}
+	}
+	return false
+}
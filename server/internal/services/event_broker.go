@@ -0,0 +1,164 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"tamis-server/internal/models"
+	"time"
+)
+
+const (
+	// eventHistorySize - Événements conservés par utilisateur pour rejouer après une reconnexion
+	eventHistorySize = 200
+	// eventSubscriberBuffer - Événements en attente par connexion avant de la considérer trop lente
+	eventSubscriberBuffer = 64
+)
+
+// EventBroker - Diffusion en mémoire des événements par utilisateur (Server-Sent Events)
+type EventBroker struct {
+	epoch string // Identifie le processus : un Last-Event-ID d'une autre instance ne peut pas être rejoué
+
+	mu          sync.Mutex
+	closed      bool
+	seq         uint64
+	history     map[int][]*models.Event
+	subscribers map[int]map[chan *models.Event]struct{}
+}
+
+func NewEventBroker() *EventBroker {
+	return &EventBroker{
+		epoch:       strconv.FormatInt(time.Now().UnixNano(), 36),
+		history:     make(map[int][]*models.Event),
+		subscribers: make(map[int]map[chan *models.Event]struct{}),
+	}
+}
+
+// Publish - Envoyer un événement à toutes les connexions de l'utilisateur
+func (b *EventBroker) Publish(userID int, eventType models.EventType, data interface{}) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seq++
+	event := &models.Event{
+		ID:        fmt.Sprintf("%s-%d", b.epoch, b.seq),
+		UserID:    userID,
+		Type:      eventType,
+		Data:      data,
+		CreatedAt: time.Now(),
+	}
+
+	history := append(b.history[userID], event)
+	if len(history) > eventHistorySize {
+		history = history[len(history)-eventHistorySize:]
+	}
+	b.history[userID] = history
+
+	for ch := range b.subscribers[userID] {
+		select {
+		case ch <- event:
+		default:
+			// Connexion trop lente : la fermer, le client se reconnectera avec Last-Event-ID
+			delete(b.subscribers[userID], ch)
+			close(ch)
+		}
+	}
+}
+
+// Subscribe - S'abonner aux événements d'un utilisateur ; renvoie les événements manqués depuis lastEventID
+func (b *EventBroker) Subscribe(userID int, lastEventID string) (<-chan *models.Event, []*models.Event, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan *models.Event, eventSubscriberBuffer)
+	if b.closed {
+		close(ch)
+		return ch, nil, func() {}
+	}
+	if b.subscribers[userID] == nil {
+		b.subscribers[userID] = make(map[chan *models.Event]struct{})
+	}
+	b.subscribers[userID][ch] = struct{}{}
+
+	unsubscribe := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		if _, ok := b.subscribers[userID][ch]; ok {
+			delete(b.subscribers[userID], ch)
+			close(ch)
+		}
+		if len(b.subscribers[userID]) == 0 {
+			delete(b.subscribers, userID)
+		}
+	}
+
+	return ch, b.replay(userID, lastEventID), unsubscribe
+}
+
+// Close - Fermer toutes les connexions (arrêt du serveur) : les flux SSE se terminent sans attendre le client
+func (b *EventBroker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for userID, subscribers := range b.subscribers {
+		for ch := range subscribers {
+			close(ch)
+		}
+		delete(b.subscribers, userID)
+	}
+}
+
+// replay - Événements postérieurs à lastEventID (appelé sous verrou)
+func (b *EventBroker) replay(userID int, lastEventID string) []*models.Event {
+	if lastEventID == "" {
+		return nil
+	}
+
+	history := b.history[userID]
+	epoch, seq, ok := parseEventID(lastEventID)
+
+	// Identifiant inconnu, d'un autre processus ou plus ancien que l'historique conservé
+	if !ok || epoch != b.epoch || (len(history) > 0 && seq < b.sequenceOf(history[0])-1) {
+		return []*models.Event{{
+			ID:        fmt.Sprintf("%s-%d", b.epoch, b.seq),
+			UserID:    userID,
+			Type:      models.EventStreamReset,
+			CreatedAt: time.Now(),
+		}}
+	}
+
+	missed := []*models.Event{}
+	for _, event := range history {
+		if b.sequenceOf(event) > seq {
+			missed = append(missed, event)
+		}
+	}
+	return missed
+}
+
+// sequenceOf - Numéro de séquence d'un événement émis par ce broker
+func (b *EventBroker) sequenceOf(event *models.Event) uint64 {
+	_, seq, _ := parseEventID(event.ID)
+	return seq
+}
+
+// parseEventID - Découper un identifiant "epoch-sequence"
+func parseEventID(id string) (string, uint64, bool) {
+	separator := strings.LastIndex(id, "-")
+	if separator <= 0 {
+		return "", 0, false
+	}
+
+	seq, err := strconv.ParseUint(id[separator+1:], 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return id[:separator], seq, true
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"tamis-server/internal/database"
+	"tamis-server/internal/models"
+)
+
+// fakeDB - Base SQL en mémoire : chaque requête est transmise à handler, qui renvoie les lignes
+// (une ligne par valeur driver). Les Exec réussis affectent une ligne.
+type fakeDB struct {
+	handler func(query string, args []driver.Value) ([][]driver.Value, error)
+
+	mu      sync.Mutex
+	queries []string
+}
+
+func newFakeDB(t *testing.T, handler func(query string, args []driver.Value) ([][]driver.Value, error)) (*fakeDB, *database.DB) {
+	t.Helper()
+
+	fake := &fakeDB{handler: handler}
+	db := sql.OpenDB(fake)
+	t.Cleanup(func() { db.Close() })
+	return fake, &database.DB{DB: db}
+}
+
+// executed - Requêtes reçues, espaces normalisés
+func (f *fakeDB) executed() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string{}, f.queries...)
+}
+
+func (f *fakeDB) run(query string, named []driver.NamedValue) ([][]driver.Value, error) {
+	query = strings.Join(strings.Fields(query), " ")
+	f.mu.Lock()
+	f.queries = append(f.queries, query)
+	f.mu.Unlock()
+
+	args := make([]driver.Value, len(named))
+	for i, arg := range named {
+		args[i] = arg.Value
+	}
+	return f.handler(query, args)
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) { return &fakeConn{db: f}, nil }
+func (f *fakeDB) Driver() driver.Driver                        { return fakeDriver{} }
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("fake driver is only usable through its connector")
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("fake driver does not prepare statements")
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	rows, err := c.db.run(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{rows: rows}, nil
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if _, err := c.db.run(query, args); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(1), nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeRows struct {
+	rows [][]driver.Value
+	next int
+}
+
+func (r *fakeRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return nil
+	}
+	columns := make([]string, len(r.rows[0]))
+	for i := range columns {
+		columns[i] = fmt.Sprintf("c%d", i)
+	}
+	return columns
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.next])
+	r.next++
+	return nil
+}
+
+// fakeAccountRow - Ligne email_accounts (colonnes de AccountRepository.GetByID) avec des tokens chiffrés
+func fakeAccountRow(t *testing.T, accounts *AccountService, account *models.EmailAccount, accessToken, refreshToken string) []driver.Value {
+	t.Helper()
+
+	encryptedAccess, err := accounts.encryptToken(accessToken)
+	if err != nil {
+		t.Fatalf("encrypt access token: %v", err)
+	}
+	encryptedRefresh, err := accounts.encryptToken(refreshToken)
+	if err != nil {
+		t.Fatalf("encrypt refresh token: %v", err)
+	}
+
+	var expiresAt driver.Value
+	if account.TokenExpiresAt != nil {
+		expiresAt = *account.TokenExpiresAt
+	}
+	return []driver.Value{
+		int64(account.ID), int64(account.UserID), string(account.Provider), account.Email, account.DisplayName,
+		encryptedAccess, encryptedRefresh, expiresAt, string(account.AuthType), account.IMAPHost, int64(account.IMAPPort),
+		account.IsActive, account.InactiveReason, nil, account.CreatedAt, account.UpdatedAt,
+	}
+}
@@ -0,0 +1,461 @@
+package services
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+	"tamis-server/internal/models"
+	"time"
+)
+
+const defaultGmailBaseURL = "https://gmail.googleapis.com/gmail/v1/users/me"
+
+// gmailMetadataHeaders - En-têtes demandés avec format=metadata
+var gmailMetadataHeaders = append([]string{"Message-ID", "Subject", "From", "To", "Date"}, listHeaderNames...)
+
+// GmailClient - Client Gmail API v1
+type GmailClient struct {
+	accessToken string
+	baseURL     string
+	httpClient  *http.Client
+	labelNames  map[string]string // ID de label -> nom affiché (labels utilisateur)
+}
+
+func NewGmailClient(accessToken string) *GmailClient {
+	return NewGmailClientWithBaseURL(accessToken, defaultGmailBaseURL)
+}
+
+// NewGmailClientWithBaseURL - Client Gmail pointant vers une autre URL (serveur de test)
+func NewGmailClientWithBaseURL(accessToken, baseURL string) *GmailClient {
+	return &GmailClient{
+		accessToken: accessToken,
+		baseURL:     strings.TrimSuffix(baseURL, "/"),
+		httpClient:  newProviderHTTPClient(),
+	}
+}
+
+type gmailMessageRef struct {
+	ID       string `json:"id"`
+	ThreadID string `json:"threadId"`
+}
+
+type gmailHistoryMessage struct {
+	Message gmailMessageRef `json:"message"`
+}
+
+type gmailMessage struct {
+	ID           string   `json:"id"`
+	ThreadID     string   `json:"threadId"`
+	LabelIDs     []string `json:"labelIds"`
+	SizeEstimate int64    `json:"sizeEstimate"`
+	InternalDate string   `json:"internalDate"`
+	Payload      struct {
+		MimeType string `json:"mimeType"`
+		Headers  []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"headers"`
+	} `json:"payload"`
+}
+
+// FetchRecentEmails - messages.list puis messages.get (format metadata)
+func (c *GmailClient) FetchRecentEmails(limit int) ([]*models.Email, error) {
+	refs, err := c.listMessageRefs(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	emails := make([]*models.Email, 0, len(refs))
+	for _, ref := range refs {
+		email, err := c.getMessage(ref.ID)
+		if err != nil {
+			return nil, err
+		}
+		emails = append(emails, email)
+	}
+
+	return emails, nil
+}
+
+// SyncMailbox - Gmail est synchronisé globalement via l'historyId
+func (c *GmailClient) SyncMailbox() string {
+	return "ALL"
+}
+
+// FetchChanges - Synchronisation incrémentale via users.history.list
+func (c *GmailClient) FetchChanges(state *models.SyncState, knownIDs []string) (*models.SyncChanges, error) {
+	if state == nil || state.HistoryID == "" {
+		return c.initialChanges()
+	}
+
+	changed := []string{}
+	seen := map[string]bool{}
+	deleted := map[string]bool{}
+	historyID := state.HistoryID
+	pageToken := ""
+
+	for {
+		params := url.Values{}
+		params.Set("startHistoryId", state.HistoryID)
+		for _, historyType := range []string{"messageAdded", "messageDeleted", "labelAdded", "labelRemoved"} {
+			params.Add("historyTypes", historyType)
+		}
+		if pageToken != "" {
+			params.Set("pageToken", pageToken)
+		}
+
+		var page struct {
+			History []struct {
+				MessagesAdded   []gmailHistoryMessage `json:"messagesAdded"`
+				MessagesDeleted []gmailHistoryMessage `json:"messagesDeleted"`
+				LabelsAdded     []gmailHistoryMessage `json:"labelsAdded"`
+				LabelsRemoved   []gmailHistoryMessage `json:"labelsRemoved"`
+			} `json:"history"`
+			HistoryID     string `json:"historyId"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+
+		if err := c.do(http.MethodGet, "/history?"+params.Encode(), nil, &page); err != nil {
+			// historyId trop ancien : repartir d'une synchronisation initiale
+			if apiErr, ok := err.(*ProviderAPIError); ok && apiErr.StatusCode == http.StatusNotFound {
+				return c.initialChanges()
+			}
+			return nil, err
+		}
+
+		for _, record := range page.History {
+			for _, group := range [][]gmailHistoryMessage{record.MessagesAdded, record.LabelsAdded, record.LabelsRemoved} {
+				for _, item := range group {
+					if !seen[item.Message.ID] {
+						seen[item.Message.ID] = true
+						changed = append(changed, item.Message.ID)
+					}
+				}
+			}
+			for _, item := range record.MessagesDeleted {
+				deleted[item.Message.ID] = true
+			}
+		}
+
+		if page.HistoryID != "" {
+			historyID = page.HistoryID
+		}
+		if page.NextPageToken == "" {
+			break
+		}
+		pageToken = page.NextPageToken
+	}
+
+	changes := &models.SyncChanges{
+		State: &models.SyncState{Mailbox: c.SyncMailbox(), HistoryID: historyID},
+	}
+
+	for _, id := range changed {
+		if deleted[id] {
+			continue
+		}
+		email, err := c.getMessage(id)
+		if err != nil {
+			if apiErr, ok := err.(*ProviderAPIError); ok && apiErr.StatusCode == http.StatusNotFound {
+				deleted[id] = true
+				continue
+			}
+			return nil, err
+		}
+		changes.Emails = append(changes.Emails, email)
+	}
+
+	for id := range deleted {
+		changes.DeletedIDs = append(changes.DeletedIDs, id)
+	}
+
+	return changes, nil
+}
+
+// initialChanges - Derniers messages et historyId courant (lu avant la liste pour ne rien manquer)
+func (c *GmailClient) initialChanges() (*models.SyncChanges, error) {
+	var profile struct {
+		HistoryID string `json:"historyId"`
+	}
+	if err := c.do(http.MethodGet, "/profile", nil, &profile); err != nil {
+		return nil, err
+	}
+
+	emails, err := c.FetchRecentEmails(initialSyncLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.SyncChanges{
+		Emails: emails,
+		State:  &models.SyncState{Mailbox: c.SyncMailbox(), HistoryID: profile.HistoryID},
+	}, nil
+}
+
+// FetchPage - Une page de messages.list ; le curseur est le pageToken Gmail
+func (c *GmailClient) FetchPage(cursor string, pageSize int) (*models.EmailPage, error) {
+	var profile struct {
+		MessagesTotal int `json:"messagesTotal"`
+	}
+	if err := c.do(http.MethodGet, "/profile", nil, &profile); err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+	params.Set("maxResults", strconv.Itoa(pageSize))
+	if cursor != "" {
+		params.Set("pageToken", cursor)
+	}
+
+	var list struct {
+		Messages      []gmailMessageRef `json:"messages"`
+		NextPageToken string            `json:"nextPageToken"`
+	}
+	if err := c.do(http.MethodGet, "/messages?"+params.Encode(), nil, &list); err != nil {
+		return nil, err
+	}
+
+	page := &models.EmailPage{
+		Emails:         make([]*models.Email, 0, len(list.Messages)),
+		NextCursor:     list.NextPageToken,
+		EstimatedTotal: profile.MessagesTotal,
+	}
+
+	for _, ref := range list.Messages {
+		email, err := c.getMessage(ref.ID)
+		if err != nil {
+			// Message supprimé entre la liste et la lecture
+			if apiErr, ok := err.(*ProviderAPIError); ok && apiErr.StatusCode == http.StatusNotFound {
+				continue
+			}
+			return nil, err
+		}
+		page.Emails = append(page.Emails, email)
+	}
+
+	return page, nil
+}
+
+// MarkAsRead - Retirer le label UNREAD
+func (c *GmailClient) MarkAsRead(emailID string) error {
+	return c.batchModify([]string{emailID}, nil, []string{"UNREAD"})
+}
+
+// MarkAsUnread - Ajouter le label UNREAD
+func (c *GmailClient) MarkAsUnread(emailID string) error {
+	return c.batchModify([]string{emailID}, []string{"UNREAD"}, nil)
+}
+
+// Delete - Déplacer dans la corbeille Gmail
+func (c *GmailClient) Delete(emailID string) error {
+	return c.do(http.MethodPost, "/messages/"+url.PathEscape(emailID)+"/trash", nil, nil)
+}
+
+// DeletePermanently - Supprimer définitivement via messages.delete, sans passer par la corbeille
+func (c *GmailClient) DeletePermanently(emailID, messageID string) error {
+	return c.do(http.MethodDelete, "/messages/"+url.PathEscape(emailID), nil, nil)
+}
+
+// Archive - Retirer le label INBOX
+func (c *GmailClient) Archive(emailID string) error {
+	return c.batchModify([]string{emailID}, nil, []string{"INBOX"})
+}
+
+// Restore - Sortir de la corbeille Gmail
+func (c *GmailClient) Restore(emailID, messageID string) error {
+	return c.do(http.MethodPost, "/messages/"+url.PathEscape(emailID)+"/untrash", nil, nil)
+}
+
+// Unarchive - Remettre le label INBOX
+func (c *GmailClient) Unarchive(emailID, messageID string) error {
+	return c.batchModify([]string{emailID}, []string{"INBOX"}, nil)
+}
+
+// MarkAsSpam - Ajouter le label SPAM et retirer INBOX
+func (c *GmailClient) MarkAsSpam(emailID string) error {
+	return c.batchModify([]string{emailID}, []string{"SPAM"}, []string{"INBOX"})
+}
+
+// MarkAsNotSpam - Retirer le label SPAM et remettre INBOX
+func (c *GmailClient) MarkAsNotSpam(emailID, messageID string) error {
+	return c.batchModify([]string{emailID}, []string{"INBOX"}, []string{"SPAM"})
+}
+
+// SendMail - Envoyer un message texte via messages.send (l'expéditeur est le compte authentifié)
+func (c *GmailClient) SendMail(msg *OutgoingMail) error {
+	raw := base64.URLEncoding.EncodeToString(buildOutgoingMessage("", msg))
+	return c.do(http.MethodPost, "/messages/send", map[string]string{"raw": raw}, nil)
+}
+
+// listMessageRefs - Parcourir messages.list jusqu'à limit résultats
+func (c *GmailClient) listMessageRefs(limit int) ([]gmailMessageRef, error) {
+	var refs []gmailMessageRef
+	pageToken := ""
+
+	for limit <= 0 || len(refs) < limit {
+		params := url.Values{}
+		pageSize := 500
+		if limit > 0 && limit-len(refs) < pageSize {
+			pageSize = limit - len(refs)
+		}
+		params.Set("maxResults", strconv.Itoa(pageSize))
+		if pageToken != "" {
+			params.Set("pageToken", pageToken)
+		}
+
+		var page struct {
+			Messages      []gmailMessageRef `json:"messages"`
+			NextPageToken string            `json:"nextPageToken"`
+		}
+		if err := c.do(http.MethodGet, "/messages?"+params.Encode(), nil, &page); err != nil {
+			return nil, err
+		}
+
+		refs = append(refs, page.Messages...)
+		if page.NextPageToken == "" {
+			break
+		}
+		pageToken = page.NextPageToken
+	}
+
+	return refs, nil
+}
+
+// getMessage - messages.get en format metadata
+func (c *GmailClient) getMessage(id string) (*models.Email, error) {
+	params := url.Values{}
+	params.Set("format", "metadata")
+	for _, header := range gmailMetadataHeaders {
+		params.Add("metadataHeaders", header)
+	}
+
+	var msg gmailMessage
+	if err := c.do(http.MethodGet, "/messages/"+url.PathEscape(id)+"?"+params.Encode(), nil, &msg); err != nil {
+		return nil, err
+	}
+
+	return c.toEmail(&msg)
+}
+
+// toEmail - Convertir un message Gmail en models.Email
+func (c *GmailClient) toEmail(msg *gmailMessage) (*models.Email, error) {
+	labels, err := c.resolveLabels(msg.LabelIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	email := &models.Email{
+		ProviderID: msg.ID,
+		Size:       msg.SizeEstimate,
+		IsRead:     true,
+		To:         []string{},
+		Labels:     labels,
+
+		// Le format metadata ne détaille pas les parties : multipart/mixed signale des pièces jointes
+		HasAttachments: strings.EqualFold(msg.Payload.MimeType, "multipart/mixed"),
+	}
+
+	if millis, err := strconv.ParseInt(msg.InternalDate, 10, 64); err == nil {
+		email.Date = time.UnixMilli(millis)
+	}
+
+	for _, label := range msg.LabelIDs {
+		switch label {
+		case "UNREAD":
+			email.IsRead = false
+		case "SPAM":
+			email.IsSpam = true
+		case "TRASH":
+			email.IsDeleted = true
+		}
+	}
+
+	for _, header := range msg.Payload.Headers {
+		switch strings.ToLower(header.Name) {
+		case "message-id":
+			email.MessageID = strings.TrimSpace(header.Value)
+		case "subject":
+			email.Subject = header.Value
+		case "from":
+			if address, err := mail.ParseAddress(header.Value); err == nil {
+				email.From = address.Address
+			} else {
+				email.From = header.Value
+			}
+		case "to":
+			if addresses, err := mail.ParseAddressList(header.Value); err == nil {
+				for _, address := range addresses {
+					email.To = append(email.To, address.Address)
+				}
+			}
+		}
+	}
+
+	email.Headers = captureHeaders(func(name string) string {
+		for _, header := range msg.Payload.Headers {
+			if strings.EqualFold(header.Name, name) {
+				return header.Value
+			}
+		}
+		return ""
+	})
+
+	if email.MessageID == "" {
+		email.MessageID = fmt.Sprintf("<%s@mail.gmail.com>", msg.ID)
+	}
+
+	return email, nil
+}
+
+// resolveLabels - Remplacer les IDs des labels utilisateur par leur nom
+func (c *GmailClient) resolveLabels(labelIDs []string) ([]string, error) {
+	if c.labelNames == nil {
+		var response struct {
+			Labels []struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+				Type string `json:"type"`
+			} `json:"labels"`
+		}
+		if err := c.do(http.MethodGet, "/labels", nil, &response); err != nil {
+			return nil, err
+		}
+
+		c.labelNames = make(map[string]string, len(response.Labels))
+		for _, label := range response.Labels {
+			if label.Type == "user" {
+				c.labelNames[label.ID] = label.Name
+			}
+		}
+	}
+
+	labels := make([]string, 0, len(labelIDs))
+	for _, id := range labelIDs {
+		if name, ok := c.labelNames[id]; ok {
+			labels = append(labels, name)
+		} else {
+			labels = append(labels, id)
+		}
+	}
+	return labels, nil
+}
+
+// batchModify - Ajouter/retirer des labels sur plusieurs messages
+func (c *GmailClient) batchModify(ids, addLabels, removeLabels []string) error {
+	payload := map[string]interface{}{"ids": ids}
+	if len(addLabels) > 0 {
+		payload["addLabelIds"] = addLabels
+	}
+	if len(removeLabels) > 0 {
+		payload["removeLabelIds"] = removeLabels
+	}
+	return c.do(http.MethodPost, "/messages/batchModify", payload, nil)
+}
+
+func (c *GmailClient) do(method, path string, payload, out interface{}) error {
+	return doProviderRequest(c.httpClient, "gmail", method, c.baseURL+path, c.accessToken, payload, out)
+}
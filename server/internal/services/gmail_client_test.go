@@ -0,0 +1,263 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"tamis-server/internal/models"
+	"testing"
+)
+
+// fakeGmailMessage - Réponse messages.get minimale
+func fakeGmailMessage(id string, labels ...string) map[string]interface{} {
+	return map[string]interface{}{
+		"id":           id,
+		"threadId":     "t-" + id,
+		"labelIds":     labels,
+		"sizeEstimate": 2048,
+		"internalDate": "1706781600000",
+		"payload": map[string]interface{}{
+			"mimeType": "multipart/alternative",
+			"headers": []map[string]string{
+				{"name": "Message-ID", "value": "<" + id + "@example.com>"},
+				{"name": "Subject", "value": "Message " + id},
+				{"name": "From", "value": "Alice <alice@example.com>"},
+				{"name": "To", "value": "user@example.com, other@example.com"},
+				{"name": "List-Id", "value": "<news.example.com>"},
+			},
+		},
+	}
+}
+
+func writeJSON(t *testing.T, w http.ResponseWriter, value interface{}) {
+	t.Helper()
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		t.Errorf("encode response: %v", err)
+	}
+}
+
+// newFakeGmailServer - API Gmail en mémoire : labels, messages.get, et les handlers propres au test
+func newFakeGmailServer(t *testing.T, missing map[string]bool, routes map[string]http.HandlerFunc) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/labels", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, map[string]interface{}{"labels": []map[string]string{
+			{"id": "INBOX", "name": "INBOX", "type": "system"},
+			{"id": "Label_7", "name": "Factures", "type": "user"},
+		}})
+	})
+	mux.HandleFunc("/messages/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		id := strings.TrimPrefix(r.URL.Path, "/messages/")
+		if missing[id] {
+			http.Error(w, `{"error":{"code":404}}`, http.StatusNotFound)
+			return
+		}
+		writeJSON(t, w, fakeGmailMessage(id, "INBOX", "UNREAD", "Label_7"))
+	})
+	for pattern, handler := range routes {
+		mux.HandleFunc(pattern, handler)
+	}
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGmailFetchRecentEmailsPaging(t *testing.T) {
+	requests := []string{}
+	server := newFakeGmailServer(t, nil, map[string]http.HandlerFunc{
+		"/messages": func(w http.ResponseWriter, r *http.Request) {
+			query := r.URL.Query()
+			requests = append(requests, query.Get("maxResults")+"/"+query.Get("pageToken"))
+			switch query.Get("pageToken") {
+			case "":
+				writeJSON(t, w, map[string]interface{}{
+					"messages":      []map[string]string{{"id": "m1"}, {"id": "m2"}},
+					"nextPageToken": "page-2",
+				})
+			case "page-2":
+				writeJSON(t, w, map[string]interface{}{
+					"messages":      []map[string]string{{"id": "m3"}},
+					"nextPageToken": "page-3",
+				})
+			default:
+				t.Errorf("unexpected page token %q", query.Get("pageToken"))
+			}
+		},
+	})
+
+	client := NewGmailClientWithBaseURL("token", server.URL)
+	emails, err := client.FetchRecentEmails(3)
+	if err != nil {
+		t.Fatalf("FetchRecentEmails: %v", err)
+	}
+
+	// La deuxième page ne demande que le reste de la limite, et la troisième n'est pas lue
+	if !reflect.DeepEqual(requests, []string{"3/", "1/page-2"}) {
+		t.Errorf("list requests = %v", requests)
+	}
+	if len(emails) != 3 {
+		t.Fatalf("emails = %d, want 3", len(emails))
+	}
+
+	email := emails[0]
+	if email.ProviderID != "m1" || email.MessageID != "<m1@example.com>" || email.Subject != "Message m1" ||
+		email.From != "alice@example.com" || email.IsRead || email.Size != 2048 {
+		t.Errorf("email = %+v", email)
+	}
+	if !reflect.DeepEqual(email.To, []string{"user@example.com", "other@example.com"}) {
+		t.Errorf("to = %v", email.To)
+	}
+	if !reflect.DeepEqual(email.Labels, []string{"INBOX", "UNREAD", "Factures"}) {
+		t.Errorf("labels = %v", email.Labels)
+	}
+	if email.Headers["list-id"] != "<news.example.com>" {
+		t.Errorf("headers = %v", email.Headers)
+	}
+}
+
+func TestGmailFetchChangesHistoryPaging(t *testing.T) {
+	server := newFakeGmailServer(t, map[string]bool{"gone": true}, map[string]http.HandlerFunc{
+		"/history": func(w http.ResponseWriter, r *http.Request) {
+			query := r.URL.Query()
+			if query.Get("startHistoryId") != "100" {
+				t.Errorf("startHistoryId = %q", query.Get("startHistoryId"))
+			}
+			switch query.Get("pageToken") {
+			case "":
+				writeJSON(t, w, map[string]interface{}{
+					"history": []map[string]interface{}{
+						{"messagesAdded": []map[string]interface{}{{"message": map[string]string{"id": "new"}}}},
+						{"labelsRemoved": []map[string]interface{}{{"message": map[string]string{"id": "read"}}}},
+					},
+					"historyId":     "110",
+					"nextPageToken": "next",
+				})
+			default:
+				writeJSON(t, w, map[string]interface{}{
+					"history": []map[string]interface{}{
+						{"messagesAdded": []map[string]interface{}{{"message": map[string]string{"id": "gone"}}}},
+						{"messagesDeleted": []map[string]interface{}{{"message": map[string]string{"id": "removed"}}}},
+						{"labelsAdded": []map[string]interface{}{{"message": map[string]string{"id": "new"}}}},
+					},
+					"historyId": "120",
+				})
+			}
+		},
+	})
+
+	client := NewGmailClientWithBaseURL("token", server.URL)
+	changes, err := client.FetchChanges(&models.SyncState{HistoryID: "100"}, nil)
+	if err != nil {
+		t.Fatalf("FetchChanges: %v", err)
+	}
+
+	if changes.State.HistoryID != "120" {
+		t.Errorf("history id = %q, want 120", changes.State.HistoryID)
+	}
+	ids := []string{}
+	for _, email := range changes.Emails {
+		ids = append(ids, email.ProviderID)
+	}
+	if !reflect.DeepEqual(ids, []string{"new", "read"}) {
+		t.Errorf("changed = %v", ids)
+	}
+
+	// Un message introuvable à la lecture est traité comme supprimé
+	sort.Strings(changes.DeletedIDs)
+	if !reflect.DeepEqual(changes.DeletedIDs, []string{"gone", "removed"}) {
+		t.Errorf("deleted = %v", changes.DeletedIDs)
+	}
+}
+
+func TestGmailFetchChangesExpiredHistory(t *testing.T) {
+	server := newFakeGmailServer(t, nil, map[string]http.HandlerFunc{
+		"/history": func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, `{"error":{"code":404}}`, http.StatusNotFound)
+		},
+		"/profile": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(t, w, map[string]interface{}{"historyId": "500", "messagesTotal": 1})
+		},
+		"/messages": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(t, w, map[string]interface{}{"messages": []map[string]string{{"id": "m1"}}})
+		},
+	})
+
+	client := NewGmailClientWithBaseURL("token", server.URL)
+	changes, err := client.FetchChanges(&models.SyncState{HistoryID: "1"}, nil)
+	if err != nil {
+		t.Fatalf("FetchChanges: %v", err)
+	}
+	if changes.State.HistoryID != "500" || len(changes.Emails) != 1 {
+		t.Errorf("changes = %+v, state = %+v", changes, changes.State)
+	}
+}
+
+func TestGmailFetchPageSkipsMissingMessages(t *testing.T) {
+	server := newFakeGmailServer(t, map[string]bool{"m2": true}, map[string]http.HandlerFunc{
+		"/profile": func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(t, w, map[string]interface{}{"historyId": "1", "messagesTotal": 250})
+		},
+		"/messages": func(w http.ResponseWriter, r *http.Request) {
+			if token := r.URL.Query().Get("pageToken"); token != "cursor" {
+				t.Errorf("pageToken = %q", token)
+			}
+			writeJSON(t, w, map[string]interface{}{
+				"messages":      []map[string]string{{"id": "m1"}, {"id": "m2"}, {"id": "m3"}},
+				"nextPageToken": "after",
+			})
+		},
+	})
+
+	client := NewGmailClientWithBaseURL("token", server.URL)
+	page, err := client.FetchPage("cursor", 3)
+	if err != nil {
+		t.Fatalf("FetchPage: %v", err)
+	}
+	if len(page.Emails) != 2 || page.NextCursor != "after" || page.EstimatedTotal != 250 {
+		t.Errorf("page = %d emails, cursor %q, total %d", len(page.Emails), page.NextCursor, page.EstimatedTotal)
+	}
+}
+
+func TestGmailAPIErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		http.Error(w, "slow down", http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	err := NewGmailClientWithBaseURL("token", server.URL).MarkAsRead("m1")
+	apiErr, ok := err.(*ProviderAPIError)
+	if !ok {
+		t.Fatalf("error = %v, want *ProviderAPIError", err)
+	}
+	if apiErr.StatusCode != http.StatusTooManyRequests || apiErr.RetryAfter.Seconds() != 30 || !isTransientProviderError(err) {
+		t.Errorf("api error = %+v", apiErr)
+	}
+}
+
+func TestGmailDeletePermanently(t *testing.T) {
+	requests := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	if err := NewGmailClientWithBaseURL("token", server.URL).DeletePermanently("m1", "<m1@example.com>"); err != nil {
+		t.Fatalf("DeletePermanently: %v", err)
+	}
+	// messages.delete, et non messages.trash
+	if len(requests) != 1 || requests[0] != "DELETE /messages/m1" {
+		t.Errorf("requests = %v", requests)
+	}
+}
@@ -0,0 +1,1236 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/mail"
+	"sort"
+	"strconv"
+	"strings"
+	"tamis-server/internal/models"
+	"time"
+)
+
+// IMAPAuthMethod - Méthode d'authentification IMAP
+type IMAPAuthMethod string
+
+const (
+	IMAPAuthXOAuth2 IMAPAuthMethod = "xoauth2"
+	IMAPAuthLogin   IMAPAuthMethod = "login"
+)
+
+// imapHeaderFields - En-têtes récupérés pour construire un models.Email
+var imapHeaderFields = append([]string{"MESSAGE-ID", "SUBJECT", "TO", "DATE", "CONTENT-TYPE"}, capturedHeaderNames...)
+
+// errIMAPMessageNotFound - Aucun message ne porte le Message-ID recherché dans le dossier
+var errIMAPMessageNotFound = errors.New("message not found")
+
+// errIMAPNoTrashMailbox - Ni corbeille configurée ni dossier \Trash : une suppression serait définitive
+var errIMAPNoTrashMailbox = errors.New("imap server has no trash mailbox, refusing to expunge")
+
+// errIMAPNoUIDPlus - Sans UID EXPUNGE, seul un EXPUNGE de tout le dossier permettrait d'effacer un message
+var errIMAPNoUIDPlus = errors.New("imap server does not support UIDPLUS, refusing to expunge")
+
+// IMAPConfig - Paramètres de connexion à un serveur IMAP
+type IMAPConfig struct {
+	Addr           string         // host:port
+	Username       string         // Adresse email du compte
+	Secret         string         // Access token OAuth2 ou mot de passe selon Auth
+	Auth           IMAPAuthMethod // XOAUTH2 par défaut
+	TLSConfig      *tls.Config    // Optionnel (certificats de test, ServerName...)
+	DisableTLS     bool           // Connexion en clair, réservé aux serveurs de test locaux
+	Timeout        time.Duration
+	Mailbox        string // Dossier synchronisé (INBOX par défaut)
+	ArchiveMailbox string // Dossier d'archive (Archive par défaut)
+	TrashMailbox   string // Dossier corbeille, vide = dossier portant l'attribut \Trash (RFC 6154)
+	SpamMailbox    string // Dossier des indésirables (Junk, Bulk chez Yahoo...)
+}
+
+// GenericIMAPClient - Client IMAP4rev1 pour les comptes "other" et les providers IMAP
+type GenericIMAPClient struct {
+	config IMAPConfig
+	conn   *imapConn
+}
+
+func NewGenericIMAPClient(config IMAPConfig) *GenericIMAPClient {
+	if config.Auth == "" {
+		config.Auth = IMAPAuthXOAuth2
+	}
+	if config.Mailbox == "" {
+		config.Mailbox = "INBOX"
+	}
+	if config.ArchiveMailbox == "" {
+		config.ArchiveMailbox = "Archive"
+	}
+	if config.SpamMailbox == "" {
+		config.SpamMailbox = "Junk"
+	}
+	if config.Timeout == 0 {
+		config.Timeout = 30 * time.Second
+	}
+	return &GenericIMAPClient{config: config}
+}
+
+// FetchRecentEmails - Récupérer les derniers messages du dossier synchronisé
+func (c *GenericIMAPClient) FetchRecentEmails(limit int) ([]*models.Email, error) {
+	conn, err := c.connect()
+	if err != nil {
+		return nil, err
+	}
+
+	mailbox, err := conn.selectMailbox(c.config.Mailbox)
+	if err != nil {
+		return nil, err
+	}
+
+	if mailbox.Exists == 0 {
+		return []*models.Email{}, nil
+	}
+
+	start := 1
+	if limit > 0 && mailbox.Exists > limit {
+		start = mailbox.Exists - limit + 1
+	}
+
+	return c.fetchEmails(conn, false, fmt.Sprintf("%d:%d", start, mailbox.Exists))
+}
+
+// SyncMailbox - Dossier suivi par FetchChanges
+func (c *GenericIMAPClient) SyncMailbox() string {
+	return c.config.Mailbox
+}
+
+// FetchChanges - Synchronisation incrémentale basée sur UIDVALIDITY, le dernier UID et HIGHESTMODSEQ
+func (c *GenericIMAPClient) FetchChanges(state *models.SyncState, knownIDs []string) (*models.SyncChanges, error) {
+	conn, err := c.connect()
+	if err != nil {
+		return nil, err
+	}
+
+	mailbox, err := conn.selectMailbox(c.config.Mailbox)
+	if err != nil {
+		return nil, err
+	}
+
+	newState := &models.SyncState{
+		Mailbox:       c.config.Mailbox,
+		UIDValidity:   mailbox.UIDValidity,
+		HighestModSeq: mailbox.HighestModSeq,
+	}
+	changes := &models.SyncChanges{State: newState}
+
+	// Premier passage ou UIDVALIDITY modifiée : les UIDs connus ne sont plus fiables
+	if state == nil || state.UIDValidity != mailbox.UIDValidity {
+		changes.ResetIDs = state != nil
+		if mailbox.Exists > 0 {
+			start := 1
+			if mailbox.Exists > initialSyncLimit {
+				start = mailbox.Exists - initialSyncLimit + 1
+			}
+			changes.Emails, err = c.fetchEmails(conn, false, fmt.Sprintf("%d:%d", start, mailbox.Exists))
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		newState.LastUID = maxUID(changes.Emails, 0)
+		if mailbox.UIDNext > 0 {
+			newState.LastUID = mailbox.UIDNext - 1
+		}
+		return changes, nil
+	}
+
+	newState.LastUID = state.LastUID
+
+	// Nouveaux messages (UID > dernier UID vu)
+	if mailbox.Exists > 0 && (mailbox.UIDNext == 0 || mailbox.UIDNext-1 > state.LastUID) {
+		emails, err := c.fetchEmails(conn, true, fmt.Sprintf("%d:*", state.LastUID+1))
+		if err != nil {
+			return nil, err
+		}
+		// "n:*" renvoie toujours le dernier message, même s'il est déjà connu
+		for _, email := range emails {
+			if uid, _ := strconv.ParseUint(email.ProviderID, 10, 32); uint32(uid) > state.LastUID {
+				changes.Emails = append(changes.Emails, email)
+			}
+		}
+		newState.LastUID = maxUID(changes.Emails, state.LastUID)
+	}
+
+	if state.LastUID == 0 {
+		return changes, nil
+	}
+
+	// Changements de flags sur les messages déjà connus
+	knownRange := fmt.Sprintf("1:%d", state.LastUID)
+	if conn.caps["CONDSTORE"] && state.HighestModSeq > 0 {
+		if mailbox.HighestModSeq != state.HighestModSeq {
+			changes.FlagChanges, err = c.fetchFlags(conn, knownRange, state.HighestModSeq)
+		}
+	} else {
+		changes.FlagChanges, err = c.fetchFlags(conn, knownRange, 0)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Suppressions côté serveur : UIDs connus absents du dossier
+	present, err := conn.uidSearch("UID " + knownRange)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range knownIDs {
+		uid, err := strconv.ParseUint(id, 10, 32)
+		if err != nil || uint32(uid) > state.LastUID {
+			continue
+		}
+		if !present[uint32(uid)] {
+			changes.DeletedIDs = append(changes.DeletedIDs, id)
+		}
+	}
+
+	return changes, nil
+}
+
+// FetchPage - Parcourir le dossier du plus récent au plus ancien ; curseur "uidvalidity:uid" (dernier UID importé)
+func (c *GenericIMAPClient) FetchPage(cursor string, pageSize int) (*models.EmailPage, error) {
+	conn, err := c.connect()
+	if err != nil {
+		return nil, err
+	}
+
+	mailbox, err := conn.selectMailbox(c.config.Mailbox)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.EmailPage{EstimatedTotal: mailbox.Exists}
+	if mailbox.Exists == 0 {
+		return page, nil
+	}
+
+	// Curseur absent ou UIDVALIDITY modifiée : reprendre depuis le message le plus récent
+	criteria := "ALL"
+	var validity, before uint32
+	if _, err := fmt.Sscanf(cursor, "%d:%d", &validity, &before); err == nil && validity == mailbox.UIDValidity {
+		if before <= 1 {
+			return page, nil
+		}
+		criteria = fmt.Sprintf("UID 1:%d", before-1)
+	}
+
+	found, err := conn.uidSearch(criteria)
+	if err != nil {
+		return nil, err
+	}
+
+	uids := make([]uint32, 0, len(found))
+	for uid := range found {
+		uids = append(uids, uid)
+	}
+	sort.Slice(uids, func(i, j int) bool { return uids[i] > uids[j] })
+
+	remaining := len(uids) > pageSize
+	if remaining {
+		uids = uids[:pageSize]
+	}
+	if len(uids) == 0 {
+		return page, nil
+	}
+
+	set := make([]string, len(uids))
+	for i, uid := range uids {
+		set[i] = strconv.FormatUint(uint64(uid), 10)
+	}
+
+	page.Emails, err = c.fetchEmails(conn, true, strings.Join(set, ","))
+	if err != nil {
+		return nil, err
+	}
+
+	if remaining {
+		page.NextCursor = fmt.Sprintf("%d:%d", mailbox.UIDValidity, uids[len(uids)-1])
+	}
+	return page, nil
+}
+
+// MarkAsRead - Ajouter le flag \Seen
+func (c *GenericIMAPClient) MarkAsRead(emailID string) error {
+	return c.storeFlags(emailID, "+FLAGS.SILENT", `\Seen`)
+}
+
+// MarkAsUnread - Retirer le flag \Seen
+func (c *GenericIMAPClient) MarkAsUnread(emailID string) error {
+	return c.storeFlags(emailID, "-FLAGS.SILENT", `\Seen`)
+}
+
+// Delete - Déplacer dans la corbeille. Sans corbeille, la suppression est refusée plutôt que
+// rendue définitive : Tamis la présente comme annulable.
+func (c *GenericIMAPClient) Delete(emailID string) error {
+	trash, err := c.trashMailbox()
+	if err != nil {
+		return err
+	}
+	return c.move(emailID, trash)
+}
+
+// DeletePermanently - Déplacer dans la corbeille puis y effacer le message (\Deleted + UID EXPUNGE).
+// Un message déjà à la corbeille y est retrouvé par son Message-ID. Sans UIDPLUS, la suppression
+// est refusée : un EXPUNGE simple effacerait aussi les messages marqués \Deleted par un autre client.
+func (c *GenericIMAPClient) DeletePermanently(emailID, messageID string) error {
+	trash, err := c.trashMailbox()
+	if err != nil {
+		return err
+	}
+	conn, err := c.connectSelected()
+	if err != nil {
+		return err
+	}
+	if !conn.caps["UIDPLUS"] {
+		return errIMAPNoUIDPlus
+	}
+	if err := conn.uidMove(emailID, trash); err != nil {
+		return err
+	}
+
+	conn, set, err := c.locate(trash, messageID)
+	if err != nil {
+		return err
+	}
+	if err := conn.uidStore(set, "+FLAGS.SILENT", `\Deleted`); err != nil {
+		return err
+	}
+	return conn.expunge(set)
+}
+
+// Archive - Déplacer dans le dossier d'archive
+func (c *GenericIMAPClient) Archive(emailID string) error {
+	return c.move(emailID, c.config.ArchiveMailbox)
+}
+
+// Restore - Ramener un message de la corbeille dans le dossier synchronisé
+func (c *GenericIMAPClient) Restore(emailID, messageID string) error {
+	trash, err := c.trashMailbox()
+	if err != nil {
+		return err
+	}
+	return c.moveBack(trash, messageID)
+}
+
+// Unarchive - Ramener un message archivé dans le dossier synchronisé
+func (c *GenericIMAPClient) Unarchive(emailID, messageID string) error {
+	return c.moveBack(c.config.ArchiveMailbox, messageID)
+}
+
+// MarkAsSpam - Déplacer dans le dossier des indésirables
+func (c *GenericIMAPClient) MarkAsSpam(emailID string) error {
+	return c.move(emailID, c.config.SpamMailbox)
+}
+
+// MarkAsNotSpam - Ramener un message du dossier des indésirables dans le dossier synchronisé.
+// Un message resté sur place mais marqué $Junk par un autre client perd simplement ce flag.
+func (c *GenericIMAPClient) MarkAsNotSpam(emailID, messageID string) error {
+	err := c.moveBack(c.config.SpamMailbox, messageID)
+	if errors.Is(err, errIMAPMessageNotFound) {
+		return c.storeFlags(emailID, "-FLAGS.SILENT", "$Junk")
+	}
+	return err
+}
+
+// Close - Fermer proprement la connexion IMAP
+func (c *GenericIMAPClient) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.logout()
+	c.conn = nil
+	return err
+}
+
+// connect - Ouvrir (ou réutiliser) une connexion authentifiée
+func (c *GenericIMAPClient) connect() (*imapConn, error) {
+	if c.conn != nil {
+		if err := c.conn.noop(); err == nil {
+			return c.conn, nil
+		}
+		c.conn.close()
+		c.conn = nil
+	}
+
+	conn, err := dialIMAP(c.config)
+	if err != nil {
+		return nil, err
+	}
+
+	switch c.config.Auth {
+	case IMAPAuthLogin:
+		err = conn.login(c.config.Username, c.config.Secret)
+	case IMAPAuthXOAuth2:
+		err = conn.authenticateXOAuth2(c.config.Username, c.config.Secret)
+	default:
+		err = fmt.Errorf("unsupported imap auth method: %s", c.config.Auth)
+	}
+	if err != nil {
+		conn.close()
+		return nil, err
+	}
+
+	c.conn = conn
+	return conn, nil
+}
+
+// connectSelected - Connexion avec le dossier synchronisé sélectionné
+func (c *GenericIMAPClient) connectSelected() (*imapConn, error) {
+	conn, err := c.connect()
+	if err != nil {
+		return nil, err
+	}
+	if conn.selected != c.config.Mailbox {
+		if _, err := conn.selectMailbox(c.config.Mailbox); err != nil {
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// trashMailbox - Corbeille configurée, sinon découverte via LIST et mémorisée
+func (c *GenericIMAPClient) trashMailbox() (string, error) {
+	if c.config.TrashMailbox != "" {
+		return c.config.TrashMailbox, nil
+	}
+
+	conn, err := c.connect()
+	if err != nil {
+		return "", err
+	}
+	specialUse, err := conn.listSpecialUse()
+	if err != nil {
+		return "", err
+	}
+	if specialUse[`\trash`] == "" {
+		return "", errIMAPNoTrashMailbox
+	}
+
+	c.config.TrashMailbox = specialUse[`\trash`]
+	return c.config.TrashMailbox, nil
+}
+
+// storeFlags - Modifier les flags d'un message
+func (c *GenericIMAPClient) storeFlags(uid, item, flags string) error {
+	conn, err := c.connectSelected()
+	if err != nil {
+		return err
+	}
+	return conn.uidStore(uid, item, flags)
+}
+
+// move - Déplacer un message vers un autre dossier (MOVE ou COPY + expunge)
+func (c *GenericIMAPClient) move(uid, destination string) error {
+	conn, err := c.connectSelected()
+	if err != nil {
+		return err
+	}
+	return conn.uidMove(uid, destination)
+}
+
+// moveBack - Ramener un message d'un dossier vers le dossier synchronisé.
+// Son UID a changé en changeant de dossier : il est retrouvé par son Message-ID.
+func (c *GenericIMAPClient) moveBack(source, messageID string) error {
+	conn, set, err := c.locate(source, messageID)
+	if err != nil {
+		return err
+	}
+	return conn.uidMove(set, c.config.Mailbox)
+}
+
+// locate - Sélectionner un dossier et y retrouver un message par son Message-ID (ensemble d'UIDs)
+func (c *GenericIMAPClient) locate(mailbox, messageID string) (*imapConn, string, error) {
+	if messageID == "" {
+		return nil, "", fmt.Errorf("message has no Message-ID and cannot be located in %s", mailbox)
+	}
+
+	conn, err := c.connect()
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := conn.selectMailbox(mailbox); err != nil {
+		return nil, "", err
+	}
+
+	quotedID, err := imapQuote(messageID)
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot search message: Message-ID %w", err)
+	}
+	found, err := conn.uidSearch("HEADER Message-ID " + quotedID)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(found) == 0 {
+		return nil, "", fmt.Errorf("%w in %s", errIMAPMessageNotFound, mailbox)
+	}
+
+	uids := make([]string, 0, len(found))
+	for uid := range found {
+		uids = append(uids, strconv.FormatUint(uint64(uid), 10))
+	}
+	sort.Strings(uids)
+	return conn, strings.Join(uids, ","), nil
+}
+
+// fetchEmails - FETCH des en-têtes et flags pour un ensemble de messages
+func (c *GenericIMAPClient) fetchEmails(conn *imapConn, byUID bool, set string) ([]*models.Email, error) {
+	command := "FETCH"
+	if byUID {
+		command = "UID FETCH"
+	}
+
+	responses, err := conn.execute("%s %s (UID FLAGS RFC822.SIZE INTERNALDATE BODY.PEEK[HEADER.FIELDS (%s)])",
+		command, set, strings.Join(imapHeaderFields, " "))
+	if err != nil {
+		return nil, err
+	}
+
+	emails := make([]*models.Email, 0, len(responses))
+	for _, resp := range responses {
+		if len(resp.Fields) < 3 || !strings.EqualFold(imapString(resp.Fields[1]), "FETCH") {
+			continue
+		}
+		items, ok := resp.Fields[2].([]interface{})
+		if !ok {
+			continue
+		}
+
+		email, err := c.parseFetchItems(items)
+		if err != nil {
+			continue
+		}
+		emails = append(emails, email)
+	}
+
+	return emails, nil
+}
+
+// fetchFlags - UID FETCH des flags seuls, filtrés par CHANGEDSINCE si changedSince > 0
+func (c *GenericIMAPClient) fetchFlags(conn *imapConn, set string, changedSince uint64) ([]*models.Email, error) {
+	command := fmt.Sprintf("UID FETCH %s (UID FLAGS)", set)
+	if changedSince > 0 {
+		command += fmt.Sprintf(" (CHANGEDSINCE %d)", changedSince)
+	}
+
+	responses, err := conn.execute("%s", command)
+	if err != nil {
+		return nil, err
+	}
+
+	emails := make([]*models.Email, 0, len(responses))
+	for _, resp := range responses {
+		if len(resp.Fields) < 3 || !strings.EqualFold(imapString(resp.Fields[1]), "FETCH") {
+			continue
+		}
+		items, ok := resp.Fields[2].([]interface{})
+		if !ok {
+			continue
+		}
+		if email, err := c.parseFetchItems(items); err == nil {
+			emails = append(emails, email)
+		}
+	}
+
+	return emails, nil
+}
+
+// maxUID - Plus grand UID parmi des emails IMAP (au moins floor)
+func maxUID(emails []*models.Email, floor uint32) uint32 {
+	max := floor
+	for _, email := range emails {
+		if uid, err := strconv.ParseUint(email.ProviderID, 10, 32); err == nil && uint32(uid) > max {
+			max = uint32(uid)
+		}
+	}
+	return max
+}
+
+// parseFetchItems - Convertir une réponse FETCH en models.Email
+func (c *GenericIMAPClient) parseFetchItems(items []interface{}) (*models.Email, error) {
+	email := &models.Email{
+		To:     []string{},
+		Labels: []string{c.config.Mailbox},
+	}
+
+	var internalDate time.Time
+	var header []byte
+
+	for i := 0; i+1 < len(items); i += 2 {
+		name := strings.ToUpper(imapString(items[i]))
+		value := items[i+1]
+
+		switch {
+		case name == "UID":
+			email.ProviderID = imapString(value)
+		case name == "RFC822.SIZE":
+			email.Size, _ = strconv.ParseInt(imapString(value), 10, 64)
+		case name == "INTERNALDATE":
+			internalDate, _ = time.Parse("_2-Jan-2006 15:04:05 -0700", imapString(value))
+		case name == "FLAGS":
+			flags, _ := value.([]interface{})
+			for _, flag := range flags {
+				switch strings.ToLower(imapString(flag)) {
+				case `\seen`:
+					email.IsRead = true
+				case `\flagged`:
+					email.Labels = append(email.Labels, "FLAGGED")
+				case `\deleted`:
+					email.IsDeleted = true
+				case "$junk", "junk":
+					email.IsSpam = true
+				}
+			}
+		case strings.HasPrefix(name, "BODY["):
+			header = []byte(imapString(value))
+		}
+	}
+
+	if email.ProviderID == "" {
+		return nil, fmt.Errorf("fetch response without uid")
+	}
+
+	email.Date = internalDate
+	if len(header) > 0 {
+		applyIMAPHeader(email, header)
+	}
+
+	if email.MessageID == "" {
+		email.MessageID = fmt.Sprintf("<uid-%s@%s>", email.ProviderID, c.config.Addr)
+	}
+
+	return email, nil
+}
+
+// applyIMAPHeader - Renseigner sujet, expéditeur, destinataires et date depuis les en-têtes bruts
+func applyIMAPHeader(email *models.Email, raw []byte) {
+	msg, err := mail.ReadMessage(bytes.NewReader(append(raw, '\r', '\n')))
+	if err != nil {
+		return
+	}
+
+	decoder := new(mime.WordDecoder)
+
+	email.MessageID = strings.TrimSpace(msg.Header.Get("Message-Id"))
+
+	subject := msg.Header.Get("Subject")
+	if decoded, err := decoder.DecodeHeader(subject); err == nil {
+		subject = decoded
+	}
+	email.Subject = subject
+
+	if from, err := mail.ParseAddress(msg.Header.Get("From")); err == nil {
+		email.From = from.Address
+	} else {
+		email.From = msg.Header.Get("From")
+	}
+
+	if to, err := msg.Header.AddressList("To"); err == nil {
+		for _, address := range to {
+			email.To = append(email.To, address.Address)
+		}
+	}
+
+	if date, err := msg.Header.Date(); err == nil {
+		email.Date = date
+	}
+
+	if mediaType, _, err := mime.ParseMediaType(msg.Header.Get("Content-Type")); err == nil {
+		email.HasAttachments = mediaType == "multipart/mixed"
+	}
+
+	email.Headers = captureHeaders(msg.Header.Get)
+}
+
+// ============================================
+// Protocole IMAP4rev1 (RFC 3501)
+// ============================================
+
+// imapResponse - Réponse IMAP (untagged "*", continuation "+" ou tagguée)
+type imapResponse struct {
+	Tag    string
+	Status string        // OK / NO / BAD / BYE / PREAUTH pour les réponses d'état
+	Text   string        // Texte libre des réponses d'état et de continuation
+	Fields []interface{} // Données des autres réponses : string, nil (NIL) ou []interface{}
+}
+
+// imapMailbox - Informations retournées par SELECT
+type imapMailbox struct {
+	Name          string
+	Exists        int
+	UIDValidity   uint32
+	UIDNext       uint32
+	HighestModSeq uint64
+}
+
+type imapConn struct {
+	conn     net.Conn
+	reader   *bufio.Reader
+	writer   *bufio.Writer
+	timeout  time.Duration
+	tagSeq   int
+	caps     map[string]bool
+	selected string
+}
+
+// dialIMAP - Se connecter au serveur et lire le message d'accueil
+func dialIMAP(config IMAPConfig) (*imapConn, error) {
+	dialer := &net.Dialer{Timeout: config.Timeout}
+
+	var conn net.Conn
+	var err error
+	if config.DisableTLS {
+		conn, err = dialer.Dial("tcp", config.Addr)
+	} else {
+		tlsConfig := config.TLSConfig
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{}
+		}
+		if tlsConfig.ServerName == "" {
+			tlsConfig = tlsConfig.Clone()
+			tlsConfig.ServerName, _, _ = net.SplitHostPort(config.Addr)
+		}
+		conn, err = tls.DialWithDialer(dialer, "tcp", config.Addr, tlsConfig)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to imap server: %w", err)
+	}
+
+	c := &imapConn{
+		conn:    conn,
+		reader:  bufio.NewReader(conn),
+		writer:  bufio.NewWriter(conn),
+		timeout: config.Timeout,
+		caps:    map[string]bool{},
+	}
+
+	c.conn.SetDeadline(time.Now().Add(c.timeout))
+	greeting, err := c.readResponse()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to read imap greeting: %w", err)
+	}
+	if greeting.Status != "OK" && greeting.Status != "PREAUTH" {
+		conn.Close()
+		return nil, fmt.Errorf("imap server rejected connection: %s", greeting.Text)
+	}
+
+	if err := c.capability(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// capability - Récupérer les capacités annoncées par le serveur
+func (c *imapConn) capability() error {
+	responses, err := c.execute("CAPABILITY")
+	if err != nil {
+		return err
+	}
+
+	c.caps = map[string]bool{}
+	for _, resp := range responses {
+		if len(resp.Fields) == 0 || !strings.EqualFold(imapString(resp.Fields[0]), "CAPABILITY") {
+			continue
+		}
+		for _, field := range resp.Fields[1:] {
+			c.caps[strings.ToUpper(imapString(field))] = true
+		}
+	}
+	return nil
+}
+
+// login - Authentification LOGIN (mot de passe). Un identifiant non ASCII ne tient pas dans une chaîne
+// quotée : il est envoyé en littéral {n}, après la continuation du serveur.
+func (c *imapConn) login(username, password string) error {
+	if c.caps["LOGINDISABLED"] {
+		return fmt.Errorf("imap server does not allow LOGIN")
+	}
+
+	// segments[0] est la commande, chaque segment suivant commence par les octets d'un littéral
+	segments := []string{"LOGIN"}
+	for _, field := range []struct{ name, value string }{{"username", username}, {"password", password}} {
+		if quoted, err := imapQuote(field.value); err == nil {
+			segments[len(segments)-1] += " " + quoted
+			continue
+		}
+		if strings.ContainsAny(field.value, "\r\n\x00") {
+			return fmt.Errorf("imap login failed: %s contains line breaks or NUL characters", field.name)
+		}
+		segments[len(segments)-1] += fmt.Sprintf(" {%d}", len(field.value))
+		segments = append(segments, field.value)
+	}
+
+	if _, err := c.executeWithContinuation(segments[0], segments[1:]...); err != nil {
+		return fmt.Errorf("imap login failed: %w", err)
+	}
+	return c.capability()
+}
+
+// authenticateXOAuth2 - Authentification SASL XOAUTH2
+func (c *imapConn) authenticateXOAuth2(username, accessToken string) error {
+	if !c.caps["AUTH=XOAUTH2"] {
+		return fmt.Errorf("imap server does not support XOAUTH2")
+	}
+
+	payload := base64.StdEncoding.EncodeToString(
+		[]byte("user=" + username + "\x01auth=Bearer " + accessToken + "\x01\x01"))
+
+	var err error
+	if c.caps["SASL-IR"] {
+		_, err = c.execute("AUTHENTICATE XOAUTH2 %s", payload)
+	} else {
+		_, err = c.executeWithContinuation("AUTHENTICATE XOAUTH2", payload)
+	}
+	if err != nil {
+		return fmt.Errorf("imap xoauth2 authentication failed: %w", err)
+	}
+	return c.capability()
+}
+
+// selectMailbox - Sélectionner un dossier
+func (c *imapConn) selectMailbox(name string) (*imapMailbox, error) {
+	command := "SELECT %s"
+	if c.caps["CONDSTORE"] {
+		command = "SELECT %s (CONDSTORE)"
+	}
+
+	quoted, err := imapQuote(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select mailbox: name %w", err)
+	}
+	responses, err := c.execute(command, quoted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select mailbox %s: %w", name, err)
+	}
+
+	mailbox := &imapMailbox{Name: name}
+	for _, resp := range responses {
+		if resp.Status == "OK" {
+			code, args := imapResponseCode(resp.Text)
+			value, _ := strconv.ParseUint(args, 10, 64)
+			switch code {
+			case "UIDVALIDITY":
+				mailbox.UIDValidity = uint32(value)
+			case "UIDNEXT":
+				mailbox.UIDNext = uint32(value)
+			case "HIGHESTMODSEQ":
+				mailbox.HighestModSeq = value
+			}
+			continue
+		}
+		if len(resp.Fields) >= 2 && strings.EqualFold(imapString(resp.Fields[1]), "EXISTS") {
+			mailbox.Exists, _ = strconv.Atoi(imapString(resp.Fields[0]))
+		}
+	}
+
+	c.selected = name
+	return mailbox, nil
+}
+
+// listSpecialUse - Premier dossier portant chaque attribut (\trash, \junk, \archive... RFC 6154), clés en minuscules
+func (c *imapConn) listSpecialUse() (map[string]string, error) {
+	responses, err := c.execute(`LIST "" "*"`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mailboxes: %w", err)
+	}
+
+	mailboxes := map[string]string{}
+	for _, resp := range responses {
+		// * LIST (\HasNoChildren \Trash) "/" Trash
+		if len(resp.Fields) < 4 || !strings.EqualFold(imapString(resp.Fields[0]), "LIST") {
+			continue
+		}
+		attributes, _ := resp.Fields[1].([]interface{})
+		name := imapString(resp.Fields[3])
+		for _, attribute := range attributes {
+			key := strings.ToLower(imapString(attribute))
+			if mailboxes[key] == "" {
+				mailboxes[key] = name
+			}
+		}
+	}
+	return mailboxes, nil
+}
+
+// uidSearch - UID SEARCH, renvoie l'ensemble des UIDs correspondants
+func (c *imapConn) uidSearch(criteria string) (map[uint32]bool, error) {
+	responses, err := c.execute("UID SEARCH %s", criteria)
+	if err != nil {
+		return nil, err
+	}
+
+	uids := map[uint32]bool{}
+	for _, resp := range responses {
+		if len(resp.Fields) == 0 || !strings.EqualFold(imapString(resp.Fields[0]), "SEARCH") {
+			continue
+		}
+		for _, field := range resp.Fields[1:] {
+			if uid, err := strconv.ParseUint(imapString(field), 10, 32); err == nil {
+				uids[uint32(uid)] = true
+			}
+		}
+	}
+	return uids, nil
+}
+
+// uidMove - Déplacer des UIDs du dossier sélectionné (MOVE, ou COPY + \Deleted + expunge)
+func (c *imapConn) uidMove(set, destination string) error {
+	quoted, err := imapQuote(destination)
+	if err != nil {
+		return fmt.Errorf("invalid destination mailbox: name %w", err)
+	}
+
+	if c.caps["MOVE"] {
+		_, err := c.execute("UID MOVE %s %s", set, quoted)
+		return err
+	}
+
+	if _, err := c.execute("UID COPY %s %s", set, quoted); err != nil {
+		return err
+	}
+	if err := c.uidStore(set, "+FLAGS.SILENT", `\Deleted`); err != nil {
+		return err
+	}
+	return c.expunge(set)
+}
+
+// uidStore - Modifier les flags d'un ensemble de UIDs
+func (c *imapConn) uidStore(set, item, flags string) error {
+	_, err := c.execute("UID STORE %s %s (%s)", set, item, flags)
+	return err
+}
+
+// expunge - Supprimer définitivement les UIDs \Deleted de set. Sans UIDPLUS, ils restent marqués \Deleted :
+// un EXPUNGE simple effacerait aussi les messages que l'utilisateur a marqués ailleurs.
+func (c *imapConn) expunge(set string) error {
+	if !c.caps["UIDPLUS"] {
+		return nil
+	}
+	_, err := c.execute("UID EXPUNGE %s", set)
+	return err
+}
+
+func (c *imapConn) noop() error {
+	_, err := c.execute("NOOP")
+	return err
+}
+
+func (c *imapConn) logout() error {
+	defer c.close()
+	_, err := c.execute("LOGOUT")
+	return err
+}
+
+func (c *imapConn) close() error {
+	return c.conn.Close()
+}
+
+// execute - Envoyer une commande et collecter les réponses untagged jusqu'à la réponse tagguée
+func (c *imapConn) execute(format string, args ...interface{}) ([]*imapResponse, error) {
+	return c.executeWithContinuation(fmt.Sprintf(format, args...))
+}
+
+// executeWithContinuation - Comme execute, en répondant dans l'ordre aux demandes de continuation
+// (données SASL ou littéraux suivis de la fin de la commande)
+func (c *imapConn) executeWithContinuation(command string, continuations ...string) ([]*imapResponse, error) {
+	c.tagSeq++
+	tag := fmt.Sprintf("T%04d", c.tagSeq)
+
+	c.conn.SetDeadline(time.Now().Add(c.timeout))
+	if _, err := c.writer.WriteString(tag + " " + command + "\r\n"); err != nil {
+		return nil, err
+	}
+	if err := c.writer.Flush(); err != nil {
+		return nil, err
+	}
+
+	var responses []*imapResponse
+	for {
+		resp, err := c.readResponse()
+		if err != nil {
+			return nil, err
+		}
+
+		switch resp.Tag {
+		case "+":
+			// Un échec SASL renvoie aussi une continuation : une fois les données épuisées, y répondre par une ligne vide
+			continuation := ""
+			if len(continuations) > 0 {
+				continuation, continuations = continuations[0], continuations[1:]
+			}
+			c.writer.WriteString(continuation + "\r\n")
+			if err := c.writer.Flush(); err != nil {
+				return nil, err
+			}
+		case "*":
+			responses = append(responses, resp)
+		case tag:
+			if resp.Status != "OK" {
+				return responses, fmt.Errorf("imap %s: %s", strings.ToLower(resp.Status), resp.Text)
+			}
+			return responses, nil
+		default:
+			return nil, fmt.Errorf("unexpected imap tag %q", resp.Tag)
+		}
+	}
+}
+
+// readResponse - Lire une réponse complète, littéraux compris
+func (c *imapConn) readResponse() (*imapResponse, error) {
+	tag, err := c.readAtom()
+	if err != nil {
+		return nil, err
+	}
+	resp := &imapResponse{Tag: tag}
+
+	if tag == "+" {
+		c.skipSpace()
+		resp.Text, err = c.readLine()
+		return resp, err
+	}
+
+	if err := c.expectSpace(); err != nil {
+		return nil, err
+	}
+
+	first, err := c.readValue()
+	if err != nil {
+		return nil, err
+	}
+
+	if word, ok := first.(string); ok {
+		switch strings.ToUpper(word) {
+		case "OK", "NO", "BAD", "BYE", "PREAUTH":
+			resp.Status = strings.ToUpper(word)
+			c.skipSpace()
+			resp.Text, err = c.readLine()
+			return resp, err
+		}
+	}
+
+	resp.Fields = append(resp.Fields, first)
+	for {
+		b, err := c.reader.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		switch b {
+		case ' ':
+			// Espace final avant CRLF ("* SEARCH \r\n") : fin de ligne
+			if next, err := c.reader.Peek(1); err == nil && (next[0] == '\r' || next[0] == '\n') {
+				continue
+			}
+			value, err := c.readValue()
+			if err != nil {
+				return nil, err
+			}
+			resp.Fields = append(resp.Fields, value)
+		case '\r':
+			if _, err := c.reader.ReadByte(); err != nil {
+				return nil, err
+			}
+			return resp, nil
+		case '\n':
+			return resp, nil
+		default:
+			return nil, fmt.Errorf("unexpected character %q in imap response", b)
+		}
+	}
+}
+
+// readValue - Lire un atome, une chaîne quotée, un littéral ou une liste
+func (c *imapConn) readValue() (interface{}, error) {
+	b, err := c.reader.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+
+	switch b[0] {
+	case '(':
+		c.reader.ReadByte()
+		list := []interface{}{}
+		for {
+			next, err := c.reader.Peek(1)
+			if err != nil {
+				return nil, err
+			}
+			switch next[0] {
+			case ')':
+				c.reader.ReadByte()
+				return list, nil
+			case ' ':
+				c.reader.ReadByte()
+				continue
+			}
+			value, err := c.readValue()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+	case '"':
+		return c.readQuoted()
+	case '{':
+		return c.readLiteral()
+	default:
+		atom, err := c.readAtom()
+		if err != nil {
+			return nil, err
+		}
+		if strings.EqualFold(atom, "NIL") {
+			return nil, nil
+		}
+		return atom, nil
+	}
+}
+
+// readAtom - Lire un atome ; les sections entre crochets (BODY[...]) sont lues en entier
+func (c *imapConn) readAtom() (string, error) {
+	var sb strings.Builder
+	depth := 0
+	for {
+		b, err := c.reader.Peek(1)
+		if err != nil {
+			return "", err
+		}
+		ch := b[0]
+		if depth == 0 && (ch == ' ' || ch == '(' || ch == ')' || ch == '\r' || ch == '\n') {
+			break
+		}
+		if ch == '\r' || ch == '\n' {
+			break
+		}
+		switch ch {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		}
+		c.reader.ReadByte()
+		sb.WriteByte(ch)
+	}
+	if sb.Len() == 0 {
+		return "", fmt.Errorf("empty imap atom")
+	}
+	return sb.String(), nil
+}
+
+// readQuoted - Lire une chaîne entre guillemets
+func (c *imapConn) readQuoted() (string, error) {
+	c.reader.ReadByte()
+	var sb strings.Builder
+	for {
+		b, err := c.reader.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		switch b {
+		case '\\':
+			escaped, err := c.reader.ReadByte()
+			if err != nil {
+				return "", err
+			}
+			sb.WriteByte(escaped)
+		case '"':
+			return sb.String(), nil
+		default:
+			sb.WriteByte(b)
+		}
+	}
+}
+
+// readLiteral - Lire un littéral {n}\r\n suivi de n octets
+func (c *imapConn) readLiteral() (string, error) {
+	line, err := c.reader.ReadString('}')
+	if err != nil {
+		return "", err
+	}
+	size, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(line, "{"), "}"))
+	if err != nil {
+		return "", fmt.Errorf("invalid imap literal size: %s", line)
+	}
+	if _, err := c.readLine(); err != nil {
+		return "", err
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(c.reader, data); err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// readLine - Lire le reste de la ligne courante (sans CRLF)
+func (c *imapConn) readLine() (string, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (c *imapConn) expectSpace() error {
+	b, err := c.reader.ReadByte()
+	if err != nil {
+		return err
+	}
+	if b != ' ' {
+		return fmt.Errorf("expected space in imap response, got %q", b)
+	}
+	return nil
+}
+
+func (c *imapConn) skipSpace() {
+	if b, err := c.reader.Peek(1); err == nil && b[0] == ' ' {
+		c.reader.ReadByte()
+	}
+}
+
+// imapResponseCode - Extraire le code entre crochets d'un texte de réponse ("[UIDVALIDITY 42] ...")
+func imapResponseCode(text string) (string, string) {
+	if !strings.HasPrefix(text, "[") {
+		return "", ""
+	}
+	end := strings.Index(text, "]")
+	if end < 0 {
+		return "", ""
+	}
+	code, args, _ := strings.Cut(text[1:end], " ")
+	return strings.ToUpper(code), args
+}
+
+// imapQuote - Encoder une chaîne quotée IMAP. CR, LF, NUL et octets 8 bits sont refusés (RFC 3501 :
+// une chaîne quotée est en 7 bits) : un CRLF injecterait une commande supplémentaire.
+func imapQuote(value string) (string, error) {
+	for i := 0; i < len(value); i++ {
+		if b := value[i]; b == '\r' || b == '\n' || b == 0 || b >= 0x80 {
+			return "", fmt.Errorf("contains characters that cannot be sent in an imap quoted string")
+		}
+	}
+
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return `"` + value + `"`, nil
+}
+
+// imapString - Convertir une valeur parsée en chaîne (NIL devient vide)
+func imapString(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	return ""
+}
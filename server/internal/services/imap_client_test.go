@@ -0,0 +1,488 @@
+package services
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"tamis-server/internal/models"
+	"testing"
+	"time"
+)
+
+// imapLiteralSuffix - Annonce d'un littéral en fin de ligne
+var imapLiteralSuffix = regexp.MustCompile(`\{(\d+)\}$`)
+
+// fakeIMAPServer - Serveur IMAP en mémoire : chaque commande reçue est transmise à handler,
+// qui renvoie les lignes untagged et le statut tagué
+type fakeIMAPServer struct {
+	listener     net.Listener
+	handler      func(command string) (untagged []string, status string)
+	capabilities string
+
+	mu       sync.Mutex
+	commands []string
+}
+
+func newFakeIMAPServer(t *testing.T, handler func(command string) ([]string, string)) *fakeIMAPServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	server := &fakeIMAPServer{listener: listener, handler: handler, capabilities: "IMAP4rev1 UIDPLUS MOVE"}
+	t.Cleanup(func() { listener.Close() })
+	go server.serve()
+	return server
+}
+
+func (s *fakeIMAPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeIMAPServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	writer := bufio.NewWriter(conn)
+	writer.WriteString("* OK fake imap ready\r\n")
+	writer.Flush()
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		// Littéral synchronisant {n} : continuation, puis n octets et la suite de la commande
+		for match := imapLiteralSuffix.FindStringSubmatch(line); match != nil; match = imapLiteralSuffix.FindStringSubmatch(line) {
+			size, _ := strconv.Atoi(match[1])
+			writer.WriteString("+ Ready for literal data\r\n")
+			writer.Flush()
+			data := make([]byte, size)
+			if _, err := io.ReadFull(reader, data); err != nil {
+				return
+			}
+			rest, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line += "\r\n" + string(data) + strings.TrimRight(rest, "\r\n")
+		}
+		tag, command, _ := strings.Cut(line, " ")
+
+		s.mu.Lock()
+		s.commands = append(s.commands, command)
+		s.mu.Unlock()
+
+		untagged, status := s.defaultResponse(command)
+		for _, response := range untagged {
+			writer.WriteString(response + "\r\n")
+		}
+		writer.WriteString(tag + " " + status + "\r\n")
+		writer.Flush()
+
+		if strings.HasPrefix(command, "LOGOUT") {
+			return
+		}
+	}
+}
+
+// defaultResponse - Réponses de connexion communes, puis handler du test
+func (s *fakeIMAPServer) defaultResponse(command string) ([]string, string) {
+	switch {
+	case command == "CAPABILITY":
+		return []string{"* CAPABILITY " + s.capabilities}, "OK done"
+	case strings.HasPrefix(command, "LOGIN "):
+		return nil, "OK logged in"
+	case command == "LOGOUT":
+		return []string{"* BYE"}, "OK bye"
+	case command == "NOOP":
+		return nil, "OK"
+	}
+	return s.handler(command)
+}
+
+// received - Commandes reçues, hors connexion
+func (s *fakeIMAPServer) received() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	commands := []string{}
+	for _, command := range s.commands {
+		if command != "CAPABILITY" && command != "NOOP" && !strings.HasPrefix(command, "LOGIN ") {
+			commands = append(commands, command)
+		}
+	}
+	return commands
+}
+
+func (s *fakeIMAPServer) client() *GenericIMAPClient {
+	return NewGenericIMAPClient(IMAPConfig{
+		Addr:       s.listener.Addr().String(),
+		Username:   "user@example.com",
+		Secret:     "secret",
+		Auth:       IMAPAuthLogin,
+		DisableTLS: true,
+		Timeout:    5 * time.Second,
+	})
+}
+
+// imapLiteral - Littéral IMAP {n}\r\n suivi des données
+func imapLiteral(data string) string {
+	return fmt.Sprintf("{%d}\r\n%s", len(data), data)
+}
+
+func parseIMAPResponse(t *testing.T, raw string) *imapResponse {
+	t.Helper()
+
+	conn := &imapConn{reader: bufio.NewReader(strings.NewReader(raw))}
+	resp, err := conn.readResponse()
+	if err != nil {
+		t.Fatalf("readResponse(%q): %v", raw, err)
+	}
+	return resp
+}
+
+func TestIMAPReadResponse(t *testing.T) {
+	t.Run("search with trailing space", func(t *testing.T) {
+		resp := parseIMAPResponse(t, "* SEARCH 3 5 \r\n")
+		if !reflect.DeepEqual(resp.Fields, []interface{}{"SEARCH", "3", "5"}) {
+			t.Errorf("fields = %#v", resp.Fields)
+		}
+	})
+
+	t.Run("empty search with trailing space", func(t *testing.T) {
+		resp := parseIMAPResponse(t, "* SEARCH \r\n")
+		if !reflect.DeepEqual(resp.Fields, []interface{}{"SEARCH"}) {
+			t.Errorf("fields = %#v", resp.Fields)
+		}
+	})
+
+	t.Run("status with response code", func(t *testing.T) {
+		resp := parseIMAPResponse(t, "* OK [UIDVALIDITY 42] UIDs valid\r\n")
+		if resp.Tag != "*" || resp.Status != "OK" {
+			t.Fatalf("tag/status = %q/%q", resp.Tag, resp.Status)
+		}
+		code, args := imapResponseCode(resp.Text)
+		if code != "UIDVALIDITY" || args != "42" {
+			t.Errorf("response code = %q %q", code, args)
+		}
+	})
+
+	t.Run("tagged failure", func(t *testing.T) {
+		resp := parseIMAPResponse(t, "T0001 NO [AUTHENTICATIONFAILED] invalid credentials\r\n")
+		if resp.Tag != "T0001" || resp.Status != "NO" || resp.Text != "[AUTHENTICATIONFAILED] invalid credentials" {
+			t.Errorf("response = %+v", resp)
+		}
+	})
+
+	t.Run("fetch with literal, quoted string and NIL", func(t *testing.T) {
+		header := "Subject: Hello\r\n\r\n"
+		raw := `* 2 FETCH (UID 17 FLAGS (\Seen $Junk) INTERNALDATE "01-Feb-2024 10:00:00 +0000" X-GM-MSGID NIL ` +
+			`BODY[HEADER.FIELDS (SUBJECT FROM)] ` + imapLiteral(header) + ")\r\n"
+		resp := parseIMAPResponse(t, raw)
+
+		if len(resp.Fields) != 3 || resp.Fields[0] != "2" || resp.Fields[1] != "FETCH" {
+			t.Fatalf("fields = %#v", resp.Fields)
+		}
+		want := []interface{}{
+			"UID", "17",
+			"FLAGS", []interface{}{`\Seen`, "$Junk"},
+			"INTERNALDATE", "01-Feb-2024 10:00:00 +0000",
+			"X-GM-MSGID", nil,
+			"BODY[HEADER.FIELDS (SUBJECT FROM)]", header,
+		}
+		if !reflect.DeepEqual(resp.Fields[2], want) {
+			t.Errorf("items = %#v", resp.Fields[2])
+		}
+	})
+
+	t.Run("quoted string escapes", func(t *testing.T) {
+		resp := parseIMAPResponse(t, `* LIST (\HasNoChildren) "/" "Say \"hi\" \\ there"`+"\r\n")
+		if got := imapString(resp.Fields[3]); got != `Say "hi" \ there` {
+			t.Errorf("name = %q", got)
+		}
+	})
+
+	t.Run("continuation", func(t *testing.T) {
+		resp := parseIMAPResponse(t, "+ eyJzdGF0dXMiOiI0MDEifQ==\r\n")
+		if resp.Tag != "+" || resp.Text != "eyJzdGF0dXMiOiI0MDEifQ==" {
+			t.Errorf("response = %+v", resp)
+		}
+	})
+}
+
+func TestIMAPQuote(t *testing.T) {
+	quoted, err := imapQuote(`pa"ss\word`)
+	if err != nil {
+		t.Fatalf("imapQuote: %v", err)
+	}
+	if quoted != `"pa\"ss\\word"` {
+		t.Errorf("quoted = %s", quoted)
+	}
+
+	for _, value := range []string{"secret\r\nA001 DELETE INBOX", "line\nfeed", "nul\x00", "mot de passé"} {
+		if _, err := imapQuote(value); err == nil {
+			t.Errorf("imapQuote(%q) should be rejected", value)
+		}
+	}
+}
+
+func TestIMAPLoginSendsNonASCIIAsLiteral(t *testing.T) {
+	server := newFakeIMAPServer(t, func(command string) ([]string, string) {
+		return nil, "BAD unexpected"
+	})
+
+	client := server.client()
+	client.config.Username = "josé@example.com"
+	client.config.Secret = "mot de passé"
+	defer client.Close()
+
+	if _, err := client.connect(); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+
+	// Les longueurs sont en octets UTF-8
+	want := "LOGIN {17}\r\njosé@example.com {13}\r\nmot de passé"
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	for _, command := range server.commands {
+		if strings.HasPrefix(command, "LOGIN ") {
+			if command != want {
+				t.Errorf("login = %q, want %q", command, want)
+			}
+			return
+		}
+	}
+	t.Errorf("no LOGIN in %q", server.commands)
+}
+
+func TestIMAPLoginRejectsInjectedPassword(t *testing.T) {
+	server := newFakeIMAPServer(t, func(command string) ([]string, string) {
+		return nil, "BAD unexpected"
+	})
+
+	client := server.client()
+	client.config.Secret = "secret\r\nT0099 DELETE INBOX"
+	defer client.Close()
+
+	if _, err := client.connect(); err == nil {
+		t.Fatal("connect should fail")
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	for _, command := range server.commands {
+		if strings.Contains(command, "DELETE") {
+			t.Fatalf("injected command reached the server: %q", command)
+		}
+	}
+}
+
+func TestIMAPFetchChangesIncremental(t *testing.T) {
+	header := "Message-ID: <new@example.com>\r\n" +
+		"Subject: =?UTF-8?Q?Caf=C3=A9?=\r\n" +
+		"From: Alice <alice@example.com>\r\n" +
+		"To: user@example.com\r\n" +
+		"List-Unsubscribe: <mailto:leave@example.com>\r\n"
+
+	server := newFakeIMAPServer(t, func(command string) ([]string, string) {
+		switch {
+		case strings.HasPrefix(command, "SELECT "):
+			return []string{
+				"* 3 EXISTS",
+				"* OK [UIDVALIDITY 7] UIDs valid",
+				"* OK [UIDNEXT 5] next",
+			}, "OK [READ-WRITE] selected"
+		case strings.HasPrefix(command, "UID FETCH 4:* "):
+			return []string{
+				`* 3 FETCH (UID 4 FLAGS () RFC822.SIZE 120 INTERNALDATE "01-Feb-2024 10:00:00 +0000" ` +
+					`BODY[HEADER.FIELDS (MESSAGE-ID SUBJECT)] ` + imapLiteral(header) + ")",
+			}, "OK fetched"
+		case command == "UID FETCH 1:3 (UID FLAGS)":
+			return []string{
+				`* 1 FETCH (UID 1 FLAGS (\Seen))`,
+				`* 2 FETCH (UID 3 FLAGS ($Junk))`,
+			}, "OK fetched"
+		case command == "UID SEARCH UID 1:3":
+			return []string{"* SEARCH 1 3 "}, "OK searched"
+		}
+		return nil, "BAD unexpected command"
+	})
+
+	client := server.client()
+	defer client.Close()
+
+	state := &models.SyncState{Mailbox: "INBOX", UIDValidity: 7, LastUID: 3}
+	changes, err := client.FetchChanges(state, []string{"1", "2", "3"})
+	if err != nil {
+		t.Fatalf("FetchChanges: %v", err)
+	}
+
+	if changes.ResetIDs {
+		t.Error("ResetIDs should be false with an unchanged UIDVALIDITY")
+	}
+	if changes.State.LastUID != 4 || changes.State.UIDValidity != 7 {
+		t.Errorf("state = %+v", changes.State)
+	}
+
+	if len(changes.Emails) != 1 {
+		t.Fatalf("emails = %d, want 1", len(changes.Emails))
+	}
+	email := changes.Emails[0]
+	if email.ProviderID != "4" || email.MessageID != "<new@example.com>" || email.Subject != "Café" ||
+		email.From != "alice@example.com" || email.IsRead || email.Size != 120 {
+		t.Errorf("email = %+v", email)
+	}
+	if email.Headers["list-unsubscribe"] != "<mailto:leave@example.com>" {
+		t.Errorf("headers = %v", email.Headers)
+	}
+
+	if len(changes.FlagChanges) != 2 || !changes.FlagChanges[0].IsRead || !changes.FlagChanges[1].IsSpam {
+		t.Errorf("flag changes = %+v", changes.FlagChanges)
+	}
+	if !reflect.DeepEqual(changes.DeletedIDs, []string{"2"}) {
+		t.Errorf("deleted = %v, want [2]", changes.DeletedIDs)
+	}
+}
+
+func TestIMAPFetchChangesUIDValidityReset(t *testing.T) {
+	server := newFakeIMAPServer(t, func(command string) ([]string, string) {
+		switch {
+		case strings.HasPrefix(command, "SELECT "):
+			return []string{"* 1 EXISTS", "* OK [UIDVALIDITY 9] new validity", "* OK [UIDNEXT 2] next"}, "OK selected"
+		case strings.HasPrefix(command, "FETCH 1:1 "):
+			return []string{`* 1 FETCH (UID 1 FLAGS (\Seen))`}, "OK fetched"
+		}
+		return nil, "BAD unexpected command"
+	})
+
+	client := server.client()
+	defer client.Close()
+
+	changes, err := client.FetchChanges(&models.SyncState{Mailbox: "INBOX", UIDValidity: 7, LastUID: 40}, []string{"40"})
+	if err != nil {
+		t.Fatalf("FetchChanges: %v", err)
+	}
+	if !changes.ResetIDs || len(changes.Emails) != 1 || changes.State.LastUID != 1 || changes.State.UIDValidity != 9 {
+		t.Errorf("changes = %+v, state = %+v", changes, changes.State)
+	}
+}
+
+func TestIMAPDeleteMovesToDiscoveredTrash(t *testing.T) {
+	server := newFakeIMAPServer(t, func(command string) ([]string, string) {
+		switch {
+		case command == `LIST "" "*"`:
+			return []string{
+				`* LIST (\HasNoChildren) "/" INBOX`,
+				`* LIST (\HasNoChildren \Trash) "/" "Deleted Items"`,
+			}, "OK listed"
+		case strings.HasPrefix(command, "SELECT "):
+			return []string{"* 1 EXISTS"}, "OK selected"
+		case strings.HasPrefix(command, "UID MOVE "):
+			return nil, "OK moved"
+		}
+		return nil, "BAD unexpected command"
+	})
+
+	client := server.client()
+	defer client.Close()
+
+	if err := client.Delete("12"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	commands := server.received()
+	if last := commands[len(commands)-1]; last != `UID MOVE 12 "Deleted Items"` {
+		t.Errorf("last command = %q", last)
+	}
+}
+
+func TestIMAPDeleteWithoutTrashNeverExpunges(t *testing.T) {
+	server := newFakeIMAPServer(t, func(command string) ([]string, string) {
+		if command == `LIST "" "*"` {
+			return []string{`* LIST (\HasNoChildren) "/" INBOX`}, "OK listed"
+		}
+		return nil, "OK"
+	})
+
+	client := server.client()
+	defer client.Close()
+
+	if err := client.Delete("12"); !errors.Is(err, errIMAPNoTrashMailbox) {
+		t.Fatalf("Delete error = %v, want errIMAPNoTrashMailbox", err)
+	}
+	for _, command := range server.received() {
+		if strings.Contains(command, "EXPUNGE") || strings.Contains(command, "STORE") {
+			t.Errorf("unexpected command %q", command)
+		}
+	}
+}
+
+func TestIMAPDeletePermanentlyExpungesFromTrash(t *testing.T) {
+	server := newFakeIMAPServer(t, func(command string) ([]string, string) {
+		switch {
+		case strings.HasPrefix(command, "SELECT "):
+			return []string{"* 1 EXISTS"}, "OK selected"
+		case strings.HasPrefix(command, "UID SEARCH "):
+			return []string{"* SEARCH 40 7"}, "OK searched"
+		}
+		return nil, "OK"
+	})
+
+	client := server.client()
+	client.config.TrashMailbox = "Trash"
+	defer client.Close()
+
+	if err := client.DeletePermanently("12", "<a@example.com>"); err != nil {
+		t.Fatalf("DeletePermanently: %v", err)
+	}
+
+	// Le nouvel UID dans la corbeille est retrouvé par Message-ID : seul lui est effacé
+	want := []string{
+		`SELECT "INBOX"`,
+		`UID MOVE 12 "Trash"`,
+		`SELECT "Trash"`,
+		`UID SEARCH HEADER Message-ID "<a@example.com>"`,
+		`UID STORE 40,7 +FLAGS.SILENT (\Deleted)`,
+		"UID EXPUNGE 40,7",
+	}
+	if got := server.received(); !reflect.DeepEqual(got, want) {
+		t.Errorf("commands = %q\nwant %q", got, want)
+	}
+}
+
+func TestIMAPDeletePermanentlyRequiresUIDPlus(t *testing.T) {
+	server := newFakeIMAPServer(t, func(command string) ([]string, string) {
+		return nil, "OK"
+	})
+	server.capabilities = "IMAP4rev1 MOVE"
+
+	client := server.client()
+	client.config.TrashMailbox = "Trash"
+	defer client.Close()
+
+	if err := client.DeletePermanently("12", "<a@example.com>"); !errors.Is(err, errIMAPNoUIDPlus) {
+		t.Fatalf("DeletePermanently error = %v, want errIMAPNoUIDPlus", err)
+	}
+	for _, command := range server.received() {
+		if strings.Contains(command, "MOVE") || strings.Contains(command, "EXPUNGE") {
+			t.Errorf("unexpected command %q", command)
+		}
+	}
+}
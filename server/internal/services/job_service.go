@@ -0,0 +1,246 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"tamis-server/internal/models"
+	"tamis-server/internal/repository"
+	"tamis-server/internal/utils"
+	"time"
+)
+
+const (
+	// jobWorkerCount - Nombre de jobs exécutés simultanément
+	jobWorkerCount = 2
+	// jobPollInterval - Fréquence de vérification de la file en l'absence de notification
+	jobPollInterval = 5 * time.Second
+)
+
+// JobReporter - Enregistre la progression (sérialisée en JSON) d'un job en cours
+type JobReporter func(result interface{})
+
+// JobHandler - Exécute un job ; ctx est annulé à l'arrêt du serveur (le job sera repris)
+type JobHandler func(ctx context.Context, job *models.Job, report JobReporter) error
+
+// JobService - File de jobs persistée dans Postgres
+type JobService struct {
+	jobRepo *repository.JobRepository
+	logger  *utils.Logger
+
+	handlers map[models.JobType]JobHandler
+	wake     chan struct{}
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewJobService(jobRepo *repository.JobRepository, logger *utils.Logger) *JobService {
+	return &JobService{
+		jobRepo:  jobRepo,
+		logger:   logger,
+		handlers: make(map[models.JobType]JobHandler),
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// RegisterHandler - Associer un type de job à sa fonction d'exécution (avant Start)
+func (s *JobService) RegisterHandler(jobType models.JobType, handler JobHandler) {
+	s.handlers[jobType] = handler
+}
+
+// Enqueue - Créer un job en attente ; un job identique déjà actif pour l'utilisateur est renvoyé tel quel
+func (s *JobService) Enqueue(userID int, jobType models.JobType, params interface{}) (*models.Job, error) {
+	if _, ok := s.handlers[jobType]; !ok {
+		return nil, fmt.Errorf("unsupported job type: %s", jobType)
+	}
+
+	active, err := s.jobRepo.GetActive(userID, jobType)
+	if err != nil {
+		return nil, err
+	}
+	if active != nil {
+		return active, nil
+	}
+
+	encodedParams, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job params: %w", err)
+	}
+
+	id, err := newUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	job := &models.Job{
+		ID:     id,
+		UserID: userID,
+		Type:   jobType,
+		Status: models.JobPending,
+		Params: encodedParams,
+	}
+
+	created, err := s.jobRepo.Create(job)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		// Un job du même type a été créé entre-temps par une requête concurrente
+		active, err := s.jobRepo.GetActive(userID, jobType)
+		if err != nil {
+			return nil, err
+		}
+		if active == nil {
+			return nil, fmt.Errorf("failed to create job: conflicting active job disappeared")
+		}
+		return active, nil
+	}
+
+	s.logger.Info(fmt.Sprintf("Job %s (%s) enqueued for user %d", job.ID, job.Type, userID))
+	s.notify()
+	return job, nil
+}
+
+// GetJob - Récupérer un job en vérifiant qu'il appartient à l'utilisateur
+func (s *JobService) GetJob(userID int, jobID string) (*models.Job, error) {
+	job, err := s.jobRepo.GetByID(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	if job.UserID != userID {
+		return nil, fmt.Errorf("job not found")
+	}
+
+	return job, nil
+}
+
+// Start - Reprendre les jobs interrompus puis démarrer les workers
+func (s *JobService) Start(ctx context.Context) error {
+	requeued, err := s.jobRepo.RequeueRunning()
+	if err != nil {
+		return err
+	}
+	if requeued > 0 {
+		s.logger.Info(fmt.Sprintf("Requeued %d interrupted jobs", requeued))
+	}
+
+	ctx, s.cancel = context.WithCancel(ctx)
+	for i := 0; i < jobWorkerCount; i++ {
+		s.wg.Add(1)
+		go s.worker(ctx)
+	}
+
+	return nil
+}
+
+// Stop - Arrêter les workers ; les jobs interrompus restent en attente pour le prochain démarrage
+func (s *JobService) Stop() {
+	if s.cancel == nil {
+		return
+	}
+
+	s.cancel()
+	s.wg.Wait()
+	s.logger.Info("Job workers stopped")
+}
+
+// notify - Réveiller un worker sans bloquer
+func (s *JobService) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// worker - Exécuter les jobs en attente jusqu'à l'arrêt
+func (s *JobService) worker(ctx context.Context) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(jobPollInterval)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil {
+			job, err := s.jobRepo.ClaimNext()
+			if err != nil {
+				s.logger.Error(fmt.Sprintf("Failed to claim job: %v", err))
+				break
+			}
+			if job == nil {
+				break
+			}
+			s.run(ctx, job)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// run - Exécuter un job et enregistrer son état final
+func (s *JobService) run(ctx context.Context, job *models.Job) {
+	handler, ok := s.handlers[job.Type]
+	if !ok {
+		s.finish(job, models.JobFailed, fmt.Sprintf("unsupported job type: %s", job.Type))
+		return
+	}
+
+	report := func(result interface{}) {
+		encoded, err := json.Marshal(result)
+		if err != nil {
+			s.logger.Error(fmt.Sprintf("Failed to encode progress for job %s: %v", job.ID, err))
+			return
+		}
+		if err := s.jobRepo.UpdateResult(job.ID, encoded); err != nil {
+			s.logger.Error(fmt.Sprintf("Failed to save progress for job %s: %v", job.ID, err))
+		}
+	}
+
+	err := handler(ctx, job, report)
+	if err != nil && errors.Is(err, context.Canceled) && ctx.Err() != nil {
+		// Arrêt du serveur : le job sera repris au prochain démarrage
+		if requeueErr := s.jobRepo.Requeue(job.ID); requeueErr != nil {
+			s.logger.Error(fmt.Sprintf("Failed to requeue job %s: %v", job.ID, requeueErr))
+		}
+		return
+	}
+
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Job %s (%s) failed: %v", job.ID, job.Type, err))
+		s.finish(job, models.JobFailed, err.Error())
+		return
+	}
+
+	s.finish(job, models.JobCompleted, "")
+}
+
+// finish - Enregistrer l'état final d'un job
+func (s *JobService) finish(job *models.Job, status models.JobStatus, errorMessage string) {
+	if err := s.jobRepo.Finish(job.ID, status, errorMessage); err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to finish job %s: %v", job.ID, err))
+	}
+}
+
+// newUUID - Identifiant aléatoire au format UUID v4
+func newUUID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate job id: %w", err)
+	}
+
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	encoded := hex.EncodeToString(b)
+	return fmt.Sprintf("%s-%s-%s-%s-%s", encoded[0:8], encoded[8:12], encoded[12:16], encoded[16:20], encoded[20:]), nil
+}
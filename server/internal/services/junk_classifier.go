@@ -0,0 +1,370 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/mail"
+	"strings"
+	"tamis-server/internal/models"
+	"tamis-server/internal/repository"
+	"tamis-server/internal/utils"
+	"unicode"
+)
+
+const (
+	// minClassifierExamples - Exemples requis dans chaque classe avant de calculer des scores
+	minClassifierExamples = 10
+	// maxEmailTokens - Nombre maximal de lexèmes retenus par email
+	maxEmailTokens = 200
+	// classifierChunkSize - Nombre d'emails réévalués par lot lors d'un réentraînement
+	classifierChunkSize = 500
+)
+
+// junkLabel - Classe apprise d'une action de l'utilisateur : indésirable (delete, spam) ou conservé
+func junkLabel(action models.EmailAction) (isJunk bool, ok bool) {
+	switch action {
+	case models.ActionDelete, models.ActionSpam:
+		return true, true
+	case models.ActionArchive, models.ActionRestore, models.ActionUnarchive, models.ActionNotSpam:
+		return false, true
+	}
+	return false, false
+}
+
+// junkTokens - Lexèmes d'un email : expéditeur, domaine, mots du nom affiché et du sujet, en-têtes de diffusion, catégorie.
+// Les libellés d'état (lu, archivé...) sont exclus : ils reflètent les décisions passées plutôt que le contenu.
+func junkTokens(email *models.Email) []string {
+	seen := map[string]bool{}
+	tokens := []string{}
+	add := func(token string) {
+		if len(tokens) < maxEmailTokens && !seen[token] {
+			seen[token] = true
+			tokens = append(tokens, token)
+		}
+	}
+
+	if sender := normalizeSender(email.From); sender != "" {
+		add("from:" + sender)
+		if at := strings.LastIndex(sender, "@"); at >= 0 {
+			domain := sender[at+1:]
+			add("domain:" + domain)
+			if labels := strings.Split(domain, "."); len(labels) > 2 {
+				add("domain:" + strings.Join(labels[len(labels)-2:], "."))
+			}
+		}
+	}
+
+	if address, err := mail.ParseAddress(email.From); err == nil {
+		for _, word := range junkWords(address.Name) {
+			add("name:" + word)
+		}
+	}
+
+	for _, word := range junkWords(email.Subject) {
+		add("subject:" + word)
+	}
+
+	// Seule la présence des en-têtes de diffusion est significative (From, Authentication-Results... sont partout)
+	for _, name := range listHeaderNames {
+		if name = strings.ToLower(name); email.Headers[name] != "" {
+			add("header:" + name)
+		}
+	}
+	if precedence := strings.ToLower(email.Headers["precedence"]); precedence != "" {
+		add("precedence:" + precedence)
+	}
+
+	if email.Category != "" {
+		add("category:" + string(email.Category))
+	}
+	if email.HasAttachments {
+		add("attachment")
+	}
+	for _, label := range email.Labels {
+		if strings.HasPrefix(label, "CATEGORY_") {
+			add("label:" + strings.ToLower(label))
+		}
+	}
+
+	return tokens
+}
+
+// junkWords - Mots en minuscules d'un texte (2 à 24 caractères), les nombres réduits à "#"
+func junkWords(text string) []string {
+	words := []string{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		length := len([]rune(word))
+		switch {
+		case strings.Trim(word, "0123456789") == "":
+			words = append(words, "#")
+		case length >= 2 && length <= 24:
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// junkScore - Probabilité a posteriori de la classe indésirable (Bayes naïf multinomial, lissage de Laplace).
+// Les lexèmes jamais rencontrés à l'apprentissage sont ignorés.
+func junkScore(stats *models.ClassifierStats, counts map[string]*models.ClassifierTokenCount, tokens []string) float64 {
+	examples := float64(stats.JunkExamples + stats.KeepExamples)
+	logJunk := math.Log(float64(stats.JunkExamples) / examples)
+	logKeep := math.Log(float64(stats.KeepExamples) / examples)
+
+	vocabulary := float64(stats.Vocabulary)
+	for _, token := range tokens {
+		count, ok := counts[token]
+		if !ok {
+			continue
+		}
+		logJunk += math.Log((float64(count.Junk) + 1) / (float64(stats.JunkTokens) + vocabulary))
+		logKeep += math.Log((float64(count.Keep) + 1) / (float64(stats.KeepTokens) + vocabulary))
+	}
+
+	score := 1 / (1 + math.Exp(logKeep-logJunk))
+	return math.Round(score*1000) / 1000
+}
+
+// ClassifierService - Classifieur d'emails indésirables propre à chaque utilisateur, entraîné par ses actions
+type ClassifierService struct {
+	classifierRepo *repository.ClassifierRepository
+	emailRepo      *repository.EmailRepository
+	accountService *AccountService
+	jobService     *JobService
+	logger         *utils.Logger
+}
+
+func NewClassifierService(classifierRepo *repository.ClassifierRepository, emailRepo *repository.EmailRepository, accountService *AccountService, jobService *JobService, logger *utils.Logger) *ClassifierService {
+	return &ClassifierService{
+		classifierRepo: classifierRepo,
+		emailRepo:      emailRepo,
+		accountService: accountService,
+		jobService:     jobService,
+		logger:         logger,
+	}
+}
+
+// GetStats - État du modèle de l'utilisateur
+func (s *ClassifierService) GetStats(userID int) (*models.ClassifierStats, error) {
+	stats, err := s.classifierRepo.GetStats(userID)
+	if err != nil {
+		return nil, err
+	}
+	stats.Ready = classifierReady(stats)
+	stats.MinExamples = minClassifierExamples
+	return stats, nil
+}
+
+// Learn - Apprendre d'une action de l'utilisateur (ActionHandler). Un email déjà appris
+// dans l'autre classe est corrigé : ses lexèmes sont retirés de l'ancienne classe.
+func (s *ClassifierService) Learn(userID int, action models.EmailAction, emails []*models.Email) {
+	isJunk, ok := junkLabel(action)
+	if !ok {
+		return
+	}
+
+	ids := make([]string, len(emails))
+	for i, email := range emails {
+		ids[i] = email.ID
+	}
+	existing, err := s.classifierRepo.GetExamples(userID, ids)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to load classifier examples for user %d: %v", userID, err))
+		return
+	}
+
+	examples := []*models.ClassifierExample{}
+	deltas := map[string]*models.ClassifierTokenCount{}
+	for _, email := range emails {
+		if previous := existing[email.ID]; previous != nil {
+			if previous.IsJunk == isJunk {
+				continue
+			}
+			addTokenCounts(deltas, previous.Tokens, previous.IsJunk, -1)
+		}
+
+		tokens := junkTokens(email)
+		addTokenCounts(deltas, tokens, isJunk, 1)
+		examples = append(examples, &models.ClassifierExample{EmailID: email.ID, IsJunk: isJunk, Tokens: tokens})
+	}
+
+	if err := s.classifierRepo.Train(userID, examples, deltas); err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to train classifier for user %d: %v", userID, err))
+	}
+}
+
+// ScoreNewEmails - Évaluer les emails arrivés lors d'une synchronisation (NewMailHandler)
+func (s *ClassifierService) ScoreNewEmails(account *models.EmailAccount, emails []*models.Email) {
+	if _, err := s.score(account.UserID, emails); err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to score new emails for account %d: %v", account.ID, err))
+	}
+}
+
+// Retrain - Lancer la reconstruction du modèle et la réévaluation de tous les emails (un seul job actif par utilisateur)
+func (s *ClassifierService) Retrain(userID int) (*models.Job, error) {
+	job, err := s.jobService.Enqueue(userID, models.JobTypeClassifier, struct{}{})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info(fmt.Sprintf("Classifier retrain requested by user %d (job %s)", userID, job.ID))
+	return job, nil
+}
+
+// RunRetrainJob - Exécuter un job classifier_retrain (JobHandler) : comptes de lexèmes recalculés
+// depuis les exemples, puis scores de tous les emails réévalués par lots
+func (s *ClassifierService) RunRetrainJob(ctx context.Context, job *models.Job, report JobReporter) error {
+	progress := &models.ClassifierRetrainProgress{}
+	if len(job.Result) > 0 {
+		if err := json.Unmarshal(job.Result, progress); err != nil {
+			return fmt.Errorf("invalid classifier job progress: %w", err)
+		}
+	}
+
+	if !progress.Rebuilt {
+		if err := s.classifierRepo.Rebuild(job.UserID); err != nil {
+			return err
+		}
+		progress.Rebuilt = true
+		report(progress)
+	}
+
+	stats, err := s.classifierRepo.GetStats(job.UserID)
+	if err != nil {
+		return err
+	}
+	if !classifierReady(stats) {
+		// Pas assez d'exemples : d'anciens scores ne doivent pas subsister
+		if err := s.emailRepo.ClearJunkScores(job.UserID); err != nil {
+			return err
+		}
+		report(progress)
+		return nil
+	}
+
+	accounts, err := s.accountService.GetUserAccounts(job.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get user accounts: %w", err)
+	}
+	accountIDs := make([]int, len(accounts))
+	for i, account := range accounts {
+		accountIDs[i] = account.ID
+	}
+
+	for len(accountIDs) > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		ids, err := s.emailRepo.GetIDsByFilter(accountIDs, &models.EmailFilter{}, progress.LastID, classifierChunkSize)
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			break
+		}
+
+		emails, err := s.emailRepo.GetByIDsForUser(job.UserID, ids)
+		if err != nil {
+			return err
+		}
+		scored, err := s.score(job.UserID, emails)
+		if err != nil {
+			return err
+		}
+
+		progress.Scanned += len(ids)
+		progress.Scored += scored
+		progress.LastID = ids[len(ids)-1]
+		report(progress)
+	}
+
+	s.logger.Info(fmt.Sprintf("Classifier retrained for user %d: %d junk and %d kept examples, %d emails scored",
+		job.UserID, stats.JunkExamples, stats.KeepExamples, progress.Scored))
+	return nil
+}
+
+// Reset - Oublier tout l'apprentissage de l'utilisateur et effacer les scores
+func (s *ClassifierService) Reset(userID int) error {
+	if err := s.classifierRepo.Reset(userID); err != nil {
+		return err
+	}
+	if err := s.emailRepo.ClearJunkScores(userID); err != nil {
+		return err
+	}
+
+	s.logger.Info(fmt.Sprintf("Classifier reset for user %d", userID))
+	return nil
+}
+
+// score - Calculer et enregistrer le score des emails (aucun tant que le modèle n'est pas prêt)
+func (s *ClassifierService) score(userID int, emails []*models.Email) (int, error) {
+	if len(emails) == 0 {
+		return 0, nil
+	}
+
+	stats, err := s.classifierRepo.GetStats(userID)
+	if err != nil {
+		return 0, err
+	}
+	if !classifierReady(stats) {
+		return 0, nil
+	}
+
+	tokensByEmail := make([][]string, len(emails))
+	all := []string{}
+	seen := map[string]bool{}
+	for i, email := range emails {
+		tokensByEmail[i] = junkTokens(email)
+		for _, token := range tokensByEmail[i] {
+			if !seen[token] {
+				seen[token] = true
+				all = append(all, token)
+			}
+		}
+	}
+
+	counts, err := s.classifierRepo.GetTokenCounts(userID, all)
+	if err != nil {
+		return 0, err
+	}
+
+	ids := make([]string, len(emails))
+	scores := make([]float64, len(emails))
+	for i, email := range emails {
+		score := junkScore(stats, counts, tokensByEmail[i])
+		email.JunkScore = &score
+		ids[i] = email.ID
+		scores[i] = score
+	}
+
+	if err := s.emailRepo.UpdateJunkScores(ids, scores); err != nil {
+		return 0, err
+	}
+	return len(emails), nil
+}
+
+// classifierReady - Assez d'exemples de chaque classe pour calculer un score
+func classifierReady(stats *models.ClassifierStats) bool {
+	return stats.JunkExamples >= minClassifierExamples && stats.KeepExamples >= minClassifierExamples && stats.Vocabulary > 0
+}
+
+// addTokenCounts - Ajouter sign occurrence de chaque lexème à la classe indiquée
+func addTokenCounts(deltas map[string]*models.ClassifierTokenCount, tokens []string, isJunk bool, sign int) {
+	for _, token := range tokens {
+		delta := deltas[token]
+		if delta == nil {
+			delta = &models.ClassifierTokenCount{}
+			deltas[token] = delta
+		}
+		if isJunk {
+			delta.Junk += sign
+		} else {
+			delta.Keep += sign
+		}
+	}
+}
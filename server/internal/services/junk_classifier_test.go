@@ -0,0 +1,153 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"tamis-server/internal/models"
+)
+
+func TestJunkWords(t *testing.T) {
+	got := junkWords("Votre facture n°12345 — Été 2024 !")
+	want := []string{"votre", "facture", "#", "été", "#"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("junkWords = %q, want %q", got, want)
+	}
+}
+
+func TestJunkTokens(t *testing.T) {
+	email := &models.Email{
+		From:           "Shop Deals <News@Mail.Shop.Example.com>",
+		Subject:        "Deals -50% today",
+		Headers:        map[string]string{"list-unsubscribe": "<mailto:stop@example.com>", "precedence": "Bulk"},
+		Category:       models.CategoryNewsletter,
+		HasAttachments: true,
+		Labels:         []string{"INBOX", "UNREAD", "CATEGORY_PROMOTIONS"},
+	}
+
+	// Les libellés d'état (INBOX, UNREAD) ne sont pas des lexèmes
+	want := []string{
+		"from:news@mail.shop.example.com",
+		"domain:mail.shop.example.com",
+		"domain:example.com",
+		"name:shop",
+		"name:deals",
+		"subject:deals",
+		"subject:#",
+		"subject:today",
+		"header:list-unsubscribe",
+		"header:precedence",
+		"precedence:bulk",
+		"category:newsletter",
+		"attachment",
+		"label:category_promotions",
+	}
+	if got := junkTokens(email); !reflect.DeepEqual(got, want) {
+		t.Errorf("junkTokens = %q\nwant %q", got, want)
+	}
+}
+
+func TestJunkTokensLimit(t *testing.T) {
+	subject := ""
+	for i := 0; i < maxEmailTokens*2; i++ {
+		subject += string(rune('a'+i%26)) + string(rune('a'+i/26%26)) + " "
+	}
+	if got := junkTokens(&models.Email{Subject: subject}); len(got) != maxEmailTokens {
+		t.Errorf("tokens = %d, want %d", len(got), maxEmailTokens)
+	}
+}
+
+// trainJunkModel - Statistiques et comptes de lexèmes obtenus à partir d'exemples
+func trainJunkModel(junk, keep [][]string) (*models.ClassifierStats, map[string]*models.ClassifierTokenCount) {
+	counts := map[string]*models.ClassifierTokenCount{}
+	for _, tokens := range junk {
+		addTokenCounts(counts, tokens, true, 1)
+	}
+	for _, tokens := range keep {
+		addTokenCounts(counts, tokens, false, 1)
+	}
+
+	stats := &models.ClassifierStats{JunkExamples: len(junk), KeepExamples: len(keep), Vocabulary: len(counts)}
+	for _, count := range counts {
+		stats.JunkTokens += count.Junk
+		stats.KeepTokens += count.Keep
+	}
+	return stats, counts
+}
+
+func repeatTokens(n int, tokens ...string) [][]string {
+	examples := make([][]string, n)
+	for i := range examples {
+		examples[i] = tokens
+	}
+	return examples
+}
+
+func TestJunkScore(t *testing.T) {
+	junk := repeatTokens(minClassifierExamples, "domain:promo.example.com", "header:list-unsubscribe", "subject:soldes")
+	keep := repeatTokens(minClassifierExamples, "domain:example.org", "subject:réunion", "header:list-unsubscribe")
+	stats, counts := trainJunkModel(junk, keep)
+	if !classifierReady(stats) {
+		t.Fatalf("classifier not ready: %+v", stats)
+	}
+
+	cases := []struct {
+		name     string
+		tokens   []string
+		min, max float64
+	}{
+		{"junk tokens", []string{"domain:promo.example.com", "subject:soldes"}, 0.99, 1},
+		{"kept tokens", []string{"domain:example.org", "subject:réunion"}, 0, 0.01},
+		{"shared token is neutral", []string{"header:list-unsubscribe"}, 0.5, 0.5},
+		{"unknown tokens are ignored", []string{"domain:unknown.example", "subject:inconnu"}, 0.5, 0.5},
+		{"mixed evidence", []string{"domain:promo.example.com", "subject:réunion"}, 0.5, 0.5},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			score := junkScore(stats, counts, tc.tokens)
+			if score < tc.min || score > tc.max {
+				t.Errorf("score = %v, want in [%v, %v]", score, tc.min, tc.max)
+			}
+		})
+	}
+}
+
+func TestJunkScorePrior(t *testing.T) {
+	// Sans lexème connu, le score est la proportion d'exemples indésirables, arrondie au millième
+	stats, counts := trainJunkModel(repeatTokens(20, "a"), repeatTokens(10, "b"))
+	if score := junkScore(stats, counts, nil); score != 0.667 {
+		t.Errorf("score = %v, want 0.667", score)
+	}
+}
+
+func TestAddTokenCountsCorrection(t *testing.T) {
+	// Un email appris comme indésirable puis conservé ne compte plus que dans la classe conservée
+	deltas := map[string]*models.ClassifierTokenCount{}
+	tokens := []string{"from:a@example.com", "subject:offre"}
+	addTokenCounts(deltas, tokens, true, 1)
+	addTokenCounts(deltas, tokens, true, -1)
+	addTokenCounts(deltas, tokens, false, 1)
+
+	for _, token := range tokens {
+		if count := deltas[token]; count.Junk != 0 || count.Keep != 1 {
+			t.Errorf("%s = %+v", token, count)
+		}
+	}
+}
+
+func TestJunkLabel(t *testing.T) {
+	cases := map[models.EmailAction][2]bool{
+		models.ActionDelete:     {true, true},
+		models.ActionSpam:       {true, true},
+		models.ActionArchive:    {false, true},
+		models.ActionNotSpam:    {false, true},
+		models.ActionMarkRead:   {false, false},
+		models.ActionMarkUnread: {false, false},
+	}
+	for action, want := range cases {
+		isJunk, ok := junkLabel(action)
+		if isJunk != want[0] || ok != want[1] {
+			t.Errorf("junkLabel(%s) = %v, %v", action, isJunk, ok)
+		}
+	}
+}
@@ -1,27 +1,86 @@
 package services
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
 	"tamis-server/internal/models"
 	"tamis-server/internal/repository"
 	"tamis-server/internal/utils"
 	"time"
 )
 
+// ErrSyncInProgress - Une synchronisation du compte est déjà en cours
+var ErrSyncInProgress = errors.New("sync already in progress")
+
 type MailService struct {
-	emailRepo      *repository.EmailRepository
-	accountService *AccountService
-	logger         *utils.Logger
+	emailRepo       *repository.EmailRepository
+	syncStateRepo   *repository.SyncStateRepository
+	outboxRepo      *repository.OutboxRepository
+	batchRepo       *repository.ActionBatchRepository
+	accountService  *AccountService
+	settingsService *SettingsService
+	tokenManager    *TokenManager
+	confirmer       *ActionConfirmer
+	events          *EventBroker
+	logger          *utils.Logger
+
+	syncMu  sync.Mutex
+	syncing map[int]bool // Comptes en cours de synchronisation (manuelle ou planifiée)
+
+	limiters map[models.EmailProvider]*rateLimiter // Débit des actions par provider
+
+	newMailHandlers []NewMailHandler
+	actionHandlers  []ActionHandler
+	resetHandlers   []ProviderIDsResetHandler
 }
 
-func NewMailService(emailRepo *repository.EmailRepository, accountService *AccountService, logger *utils.Logger) *MailService {
+// NewMailHandler - Traitement appliqué aux emails arrivés lors d'une synchronisation
+type NewMailHandler func(account *models.EmailAccount, emails []*models.Email)
+
+// ActionHandler - Traitement des emails concernés par une action demandée par l'utilisateur
+type ActionHandler func(userID int, action models.EmailAction, emails []*models.Email)
+
+// ProviderIDsResetHandler - Traitement d'un compte dont les provider IDs ont été invalidés (UIDVALIDITY)
+type ProviderIDsResetHandler func(account *models.EmailAccount)
+
+func NewMailService(emailRepo *repository.EmailRepository, syncStateRepo *repository.SyncStateRepository, outboxRepo *repository.OutboxRepository, batchRepo *repository.ActionBatchRepository, accountService *AccountService, settingsService *SettingsService, tokenManager *TokenManager, confirmer *ActionConfirmer, events *EventBroker, logger *utils.Logger) *MailService {
 	return &MailService{
-		emailRepo:      emailRepo,
-		accountService: accountService,
-		logger:         logger,
+		emailRepo:       emailRepo,
+		syncStateRepo:   syncStateRepo,
+		outboxRepo:      outboxRepo,
+		batchRepo:       batchRepo,
+		accountService:  accountService,
+		settingsService: settingsService,
+		tokenManager:    tokenManager,
+		confirmer:       confirmer,
+		events:          events,
+		logger:          logger,
+		syncing:         make(map[int]bool),
+		limiters:        newProviderRateLimiters(),
 	}
 }
 
+// OnNewEmails - Enregistrer un traitement des nouveaux emails (avant le démarrage des synchronisations)
+func (s *MailService) OnNewEmails(handler NewMailHandler) {
+	s.newMailHandlers = append(s.newMailHandlers, handler)
+}
+
+// OnEmailAction - Enregistrer un traitement des actions de l'utilisateur (hors actions automatiques)
+func (s *MailService) OnEmailAction(handler ActionHandler) {
+	s.actionHandlers = append(s.actionHandlers, handler)
+}
+
+// OnProviderIDsReset - Enregistrer un traitement des comptes dont les provider IDs ont été invalidés
+func (s *MailService) OnProviderIDsReset(handler ProviderIDsResetHandler) {
+	s.resetHandlers = append(s.resetHandlers, handler)
+}
+
 // GetUserEmails - Récupérer tous les emails consolidés de l'utilisateur
 func (s *MailService) GetUserEmails(userID int, filter *models.EmailFilter) ([]*models.Email, int, error) {
 	// Récupérer les comptes de l'utilisateur
@@ -52,308 +111,437 @@ func (s *MailService) GetUserEmails(userID int, filter *models.EmailFilter) ([]*
 	return emails, totalCount, nil
 }
 
-// ExecuteEmailAction - Exécuter une action sur des emails
-func (s *MailService) ExecuteEmailAction(userID int, req *models.EmailActionRequest) (*models.EmailActionResult, error) {
-	// Vérifier que les emails appartiennent à l'utilisateur
-	if err := s.validateEmailOwnership(userID, req.EmailIDs); err != nil {
+// SyncUserEmails - Synchroniser les emails depuis les serveurs
+func (s *MailService) SyncUserEmails(userID int, forceSync bool) (*models.EmailSyncResult, error) {
+	progress, err := s.syncUserEmails(context.Background(), userID, forceSync, nil)
+	if err != nil {
 		return nil, err
 	}
+	return progress.Summary, nil
+}
 
-	result := &models.EmailActionResult{
-		Action:       req.Action,
-		ProcessedIDs: []string{},
-		FailedIDs:    []string{},
-		SuccessCount: 0,
-		FailureCount: 0,
-	}
-
-	// Exécuter l'action selon le type
-	switch req.Action {
-	case models.ActionDelete:
-		err := s.executeDeleteAction(req.EmailIDs, req.Force)
-		if err != nil {
-			s.logger.Error(fmt.Sprintf("Delete action failed: %v", err))
-			result.FailedIDs = req.EmailIDs
-			result.FailureCount = len(req.EmailIDs)
-		} else {
-			result.ProcessedIDs = req.EmailIDs
-			result.SuccessCount = len(req.EmailIDs)
-		}
-
-	case models.ActionMarkRead:
-		err := s.executeMarkReadAction(req.EmailIDs, true)
-		if err != nil {
-			result.FailedIDs = req.EmailIDs
-			result.FailureCount = len(req.EmailIDs)
-		} else {
-			result.ProcessedIDs = req.EmailIDs
-			result.SuccessCount = len(req.EmailIDs)
+// RunSyncJob - Exécuter un job de synchronisation (JobHandler) en publiant l'avancement par compte
+func (s *MailService) RunSyncJob(ctx context.Context, job *models.Job, report JobReporter) error {
+	var params models.SyncJobParams
+	if len(job.Params) > 0 {
+		if err := json.Unmarshal(job.Params, &params); err != nil {
+			return fmt.Errorf("invalid sync job params: %w", err)
 		}
+	}
 
-	case models.ActionMarkUnread:
-		err := s.executeMarkReadAction(req.EmailIDs, false)
-		if err != nil {
-			result.FailedIDs = req.EmailIDs
-			result.FailureCount = len(req.EmailIDs)
-		} else {
-			result.ProcessedIDs = req.EmailIDs
-			result.SuccessCount = len(req.EmailIDs)
-		}
+	_, err := s.syncUserEmails(ctx, job.UserID, params.Force, func(progress *models.SyncJobResult) {
+		report(progress)
 
-	case models.ActionArchive:
-		err := s.executeArchiveAction(req.EmailIDs)
+		// Copie figée : progress continue d'évoluer pendant l'envoi de l'événement
+		snapshot, err := json.Marshal(progress)
 		if err != nil {
-			result.FailedIDs = req.EmailIDs
-			result.FailureCount = len(req.EmailIDs)
-		} else {
-			result.ProcessedIDs = req.EmailIDs
-			result.SuccessCount = len(req.EmailIDs)
+			return
 		}
-
-	default:
-		return nil, fmt.Errorf("unsupported action: %s", req.Action)
-	}
-
-	s.logger.Info(fmt.Sprintf("Action %s executed for user %d - Success: %d, Failed: %d",
-		req.Action, userID, result.SuccessCount, result.FailureCount))
-
-	return result, nil
+		s.events.Publish(job.UserID, models.EventSyncProgress, map[string]interface{}{
+			"job_id":   job.ID,
+			"progress": json.RawMessage(snapshot),
+		})
+	})
+	return err
 }
 
-// SyncUserEmails - Synchroniser les emails depuis les serveurs
-func (s *MailService) SyncUserEmails(userID int, forceSync bool) (*models.EmailSyncResult, error) {
+// syncUserEmails - Synchroniser les comptes actifs un par un ; onProgress est appelé après chaque étape
+func (s *MailService) syncUserEmails(ctx context.Context, userID int, forceSync bool, onProgress func(*models.SyncJobResult)) (*models.SyncJobResult, error) {
 	accounts, err := s.accountService.GetUserAccounts(userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user accounts: %w", err)
 	}
 
-	result := &models.EmailSyncResult{
-		SyncedCount:   0,
-		FailedCount:   0,
-		NewEmails:     0,
-		UpdatedEmails: 0,
-		Accounts:      []string{},
+	progress := &models.SyncJobResult{
+		Summary: &models.EmailSyncResult{
+			SyncedCount:   0,
+			FailedCount:   0,
+			NewEmails:     0,
+			UpdatedEmails: 0,
+			Accounts:      []string{},
+		},
+		Accounts: []*models.AccountSyncProgress{},
 	}
 
+	active := []*models.EmailAccount{}
 	for _, account := range accounts {
 		if !account.IsActive {
 			continue
 		}
+		active = append(active, account)
+		progress.Accounts = append(progress.Accounts, &models.AccountSyncProgress{
+			AccountID: account.ID,
+			Email:     account.Email,
+			Status:    models.JobPending,
+		})
+	}
+
+	if onProgress != nil {
+		onProgress(progress)
+	}
+
+	result := progress.Summary
+	for i, account := range active {
+		if err := ctx.Err(); err != nil {
+			return progress, err
+		}
 
-		syncResult, err := s.syncAccountEmails(account, forceSync)
+		accountProgress := progress.Accounts[i]
+		accountProgress.Status = models.JobRunning
+		if onProgress != nil {
+			onProgress(progress)
+		}
+
+		syncResult, err := s.SyncAccount(account, forceSync)
 		if err != nil {
 			s.logger.Error(fmt.Sprintf("Failed to sync account %d: %v", account.ID, err))
 			result.FailedCount++
-			continue
+			accountProgress.Status = models.JobFailed
+			accountProgress.Error = err.Error()
+		} else {
+			result.SyncedCount++
+			result.NewEmails += syncResult.NewEmails
+			result.UpdatedEmails += syncResult.UpdatedEmails
+			result.DeletedEmails += syncResult.DeletedEmails
+			result.Accounts = append(result.Accounts, account.Email)
+
+			accountProgress.Status = models.JobCompleted
+			accountProgress.NewEmails = syncResult.NewEmails
+			accountProgress.UpdatedEmails = syncResult.UpdatedEmails
+			accountProgress.DeletedEmails = syncResult.DeletedEmails
 		}
 
-		result.SyncedCount++
-		result.NewEmails += syncResult.NewEmails
-		result.UpdatedEmails += syncResult.UpdatedEmails
-		result.Accounts = append(result.Accounts, account.Email)
+		if onProgress != nil {
+			onProgress(progress)
+		}
 	}
 
-	s.logger.Info(fmt.Sprintf("Email sync completed for user %d - Accounts: %d, New: %d, Updated: %d",
-		userID, result.SyncedCount, result.NewEmails, result.UpdatedEmails))
+	result.LastSync = time.Now()
+	if onProgress != nil {
+		onProgress(progress)
+	}
 
-	return result, nil
+	s.logger.Info(fmt.Sprintf("Email sync completed for user %d - Accounts: %d, New: %d, Updated: %d, Deleted: %d",
+		userID, result.SyncedCount, result.NewEmails, result.UpdatedEmails, result.DeletedEmails))
+
+	return progress, nil
 }
 
-// validateEmailOwnership - Vérifier que les emails appartiennent à l'utilisateur
-func (s *MailService) validateEmailOwnership(userID int, emailIDs []string) error {
-	for _, emailID := range emailIDs {
-		email, err := s.emailRepo.GetByID(emailID)
-		if err != nil {
-			return fmt.Errorf("email %s not found", emailID)
-		}
+// SyncAccount - Synchroniser un compte, sauf si une synchronisation est déjà en cours
+func (s *MailService) SyncAccount(account *models.EmailAccount, forceSync bool) (*models.AccountSyncResult, error) {
+	if !s.beginSync(account.ID) {
+		return nil, ErrSyncInProgress
+	}
+	defer s.endSync(account.ID)
 
-		// Vérifier que le compte associé appartient à l'utilisateur
-		account, err := s.accountService.accountRepo.GetByID(email.AccountID)
-		if err != nil || account.UserID != userID {
-			return fmt.Errorf("unauthorized access to email %s", emailID)
-		}
+	s.events.Publish(account.UserID, models.EventSyncStarted, map[string]interface{}{
+		"account_id": account.ID,
+	})
+
+	result, err := s.syncAccountEmails(account, forceSync)
+	if err != nil {
+		s.events.Publish(account.UserID, models.EventSyncFailed, map[string]interface{}{
+			"account_id": account.ID,
+			"error":      err.Error(),
+		})
+		return nil, err
+	}
+
+	if err := s.accountService.MarkSynced(account.ID, time.Now()); err != nil {
+		s.logger.Warn(fmt.Sprintf("Failed to record sync time for account %d: %v", account.ID, err))
 	}
-	return nil
-}
 
-// executeDeleteAction - Supprimer des emails
-func (s *MailService) executeDeleteAction(emailIDs []string, force bool) error {
-	if force {
-		// Suppression définitive
-		return s.emailRepo.DeletePermanently(emailIDs)
-	} else {
-		// Marquer comme supprimé (soft delete)
-		return s.emailRepo.MarkAsDeleted(emailIDs)
+	s.events.Publish(account.UserID, models.EventSyncFinished, map[string]interface{}{
+		"account_id": account.ID,
+		"result":     result,
+	})
+	if result.NewEmails > 0 {
+		s.events.Publish(account.UserID, models.EventNewMail, map[string]interface{}{
+			"account_id": account.ID,
+			"count":      result.NewEmails,
+		})
 	}
+
+	if len(result.Inserted) > 0 {
+		for _, handler := range s.newMailHandlers {
+			handler(account, result.Inserted)
+		}
+	}
+
+	return result, nil
 }
 
-// executeMarkReadAction - Marquer des emails comme lus/non lus
-func (s *MailService) executeMarkReadAction(emailIDs []string, isRead bool) error {
-	return s.emailRepo.UpdateReadStatus(emailIDs, isRead)
+// beginSync - Réserver un compte pour la synchronisation (false s'il est déjà réservé)
+func (s *MailService) beginSync(accountID int) bool {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	if s.syncing[accountID] {
+		return false
+	}
+	s.syncing[accountID] = true
+	return true
 }
 
-// executeArchiveAction - Archiver des emails
-func (s *MailService) executeArchiveAction(emailIDs []string) error {
-	return s.emailRepo.ArchiveEmails(emailIDs)
+// endSync - Libérer un compte après synchronisation
+func (s *MailService) endSync(accountID int) {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+	delete(s.syncing, accountID)
 }
 
 // syncAccountEmails - Synchroniser les emails d'un compte spécifique
 func (s *MailService) syncAccountEmails(account *models.EmailAccount, forceSync bool) (*models.AccountSyncResult, error) {
-	// Récupérer les tokens déchiffrés
-	tokens, err := s.accountService.GetDecryptedToken(account.ID)
+	// Récupérer des tokens valides (rafraîchis avant expiration si nécessaire)
+	tokens, err := s.tokenManager.GetValidToken(account)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get tokens: %w", err)
-	}
-
-	// Vérifier si le token a expiré et le rafraîchir si nécessaire
-	if time.Now().After(*tokens.ExpiresAt) {
-		// Implémenter le refresh du token OAuth2
-		s.logger.Info(fmt.Sprintf("Refreshing expired token for account %d", account.ID))
-		// newTokens, err := s.refreshOAuth2Token(account, tokens.RefreshToken)
-		// if err != nil {
-		//     return nil, fmt.Errorf("failed to refresh token: %w", err)
-		// }
-		// tokens = newTokens
+		return nil, err
 	}
 
 	// Connecter au serveur IMAP/API du provider
-	emailClient, err := s.createEmailClient(account.Provider, tokens.AccessToken)
+	emailClient, err := s.createEmailClient(account, tokens.AccessToken)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create email client: %w", err)
 	}
+	defer closeEmailClient(emailClient)
+
+	incremental, ok := emailClient.(IncrementalEmailClient)
+	if !ok {
+		return s.syncRecentEmails(account, emailClient)
+	}
 
-	// Récupérer les emails récents
-	recentEmails, err := emailClient.FetchRecentEmails(100) // Limiter à 100 emails récents
+	// forceSync : oublier le checkpoint et repartir d'une synchronisation complète
+	if forceSync {
+		if err := s.syncStateRepo.DeleteByAccountID(account.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	state, err := s.syncStateRepo.Get(account.ID, incremental.SyncMailbox())
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch emails: %w", err)
+		return nil, err
+	}
+
+	knownIDs, err := s.emailRepo.GetProviderIDsByAccount(account.ID)
+	if err != nil {
+		return nil, err
 	}
 
-	result := &models.AccountSyncResult{
-		NewEmails:     0,
-		UpdatedEmails: 0,
+	changes, err := incremental.FetchChanges(state, knownIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch changes: %w", err)
 	}
 
-	// Traiter chaque email
-	for _, email := range recentEmails {
-		email.AccountID = account.ID
-
-		// Vérifier si l'email existe déjà
-		existingEmail, _ := s.emailRepo.GetByMessageID(email.MessageID, account.ID)
-
-		if existingEmail == nil {
-			// Nouvel email
-			_, err := s.emailRepo.Create(email)
-			if err != nil {
-				s.logger.Error(fmt.Sprintf("Failed to save new email: %v", err))
-				continue
-			}
-			result.NewEmails++
-		} else {
-			// Email existant, mettre à jour si nécessaire
-			if s.emailNeedsUpdate(existingEmail, email) {
-				err := s.emailRepo.Update(email)
-				if err != nil {
-					s.logger.Error(fmt.Sprintf("Failed to update email: %v", err))
-					continue
-				}
-				result.UpdatedEmails++
-			}
+	result := &models.AccountSyncResult{}
+
+	// Les identifiants provider ont changé (UIDVALIDITY) : détacher les emails connus, qui seront
+	// rattachés par Message-ID sans perdre corbeille, annulations, verdicts spam ni archivage
+	if changes.ResetIDs {
+		detached, err := s.emailRepo.DetachProviderIDs(account.ID)
+		if err != nil {
+			return nil, err
+		}
+		s.logger.Warn(fmt.Sprintf("Provider IDs invalidated for account %d, %d cached emails detached", account.ID, detached))
+	}
+
+	for _, email := range changes.Emails {
+		s.saveSyncedEmail(account, email, result)
+	}
+
+	for _, email := range changes.FlagChanges {
+		updated, err := s.emailRepo.UpdateFlagsByProviderID(account.ID, email.ProviderID, email.IsRead, email.IsSpam, email.Labels)
+		if err != nil {
+			s.logger.Error(fmt.Sprintf("Failed to update email flags: %v", err))
+			continue
+		}
+		if updated {
+			result.UpdatedEmails++
+		}
+	}
+
+	if len(changes.DeletedIDs) > 0 {
+		deleted, err := s.emailRepo.DeleteByProviderIDs(account.ID, changes.DeletedIDs)
+		if err != nil {
+			return nil, err
+		}
+		result.DeletedEmails = deleted
+	}
+
+	// Enregistrer le checkpoint seulement après application des changements
+	changes.State.AccountID = account.ID
+	if err := s.syncStateRepo.Save(changes.State); err != nil {
+		return nil, err
+	}
+
+	// Seul le lot initial a été relu : un import complet rattache les emails plus anciens
+	if changes.ResetIDs {
+		for _, handler := range s.resetHandlers {
+			handler(account)
 		}
 	}
 
 	return result, nil
 }
 
+// syncRecentEmails - Synchronisation simple des messages récents (clients sans support incrémental)
+func (s *MailService) syncRecentEmails(account *models.EmailAccount, emailClient EmailClient) (*models.AccountSyncResult, error) {
+	recentEmails, err := emailClient.FetchRecentEmails(initialSyncLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch emails: %w", err)
+	}
+
+	result := &models.AccountSyncResult{}
+	for _, email := range recentEmails {
+		s.saveSyncedEmail(account, email, result)
+	}
+
+	return result, nil
+}
+
+// saveSyncedEmail - Enregistrer un email synchronisé et mettre à jour les compteurs
+func (s *MailService) saveSyncedEmail(account *models.EmailAccount, email *models.Email, result *models.AccountSyncResult) {
+	email.AccountID = account.ID
+	id, err := s.emailRepo.ResolveSyncedID(account.ID, email.ProviderID, email.MessageID, fmt.Sprintf("%d-%s", account.ID, email.ProviderID))
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to resolve email id: %v", err))
+		return
+	}
+	email.ID = id
+	email.Category = classifyEmail(email)
+	email.SpamScore, email.SpamSignals = scoreSpam(email)
+	if email.SpamScore >= models.SpamScoreThreshold {
+		email.IsSpam = true
+	}
+
+	inserted, changed, err := s.emailRepo.Upsert(email)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to save email: %v", err))
+		return
+	}
+
+	if inserted {
+		result.NewEmails++
+		result.Inserted = append(result.Inserted, email)
+	} else if changed {
+		result.UpdatedEmails++
+	}
+}
+
 // createEmailClient - Créer un client email selon le provider
-func (s *MailService) createEmailClient(provider models.EmailProvider, accessToken string) (EmailClient, error) {
+func (s *MailService) createEmailClient(account *models.EmailAccount, accessToken string) (EmailClient, error) {
 	// Factory pattern pour créer le bon client selon le provider
-	switch provider {
+	switch account.Provider {
+	case models.ProviderGmail:
+		return NewGmailClient(accessToken), nil
+	case models.ProviderOutlook:
+		return NewOutlookClient(accessToken), nil
+	case models.ProviderYahoo:
+		return NewYahooClient(account.Email, accessToken), nil
+	default:
+		if account.IMAPHost == "" {
+			return nil, fmt.Errorf("imap host not configured for account %d", account.ID)
+		}
+
+		port := account.IMAPPort
+		if port == 0 {
+			port = 993
+		}
+
+		auth := IMAPAuthXOAuth2
+		if account.AuthType == models.AuthTypePassword {
+			auth = IMAPAuthLogin
+		}
+
+		return NewGenericIMAPClient(IMAPConfig{
+			Addr:     net.JoinHostPort(account.IMAPHost, strconv.Itoa(port)),
+			Username: account.Email,
+			Secret:   accessToken,
+			Auth:     auth,
+		}), nil
+	}
+}
+
+// createMailSender - Expéditeur du compte : API Gmail/Graph, SMTP pour les comptes IMAP
+func (s *MailService) createMailSender(account *models.EmailAccount, accessToken string) (MailSender, error) {
+	switch account.Provider {
 	case models.ProviderGmail:
 		return NewGmailClient(accessToken), nil
 	case models.ProviderOutlook:
 		return NewOutlookClient(accessToken), nil
 	case models.ProviderYahoo:
-		return NewYahooClient(accessToken), nil
+		return NewSMTPClient(SMTPConfig{
+			Addr:     yahooSMTPAddr,
+			Username: account.Email,
+			Secret:   accessToken,
+		}), nil
 	default:
-		return NewGenericIMAPClient(accessToken), nil
+		if account.IMAPHost == "" {
+			return nil, fmt.Errorf("imap host not configured for account %d", account.ID)
+		}
+
+		auth := IMAPAuthXOAuth2
+		if account.AuthType == models.AuthTypePassword {
+			auth = IMAPAuthLogin
+		}
+
+		return NewSMTPClient(SMTPConfig{
+			Addr:     smtpAddrForIMAPHost(account.IMAPHost),
+			Username: account.Email,
+			Secret:   accessToken,
+			Auth:     auth,
+		}), nil
 	}
 }
 
-// emailNeedsUpdate - Vérifier si un email a besoin d'être mis à jour
-func (s *MailService) emailNeedsUpdate(existing, new *models.Email) bool {
-	return existing.IsRead != new.IsRead ||
-		existing.IsSpam != new.IsSpam ||
-		existing.IsDeleted != new.IsDeleted ||
-		len(existing.Labels) != len(new.Labels)
+// closeEmailClient - Fermer les connexions persistantes (IMAP) d'un client
+func closeEmailClient(client EmailClient) {
+	if closer, ok := client.(io.Closer); ok {
+		closer.Close()
+	}
 }
 
 // Interface pour les clients email
 type EmailClient interface {
 	FetchRecentEmails(limit int) ([]*models.Email, error)
 	MarkAsRead(emailID string) error
+	MarkAsUnread(emailID string) error
 	Delete(emailID string) error
 	Archive(emailID string) error
 }
 
-// Implémentations des clients (simplifié)
-type GmailClient struct {
-	accessToken string
+// ReversibleEmailClient - Client capable d'annuler une suppression ou un archivage.
+// messageID permet de retrouver le message quand son identifiant change avec le dossier (IMAP).
+type ReversibleEmailClient interface {
+	Restore(emailID, messageID string) error
+	Unarchive(emailID, messageID string) error
 }
 
-func NewGmailClient(accessToken string) *GmailClient {
-	return &GmailClient{accessToken: accessToken}
+// SpamEmailClient - Client capable de classer un message en indésirable ou de l'en sortir
+type SpamEmailClient interface {
+	MarkAsSpam(emailID string) error
+	MarkAsNotSpam(emailID, messageID string) error
 }
 
-func (c *GmailClient) FetchRecentEmails(limit int) ([]*models.Email, error) {
-	// Implémentation Gmail API
-	// Simulation pour l'exemple
-	return []*models.Email{}, nil
+// PermanentDeleteEmailClient - Client capable de supprimer définitivement un message, sans passage par la corbeille
+type PermanentDeleteEmailClient interface {
+	DeletePermanently(emailID, messageID string) error
 }
 
-func (c *GmailClient) MarkAsRead(emailID string) error {
-	// Implémentation Gmail API
-	return nil
-}
-
-func (c *GmailClient) Delete(emailID string) error {
-	// Implémentation Gmail API
-	return nil
-}
-
-func (c *GmailClient) Archive(emailID string) error {
-	// Implémentation Gmail API
-	return nil
-}
-
-// Clients similaires pour Outlook, Yahoo, etc.
-type OutlookClient struct{ accessToken string }
-type YahooClient struct{ accessToken string }
-type GenericIMAPClient struct{ accessToken string }
-
-func NewOutlookClient(token string) *OutlookClient { return &OutlookClient{accessToken: token} }
-func NewYahooClient(token string) *YahooClient     { return &YahooClient{accessToken: token} }
-func NewGenericIMAPClient(token string) *GenericIMAPClient {
-	return &GenericIMAPClient{accessToken: token}
-}
-
-// Implémentations des méthodes pour chaque client...
-func (c *OutlookClient) FetchRecentEmails(limit int) ([]*models.Email, error) {
-	return []*models.Email{}, nil
-}
-func (c *OutlookClient) MarkAsRead(emailID string) error { return nil }
-func (c *OutlookClient) Delete(emailID string) error     { return nil }
-func (c *OutlookClient) Archive(emailID string) error    { return nil }
+// initialSyncLimit - Nombre de messages importés lors d'une première synchronisation
+const initialSyncLimit = 100
 
-func (c *YahooClient) FetchRecentEmails(limit int) ([]*models.Email, error) {
-	return []*models.Email{}, nil
+// IncrementalEmailClient - Client capable de ne renvoyer que les changements depuis un checkpoint
+type IncrementalEmailClient interface {
+	EmailClient
+	// SyncMailbox - Dossier (ou périmètre) couvert par le checkpoint
+	SyncMailbox() string
+	// FetchChanges - state nil pour une synchronisation initiale ; knownIDs sert à détecter les suppressions
+	FetchChanges(state *models.SyncState, knownIDs []string) (*models.SyncChanges, error)
 }
-func (c *YahooClient) MarkAsRead(emailID string) error { return nil }
-func (c *YahooClient) Delete(emailID string) error     { return nil }
-func (c *YahooClient) Archive(emailID string) error    { return nil }
 
-func (c *GenericIMAPClient) FetchRecentEmails(limit int) ([]*models.Email, error) {
-	return []*models.Email{}, nil
+// PagedEmailClient - Client capable de parcourir toute la boîte page par page (backfill)
+type PagedEmailClient interface {
+	EmailClient
+	// FetchPage - cursor vide pour la première page ; NextCursor vide quand la boîte est épuisée
+	FetchPage(cursor string, pageSize int) (*models.EmailPage, error)
 }
-func (c *GenericIMAPClient) MarkAsRead(emailID string) error { return nil }
-func (c *GenericIMAPClient) Delete(emailID string) error     { return nil }
-func (c *GenericIMAPClient) Archive(emailID string) error    { return nil }
@@ -0,0 +1,209 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"tamis-server/internal/models"
+	"tamis-server/internal/repository"
+	"tamis-server/internal/utils"
+	"time"
+)
+
+const (
+	// outboxPollInterval - Fréquence de traitement des actions en attente
+	outboxPollInterval = 5 * time.Second
+	// outboxBatchSize - Opérations réclamées par passage
+	outboxBatchSize = 100
+	// outboxRetention - Durée de conservation des opérations terminées ou annulées
+	outboxRetention = 7 * 24 * time.Hour
+	// outboxListLimit - Nombre maximal d'opérations renvoyées à l'utilisateur
+	outboxListLimit = 500
+)
+
+// OutboxService - Rejoue en arrière-plan les actions provider différées ou en échec temporaire
+type OutboxService struct {
+	outboxRepo  *repository.OutboxRepository
+	mailService *MailService
+	events      *EventBroker
+	logger      *utils.Logger
+
+	wake chan struct{}
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewOutboxService(outboxRepo *repository.OutboxRepository, mailService *MailService, events *EventBroker, logger *utils.Logger) *OutboxService {
+	return &OutboxService{
+		outboxRepo:  outboxRepo,
+		mailService: mailService,
+		events:      events,
+		logger:      logger,
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// ListOperations - Opérations de l'utilisateur (par défaut celles qui ne sont pas terminées)
+func (s *OutboxService) ListOperations(userID int, statuses []models.OutboxStatus) ([]*models.OutboxOperation, error) {
+	if len(statuses) == 0 {
+		statuses = []models.OutboxStatus{models.OutboxPending, models.OutboxRunning, models.OutboxFailed}
+	}
+
+	for _, status := range statuses {
+		if !isValidOutboxStatus(status) {
+			return nil, fmt.Errorf("invalid outbox status: %s", status)
+		}
+	}
+
+	return s.outboxRepo.GetByUser(userID, statuses, outboxListLimit)
+}
+
+// RetryOperations - Relancer immédiatement des opérations échouées ou annulées
+func (s *OutboxService) RetryOperations(userID int, ids []int64) (int, error) {
+	count, err := s.outboxRepo.Retry(userID, ids)
+	if err != nil {
+		return 0, err
+	}
+
+	if count > 0 {
+		s.logger.Info(fmt.Sprintf("User %d retried %d outbox operations", userID, count))
+		s.notify()
+	}
+	return count, nil
+}
+
+// CancelOperations - Abandonner des opérations qui n'ont pas encore abouti
+func (s *OutboxService) CancelOperations(userID int, ids []int64) (int, error) {
+	count, err := s.outboxRepo.Cancel(userID, ids)
+	if err != nil {
+		return 0, err
+	}
+
+	if count > 0 {
+		s.logger.Info(fmt.Sprintf("User %d cancelled %d outbox operations", userID, count))
+	}
+	return count, nil
+}
+
+// Start - Reprendre les opérations interrompues puis démarrer le worker
+func (s *OutboxService) Start(ctx context.Context) error {
+	requeued, err := s.outboxRepo.RequeueRunning()
+	if err != nil {
+		return err
+	}
+	if requeued > 0 {
+		s.logger.Info(fmt.Sprintf("Requeued %d interrupted outbox operations", requeued))
+	}
+
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.wg.Add(1)
+	go s.worker(ctx)
+
+	return nil
+}
+
+// Stop - Arrêter le worker ; les opérations non traitées restent en attente
+func (s *OutboxService) Stop() {
+	if s.cancel == nil {
+		return
+	}
+
+	s.cancel()
+	s.wg.Wait()
+	s.logger.Info("Outbox worker stopped")
+}
+
+// notify - Réveiller le worker sans bloquer
+func (s *OutboxService) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// worker - Traiter les opérations échues jusqu'à l'arrêt et purger l'historique
+func (s *OutboxService) worker(ctx context.Context) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(outboxPollInterval)
+	defer ticker.Stop()
+
+	purge := time.NewTicker(time.Hour)
+	defer purge.Stop()
+
+	for {
+		for ctx.Err() == nil {
+			ops, err := s.outboxRepo.ClaimDue(outboxBatchSize)
+			if err != nil {
+				s.logger.Error(fmt.Sprintf("Failed to claim outbox operations: %v", err))
+				break
+			}
+			if len(ops) == 0 {
+				break
+			}
+			s.process(ctx, ops)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-ticker.C:
+		case <-purge.C:
+			s.purge()
+		}
+	}
+}
+
+// process - Traiter un lot compte par compte puis notifier chaque utilisateur concerné
+func (s *OutboxService) process(ctx context.Context, ops []*models.OutboxOperation) {
+	groups := map[int][]*models.OutboxOperation{}
+	for _, op := range ops {
+		groups[op.AccountID] = append(groups[op.AccountID], op)
+	}
+
+	accountIDs := make([]int, 0, len(groups))
+	for accountID := range groups {
+		accountIDs = append(accountIDs, accountID)
+	}
+	sort.Ints(accountIDs)
+
+	for _, accountID := range accountIDs {
+		s.mailService.ProcessOutboxOperations(ctx, accountID, groups[accountID], true)
+	}
+
+	summaries := map[int]map[models.OutboxStatus]int{}
+	for _, op := range ops {
+		if summaries[op.UserID] == nil {
+			summaries[op.UserID] = map[models.OutboxStatus]int{}
+		}
+		summaries[op.UserID][op.Status]++
+	}
+
+	for userID, counts := range summaries {
+		s.events.Publish(userID, models.EventOutboxUpdated, counts)
+	}
+}
+
+// purge - Supprimer les opérations terminées depuis plus de outboxRetention
+func (s *OutboxService) purge() {
+	count, err := s.outboxRepo.PurgeCompleted(time.Now().Add(-outboxRetention))
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to purge outbox: %v", err))
+		return
+	}
+	if count > 0 {
+		s.logger.Info(fmt.Sprintf("Purged %d outbox operations", count))
+	}
+}
+
+// isValidOutboxStatus - Statut connu de l'outbox
+func isValidOutboxStatus(status models.OutboxStatus) bool {
+	switch status {
+	case models.OutboxPending, models.OutboxRunning, models.OutboxCompleted, models.OutboxFailed, models.OutboxCancelled:
+		return true
+	}
+	return false
+}
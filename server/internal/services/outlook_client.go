@@ -0,0 +1,379 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"tamis-server/internal/models"
+	"time"
+)
+
+const defaultGraphBaseURL = "https://graph.microsoft.com/v1.0/me"
+
+// graphMessageSizeProperty - Propriété MAPI PR_MESSAGE_SIZE (taille non exposée directement par Graph)
+const graphMessageSizeProperty = "Integer 0x0E08"
+
+// graphMessageSelect - Champs demandés pour construire un models.Email
+const graphMessageSelect = "id,internetMessageId,subject,from,toRecipients,receivedDateTime,isRead,categories,flag,importance,hasAttachments"
+
+// graphListSelect - Champs des listes de messages ; internetMessageHeaders n'est pas disponible via delta
+const graphListSelect = graphMessageSelect + ",internetMessageHeaders"
+
+// OutlookClient - Client Microsoft Graph pour Outlook / Microsoft 365
+type OutlookClient struct {
+	accessToken string
+	baseURL     string
+	httpClient  *http.Client
+}
+
+func NewOutlookClient(accessToken string) *OutlookClient {
+	return NewOutlookClientWithBaseURL(accessToken, defaultGraphBaseURL)
+}
+
+// NewOutlookClientWithBaseURL - Client Graph pointant vers une autre URL (serveur de test)
+func NewOutlookClientWithBaseURL(accessToken, baseURL string) *OutlookClient {
+	return &OutlookClient{
+		accessToken: accessToken,
+		baseURL:     strings.TrimSuffix(baseURL, "/"),
+		httpClient:  newProviderHTTPClient(),
+	}
+}
+
+type graphRecipient struct {
+	EmailAddress struct {
+		Name    string `json:"name"`
+		Address string `json:"address"`
+	} `json:"emailAddress"`
+}
+
+type graphMessage struct {
+	ID                string           `json:"id"`
+	InternetMessageID string           `json:"internetMessageId"`
+	Subject           string           `json:"subject"`
+	From              *graphRecipient  `json:"from"`
+	ToRecipients      []graphRecipient `json:"toRecipients"`
+	ReceivedDateTime  time.Time        `json:"receivedDateTime"`
+	IsRead            bool             `json:"isRead"`
+	Categories        []string         `json:"categories"`
+	Importance        string           `json:"importance"`
+	HasAttachments    bool             `json:"hasAttachments"`
+	Flag              struct {
+		FlagStatus string `json:"flagStatus"`
+	} `json:"flag"`
+	Removed *struct {
+		Reason string `json:"reason"`
+	} `json:"@removed"`
+	InternetMessageHeaders []struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	} `json:"internetMessageHeaders"`
+	SingleValueExtendedProperties []struct {
+		ID    string `json:"id"`
+		Value string `json:"value"`
+	} `json:"singleValueExtendedProperties"`
+}
+
+type graphMessagePage struct {
+	Value     []graphMessage `json:"value"`
+	NextLink  string         `json:"@odata.nextLink"`
+	DeltaLink string         `json:"@odata.deltaLink"`
+}
+
+// graphInitialDeltaWindow - Période couverte par la première requête delta
+const graphInitialDeltaWindow = 30 * 24 * time.Hour
+
+// FetchRecentEmails - Lister les messages de la boîte de réception, du plus récent au plus ancien
+func (c *OutlookClient) FetchRecentEmails(limit int) ([]*models.Email, error) {
+	pageSize := 100
+	if limit > 0 && limit < pageSize {
+		pageSize = limit
+	}
+
+	next := c.inboxMessagesURL(pageSize)
+	emails := []*models.Email{}
+
+	for next != "" && (limit <= 0 || len(emails) < limit) {
+		var page graphMessagePage
+		if err := c.doURL(http.MethodGet, next, nil, &page); err != nil {
+			return nil, err
+		}
+
+		for i := range page.Value {
+			if limit > 0 && len(emails) >= limit {
+				break
+			}
+			emails = append(emails, toGraphEmail(&page.Value[i]))
+		}
+		next = page.NextLink
+	}
+
+	return emails, nil
+}
+
+// FetchPage - Une page de la boîte de réception ; le curseur est le @odata.nextLink
+func (c *OutlookClient) FetchPage(cursor string, pageSize int) (*models.EmailPage, error) {
+	var folder struct {
+		TotalItemCount int `json:"totalItemCount"`
+	}
+	if err := c.do(http.MethodGet, "/mailFolders/inbox?$select=totalItemCount", nil, &folder); err != nil {
+		return nil, err
+	}
+
+	next := cursor
+	if next == "" {
+		next = c.inboxMessagesURL(pageSize)
+	}
+
+	var list graphMessagePage
+	if err := c.doURL(http.MethodGet, next, nil, &list); err != nil {
+		return nil, err
+	}
+
+	page := &models.EmailPage{
+		Emails:         make([]*models.Email, 0, len(list.Value)),
+		NextCursor:     list.NextLink,
+		EstimatedTotal: folder.TotalItemCount,
+	}
+	for i := range list.Value {
+		page.Emails = append(page.Emails, toGraphEmail(&list.Value[i]))
+	}
+
+	return page, nil
+}
+
+// inboxMessagesURL - Première page de la boîte de réception, du plus récent au plus ancien
+func (c *OutlookClient) inboxMessagesURL(pageSize int) string {
+	params := url.Values{}
+	params.Set("$top", strconv.Itoa(pageSize))
+	params.Set("$select", graphListSelect)
+	params.Set("$orderby", "receivedDateTime desc")
+	params.Set("$expand", fmt.Sprintf("singleValueExtendedProperties($filter=id eq '%s')", graphMessageSizeProperty))
+	return c.baseURL + "/mailFolders/inbox/messages?" + params.Encode()
+}
+
+// SyncMailbox - Dossier suivi par la requête delta
+func (c *OutlookClient) SyncMailbox() string {
+	return "inbox"
+}
+
+// FetchChanges - Synchronisation incrémentale via messages/delta et le deltaLink enregistré
+func (c *OutlookClient) FetchChanges(state *models.SyncState, knownIDs []string) (*models.SyncChanges, error) {
+	next := ""
+	if state != nil {
+		next = state.DeltaLink
+	}
+	if next == "" {
+		next = c.initialDeltaURL()
+	}
+
+	known := make(map[string]bool, len(knownIDs))
+	for _, id := range knownIDs {
+		known[id] = true
+	}
+
+	changes := &models.SyncChanges{
+		State: &models.SyncState{Mailbox: c.SyncMailbox()},
+	}
+
+	for next != "" {
+		var page graphMessagePage
+		headers := map[string]string{
+			"Prefer": `IdType="ImmutableId", odata.maxpagesize=100`,
+		}
+		err := doProviderRequestWithHeaders(c.httpClient, "graph", http.MethodGet, next, c.accessToken, headers, nil, &page)
+		if err != nil {
+			// deltaLink expiré : repartir d'une requête delta initiale
+			if apiErr, ok := err.(*ProviderAPIError); ok && apiErr.StatusCode == http.StatusGone && next != c.initialDeltaURL() {
+				changes = &models.SyncChanges{State: &models.SyncState{Mailbox: c.SyncMailbox()}}
+				next = c.initialDeltaURL()
+				continue
+			}
+			return nil, err
+		}
+
+		for i := range page.Value {
+			msg := &page.Value[i]
+			if msg.Removed != nil {
+				changes.DeletedIDs = append(changes.DeletedIDs, msg.ID)
+				continue
+			}
+
+			email := toGraphEmail(msg)
+			if known[msg.ID] {
+				// Message déjà importé : seuls les flags peuvent avoir changé
+				changes.FlagChanges = append(changes.FlagChanges, email)
+				continue
+			}
+
+			// Ni la taille ni les en-têtes ne sont disponibles via delta
+			if details, err := c.messageDetails(msg.ID); err == nil {
+				email.Size = details.Size
+				email.Headers = details.Headers
+			}
+			changes.Emails = append(changes.Emails, email)
+		}
+
+		if page.DeltaLink != "" {
+			changes.State.DeltaLink = page.DeltaLink
+		}
+		next = page.NextLink
+	}
+
+	return changes, nil
+}
+
+// initialDeltaURL - Première requête delta limitée aux messages récents
+func (c *OutlookClient) initialDeltaURL() string {
+	since := time.Now().Add(-graphInitialDeltaWindow).UTC().Truncate(24 * time.Hour)
+
+	params := url.Values{}
+	params.Set("$select", graphMessageSelect)
+	params.Set("$filter", "receivedDateTime ge "+since.Format(time.RFC3339))
+	return c.baseURL + "/mailFolders/inbox/messages/delta?" + params.Encode()
+}
+
+// messageDetails - Lire PR_MESSAGE_SIZE et les en-têtes de diffusion d'un message
+func (c *OutlookClient) messageDetails(emailID string) (*models.Email, error) {
+	params := url.Values{}
+	params.Set("$select", "id,internetMessageHeaders")
+	params.Set("$expand", fmt.Sprintf("singleValueExtendedProperties($filter=id eq '%s')", graphMessageSizeProperty))
+
+	var msg graphMessage
+	if err := c.do(http.MethodGet, "/messages/"+url.PathEscape(emailID)+"?"+params.Encode(), nil, &msg); err != nil {
+		return nil, err
+	}
+	return toGraphEmail(&msg), nil
+}
+
+// MarkAsRead - PATCH isRead
+func (c *OutlookClient) MarkAsRead(emailID string) error {
+	return c.do(http.MethodPatch, "/messages/"+url.PathEscape(emailID), map[string]bool{"isRead": true}, nil)
+}
+
+// MarkAsUnread - PATCH isRead à false
+func (c *OutlookClient) MarkAsUnread(emailID string) error {
+	return c.do(http.MethodPatch, "/messages/"+url.PathEscape(emailID), map[string]bool{"isRead": false}, nil)
+}
+
+// Delete - Déplacer dans "Éléments supprimés"
+func (c *OutlookClient) Delete(emailID string) error {
+	return c.move(emailID, "deleteditems")
+}
+
+// DeletePermanently - Supprimer définitivement via permanentDelete (hors "Éléments supprimés")
+func (c *OutlookClient) DeletePermanently(emailID, messageID string) error {
+	return c.do(http.MethodPost, "/messages/"+url.PathEscape(emailID)+"/permanentDelete", nil, nil)
+}
+
+// Archive - Déplacer dans le dossier Archive
+func (c *OutlookClient) Archive(emailID string) error {
+	return c.move(emailID, "archive")
+}
+
+// Restore - Ramener dans la boîte de réception (l'ID immuable ne change pas)
+func (c *OutlookClient) Restore(emailID, messageID string) error {
+	return c.move(emailID, "inbox")
+}
+
+// Unarchive - Ramener dans la boîte de réception
+func (c *OutlookClient) Unarchive(emailID, messageID string) error {
+	return c.move(emailID, "inbox")
+}
+
+// MarkAsSpam - Déplacer dans "Courrier indésirable"
+func (c *OutlookClient) MarkAsSpam(emailID string) error {
+	return c.move(emailID, "junkemail")
+}
+
+// MarkAsNotSpam - Ramener dans la boîte de réception
+func (c *OutlookClient) MarkAsNotSpam(emailID, messageID string) error {
+	return c.move(emailID, "inbox")
+}
+
+// SendMail - Envoyer un message texte via sendMail, sans copie dans les éléments envoyés
+func (c *OutlookClient) SendMail(msg *OutgoingMail) error {
+	recipient := graphRecipient{}
+	recipient.EmailAddress.Address = msg.To
+
+	payload := map[string]interface{}{
+		"message": map[string]interface{}{
+			"subject": msg.Subject,
+			"body": map[string]string{
+				"contentType": "Text",
+				"content":     msg.Body,
+			},
+			"toRecipients": []graphRecipient{recipient},
+		},
+		"saveToSentItems": false,
+	}
+	return c.do(http.MethodPost, "/sendMail", payload, nil)
+}
+
+// move - Déplacer un message vers un dossier (nom connu ou ID)
+func (c *OutlookClient) move(emailID, destination string) error {
+	return c.do(http.MethodPost, "/messages/"+url.PathEscape(emailID)+"/move",
+		map[string]string{"destinationId": destination}, nil)
+}
+
+// toGraphEmail - Convertir un message Graph en models.Email
+func toGraphEmail(msg *graphMessage) *models.Email {
+	email := &models.Email{
+		ProviderID: msg.ID,
+		MessageID:  msg.InternetMessageID,
+		Subject:    msg.Subject,
+		Date:       msg.ReceivedDateTime,
+		IsRead:     msg.IsRead,
+		To:         []string{},
+		Labels:     []string{"INBOX"},
+
+		HasAttachments: msg.HasAttachments,
+	}
+
+	if msg.From != nil {
+		email.From = msg.From.EmailAddress.Address
+	}
+	for _, recipient := range msg.ToRecipients {
+		email.To = append(email.To, recipient.EmailAddress.Address)
+	}
+
+	email.Labels = append(email.Labels, msg.Categories...)
+	if msg.Flag.FlagStatus == "flagged" {
+		email.Labels = append(email.Labels, "FLAGGED")
+	}
+	if msg.Importance == "high" {
+		email.Labels = append(email.Labels, "IMPORTANT")
+	}
+
+	for _, property := range msg.SingleValueExtendedProperties {
+		if strings.EqualFold(property.ID, graphMessageSizeProperty) {
+			email.Size, _ = strconv.ParseInt(property.Value, 10, 64)
+		}
+	}
+
+	email.Headers = captureHeaders(func(name string) string {
+		for _, header := range msg.InternetMessageHeaders {
+			if strings.EqualFold(header.Name, name) {
+				return header.Value
+			}
+		}
+		return ""
+	})
+
+	if email.MessageID == "" {
+		email.MessageID = fmt.Sprintf("<%s@graph.microsoft.com>", msg.ID)
+	}
+
+	return email
+}
+
+func (c *OutlookClient) do(method, path string, payload, out interface{}) error {
+	return c.doURL(method, c.baseURL+path, payload, out)
+}
+
+// doURL - Les IDs immuables restent valides après un déplacement de dossier
+func (c *OutlookClient) doURL(method, fullURL string, payload, out interface{}) error {
+	headers := map[string]string{"Prefer": `IdType="ImmutableId"`}
+	return doProviderRequestWithHeaders(c.httpClient, "graph", method, fullURL, c.accessToken, headers, payload, out)
+}
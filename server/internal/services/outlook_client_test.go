@@ -0,0 +1,205 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"tamis-server/internal/models"
+)
+
+// fakeGraphMessage - Message Graph minimal tel que renvoyé par la liste ou la requête delta
+func fakeGraphMessage(id string) map[string]interface{} {
+	return map[string]interface{}{
+		"id":                id,
+		"internetMessageId": "<" + id + "@example.com>",
+		"subject":           "Message " + id,
+		"from":              map[string]interface{}{"emailAddress": map[string]string{"name": "Bob", "address": "bob@example.com"}},
+		"toRecipients":      []map[string]interface{}{{"emailAddress": map[string]string{"address": "user@example.com"}}},
+		"receivedDateTime":  "2024-02-01T10:00:00Z",
+		"isRead":            true,
+		"categories":        []string{"Travail"},
+		"importance":        "high",
+		"flag":              map[string]string{"flagStatus": "flagged"},
+		"singleValueExtendedProperties": []map[string]string{
+			{"id": graphMessageSizeProperty, "value": "4096"},
+		},
+	}
+}
+
+func TestGraphFetchRecentEmailsFollowsNextLink(t *testing.T) {
+	var server *httptest.Server
+	requests := []string{}
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/mailFolders/inbox/messages" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		query := r.URL.Query()
+		requests = append(requests, query.Get("$top")+"/"+query.Get("$skiptoken"))
+		switch query.Get("$skiptoken") {
+		case "":
+			writeJSON(t, w, map[string]interface{}{
+				"value":           []interface{}{fakeGraphMessage("a1"), fakeGraphMessage("a2")},
+				"@odata.nextLink": server.URL + "/mailFolders/inbox/messages?$top=2&$skiptoken=p2",
+			})
+		case "p2":
+			writeJSON(t, w, map[string]interface{}{
+				"value":           []interface{}{fakeGraphMessage("a3"), fakeGraphMessage("a4")},
+				"@odata.nextLink": server.URL + "/mailFolders/inbox/messages?$top=2&$skiptoken=p3",
+			})
+		default:
+			t.Errorf("unexpected skip token %q", query.Get("$skiptoken"))
+		}
+	}))
+	defer server.Close()
+
+	emails, err := NewOutlookClientWithBaseURL("token", server.URL).FetchRecentEmails(3)
+	if err != nil {
+		t.Fatalf("FetchRecentEmails: %v", err)
+	}
+
+	// La limite est atteinte au milieu de la deuxième page : la troisième n'est pas demandée
+	if !reflect.DeepEqual(requests, []string{"3/", "2/p2"}) {
+		t.Errorf("requests = %v", requests)
+	}
+	if len(emails) != 3 || emails[2].ProviderID != "a3" {
+		t.Fatalf("emails = %d", len(emails))
+	}
+
+	email := emails[0]
+	if email.MessageID != "<a1@example.com>" || email.From != "bob@example.com" || !email.IsRead || email.Size != 4096 {
+		t.Errorf("email = %+v", email)
+	}
+	if !reflect.DeepEqual(email.Labels, []string{"INBOX", "Travail", "FLAGGED", "IMPORTANT"}) {
+		t.Errorf("labels = %v", email.Labels)
+	}
+}
+
+func TestGraphFetchPage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/mailFolders/inbox":
+			writeJSON(t, w, map[string]interface{}{"totalItemCount": 42})
+		case "/mailFolders/inbox/messages":
+			if r.URL.Query().Get("$skiptoken") != "cursor" {
+				t.Errorf("cursor not followed: %s", r.URL.RawQuery)
+			}
+			writeJSON(t, w, map[string]interface{}{
+				"value":           []interface{}{fakeGraphMessage("b1")},
+				"@odata.nextLink": "next-page",
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := NewOutlookClientWithBaseURL("token", server.URL)
+	page, err := client.FetchPage(server.URL+"/mailFolders/inbox/messages?$skiptoken=cursor", 10)
+	if err != nil {
+		t.Fatalf("FetchPage: %v", err)
+	}
+	if len(page.Emails) != 1 || page.NextCursor != "next-page" || page.EstimatedTotal != 42 {
+		t.Errorf("page = %d emails, cursor %q, total %d", len(page.Emails), page.NextCursor, page.EstimatedTotal)
+	}
+}
+
+func TestGraphFetchChangesDelta(t *testing.T) {
+	var server *httptest.Server
+	details := []string{}
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/mailFolders/inbox/messages/delta":
+			if !strings.Contains(r.Header.Get("Prefer"), `IdType="ImmutableId"`) {
+				t.Errorf("prefer = %q", r.Header.Get("Prefer"))
+			}
+			switch r.URL.Query().Get("$deltatoken") {
+			case "expired":
+				http.Error(w, "sync state not found", http.StatusGone)
+			case "":
+				if r.URL.Query().Get("$skiptoken") == "" {
+					writeJSON(t, w, map[string]interface{}{
+						"value": []interface{}{
+							fakeGraphMessage("known"),
+							map[string]interface{}{"id": "removed", "@removed": map[string]string{"reason": "deleted"}},
+						},
+						"@odata.nextLink": server.URL + "/mailFolders/inbox/messages/delta?$skiptoken=s2",
+					})
+					return
+				}
+				writeJSON(t, w, map[string]interface{}{
+					"value":            []interface{}{fakeGraphMessage("fresh")},
+					"@odata.deltaLink": server.URL + "/mailFolders/inbox/messages/delta?$deltatoken=d2",
+				})
+			}
+		case strings.HasPrefix(r.URL.Path, "/messages/"):
+			id := strings.TrimPrefix(r.URL.Path, "/messages/")
+			details = append(details, id)
+			writeJSON(t, w, map[string]interface{}{
+				"id": id,
+				"internetMessageHeaders": []map[string]string{
+					{"name": "List-Unsubscribe", "value": "<mailto:unsubscribe@example.com>"},
+				},
+				"singleValueExtendedProperties": []map[string]string{
+					{"id": graphMessageSizeProperty, "value": "8192"},
+				},
+			})
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	// Le deltaLink enregistré a expiré : la synchronisation repart d'une requête initiale
+	state := &models.SyncState{DeltaLink: server.URL + "/mailFolders/inbox/messages/delta?$deltatoken=expired"}
+	changes, err := NewOutlookClientWithBaseURL("token", server.URL).FetchChanges(state, []string{"known"})
+	if err != nil {
+		t.Fatalf("FetchChanges: %v", err)
+	}
+
+	if changes.State.DeltaLink != server.URL+"/mailFolders/inbox/messages/delta?$deltatoken=d2" {
+		t.Errorf("delta link = %q", changes.State.DeltaLink)
+	}
+	if changes.State.Mailbox != "inbox" {
+		t.Errorf("mailbox = %q", changes.State.Mailbox)
+	}
+	if !reflect.DeepEqual(changes.DeletedIDs, []string{"removed"}) {
+		t.Errorf("deleted = %v", changes.DeletedIDs)
+	}
+	if len(changes.FlagChanges) != 1 || changes.FlagChanges[0].ProviderID != "known" {
+		t.Errorf("flag changes = %v", changes.FlagChanges)
+	}
+
+	// Seuls les nouveaux messages sont relus pour la taille et les en-têtes
+	if !reflect.DeepEqual(details, []string{"fresh"}) {
+		t.Errorf("details requested for %v", details)
+	}
+	if len(changes.Emails) != 1 {
+		t.Fatalf("emails = %d, want 1", len(changes.Emails))
+	}
+	email := changes.Emails[0]
+	if email.ProviderID != "fresh" || email.Size != 8192 || email.Headers["list-unsubscribe"] == "" {
+		t.Errorf("email = %+v", email)
+	}
+}
+
+func TestGraphDeletePermanently(t *testing.T) {
+	requests := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	if err := NewOutlookClientWithBaseURL("token", server.URL).DeletePermanently("a1", "<a1@example.com>"); err != nil {
+		t.Fatalf("DeletePermanently: %v", err)
+	}
+	if len(requests) != 1 || requests[0] != "POST /messages/a1/permanentDelete" {
+		t.Errorf("requests = %v", requests)
+	}
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"tamis-server/internal/models"
+	"time"
+)
+
+const (
+	// maxProtectedSenders - Taille maximale de la liste VIP
+	maxProtectedSenders = 1000
+	// maxProtectRecentDays - Ancienneté maximale de la protection des emails récents
+	maxProtectRecentDays = 3650
+)
+
+// protectedLabels - Libellés normalisés des emails étoilés, suivis ou importants
+var protectedLabels = map[string]bool{
+	"STARRED":   true,
+	"FLAGGED":   true,
+	"IMPORTANT": true,
+}
+
+// protectionChecker - Règles de protection d'un utilisateur, évaluées email par email.
+// Un checker nil ne protège rien (action non concernée ou dérogation explicite).
+type protectionChecker struct {
+	senders        map[string]bool // Adresses et domaines VIP
+	protectFlagged bool
+	recentSince    time.Time // Zéro si la protection des emails récents est désactivée
+	attachmentMin  int64
+}
+
+// newProtectionChecker - Préparer les règles pour une évaluation à l'instant now
+func newProtectionChecker(rules models.ProtectionRules, now time.Time) *protectionChecker {
+	checker := &protectionChecker{
+		senders:        make(map[string]bool, len(rules.Senders)),
+		protectFlagged: rules.ProtectFlagged,
+		attachmentMin:  rules.AttachmentMinSize,
+	}
+	for _, sender := range rules.Senders {
+		checker.senders[strings.ToLower(sender)] = true
+	}
+	if rules.RecentDays > 0 {
+		checker.recentSince = now.AddDate(0, 0, -rules.RecentDays)
+	}
+	return checker
+}
+
+// reason - Règle protégeant l'email, "" s'il ne l'est pas
+func (p *protectionChecker) reason(email *models.Email) models.ProtectionReason {
+	if p == nil {
+		return ""
+	}
+
+	if len(p.senders) > 0 && p.matchesSender(normalizeSender(email.From)) {
+		return models.ProtectedVIPSender
+	}
+
+	if p.protectFlagged {
+		for _, label := range email.Labels {
+			if protectedLabels[strings.ToUpper(label)] {
+				return models.ProtectedFlagged
+			}
+		}
+	}
+
+	if !p.recentSince.IsZero() && email.Date.After(p.recentSince) {
+		return models.ProtectedRecent
+	}
+
+	if p.attachmentMin > 0 && email.HasAttachments && email.Size >= p.attachmentMin {
+		return models.ProtectedAttachment
+	}
+
+	return ""
+}
+
+// matchesSender - Adresse exacte, domaine ou domaine parent présent dans la liste VIP
+func (p *protectionChecker) matchesSender(address string) bool {
+	if p.senders[address] {
+		return true
+	}
+
+	at := strings.LastIndex(address, "@")
+	if at < 0 {
+		return false
+	}
+
+	for domain := address[at+1:]; strings.Contains(domain, "."); domain = domain[strings.Index(domain, ".")+1:] {
+		if p.senders[domain] {
+			return true
+		}
+	}
+	return false
+}
+
+// filter - Séparer les emails à traiter des emails protégés (raison par ID)
+func (p *protectionChecker) filter(emails []*models.Email) ([]*models.Email, map[string]models.ProtectionReason) {
+	if p == nil {
+		return emails, nil
+	}
+
+	kept := make([]*models.Email, 0, len(emails))
+	protected := map[string]models.ProtectionReason{}
+	for _, email := range emails {
+		if reason := p.reason(email); reason != "" {
+			protected[email.ID] = reason
+			continue
+		}
+		kept = append(kept, email)
+	}
+	return kept, protected
+}
+
+// protectionFor - Règles à appliquer pour une action ; nil si l'action n'est pas protégée ou si l'utilisateur y déroge
+func (s *MailService) protectionFor(userID int, action models.EmailAction, override bool) (*protectionChecker, error) {
+	if override || !models.IsProtectedAction(action) {
+		return nil, nil
+	}
+
+	settings, err := s.settingsService.GetSettings(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load protection rules: %w", err)
+	}
+
+	return newProtectionChecker(settings.Protection, time.Now()), nil
+}
+
+// normalizeProtectionRules - Valider les règles et normaliser la liste VIP (minuscules, sans doublons)
+func normalizeProtectionRules(rules models.ProtectionRules) (models.ProtectionRules, error) {
+	if rules.RecentDays < 0 || rules.RecentDays > maxProtectRecentDays {
+		return rules, fmt.Errorf("protected recent days must be between 0 and %d", maxProtectRecentDays)
+	}
+	if rules.AttachmentMinSize < 0 {
+		return rules, fmt.Errorf("protected attachment size cannot be negative")
+	}
+	if len(rules.Senders) > maxProtectedSenders {
+		return rules, fmt.Errorf("at most %d protected senders are allowed", maxProtectedSenders)
+	}
+
+	seen := make(map[string]bool, len(rules.Senders))
+	senders := make([]string, 0, len(rules.Senders))
+	for _, sender := range rules.Senders {
+		value := strings.TrimPrefix(strings.TrimSpace(sender), "@")
+		if value == "" {
+			continue
+		}
+		value = normalizeSender(value)
+
+		domain := value
+		if at := strings.LastIndex(value, "@"); at >= 0 {
+			domain = value[at+1:]
+		}
+		if domain == "" || !strings.Contains(domain, ".") || strings.ContainsAny(value, " <>") {
+			return rules, fmt.Errorf("invalid protected sender: %s", sender)
+		}
+
+		if !seen[value] {
+			seen[value] = true
+			senders = append(senders, value)
+		}
+	}
+	rules.Senders = senders
+
+	return rules, nil
+}
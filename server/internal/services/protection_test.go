@@ -0,0 +1,107 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"tamis-server/internal/models"
+)
+
+func TestProtectionCheckerReason(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	checker := newProtectionChecker(models.ProtectionRules{
+		Senders:           []string{"Boss@Acme.com", "example.com"},
+		ProtectFlagged:    true,
+		RecentDays:        7,
+		AttachmentMinSize: 1 << 20,
+	}, now)
+	old := now.AddDate(0, 0, -30)
+
+	cases := []struct {
+		name  string
+		email *models.Email
+		want  models.ProtectionReason
+	}{
+		{"vip address", &models.Email{From: "Le patron <boss@acme.com>", Date: old}, models.ProtectedVIPSender},
+		{"other address on vip address domain", &models.Email{From: "sales@acme.com", Date: old}, ""},
+		{"vip domain", &models.Email{From: "news@example.com", Date: old}, models.ProtectedVIPSender},
+		{"subdomain of vip domain", &models.Email{From: "a@x.example.com", Date: old}, models.ProtectedVIPSender},
+		{"lookalike domain", &models.Email{From: "a@notexample.com", Date: old}, ""},
+		{"vip domain as subdomain elsewhere", &models.Email{From: "a@example.com.evil.net", Date: old}, ""},
+		{"flagged", &models.Email{From: "a@other.org", Date: old, Labels: []string{"INBOX", "FLAGGED"}}, models.ProtectedFlagged},
+		{"starred", &models.Email{From: "a@other.org", Date: old, Labels: []string{"starred"}}, models.ProtectedFlagged},
+		{"important", &models.Email{From: "a@other.org", Date: old, Labels: []string{"IMPORTANT"}}, models.ProtectedFlagged},
+		{"ordinary labels", &models.Email{From: "a@other.org", Date: old, Labels: []string{"INBOX", "UNREAD"}}, ""},
+		{"recent", &models.Email{From: "a@other.org", Date: now.AddDate(0, 0, -6)}, models.ProtectedRecent},
+		{"at the cutoff", &models.Email{From: "a@other.org", Date: now.AddDate(0, 0, -7)}, ""},
+		{"just after the cutoff", &models.Email{From: "a@other.org", Date: now.AddDate(0, 0, -7).Add(time.Second)}, models.ProtectedRecent},
+		{"large attachment", &models.Email{From: "a@other.org", Date: old, HasAttachments: true, Size: 1 << 20}, models.ProtectedAttachment},
+		{"small attachment", &models.Email{From: "a@other.org", Date: old, HasAttachments: true, Size: 1<<20 - 1}, ""},
+		{"large without attachment", &models.Email{From: "a@other.org", Date: old, Size: 5 << 20}, ""},
+		{"vip wins over other rules", &models.Email{From: "boss@acme.com", Date: now, Labels: []string{"STARRED"}}, models.ProtectedVIPSender},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := checker.reason(tc.email); got != tc.want {
+				t.Errorf("reason = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestProtectionCheckerDisabledRules(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	email := &models.Email{From: "boss@acme.com", Date: now, Labels: []string{"STARRED"}, HasAttachments: true, Size: 5 << 20}
+
+	if reason := newProtectionChecker(models.ProtectionRules{}, now).reason(email); reason != "" {
+		t.Errorf("empty rules: reason = %q", reason)
+	}
+	var checker *protectionChecker
+	if reason := checker.reason(email); reason != "" {
+		t.Errorf("nil checker: reason = %q", reason)
+	}
+	if kept, protected := checker.filter([]*models.Email{email}); len(kept) != 1 || protected != nil {
+		t.Errorf("nil checker filter = %d kept, %v protected", len(kept), protected)
+	}
+}
+
+func TestNormalizeProtectionRules(t *testing.T) {
+	rules, err := normalizeProtectionRules(models.ProtectionRules{
+		Senders:    []string{" Boss@Acme.com ", "@Example.com", "example.com", "", "Le patron <boss@acme.com>"},
+		RecentDays: 30,
+	})
+	if err != nil {
+		t.Fatalf("normalizeProtectionRules: %v", err)
+	}
+	if want := []string{"boss@acme.com", "example.com"}; !reflect.DeepEqual(rules.Senders, want) {
+		t.Errorf("senders = %q, want %q", rules.Senders, want)
+	}
+
+	tooMany := make([]string, maxProtectedSenders+1)
+	for i := range tooMany {
+		tooMany[i] = "example.com"
+	}
+	cases := []struct {
+		name   string
+		rules  models.ProtectionRules
+		errMsg string
+	}{
+		{"negative recent days", models.ProtectionRules{RecentDays: -1}, "recent days"},
+		{"recent days too large", models.ProtectionRules{RecentDays: maxProtectRecentDays + 1}, "recent days"},
+		{"negative attachment size", models.ProtectionRules{AttachmentMinSize: -1}, "cannot be negative"},
+		{"too many senders", models.ProtectionRules{Senders: tooMany}, "at most"},
+		{"domain without dot", models.ProtectionRules{Senders: []string{"localhost"}}, "invalid protected sender"},
+		{"address without domain", models.ProtectionRules{Senders: []string{"boss@"}}, "invalid protected sender"},
+		{"space", models.ProtectionRules{Senders: []string{"boss @acme.com"}}, "invalid protected sender"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := normalizeProtectionRules(tc.rules)
+			if err == nil || !strings.Contains(err.Error(), tc.errMsg) {
+				t.Errorf("error = %v, want %q", err, tc.errMsg)
+			}
+		})
+	}
+}
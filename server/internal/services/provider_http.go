@@ -0,0 +1,83 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ProviderAPIError - Erreur HTTP renvoyée par l'API d'un provider (Gmail, Graph...)
+type ProviderAPIError struct {
+	Provider   string
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration // En-tête Retry-After (429/503), 0 si absent
+}
+
+func (e *ProviderAPIError) Error() string {
+	return fmt.Sprintf("%s api error (status %d): %s", e.Provider, e.StatusCode, e.Body)
+}
+
+// newProviderHTTPClient - Client HTTP partagé par les clients REST
+func newProviderHTTPClient() *http.Client {
+	return &http.Client{Timeout: 30 * time.Second}
+}
+
+// doProviderRequest - Appeler une API REST authentifiée par Bearer token et décoder la réponse JSON
+func doProviderRequest(client *http.Client, provider, method, url, accessToken string, payload, out interface{}) error {
+	return doProviderRequestWithHeaders(client, provider, method, url, accessToken, nil, payload, out)
+}
+
+// doProviderRequestWithHeaders - Comme doProviderRequest avec des en-têtes supplémentaires
+func doProviderRequestWithHeaders(client *http.Client, provider, method, url, accessToken string, headers map[string]string, payload, out interface{}) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s request: %w", provider, err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s request failed: %w", provider, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		apiErr := &ProviderAPIError{Provider: provider, StatusCode: resp.StatusCode, Body: string(data)}
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			apiErr.RetryAfter = time.Duration(seconds) * time.Second
+		}
+		return apiErr
+	}
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", provider, err)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"tamis-server/internal/models"
+	"time"
+)
+
+// providerRateLimits - Appels provider par seconde (et rafale) pour les actions de nettoyage
+var providerRateLimits = map[models.EmailProvider]struct {
+	rate  float64
+	burst int
+}{
+	models.ProviderGmail:   {rate: 40, burst: 50}, // Quota Gmail : 250 unités/s, ~5 unités par modification
+	models.ProviderOutlook: {rate: 15, burst: 20}, // Graph : 10 000 requêtes / 10 min par boîte
+	models.ProviderYahoo:   {rate: 5, burst: 10},
+	models.ProviderOther:   {rate: 5, burst: 10},
+}
+
+// rateLimiter - Seau à jetons
+type rateLimiter struct {
+	mu       sync.Mutex
+	rate     float64 // Jetons ajoutés par seconde
+	capacity float64
+	tokens   float64
+	last     time.Time
+	now      func() time.Time // Horloge (remplaçable dans les tests)
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	return &rateLimiter{
+		rate:     rate,
+		capacity: float64(burst),
+		tokens:   float64(burst),
+		last:     time.Now(),
+		now:      time.Now,
+	}
+}
+
+// newProviderRateLimiters - Un seau par provider
+func newProviderRateLimiters() map[models.EmailProvider]*rateLimiter {
+	limiters := make(map[models.EmailProvider]*rateLimiter, len(providerRateLimits))
+	for provider, limit := range providerRateLimits {
+		limiters[provider] = newRateLimiter(limit.rate, limit.burst)
+	}
+	return limiters
+}
+
+// reserve - Prendre un jeton ; renvoie l'attente nécessaire s'il n'y en a pas (appelé sous verrou)
+func (l *rateLimiter) reserve() time.Duration {
+	now := l.now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.capacity {
+		l.tokens = l.capacity
+	}
+	l.last = now
+
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+	return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
+
+// Allow - Prendre un jeton sans attendre
+func (l *rateLimiter) Allow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.reserve() == 0
+}
+
+// Wait - Attendre un jeton (ou l'annulation du contexte)
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		wait := l.reserve()
+		l.mu.Unlock()
+
+		if wait == 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiterReserve(t *testing.T) {
+	clock := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	limiter := newRateLimiter(2, 3)
+	limiter.now = func() time.Time { return clock }
+	limiter.last = clock
+
+	steps := []struct {
+		name    string
+		advance time.Duration
+		want    time.Duration
+	}{
+		{"burst 1", 0, 0},
+		{"burst 2", 0, 0},
+		{"burst 3", 0, 0},
+		{"empty bucket", 0, 500 * time.Millisecond},
+		{"still empty", 0, 500 * time.Millisecond},
+		{"half refilled", 250 * time.Millisecond, 250 * time.Millisecond},
+		{"refilled", 250 * time.Millisecond, 0},
+		{"capped at burst", time.Hour, 0},
+		{"capped at burst 2", 0, 0},
+		{"capped at burst 3", 0, 0},
+		{"empty again", 0, 500 * time.Millisecond},
+	}
+	for _, step := range steps {
+		clock = clock.Add(step.advance)
+		if got := limiter.reserve(); got != step.want {
+			t.Errorf("%s: reserve = %v, want %v", step.name, got, step.want)
+		}
+	}
+}
+
+func TestRateLimiterAllow(t *testing.T) {
+	clock := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	limiter := newRateLimiter(1, 1)
+	limiter.now = func() time.Time { return clock }
+	limiter.last = clock
+
+	if !limiter.Allow() {
+		t.Fatal("first call refused")
+	}
+	// Un refus ne consomme pas de jeton : une seconde plus tard, le jeton suivant est disponible
+	if limiter.Allow() {
+		t.Fatal("second call allowed without a token")
+	}
+	clock = clock.Add(time.Second)
+	if !limiter.Allow() {
+		t.Error("call refused after refill")
+	}
+}
@@ -0,0 +1,347 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"tamis-server/internal/models"
+	"tamis-server/internal/repository"
+	"tamis-server/internal/utils"
+	"time"
+)
+
+const (
+	// retentionCheckInterval - Fréquence de recherche des politiques à exécuter
+	retentionCheckInterval = time.Hour
+	// retentionRunInterval - Délai minimal entre deux exécutions d'une même politique
+	retentionRunInterval = 24 * time.Hour
+	// retentionChunkSize - Nombre d'emails traités par lot lors d'une exécution
+	retentionChunkSize = 500
+	// retentionHistory - Durée de conservation du journal des exécutions
+	retentionHistory = 90 * 24 * time.Hour
+	// maxRetentionDays - Ancienneté maximale configurable
+	maxRetentionDays = 3650
+	// retentionRunsPageSize - Nombre d'exécutions retournées par défaut
+	retentionRunsPageSize = 50
+)
+
+// RetentionService - Politiques de rétention par compte, exécutées périodiquement
+type RetentionService struct {
+	retentionRepo  *repository.RetentionRepository
+	emailRepo      *repository.EmailRepository
+	accountService *AccountService
+	mailService    *MailService
+	events         *EventBroker
+	logger         *utils.Logger
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewRetentionService(retentionRepo *repository.RetentionRepository, emailRepo *repository.EmailRepository, accountService *AccountService, mailService *MailService, events *EventBroker, logger *utils.Logger) *RetentionService {
+	return &RetentionService{
+		retentionRepo:  retentionRepo,
+		emailRepo:      emailRepo,
+		accountService: accountService,
+		mailService:    mailService,
+		events:         events,
+		logger:         logger,
+	}
+}
+
+// GetPolicies - Politiques de l'utilisateur
+func (s *RetentionService) GetPolicies(userID int) ([]*models.RetentionPolicy, error) {
+	return s.retentionRepo.GetPoliciesByUser(userID)
+}
+
+// CreatePolicy - Valider et enregistrer une politique
+func (s *RetentionService) CreatePolicy(userID int, req *models.RetentionPolicyRequest) (*models.RetentionPolicy, error) {
+	policy, err := s.buildPolicy(userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.retentionRepo.CreatePolicy(policy); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info(fmt.Sprintf("Retention policy %d created for user %d (account %d)", policy.ID, userID, policy.AccountID))
+	return policy, nil
+}
+
+// UpdatePolicy - Remplacer une politique existante
+func (s *RetentionService) UpdatePolicy(userID, id int, req *models.RetentionPolicyRequest) (*models.RetentionPolicy, error) {
+	policy, err := s.buildPolicy(userID, req)
+	if err != nil {
+		return nil, err
+	}
+	policy.ID = id
+
+	updated, err := s.retentionRepo.UpdatePolicy(policy)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, fmt.Errorf("retention policy not found")
+	}
+
+	return policy, nil
+}
+
+// DeletePolicy - Supprimer une politique (son journal d'exécutions est conservé)
+func (s *RetentionService) DeletePolicy(userID, id int) error {
+	deleted, err := s.retentionRepo.DeletePolicy(userID, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("retention policy not found")
+	}
+	return nil
+}
+
+// GetRuns - Dernières exécutions des politiques de l'utilisateur
+func (s *RetentionService) GetRuns(userID, limit int) ([]*models.RetentionRun, error) {
+	if limit <= 0 || limit > retentionRunsPageSize {
+		limit = retentionRunsPageSize
+	}
+	return s.retentionRepo.GetRuns(userID, limit)
+}
+
+// GetRun - Exécution et journal des emails concernés
+func (s *RetentionService) GetRun(userID int, id int64) (*models.RetentionRunDetail, error) {
+	run, err := s.retentionRepo.GetRun(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if run == nil {
+		return nil, fmt.Errorf("retention run not found")
+	}
+
+	items, err := s.retentionRepo.GetRunItems(run.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.RetentionRunDetail{RetentionRun: *run, Items: items}, nil
+}
+
+// Start - Lancer l'exécution périodique des politiques
+func (s *RetentionService) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.wg.Add(1)
+	go s.worker(ctx)
+}
+
+// Stop - Arrêter l'exécution périodique (une exécution interrompue reprend au prochain démarrage)
+func (s *RetentionService) Stop() {
+	if s.cancel == nil {
+		return
+	}
+
+	s.cancel()
+	s.wg.Wait()
+	s.logger.Info("Retention policies stopped")
+}
+
+// worker - Exécuter les politiques dues au démarrage puis à intervalle régulier
+func (s *RetentionService) worker(ctx context.Context) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(retentionCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		s.runDuePolicies(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runDuePolicies - Exécuter les politiques qui n'ont pas tourné depuis retentionRunInterval
+func (s *RetentionService) runDuePolicies(ctx context.Context) {
+	now := time.Now()
+
+	policies, err := s.retentionRepo.GetDuePolicies(now.Add(-retentionRunInterval))
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to load retention policies: %v", err))
+		return
+	}
+
+	for _, policy := range policies {
+		if ctx.Err() != nil {
+			return
+		}
+		s.runPolicy(ctx, policy)
+	}
+
+	if _, err := s.retentionRepo.PurgeRuns(now.Add(-retentionHistory)); err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to purge retention runs: %v", err))
+	}
+}
+
+// runPolicy - Appliquer une politique par lots en respectant les règles de protection,
+// journaliser chaque email concerné et publier le résumé de l'exécution
+func (s *RetentionService) runPolicy(ctx context.Context, policy *models.RetentionPolicy) {
+	account, err := s.accountService.GetUserAccount(policy.UserID, policy.AccountID)
+	if err != nil || !account.IsActive {
+		return
+	}
+
+	run := &models.RetentionRun{
+		UserID:    policy.UserID,
+		PolicyID:  policy.ID,
+		AccountID: policy.AccountID,
+		Action:    policy.Action,
+		StartedAt: time.Now(),
+	}
+	if err := s.retentionRepo.CreateRun(run); err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to start retention policy %d: %v", policy.ID, err))
+		return
+	}
+
+	before := run.StartedAt.AddDate(0, 0, -policy.OlderThanDays)
+	afterID := ""
+	for {
+		if ctx.Err() != nil {
+			run.Error = "interrupted by server shutdown"
+			break
+		}
+
+		ids, err := s.emailRepo.GetRetentionCandidates(policy, before, afterID, retentionChunkSize)
+		if err != nil {
+			run.Error = err.Error()
+			break
+		}
+		if len(ids) == 0 {
+			break
+		}
+		afterID = ids[len(ids)-1]
+
+		if err := s.applyChunk(run, policy, ids); err != nil {
+			run.Error = err.Error()
+			break
+		}
+	}
+
+	finishedAt := time.Now()
+	run.FinishedAt = &finishedAt
+	if err := s.retentionRepo.FinishRun(run); err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to record retention run %d: %v", run.ID, err))
+	}
+
+	// Une exécution interrompue par l'arrêt du serveur est rejouée au prochain démarrage
+	if ctx.Err() == nil {
+		if err := s.retentionRepo.MarkPolicyRun(policy.ID, run.StartedAt); err != nil {
+			s.logger.Error(err.Error())
+		}
+	}
+
+	if run.Error != "" {
+		s.logger.Error(fmt.Sprintf("Retention policy %d stopped for account %d: %s", policy.ID, policy.AccountID, run.Error))
+	}
+	s.logger.Info(fmt.Sprintf("Retention policy %d (%s older than %d days) on account %d: %d matched, %d processed, %d failed, %d pending, %d protected",
+		policy.ID, policy.Action, policy.OlderThanDays, policy.AccountID,
+		run.MatchedCount, run.SuccessCount, run.FailureCount, run.PendingCount, run.SkippedCount))
+
+	s.events.Publish(policy.UserID, models.EventRetentionRun, run)
+}
+
+// applyChunk - Exécuter l'action sur un lot et journaliser le sort de chaque email
+func (s *RetentionService) applyChunk(run *models.RetentionRun, policy *models.RetentionPolicy, ids []string) error {
+	emails, err := s.emailRepo.GetByIDsForUser(policy.UserID, ids)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.mailService.executeEmailAction(policy.UserID, &models.EmailActionRequest{
+		EmailIDs: ids,
+		Action:   policy.Action,
+	}, actionOptions{automated: true})
+	if err != nil {
+		return err
+	}
+
+	outcomes := make(map[string]models.RetentionOutcome, len(ids))
+	for _, id := range result.ProcessedIDs {
+		outcomes[id] = models.RetentionProcessed
+	}
+	for _, id := range result.PendingIDs {
+		outcomes[id] = models.RetentionPending
+	}
+	for _, id := range result.FailedIDs {
+		outcomes[id] = models.RetentionFailed
+	}
+	for _, id := range result.SkippedIDs {
+		outcomes[id] = models.RetentionSkipped
+	}
+
+	items := make([]*models.RetentionRunItem, 0, len(emails))
+	for _, email := range emails {
+		outcome, ok := outcomes[email.ID]
+		if !ok {
+			continue
+		}
+		reason := result.Errors[email.ID]
+		if outcome == models.RetentionSkipped {
+			reason = result.SkipReasons[email.ID]
+		}
+		items = append(items, &models.RetentionRunItem{
+			EmailID: email.ID,
+			From:    email.From,
+			Subject: email.Subject,
+			Date:    email.Date,
+			Outcome: outcome,
+			Reason:  reason,
+		})
+	}
+	if err := s.retentionRepo.AddRunItems(run.ID, items); err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to log retention run %d: %v", run.ID, err))
+	}
+
+	run.MatchedCount += len(ids)
+	run.SuccessCount += result.SuccessCount
+	run.FailureCount += result.FailureCount
+	run.PendingCount += result.PendingCount
+	run.SkippedCount += result.SkippedCount
+	return nil
+}
+
+// buildPolicy - Valider une requête et construire la politique correspondante
+func (s *RetentionService) buildPolicy(userID int, req *models.RetentionPolicyRequest) (*models.RetentionPolicy, error) {
+	if req.AccountID == 0 {
+		return nil, fmt.Errorf("account_id is required")
+	}
+	if _, err := s.accountService.GetUserAccount(userID, req.AccountID); err != nil {
+		return nil, err
+	}
+
+	if req.OlderThanDays < 1 || req.OlderThanDays > maxRetentionDays {
+		return nil, fmt.Errorf("older_than_days must be between 1 and %d", maxRetentionDays)
+	}
+
+	switch req.Action {
+	case models.ActionDelete, models.ActionArchive, models.ActionMarkRead:
+	default:
+		return nil, fmt.Errorf("unsupported retention action: %s", req.Action)
+	}
+
+	if req.Category != "" && !req.Category.IsValid() {
+		return nil, fmt.Errorf("invalid category: %s", req.Category)
+	}
+
+	return &models.RetentionPolicy{
+		UserID:        userID,
+		AccountID:     req.AccountID,
+		Label:         strings.TrimSpace(req.Label),
+		Category:      req.Category,
+		OlderThanDays: req.OlderThanDays,
+		Action:        req.Action,
+		Enabled:       req.Enabled == nil || *req.Enabled,
+	}, nil
+}
@@ -0,0 +1,342 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"tamis-server/internal/models"
+	"time"
+)
+
+const (
+	// maxRuleDepth - Profondeur maximale de l'arbre de conditions d'une règle
+	maxRuleDepth = 8
+	// maxRuleConditions - Nombre maximal de comparaisons dans une règle
+	maxRuleConditions = 50
+)
+
+// ruleActionOrder - Ordre d'exécution des actions déclenchées par les règles :
+// l'état de lecture d'abord, le déplacement (archive, corbeille...) ensuite
+var ruleActionOrder = []models.EmailAction{
+	models.ActionMarkRead,
+	models.ActionMarkUnread,
+	models.ActionArchive,
+	models.ActionUnarchive,
+	models.ActionNotSpam,
+	models.ActionSpam,
+	models.ActionDelete,
+}
+
+// ruleMatcher - Condition compilée, évaluée à l'instant now
+type ruleMatcher func(email *models.Email, now time.Time) bool
+
+// compiledRule - Règle active prête à être évaluée
+type compiledRule struct {
+	rule  *models.CleanupRule
+	match ruleMatcher
+}
+
+// isRuleAction - Action qu'une règle peut déclencher
+func isRuleAction(action models.EmailAction) bool {
+	for _, candidate := range ruleActionOrder {
+		if candidate == action {
+			return isSupportedAction(action)
+		}
+	}
+	return false
+}
+
+// ruleActionSlot - Les actions d'un même groupe s'excluent : seule la plus prioritaire s'applique à un email
+func ruleActionSlot(action models.EmailAction) string {
+	if action == models.ActionMarkRead || action == models.ActionMarkUnread {
+		return "read"
+	}
+	return "location"
+}
+
+// compileRule - Valider une règle et préparer son évaluation
+func compileRule(rule *models.CleanupRule) (*compiledRule, error) {
+	if !isRuleAction(rule.Action) {
+		return nil, fmt.Errorf("unsupported rule action: %s", rule.Action)
+	}
+	if rule.Conditions == nil {
+		return nil, fmt.Errorf("rule conditions are required")
+	}
+
+	count := 0
+	match, err := compileCondition(rule.Conditions, 1, &count)
+	if err != nil {
+		return nil, err
+	}
+
+	return &compiledRule{rule: rule, match: match}, nil
+}
+
+// compileCondition - Compiler un nœud (groupe ou comparaison) et ses enfants
+func compileCondition(cond *models.RuleCondition, depth int, count *int) (ruleMatcher, error) {
+	if cond == nil {
+		return nil, fmt.Errorf("empty rule condition")
+	}
+	if depth > maxRuleDepth {
+		return nil, fmt.Errorf("rule conditions are nested deeper than %d levels", maxRuleDepth)
+	}
+
+	var match ruleMatcher
+	var err error
+
+	switch {
+	case len(cond.All) > 0 || len(cond.Any) > 0:
+		if len(cond.All) > 0 && len(cond.Any) > 0 {
+			return nil, fmt.Errorf("a rule condition cannot combine all and any")
+		}
+		if cond.Field != "" {
+			return nil, fmt.Errorf("a rule condition group cannot have a field")
+		}
+		match, err = compileGroup(cond, depth, count)
+	case cond.Field != "":
+		*count++
+		if *count > maxRuleConditions {
+			return nil, fmt.Errorf("a rule cannot have more than %d conditions", maxRuleConditions)
+		}
+		match, err = compileComparison(cond)
+	default:
+		return nil, fmt.Errorf("a rule condition needs a field or a group")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if cond.Negate {
+		inner := match
+		match = func(email *models.Email, now time.Time) bool { return !inner(email, now) }
+	}
+	return match, nil
+}
+
+// compileGroup - ET (all) ou OU (any) des conditions enfants
+func compileGroup(cond *models.RuleCondition, depth int, count *int) (ruleMatcher, error) {
+	children := cond.All
+	if len(cond.Any) > 0 {
+		children = cond.Any
+	}
+
+	matchers := make([]ruleMatcher, 0, len(children))
+	for _, child := range children {
+		match, err := compileCondition(child, depth+1, count)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, match)
+	}
+
+	if len(cond.Any) > 0 {
+		return func(email *models.Email, now time.Time) bool {
+			for _, match := range matchers {
+				if match(email, now) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	}
+
+	return func(email *models.Email, now time.Time) bool {
+		for _, match := range matchers {
+			if !match(email, now) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// compileComparison - Comparaison d'un champ avec la valeur de la condition
+func compileComparison(cond *models.RuleCondition) (ruleMatcher, error) {
+	value := string(cond.Value)
+
+	switch cond.Field {
+	case models.RuleFieldSender, models.RuleFieldDomain, models.RuleFieldTo, models.RuleFieldSubject,
+		models.RuleFieldHeader, models.RuleFieldLabel, models.RuleFieldCategory:
+		values, err := ruleTextValues(cond)
+		if err != nil {
+			return nil, err
+		}
+		if cond.Operator == models.RuleOpExists {
+			if cond.Field != models.RuleFieldHeader {
+				return nil, fmt.Errorf("operator exists only applies to headers")
+			}
+			return func(email *models.Email, now time.Time) bool {
+				return len(values(email)) > 0
+			}, nil
+		}
+		test, err := compileTextTest(cond.Operator, value)
+		if err != nil {
+			return nil, err
+		}
+		return func(email *models.Email, now time.Time) bool {
+			for _, candidate := range values(email) {
+				if test(candidate) {
+					return true
+				}
+			}
+			return false
+		}, nil
+
+	case models.RuleFieldAgeDays, models.RuleFieldSize:
+		limit, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil || limit < 0 {
+			return nil, fmt.Errorf("invalid %s value: %q", cond.Field, value)
+		}
+		if cond.Operator != models.RuleOpEquals && cond.Operator != models.RuleOpGreater && cond.Operator != models.RuleOpLess {
+			return nil, fmt.Errorf("operator %s does not apply to %s", cond.Operator, cond.Field)
+		}
+		field, operator := cond.Field, cond.Operator
+		return func(email *models.Email, now time.Time) bool {
+			actual := email.Size
+			if field == models.RuleFieldAgeDays {
+				actual = int64(now.Sub(email.Date) / (24 * time.Hour))
+			}
+			switch operator {
+			case models.RuleOpGreater:
+				return actual > limit
+			case models.RuleOpLess:
+				return actual < limit
+			default:
+				return actual == limit
+			}
+		}, nil
+
+	case models.RuleFieldIsRead, models.RuleFieldIsSpam, models.RuleFieldHasAttachments:
+		expected, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s value: %q", cond.Field, value)
+		}
+		if cond.Operator != "" && cond.Operator != models.RuleOpEquals {
+			return nil, fmt.Errorf("operator %s does not apply to %s", cond.Operator, cond.Field)
+		}
+		field := cond.Field
+		return func(email *models.Email, now time.Time) bool {
+			switch field {
+			case models.RuleFieldIsRead:
+				return email.IsRead == expected
+			case models.RuleFieldIsSpam:
+				return email.IsSpam == expected
+			default:
+				return email.HasAttachments == expected
+			}
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown rule field: %s", cond.Field)
+}
+
+// ruleTextValues - Valeurs textuelles d'un champ (plusieurs pour les destinataires et les libellés)
+func ruleTextValues(cond *models.RuleCondition) (func(email *models.Email) []string, error) {
+	switch cond.Field {
+	case models.RuleFieldSender:
+		return func(email *models.Email) []string { return []string{normalizeSender(email.From)} }, nil
+	case models.RuleFieldDomain:
+		return func(email *models.Email) []string { return []string{senderDomain(email.From)} }, nil
+	case models.RuleFieldTo:
+		return func(email *models.Email) []string {
+			values := make([]string, len(email.To))
+			for i, to := range email.To {
+				values[i] = normalizeSender(to)
+			}
+			return values
+		}, nil
+	case models.RuleFieldSubject:
+		return func(email *models.Email) []string { return []string{email.Subject} }, nil
+	case models.RuleFieldLabel:
+		return func(email *models.Email) []string { return email.Labels }, nil
+	case models.RuleFieldCategory:
+		return func(email *models.Email) []string { return []string{string(email.Category)} }, nil
+	}
+
+	name := strings.ToLower(strings.TrimSpace(cond.Header))
+	if name == "" {
+		return nil, fmt.Errorf("header name is required for header conditions")
+	}
+	return func(email *models.Email) []string {
+		if value, ok := email.Headers[name]; ok {
+			return []string{value}
+		}
+		return nil
+	}, nil
+}
+
+// compileTextTest - Comparaison textuelle insensible à la casse
+func compileTextTest(operator models.RuleOperator, value string) (func(string) bool, error) {
+	expected := strings.ToLower(value)
+
+	switch operator {
+	case models.RuleOpEquals:
+		return func(actual string) bool { return strings.ToLower(actual) == expected }, nil
+	case models.RuleOpContains:
+		return func(actual string) bool { return strings.Contains(strings.ToLower(actual), expected) }, nil
+	case models.RuleOpStartsWith:
+		return func(actual string) bool { return strings.HasPrefix(strings.ToLower(actual), expected) }, nil
+	case models.RuleOpEndsWith:
+		return func(actual string) bool { return strings.HasSuffix(strings.ToLower(actual), expected) }, nil
+	case models.RuleOpMatches:
+		pattern, err := regexp.Compile("(?i)" + value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q: %w", value, err)
+		}
+		return pattern.MatchString, nil
+	}
+
+	return nil, fmt.Errorf("operator %s does not apply to text fields", operator)
+}
+
+// senderDomain - Domaine de l'adresse de l'expéditeur ("" si absente)
+func senderDomain(from string) string {
+	address := normalizeSender(from)
+	if at := strings.LastIndex(address, "@"); at >= 0 {
+		return address[at+1:]
+	}
+	return ""
+}
+
+// ruleMatches - Actions déclenchées par les règles sur un ensemble d'emails
+type ruleMatches struct {
+	byAction    map[models.EmailAction][]string
+	ruleMatches map[int]int
+	matched     int
+}
+
+// evaluateRules - Évaluer les règles (déjà triées par priorité) sur chaque email.
+// Pour chaque groupe d'actions, la règle la plus prioritaire l'emporte ; stop_processing arrête l'évaluation.
+func evaluateRules(rules []*compiledRule, emails []*models.Email, now time.Time) *ruleMatches {
+	matches := &ruleMatches{
+		byAction:    map[models.EmailAction][]string{},
+		ruleMatches: map[int]int{},
+	}
+
+	for _, email := range emails {
+		slots := map[string]bool{}
+		for _, compiled := range rules {
+			if compiled.rule.AccountID != 0 && compiled.rule.AccountID != email.AccountID {
+				continue
+			}
+			if !compiled.match(email, now) {
+				continue
+			}
+
+			matches.ruleMatches[compiled.rule.ID]++
+			if slot := ruleActionSlot(compiled.rule.Action); !slots[slot] {
+				slots[slot] = true
+				matches.byAction[compiled.rule.Action] = append(matches.byAction[compiled.rule.Action], email.ID)
+			}
+			if compiled.rule.StopProcessing {
+				break
+			}
+		}
+		if len(slots) > 0 {
+			matches.matched++
+		}
+	}
+
+	return matches
+}
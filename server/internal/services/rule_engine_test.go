@@ -0,0 +1,179 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"tamis-server/internal/models"
+)
+
+var ruleTestNow = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func ruleTestEmail() *models.Email {
+	return &models.Email{
+		ID:             "e1",
+		AccountID:      1,
+		Subject:        "[Promo] Soldes d'hiver",
+		From:           "Boutique <Offres@Shop.Example.com>",
+		To:             []string{"Moi <me@example.org>", "team@example.org"},
+		Date:           ruleTestNow.Add(-45 * 24 * time.Hour),
+		Size:           2 << 20,
+		IsRead:         false,
+		HasAttachments: true,
+		Labels:         []string{"INBOX", "Promotions"},
+		Category:       models.CategoryNewsletter,
+		Headers:        map[string]string{"list-unsubscribe": "<mailto:stop@shop.example.com>"},
+	}
+}
+
+func TestCompileRuleMatches(t *testing.T) {
+	cases := []struct {
+		name string
+		cond *models.RuleCondition
+		want bool
+	}{
+		{"sender equals ignores display name and case", &models.RuleCondition{Field: models.RuleFieldSender, Operator: models.RuleOpEquals, Value: "offres@shop.example.com"}, true},
+		{"domain", &models.RuleCondition{Field: models.RuleFieldDomain, Operator: models.RuleOpEndsWith, Value: "example.com"}, true},
+		{"any recipient", &models.RuleCondition{Field: models.RuleFieldTo, Operator: models.RuleOpStartsWith, Value: "team@"}, true},
+		{"subject contains", &models.RuleCondition{Field: models.RuleFieldSubject, Operator: models.RuleOpContains, Value: "SOLDES"}, true},
+		{"subject regex is case-insensitive", &models.RuleCondition{Field: models.RuleFieldSubject, Operator: models.RuleOpMatches, Value: `^\[promo\]`}, true},
+		{"header exists", &models.RuleCondition{Field: models.RuleFieldHeader, Header: "List-Unsubscribe", Operator: models.RuleOpExists}, true},
+		{"missing header", &models.RuleCondition{Field: models.RuleFieldHeader, Header: "x-spam-flag", Operator: models.RuleOpContains, Value: "yes"}, false},
+		{"label", &models.RuleCondition{Field: models.RuleFieldLabel, Operator: models.RuleOpEquals, Value: "promotions"}, true},
+		{"category", &models.RuleCondition{Field: models.RuleFieldCategory, Operator: models.RuleOpEquals, Value: "personal"}, false},
+		{"older than 30 days", &models.RuleCondition{Field: models.RuleFieldAgeDays, Operator: models.RuleOpGreater, Value: "30"}, true},
+		{"exact age", &models.RuleCondition{Field: models.RuleFieldAgeDays, Operator: models.RuleOpEquals, Value: "45"}, true},
+		{"smaller than 1 MB", &models.RuleCondition{Field: models.RuleFieldSize, Operator: models.RuleOpLess, Value: "1048576"}, false},
+		{"unread", &models.RuleCondition{Field: models.RuleFieldIsRead, Value: "false"}, true},
+		{"attachments", &models.RuleCondition{Field: models.RuleFieldHasAttachments, Operator: models.RuleOpEquals, Value: "true"}, true},
+		{"negated", &models.RuleCondition{Field: models.RuleFieldIsSpam, Value: "true", Negate: true}, true},
+		{
+			"all fails on one child",
+			&models.RuleCondition{All: []*models.RuleCondition{
+				{Field: models.RuleFieldDomain, Operator: models.RuleOpEquals, Value: "shop.example.com"},
+				{Field: models.RuleFieldIsRead, Value: "true"},
+			}},
+			false,
+		},
+		{
+			"nested any",
+			&models.RuleCondition{All: []*models.RuleCondition{
+				{Field: models.RuleFieldDomain, Operator: models.RuleOpEquals, Value: "shop.example.com"},
+				{Any: []*models.RuleCondition{
+					{Field: models.RuleFieldIsRead, Value: "true"},
+					{Field: models.RuleFieldSize, Operator: models.RuleOpGreater, Value: "1048576"},
+				}},
+			}},
+			true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			compiled, err := compileRule(&models.CleanupRule{Action: models.ActionArchive, Conditions: tc.cond})
+			if err != nil {
+				t.Fatalf("compileRule: %v", err)
+			}
+			if got := compiled.match(ruleTestEmail(), ruleTestNow); got != tc.want {
+				t.Errorf("match = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCompileRuleErrors(t *testing.T) {
+	subject := &models.RuleCondition{Field: models.RuleFieldSubject, Operator: models.RuleOpContains, Value: "x"}
+
+	deep := subject
+	for i := 0; i < maxRuleDepth; i++ {
+		deep = &models.RuleCondition{All: []*models.RuleCondition{deep}}
+	}
+	many := &models.RuleCondition{}
+	for i := 0; i <= maxRuleConditions; i++ {
+		many.Any = append(many.Any, subject)
+	}
+
+	cases := []struct {
+		name   string
+		rule   *models.CleanupRule
+		errMsg string
+	}{
+		{"action", &models.CleanupRule{Action: models.ActionRestore, Conditions: subject}, "unsupported rule action"},
+		{"no conditions", &models.CleanupRule{Action: models.ActionDelete}, "conditions are required"},
+		{"empty node", &models.CleanupRule{Action: models.ActionDelete, Conditions: &models.RuleCondition{}}, "needs a field or a group"},
+		{"all and any", &models.CleanupRule{Action: models.ActionDelete, Conditions: &models.RuleCondition{All: []*models.RuleCondition{subject}, Any: []*models.RuleCondition{subject}}}, "cannot combine"},
+		{"group with field", &models.CleanupRule{Action: models.ActionDelete, Conditions: &models.RuleCondition{Field: models.RuleFieldSubject, All: []*models.RuleCondition{subject}}}, "cannot have a field"},
+		{"too deep", &models.CleanupRule{Action: models.ActionDelete, Conditions: deep}, "nested deeper"},
+		{"too many", &models.CleanupRule{Action: models.ActionDelete, Conditions: many}, "more than"},
+		{"unknown field", &models.CleanupRule{Action: models.ActionDelete, Conditions: &models.RuleCondition{Field: "body", Operator: models.RuleOpContains, Value: "x"}}, "unknown rule field"},
+		{"exists on subject", &models.CleanupRule{Action: models.ActionDelete, Conditions: &models.RuleCondition{Field: models.RuleFieldSubject, Operator: models.RuleOpExists}}, "only applies to headers"},
+		{"header without name", &models.CleanupRule{Action: models.ActionDelete, Conditions: &models.RuleCondition{Field: models.RuleFieldHeader, Operator: models.RuleOpExists}}, "header name is required"},
+		{"invalid regex", &models.CleanupRule{Action: models.ActionDelete, Conditions: &models.RuleCondition{Field: models.RuleFieldSubject, Operator: models.RuleOpMatches, Value: "("}}, "invalid regular expression"},
+		{"text operator on size", &models.CleanupRule{Action: models.ActionDelete, Conditions: &models.RuleCondition{Field: models.RuleFieldSize, Operator: models.RuleOpContains, Value: "10"}}, "does not apply"},
+		{"negative size", &models.CleanupRule{Action: models.ActionDelete, Conditions: &models.RuleCondition{Field: models.RuleFieldSize, Operator: models.RuleOpGreater, Value: "-1"}}, "invalid size value"},
+		{"boolean value", &models.CleanupRule{Action: models.ActionDelete, Conditions: &models.RuleCondition{Field: models.RuleFieldIsRead, Value: "maybe"}}, "invalid is_read value"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := compileRule(tc.rule)
+			if err == nil || !strings.Contains(err.Error(), tc.errMsg) {
+				t.Errorf("error = %v, want %q", err, tc.errMsg)
+			}
+		})
+	}
+}
+
+func TestEvaluateRules(t *testing.T) {
+	compile := func(rule *models.CleanupRule) *compiledRule {
+		t.Helper()
+		compiled, err := compileRule(rule)
+		if err != nil {
+			t.Fatalf("compileRule(%s): %v", rule.Name, err)
+		}
+		return compiled
+	}
+	domain := func(value string) *models.RuleCondition {
+		return &models.RuleCondition{Field: models.RuleFieldDomain, Operator: models.RuleOpEquals, Value: models.RuleValue(value)}
+	}
+
+	rules := []*compiledRule{
+		compile(&models.CleanupRule{ID: 1, Name: "lire", Action: models.ActionMarkRead, Conditions: domain("shop.example.com")}),
+		compile(&models.CleanupRule{ID: 2, Name: "archiver", Action: models.ActionArchive, Conditions: domain("shop.example.com")}),
+		compile(&models.CleanupRule{ID: 3, Name: "supprimer", Action: models.ActionDelete, Conditions: domain("shop.example.com")}),
+		compile(&models.CleanupRule{ID: 4, Name: "compte 2", AccountID: 2, Action: models.ActionSpam, Conditions: domain("news.example.com")}),
+		compile(&models.CleanupRule{ID: 5, Name: "stop", Action: models.ActionMarkUnread, StopProcessing: true, Conditions: domain("news.example.com")}),
+		compile(&models.CleanupRule{ID: 6, Name: "après stop", Action: models.ActionDelete, Conditions: domain("news.example.com")}),
+	}
+
+	emails := []*models.Email{
+		{ID: "shop", AccountID: 1, From: "offres@shop.example.com"},
+		{ID: "news-1", AccountID: 1, From: "news@news.example.com"},
+		{ID: "news-2", AccountID: 2, From: "news@news.example.com"},
+		{ID: "other", AccountID: 1, From: "friend@example.org"},
+	}
+
+	matches := evaluateRules(rules, emails, ruleTestNow)
+
+	// Une seule action par groupe (lecture, emplacement) : la règle la plus prioritaire l'emporte
+	want := map[models.EmailAction][]string{
+		models.ActionMarkRead:   {"shop"},
+		models.ActionArchive:    {"shop"},
+		models.ActionMarkUnread: {"news-1", "news-2"},
+		models.ActionSpam:       {"news-2"},
+	}
+	if !reflect.DeepEqual(matches.byAction, want) {
+		t.Errorf("actions = %v\nwant %v", matches.byAction, want)
+	}
+
+	// Les règles masquées par une règle plus prioritaire comptent leurs correspondances ; stop arrête l'évaluation
+	wantCounts := map[int]int{1: 1, 2: 1, 3: 1, 4: 1, 5: 2}
+	if !reflect.DeepEqual(matches.ruleMatches, wantCounts) {
+		t.Errorf("rule matches = %v, want %v", matches.ruleMatches, wantCounts)
+	}
+	if matches.matched != 3 {
+		t.Errorf("matched = %d, want 3", matches.matched)
+	}
+}
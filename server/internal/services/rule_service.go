@@ -0,0 +1,508 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"tamis-server/internal/models"
+	"tamis-server/internal/repository"
+	"tamis-server/internal/utils"
+	"time"
+)
+
+const (
+	// ruleRunChunkSize - Nombre d'emails évalués par lot lors d'une application sur les emails existants
+	ruleRunChunkSize = 500
+	// maxRulesPerUser - Nombre maximal de règles par utilisateur
+	maxRulesPerUser = 200
+)
+
+// ErrRulesRunning - Une autre application des règles est déjà en cours pour l'utilisateur
+var ErrRulesRunning = errors.New("cleanup rules are already running with other parameters")
+
+// RuleService - Règles de nettoyage de l'utilisateur, appliquées aux nouveaux emails et à la demande
+type RuleService struct {
+	ruleRepo       *repository.CleanupRuleRepository
+	emailRepo      *repository.EmailRepository
+	accountService *AccountService
+	mailService    *MailService
+	jobService     *JobService
+	events         *EventBroker
+	logger         *utils.Logger
+}
+
+func NewRuleService(ruleRepo *repository.CleanupRuleRepository, emailRepo *repository.EmailRepository, accountService *AccountService, mailService *MailService, jobService *JobService, events *EventBroker, logger *utils.Logger) *RuleService {
+	return &RuleService{
+		ruleRepo:       ruleRepo,
+		emailRepo:      emailRepo,
+		accountService: accountService,
+		mailService:    mailService,
+		jobService:     jobService,
+		events:         events,
+		logger:         logger,
+	}
+}
+
+// GetRules - Règles de l'utilisateur dans leur ordre d'évaluation
+func (s *RuleService) GetRules(userID int) ([]*models.CleanupRule, error) {
+	return s.ruleRepo.GetByUser(userID)
+}
+
+// CreateRule - Valider et enregistrer une nouvelle règle
+func (s *RuleService) CreateRule(userID int, req *models.CleanupRuleRequest) (*models.CleanupRule, error) {
+	rule, err := s.buildRule(userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.ruleRepo.GetByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= maxRulesPerUser {
+		return nil, fmt.Errorf("at most %d cleanup rules are allowed", maxRulesPerUser)
+	}
+
+	if err := s.ruleRepo.Create(rule); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info(fmt.Sprintf("Cleanup rule %d created for user %d", rule.ID, userID))
+	return rule, nil
+}
+
+// UpdateRule - Remplacer une règle existante
+func (s *RuleService) UpdateRule(userID, id int, req *models.CleanupRuleRequest) (*models.CleanupRule, error) {
+	rule, err := s.buildRule(userID, req)
+	if err != nil {
+		return nil, err
+	}
+	rule.ID = id
+
+	updated, err := s.ruleRepo.Update(rule)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, fmt.Errorf("cleanup rule not found")
+	}
+
+	return rule, nil
+}
+
+// DeleteRule - Supprimer une règle
+func (s *RuleService) DeleteRule(userID, id int) error {
+	deleted, err := s.ruleRepo.Delete(userID, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("cleanup rule not found")
+	}
+	return nil
+}
+
+// ImportSieve - Convertir un script Sieve en règles, ajoutées après les règles existantes.
+// Les constructions sans équivalent sont ignorées et listées dans le résultat.
+func (s *RuleService) ImportSieve(userID int, req *models.SieveImportRequest) (*models.SieveImportResult, error) {
+	if req.AccountID != 0 {
+		if _, err := s.accountService.GetUserAccount(userID, req.AccountID); err != nil {
+			return nil, err
+		}
+	}
+
+	commands, err := parseSieve(req.Script)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sieve script: %w", err)
+	}
+	converted, issues := convertSieve(commands)
+
+	existing, err := s.ruleRepo.GetByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	priority := 0
+	for _, rule := range existing {
+		if rule.Priority > priority {
+			priority = rule.Priority
+		}
+	}
+
+	result := &models.SieveImportResult{Rules: []*models.CleanupRule{}, Unsupported: issues}
+	for _, item := range converted {
+		if _, err := compileRule(item.rule); err != nil {
+			result.Unsupported = append(result.Unsupported, &models.SieveIssue{Line: item.line, Construct: item.rule.Name, Message: err.Error()})
+			continue
+		}
+
+		priority += 10
+		item.rule.UserID = userID
+		item.rule.AccountID = req.AccountID
+		item.rule.Priority = priority
+		result.Rules = append(result.Rules, item.rule)
+	}
+
+	if len(existing)+len(result.Rules) > maxRulesPerUser {
+		return nil, fmt.Errorf("at most %d cleanup rules are allowed", maxRulesPerUser)
+	}
+	if req.DryRun {
+		return result, nil
+	}
+
+	for _, rule := range result.Rules {
+		if err := s.ruleRepo.Create(rule); err != nil {
+			return nil, err
+		}
+		result.Created++
+	}
+
+	s.logger.Info(fmt.Sprintf("Sieve script imported for user %d: %d rules created, %d constructs unsupported",
+		userID, result.Created, len(result.Unsupported)))
+	return result, nil
+}
+
+// ExportSieve - Script Sieve des règles actives (d'un compte si accountID est renseigné)
+func (s *RuleService) ExportSieve(userID, accountID int) (*models.SieveExport, error) {
+	if accountID != 0 {
+		if _, err := s.accountService.GetUserAccount(userID, accountID); err != nil {
+			return nil, err
+		}
+	}
+
+	rules, err := s.ruleRepo.GetByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	scoped := []*models.CleanupRule{}
+	for _, rule := range rules {
+		if accountID == 0 || rule.AccountID == 0 || rule.AccountID == accountID {
+			scoped = append(scoped, rule)
+		}
+	}
+
+	return exportSieve(scoped), nil
+}
+
+// Preview - Compter les emails existants concernés par les règles, sans rien modifier
+func (s *RuleService) Preview(userID int, req *models.RuleRunRequest) (*models.RuleRunProgress, error) {
+	rules, err := s.loadRules(userID, req.RuleIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	accountIDs, err := s.scopeAccounts(userID, req.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	protections := map[models.EmailAction]*protectionChecker{}
+	for _, action := range ruleActionOrder {
+		if protections[action], err = s.mailService.protectionFor(userID, action, false); err != nil {
+			return nil, err
+		}
+	}
+
+	progress := newRuleRunProgress()
+	afterID := ""
+	for len(accountIDs) > 0 && len(rules) > 0 {
+		emails, lastID, err := s.nextChunk(userID, accountIDs, afterID)
+		if err != nil {
+			return nil, err
+		}
+		if lastID == "" {
+			break
+		}
+		afterID = lastID
+
+		matches := evaluateRules(rules, emails, time.Now())
+		addRuleMatches(progress, matches, len(emails))
+
+		// Emails protégés : comptés à part, ils ne seront pas modifiés
+		byID := make(map[string]*models.Email, len(emails))
+		for _, email := range emails {
+			byID[email.ID] = email
+		}
+		for action, ids := range matches.byAction {
+			for _, id := range ids {
+				if protections[action].reason(byID[id]) != "" {
+					progress.SkippedCount++
+				}
+			}
+		}
+	}
+
+	return progress, nil
+}
+
+// Start - Lancer l'application des règles aux emails existants (un seul job cleanup_rules actif par utilisateur)
+func (s *RuleService) Start(userID int, req *models.RuleRunRequest) (*models.Job, error) {
+	if _, err := s.loadRules(userID, req.RuleIDs); err != nil {
+		return nil, err
+	}
+	if _, err := s.scopeAccounts(userID, req.AccountID); err != nil {
+		return nil, err
+	}
+
+	params := &models.RuleRunParams{AccountID: req.AccountID, RuleIDs: req.RuleIDs}
+	job, err := s.jobService.Enqueue(userID, models.JobTypeRules, params)
+	if err != nil {
+		return nil, err
+	}
+
+	// Enqueue renvoie le job déjà actif : ce n'est pas forcément la même demande
+	var active models.RuleRunParams
+	if err := json.Unmarshal(job.Params, &active); err != nil {
+		return nil, fmt.Errorf("invalid cleanup rules job params: %w", err)
+	}
+	if active.AccountID != params.AccountID || fmt.Sprint(active.RuleIDs) != fmt.Sprint(params.RuleIDs) {
+		return job, ErrRulesRunning
+	}
+
+	s.logger.Info(fmt.Sprintf("Cleanup rules run requested by user %d (job %s)", userID, job.ID))
+	return job, nil
+}
+
+// RunRulesJob - Exécuter un job cleanup_rules (JobHandler) par lots, en reprenant après le dernier lot traité
+func (s *RuleService) RunRulesJob(ctx context.Context, job *models.Job, report JobReporter) error {
+	var params models.RuleRunParams
+	if err := json.Unmarshal(job.Params, &params); err != nil {
+		return fmt.Errorf("invalid cleanup rules job params: %w", err)
+	}
+
+	progress := newRuleRunProgress()
+	if len(job.Result) > 0 {
+		if err := json.Unmarshal(job.Result, progress); err != nil {
+			return fmt.Errorf("invalid cleanup rules job progress: %w", err)
+		}
+	}
+
+	// Règles et comptes relus à chaque reprise (modifiés ou supprimés entre-temps)
+	rules, err := s.loadRules(job.UserID, params.RuleIDs)
+	if err != nil {
+		return err
+	}
+	accountIDs, err := s.scopeAccounts(job.UserID, params.AccountID)
+	if err != nil {
+		return err
+	}
+
+	for len(accountIDs) > 0 && len(rules) > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		emails, lastID, err := s.nextChunk(job.UserID, accountIDs, progress.LastID)
+		if err != nil {
+			return err
+		}
+		if lastID == "" {
+			break
+		}
+
+		matches := evaluateRules(rules, emails, time.Now())
+		addRuleMatches(progress, matches, len(emails))
+		if err := s.applyMatches(job.UserID, matches, progress); err != nil {
+			return err
+		}
+		progress.LastID = lastID
+
+		report(progress)
+		s.events.Publish(job.UserID, models.EventRulesProgress, map[string]interface{}{
+			"job_id":   job.ID,
+			"progress": *progress,
+		})
+	}
+
+	report(progress)
+	s.logger.Info(fmt.Sprintf("Cleanup rules finished for user %d: %d scanned, %d matched, %d failed, %d protected",
+		job.UserID, progress.Scanned, progress.Matched, progress.FailureCount, progress.SkippedCount))
+	return nil
+}
+
+// ApplyToNewEmails - Appliquer les règles actives aux emails arrivés lors d'une synchronisation (NewMailHandler)
+func (s *RuleService) ApplyToNewEmails(account *models.EmailAccount, emails []*models.Email) {
+	rules, err := s.loadRules(account.UserID, nil)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to load cleanup rules for user %d: %v", account.UserID, err))
+		return
+	}
+	if len(rules) == 0 {
+		return
+	}
+
+	matches := evaluateRules(rules, emails, time.Now())
+	if matches.matched == 0 {
+		return
+	}
+
+	progress := newRuleRunProgress()
+	addRuleMatches(progress, matches, len(emails))
+	if err := s.applyMatches(account.UserID, matches, progress); err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to apply cleanup rules for account %d: %v", account.ID, err))
+		return
+	}
+
+	s.logger.Info(fmt.Sprintf("Cleanup rules matched %d new emails for account %d (%d failed, %d protected)",
+		progress.Matched, account.ID, progress.FailureCount, progress.SkippedCount))
+}
+
+// applyMatches - Exécuter les actions déclenchées, état de lecture avant déplacement
+func (s *RuleService) applyMatches(userID int, matches *ruleMatches, progress *models.RuleRunProgress) error {
+	for _, action := range ruleActionOrder {
+		ids := matches.byAction[action]
+		if len(ids) == 0 {
+			continue
+		}
+
+		result, err := s.mailService.executeEmailAction(userID, &models.EmailActionRequest{
+			EmailIDs: ids,
+			Action:   action,
+		}, actionOptions{automated: true})
+		if err != nil {
+			return err
+		}
+
+		progress.SuccessCount += result.SuccessCount
+		progress.FailureCount += result.FailureCount
+		progress.PendingCount += result.PendingCount
+		progress.SkippedCount += result.SkippedCount
+	}
+	return nil
+}
+
+// loadRules - Règles actives compilées (ou celles demandées, même désactivées), par priorité.
+// Au passage automatique, une règle invalide est ignorée plutôt que de bloquer les autres.
+func (s *RuleService) loadRules(userID int, ruleIDs []int) ([]*compiledRule, error) {
+	rules, err := s.ruleRepo.GetByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	requested := make(map[int]bool, len(ruleIDs))
+	for _, id := range ruleIDs {
+		requested[id] = true
+	}
+
+	compiled := []*compiledRule{}
+	for _, rule := range rules {
+		if len(requested) > 0 {
+			if !requested[rule.ID] {
+				continue
+			}
+			delete(requested, rule.ID)
+		} else if !rule.Enabled {
+			continue
+		}
+
+		c, err := compileRule(rule)
+		if err != nil {
+			s.logger.Error(fmt.Sprintf("Skipping invalid cleanup rule %d of user %d: %v", rule.ID, userID, err))
+			continue
+		}
+		compiled = append(compiled, c)
+	}
+
+	if len(requested) > 0 {
+		return nil, fmt.Errorf("cleanup rule not found")
+	}
+
+	return compiled, nil
+}
+
+// nextChunk - Lot suivant d'emails des comptes après afterID ; lastID vide en fin de parcours
+func (s *RuleService) nextChunk(userID int, accountIDs []int, afterID string) ([]*models.Email, string, error) {
+	ids, err := s.emailRepo.GetIDsByFilter(accountIDs, &models.EmailFilter{}, afterID, ruleRunChunkSize)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(ids) == 0 {
+		return nil, "", nil
+	}
+
+	emails, err := s.emailRepo.GetByIDsForUser(userID, ids)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return emails, ids[len(ids)-1], nil
+}
+
+// buildRule - Valider une requête et construire la règle correspondante
+func (s *RuleService) buildRule(userID int, req *models.CleanupRuleRequest) (*models.CleanupRule, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, fmt.Errorf("rule name is required")
+	}
+
+	if req.AccountID != 0 {
+		if _, err := s.accountService.GetUserAccount(userID, req.AccountID); err != nil {
+			return nil, err
+		}
+	}
+
+	rule := &models.CleanupRule{
+		UserID:         userID,
+		AccountID:      req.AccountID,
+		Name:           name,
+		Enabled:        req.Enabled == nil || *req.Enabled,
+		Priority:       req.Priority,
+		Conditions:     req.Conditions,
+		Action:         req.Action,
+		StopProcessing: req.StopProcessing,
+	}
+	if _, err := compileRule(rule); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+// scopeAccounts - Un compte de l'utilisateur (vérifié) ou tous ses comptes actifs
+func (s *RuleService) scopeAccounts(userID, accountID int) ([]int, error) {
+	if accountID != 0 {
+		account, err := s.accountService.GetUserAccount(userID, accountID)
+		if err != nil {
+			return nil, err
+		}
+		if !account.IsActive {
+			return nil, fmt.Errorf("account is inactive")
+		}
+		return []int{account.ID}, nil
+	}
+
+	accounts, err := s.accountService.GetUserAccounts(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user accounts: %w", err)
+	}
+
+	accountIDs := []int{}
+	for _, account := range accounts {
+		if account.IsActive {
+			accountIDs = append(accountIDs, account.ID)
+		}
+	}
+	return accountIDs, nil
+}
+
+// newRuleRunProgress - Avancement vide avec ses compteurs initialisés
+func newRuleRunProgress() *models.RuleRunProgress {
+	return &models.RuleRunProgress{
+		RuleMatches:  map[int]int{},
+		ActionCounts: map[models.EmailAction]int{},
+	}
+}
+
+// addRuleMatches - Cumuler les correspondances d'un lot de scanned emails
+func addRuleMatches(progress *models.RuleRunProgress, matches *ruleMatches, scanned int) {
+	progress.Scanned += scanned
+	progress.Matched += matches.matched
+	for ruleID, count := range matches.ruleMatches {
+		progress.RuleMatches[ruleID] += count
+	}
+	for action, ids := range matches.byAction {
+		progress.ActionCounts[action] += len(ids)
+	}
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"tamis-server/internal/models"
+	"tamis-server/internal/repository"
+	"tamis-server/internal/utils"
+)
+
+const (
+	// senderPageSize - Taille de page par défaut du classement
+	senderPageSize = 50
+	// senderMaxPageSize - Taille de page maximale
+	senderMaxPageSize = 500
+)
+
+// SenderService - Classement des expéditeurs par volume et actions groupées par expéditeur
+type SenderService struct {
+	emailRepo      *repository.EmailRepository
+	accountService *AccountService
+	mailService    *MailService
+	logger         *utils.Logger
+}
+
+func NewSenderService(emailRepo *repository.EmailRepository, accountService *AccountService, mailService *MailService, logger *utils.Logger) *SenderService {
+	return &SenderService{
+		emailRepo:      emailRepo,
+		accountService: accountService,
+		mailService:    mailService,
+		logger:         logger,
+	}
+}
+
+// GetLeaderboard - Expéditeurs (ou domaines) qui occupent le plus la boîte
+func (s *SenderService) GetLeaderboard(userID int, query *models.SenderQuery) (*models.SenderPage, error) {
+	if query.GroupBy == "" {
+		query.GroupBy = models.GroupBySender
+	}
+	if !query.GroupBy.IsValid() {
+		return nil, fmt.Errorf("invalid group_by: %s", query.GroupBy)
+	}
+	if query.Sort == "" {
+		query.Sort = models.SenderSortCount
+	}
+	if query.Limit <= 0 {
+		query.Limit = senderPageSize
+	}
+	if query.Limit > senderMaxPageSize {
+		query.Limit = senderMaxPageSize
+	}
+	if query.Offset < 0 {
+		query.Offset = 0
+	}
+
+	accountIDs, err := s.scopeAccounts(userID, query.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.SenderPage{Senders: []*models.SenderStats{}, Query: query}
+	if len(accountIDs) == 0 {
+		return page, nil
+	}
+
+	page.Senders, page.TotalCount, err = s.emailRepo.AggregateSenders(accountIDs, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// ApplySenderAction - Appliquer une action à tous les emails d'un expéditeur ou d'un domaine
+func (s *SenderService) ApplySenderAction(userID int, req *models.SenderActionRequest) (*models.EmailActionResult, error) {
+	ids, err := s.senderEmailIDs(userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info(fmt.Sprintf("Applying %s to %d emails from %s %s for user %d", req.Action, len(ids), req.GroupBy, req.Key, userID))
+
+	return s.mailService.ExecuteEmailAction(userID, &models.EmailActionRequest{
+		EmailIDs:           ids,
+		Action:             req.Action,
+		Force:              req.Force,
+		ConfirmationToken:  req.ConfirmationToken,
+		OverrideProtection: req.OverrideProtection,
+	})
+}
+
+// PreviewSenderAction - Aperçu (et jeton de confirmation) d'une action sur un expéditeur
+func (s *SenderService) PreviewSenderAction(userID int, req *models.SenderActionRequest) (*models.ActionPreview, error) {
+	ids, err := s.senderEmailIDs(userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.mailService.PreviewEmailAction(userID, &models.EmailActionRequest{
+		EmailIDs:           ids,
+		Action:             req.Action,
+		Force:              req.Force,
+		OverrideProtection: req.OverrideProtection,
+	})
+}
+
+// senderEmailIDs - Emails de l'expéditeur ou du domaine dans les comptes concernés
+func (s *SenderService) senderEmailIDs(userID int, req *models.SenderActionRequest) ([]string, error) {
+	if req.GroupBy == "" {
+		req.GroupBy = models.GroupBySender
+	}
+	if !req.GroupBy.IsValid() {
+		return nil, fmt.Errorf("invalid group_by: %s", req.GroupBy)
+	}
+
+	key := strings.TrimSpace(req.Key)
+	if key == "" {
+		return nil, fmt.Errorf("sender key is required")
+	}
+
+	accountIDs, err := s.scopeAccounts(userID, req.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if len(accountIDs) == 0 {
+		return nil, fmt.Errorf("no active account")
+	}
+
+	ids, err := s.emailRepo.GetIDsBySender(accountIDs, req.GroupBy, key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("no emails found for %s", key)
+	}
+
+	return ids, nil
+}
+
+// scopeAccounts - Un compte de l'utilisateur (vérifié) ou tous ses comptes actifs
+func (s *SenderService) scopeAccounts(userID, accountID int) ([]int, error) {
+	if accountID != 0 {
+		account, err := s.accountService.GetUserAccount(userID, accountID)
+		if err != nil {
+			return nil, err
+		}
+		if !account.IsActive {
+			return nil, fmt.Errorf("account is inactive")
+		}
+		return []int{account.ID}, nil
+	}
+
+	accounts, err := s.accountService.GetUserAccounts(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user accounts: %w", err)
+	}
+
+	accountIDs := []int{}
+	for _, account := range accounts {
+		if account.IsActive {
+			accountIDs = append(accountIDs, account.ID)
+		}
+	}
+	return accountIDs, nil
+}
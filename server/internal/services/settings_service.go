@@ -0,0 +1,112 @@
+package services
+
+import (
+	"fmt"
+	"tamis-server/internal/config"
+	"tamis-server/internal/models"
+	"tamis-server/internal/repository"
+	"tamis-server/internal/utils"
+	"time"
+)
+
+// SettingsService - Préférences utilisateur
+type SettingsService struct {
+	settingsRepo   *repository.SettingsRepository
+	accountService *AccountService
+	scheduler      config.SchedulerConfig
+	logger         *utils.Logger
+}
+
+func NewSettingsService(settingsRepo *repository.SettingsRepository, accountService *AccountService, scheduler config.SchedulerConfig, logger *utils.Logger) *SettingsService {
+	return &SettingsService{
+		settingsRepo:   settingsRepo,
+		accountService: accountService,
+		scheduler:      scheduler,
+		logger:         logger,
+	}
+}
+
+// GetSettings - Préférences de l'utilisateur (valeurs par défaut si jamais enregistrées)
+func (s *SettingsService) GetSettings(userID int) (*models.UserSettings, error) {
+	settings, err := s.settingsRepo.Get(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if settings == nil {
+		settings = s.defaultSettings(userID)
+	}
+
+	return settings, nil
+}
+
+// UpdateSettings - Mettre à jour les préférences de l'utilisateur
+func (s *SettingsService) UpdateSettings(userID int, req *models.UpdateSettingsRequest) (*models.UserSettings, error) {
+	settings, err := s.GetSettings(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.AutoSyncEnabled != nil {
+		settings.AutoSyncEnabled = *req.AutoSyncEnabled
+	}
+
+	if req.SyncIntervalMinutes != nil {
+		interval := *req.SyncIntervalMinutes
+		minMinutes := int(s.scheduler.MinInterval / time.Minute)
+		if interval != 0 && interval < minMinutes {
+			return nil, fmt.Errorf("sync interval must be at least %d minutes", minMinutes)
+		}
+		settings.SyncIntervalMinutes = interval
+	}
+
+	if req.PrimaryAccountID != nil {
+		if *req.PrimaryAccountID != 0 {
+			if _, err := s.accountService.GetUserAccount(userID, *req.PrimaryAccountID); err != nil {
+				return nil, fmt.Errorf("primary account not found")
+			}
+		}
+		settings.PrimaryAccountID = *req.PrimaryAccountID
+	}
+
+	if req.DuplicateKeep != nil {
+		if !req.DuplicateKeep.IsValid() {
+			return nil, fmt.Errorf("invalid duplicate keep strategy: %s", *req.DuplicateKeep)
+		}
+		settings.DuplicateKeep = *req.DuplicateKeep
+	}
+
+	if req.TrashRetentionDays != nil {
+		days := *req.TrashRetentionDays
+		if days < 1 || days > maxTrashRetentionDays {
+			return nil, fmt.Errorf("trash retention must be between 1 and %d days", maxTrashRetentionDays)
+		}
+		settings.TrashRetentionDays = days
+	}
+
+	if req.Protection != nil {
+		rules, err := normalizeProtectionRules(*req.Protection)
+		if err != nil {
+			return nil, err
+		}
+		settings.Protection = rules
+	}
+
+	if err := s.settingsRepo.Save(settings); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info(fmt.Sprintf("Settings updated for user %d", userID))
+	return settings, nil
+}
+
+// defaultSettings - Préférences appliquées tant que l'utilisateur n'a rien enregistré
+func (s *SettingsService) defaultSettings(userID int) *models.UserSettings {
+	return &models.UserSettings{
+		UserID:             userID,
+		AutoSyncEnabled:    true,
+		DuplicateKeep:      models.KeepOldest,
+		TrashRetentionDays: defaultTrashRetentionDays,
+		Protection:         models.ProtectionRules{Senders: []string{}, ProtectFlagged: true},
+	}
+}
@@ -0,0 +1,731 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"tamis-server/internal/models"
+)
+
+// sieveExtensions - Extensions Sieve (require) prises en charge à l'import
+var sieveExtensions = map[string]bool{
+	"fileinto":                   true,
+	"imap4flags":                 true,
+	"regex":                      true,
+	"comparator-i;ascii-casemap": true,
+}
+
+// sieveFolderActions - Dossiers cibles de fileinto reconnus (minuscules) et action Tamis équivalente
+var sieveFolderActions = map[string]models.EmailAction{
+	"trash":                models.ActionDelete,
+	"deleted":              models.ActionDelete,
+	"deleted items":        models.ActionDelete,
+	"deleted messages":     models.ActionDelete,
+	"corbeille":            models.ActionDelete,
+	"junk":                 models.ActionSpam,
+	"junk e-mail":          models.ActionSpam,
+	"junk email":           models.ActionSpam,
+	"spam":                 models.ActionSpam,
+	"bulk":                 models.ActionSpam,
+	"courrier indésirable": models.ActionSpam,
+	"archive":              models.ActionArchive,
+	"archives":             models.ActionArchive,
+	"all mail":             models.ActionArchive,
+	"inbox":                models.ActionUnarchive,
+}
+
+// sieveActionFolders - Dossier utilisé à l'export pour chaque action de déplacement
+var sieveActionFolders = map[models.EmailAction]string{
+	models.ActionDelete:    "Trash",
+	models.ActionSpam:      "Junk",
+	models.ActionNotSpam:   "INBOX",
+	models.ActionArchive:   "Archive",
+	models.ActionUnarchive: "INBOX",
+}
+
+// sieveTrueCondition - Condition toujours vraie (Tamis n'a pas de test "true")
+func sieveTrueCondition() *models.RuleCondition {
+	return &models.RuleCondition{Field: models.RuleFieldSize, Operator: models.RuleOpLess, Value: "0", Negate: true}
+}
+
+// sieveRule - Règle obtenue à l'import et ligne de la commande dont elle provient
+type sieveRule struct {
+	rule *models.CleanupRule
+	line int
+}
+
+// sieveConverter - Conversion des commandes Sieve en règles, avec le relevé des constructions ignorées
+type sieveConverter struct {
+	rules  []*sieveRule
+	issues []*models.SieveIssue
+}
+
+// convertSieve - Règles équivalentes à un script (dans l'ordre du script) et constructions non prises en charge
+func convertSieve(commands []*sieveCommand) ([]*sieveRule, []*models.SieveIssue) {
+	converter := &sieveConverter{rules: []*sieveRule{}, issues: []*models.SieveIssue{}}
+	converter.block(commands, nil)
+	return converter.rules, converter.issues
+}
+
+func (c *sieveConverter) unsupported(line int, construct, message string) {
+	c.issues = append(c.issues, &models.SieveIssue{Line: line, Construct: construct, Message: message})
+}
+
+// block - Convertir une suite de commandes exécutées quand toutes les conditions de guard sont vraies
+func (c *sieveConverter) block(commands []*sieveCommand, guard []*models.RuleCondition) {
+	var previous []*models.RuleCondition // Tests de la chaîne if/elsif en cours
+	chainValid := false
+	blockStart := len(c.rules)
+
+	for _, command := range commands {
+		switch command.name {
+		case "require":
+			for _, arg := range command.args {
+				for _, extension := range arg.strings {
+					if !sieveExtensions[strings.ToLower(extension)] {
+						c.unsupported(command.line, "require "+extension, "extension not supported, commands using it are ignored")
+					}
+				}
+			}
+
+		case "if", "elsif", "else":
+			if command.name != "if" && previous == nil {
+				c.unsupported(command.line, command.name, "no preceding if")
+				continue
+			}
+			if command.name == "if" {
+				previous = []*models.RuleCondition{}
+				chainValid = true
+			}
+			if !chainValid {
+				c.unsupported(command.line, command.name, "previous test of the chain is not supported, branch ignored")
+				continue
+			}
+
+			// Une branche ne s'exécute que si les tests précédents de la chaîne sont faux
+			branch := append([]*models.RuleCondition{}, guard...)
+			for _, cond := range previous {
+				negated := *cond
+				negated.Negate = !negated.Negate
+				branch = append(branch, &negated)
+			}
+
+			if command.name != "else" {
+				cond, ok := c.test(command.test)
+				if !ok {
+					chainValid = false
+					continue
+				}
+				previous = append(previous, cond)
+				branch = append(branch, cond)
+			} else {
+				previous = nil
+			}
+
+			c.block(command.block, branch)
+
+		case "stop":
+			// stop arrête tout le script : la dernière règle du bloc arrête l'évaluation des suivantes
+			if len(c.rules) > blockStart {
+				c.rules[len(c.rules)-1].rule.StopProcessing = true
+			} else {
+				c.unsupported(command.line, "stop", "stop without a supported action before it is ignored")
+			}
+			if len(guard) == 0 {
+				return
+			}
+
+		case "keep":
+			// Conserver le message : comportement par défaut
+
+		case "discard":
+			c.addRule(command.line, guard, models.ActionDelete)
+
+		case "fileinto":
+			c.fileinto(command, guard)
+
+		case "addflag", "setflag", "removeflag":
+			c.flags(command, guard)
+
+		default:
+			c.unsupported(command.line, command.name, "command not supported")
+		}
+
+		if command.name != "if" && command.name != "elsif" && command.name != "else" {
+			previous = nil
+		}
+	}
+}
+
+// addRule - Règle déclenchant action quand toutes les conditions sont vraies
+func (c *sieveConverter) addRule(line int, guard []*models.RuleCondition, action models.EmailAction) {
+	var conditions *models.RuleCondition
+	switch len(guard) {
+	case 0:
+		conditions = sieveTrueCondition()
+	case 1:
+		conditions = guard[0]
+	default:
+		conditions = &models.RuleCondition{All: guard}
+	}
+
+	c.rules = append(c.rules, &sieveRule{
+		rule: &models.CleanupRule{
+			Name:       fmt.Sprintf("Sieve line %d: %s", line, action),
+			Enabled:    true,
+			Conditions: conditions,
+			Action:     action,
+		},
+		line: line,
+	})
+}
+
+// fileinto - Déplacement vers un dossier reconnu (corbeille, spam, archive, boîte de réception)
+func (c *sieveConverter) fileinto(command *sieveCommand, guard []*models.RuleCondition) {
+	var folder string
+	var flags []string
+	for i := 0; i < len(command.args); i++ {
+		arg := command.args[i]
+		switch {
+		case arg.tag == ":flags" && i+1 < len(command.args):
+			flags = command.args[i+1].strings
+			i++
+		case arg.tag == ":copy":
+			c.unsupported(command.line, "fileinto :copy", "copies are not supported, the message is moved")
+		case arg.tag != "":
+			c.unsupported(command.line, "fileinto "+arg.tag, "argument not supported")
+		case len(arg.strings) > 0:
+			folder = arg.strings[0]
+		}
+	}
+
+	c.applyFlags(command.line, "fileinto :flags", flags, false, guard)
+
+	action, ok := sieveFolderAction(folder)
+	if !ok {
+		c.unsupported(command.line, "fileinto \""+folder+"\"", "only trash, junk, archive and inbox folders are supported")
+		return
+	}
+	c.addRule(command.line, guard, action)
+}
+
+// flags - addflag, setflag et removeflag (imap4flags) : seul \Seen a un équivalent
+func (c *sieveConverter) flags(command *sieveCommand, guard []*models.RuleCondition) {
+	lists := [][]string{}
+	for _, arg := range command.args {
+		if len(arg.strings) > 0 {
+			lists = append(lists, arg.strings)
+		}
+	}
+	if len(lists) != 1 {
+		c.unsupported(command.line, command.name, "flag variables are not supported")
+		return
+	}
+
+	c.applyFlags(command.line, command.name, lists[0], command.name == "removeflag", guard)
+}
+
+// applyFlags - Marquer lu (ou non lu si remove) pour \Seen ; les autres flags sont signalés
+func (c *sieveConverter) applyFlags(line int, construct string, flags []string, remove bool, guard []*models.RuleCondition) {
+	for _, list := range flags {
+		for _, flag := range strings.Fields(list) {
+			if !strings.EqualFold(flag, `\Seen`) {
+				c.unsupported(line, construct+" "+flag, "only the \\Seen flag is supported")
+				continue
+			}
+			action := models.ActionMarkRead
+			if remove {
+				action = models.ActionMarkUnread
+			}
+			c.addRule(line, guard, action)
+		}
+	}
+}
+
+// sieveFolderAction - Action équivalente à un dossier ("INBOX.Trash", "[Gmail]/Spam"...)
+func sieveFolderAction(folder string) (models.EmailAction, bool) {
+	name := strings.ToLower(strings.TrimSpace(folder))
+	if action, ok := sieveFolderActions[name]; ok {
+		return action, true
+	}
+	if i := strings.LastIndexAny(name, "/."); i >= 0 {
+		action, ok := sieveFolderActions[name[i+1:]]
+		return action, ok
+	}
+	return "", false
+}
+
+// sieveMatch - Options d'un test de comparaison (comparateur, type de correspondance, partie d'adresse)
+type sieveMatch struct {
+	matchType   string // :is, :contains, :matches, :regex
+	addressPart string // :all, :domain, :localpart
+	positional  [][]string
+}
+
+// matchArgs - Lire les tags et arguments positionnels d'un test
+func (c *sieveConverter) matchArgs(test *sieveTest) (*sieveMatch, bool) {
+	match := &sieveMatch{matchType: ":is", addressPart: ":all"}
+	for i := 0; i < len(test.args); i++ {
+		arg := test.args[i]
+		switch arg.tag {
+		case "":
+			match.positional = append(match.positional, arg.strings)
+		case ":is", ":contains", ":matches", ":regex":
+			match.matchType = arg.tag
+		case ":all", ":domain", ":localpart":
+			match.addressPart = arg.tag
+		case ":comparator":
+			if i+1 >= len(test.args) || len(test.args[i+1].strings) == 0 {
+				c.unsupported(test.line, test.name+" :comparator", "missing comparator name")
+				return nil, false
+			}
+			i++
+			comparator := strings.ToLower(test.args[i].strings[0])
+			switch comparator {
+			case "i;ascii-casemap":
+			case "i;octet":
+				c.unsupported(test.line, test.name+" :comparator \"i;octet\"", "comparisons are case-insensitive in Tamis")
+			default:
+				c.unsupported(test.line, test.name+" :comparator \""+comparator+"\"", "comparator not supported")
+				return nil, false
+			}
+		default:
+			c.unsupported(test.line, test.name+" "+arg.tag, "argument not supported")
+			return nil, false
+		}
+	}
+
+	if len(match.positional) != 2 {
+		c.unsupported(test.line, test.name, "expected a header list and a key list")
+		return nil, false
+	}
+	return match, true
+}
+
+// test - Condition équivalente à un test Sieve (false si non pris en charge, la raison est relevée)
+func (c *sieveConverter) test(test *sieveTest) (*models.RuleCondition, bool) {
+	if test == nil {
+		return nil, false
+	}
+
+	switch test.name {
+	case "true":
+		return sieveTrueCondition(), true
+
+	case "not":
+		if len(test.tests) != 1 {
+			c.unsupported(test.line, "not", "expected a single test")
+			return nil, false
+		}
+		cond, ok := c.test(test.tests[0])
+		if !ok {
+			return nil, false
+		}
+		negated := *cond
+		negated.Negate = !negated.Negate
+		return &negated, true
+
+	case "allof", "anyof":
+		children := []*models.RuleCondition{}
+		for _, child := range test.tests {
+			cond, ok := c.test(child)
+			if !ok {
+				return nil, false
+			}
+			children = append(children, cond)
+		}
+		if len(children) == 0 {
+			c.unsupported(test.line, test.name, "empty test list")
+			return nil, false
+		}
+		if test.name == "allof" {
+			return &models.RuleCondition{All: children}, true
+		}
+		return &models.RuleCondition{Any: children}, true
+
+	case "header", "address":
+		return c.comparisonTest(test)
+
+	case "exists":
+		if len(test.args) != 1 || len(test.args[0].strings) == 0 {
+			c.unsupported(test.line, "exists", "expected a header list")
+			return nil, false
+		}
+		children := []*models.RuleCondition{}
+		for _, name := range test.args[0].strings {
+			children = append(children, &models.RuleCondition{Field: models.RuleFieldHeader, Header: strings.ToLower(name), Operator: models.RuleOpExists})
+		}
+		return sieveGroup(children, true), true
+
+	case "size":
+		if len(test.args) != 2 || !test.args[1].isNum || (test.args[0].tag != ":over" && test.args[0].tag != ":under") {
+			c.unsupported(test.line, "size", "expected :over or :under and a number")
+			return nil, false
+		}
+		operator := models.RuleOpGreater
+		if test.args[0].tag == ":under" {
+			operator = models.RuleOpLess
+		}
+		return &models.RuleCondition{Field: models.RuleFieldSize, Operator: operator, Value: models.RuleValue(strconv.FormatInt(test.args[1].number, 10))}, true
+
+	case "hasflag":
+		return c.hasflagTest(test)
+	}
+
+	c.unsupported(test.line, test.name, "test not supported")
+	return nil, false
+}
+
+// comparisonTest - Tests header et address : une comparaison par couple (en-tête, clé), réunies par OU
+func (c *sieveConverter) comparisonTest(test *sieveTest) (*models.RuleCondition, bool) {
+	match, ok := c.matchArgs(test)
+	if !ok {
+		return nil, false
+	}
+
+	children := []*models.RuleCondition{}
+	for _, name := range match.positional[0] {
+		header := strings.ToLower(name)
+		for _, key := range match.positional[1] {
+			cond, ok := c.comparison(test, match, header, key)
+			if !ok {
+				return nil, false
+			}
+			children = append(children, cond)
+		}
+	}
+
+	return sieveGroup(children, false), true
+}
+
+// comparison - Condition sur le champ Tamis correspondant à l'en-tête
+func (c *sieveConverter) comparison(test *sieveTest, match *sieveMatch, header, key string) (*models.RuleCondition, bool) {
+	construct := fmt.Sprintf("%s %s \"%s\"", test.name, match.addressPart, header)
+
+	var field models.RuleField
+	switch header {
+	case "subject":
+		field = models.RuleFieldSubject
+	case "from", "sender":
+		field = models.RuleFieldSender
+	case "to", "cc":
+		field = models.RuleFieldTo
+	default:
+		if test.name == "address" {
+			c.unsupported(test.line, construct, "only from, sender, to and cc addresses are supported")
+			return nil, false
+		}
+		field = models.RuleFieldHeader
+	}
+
+	if test.name == "address" {
+		switch match.addressPart {
+		case ":domain":
+			if field == models.RuleFieldSender {
+				field = models.RuleFieldDomain
+			} else if match.matchType == ":is" {
+				return &models.RuleCondition{Field: field, Operator: models.RuleOpEndsWith, Value: models.RuleValue("@" + key)}, true
+			} else {
+				c.unsupported(test.line, construct, "recipient domains only support :is")
+				return nil, false
+			}
+		case ":localpart":
+			if match.matchType != ":is" {
+				c.unsupported(test.line, construct, "local parts only support :is")
+				return nil, false
+			}
+			return &models.RuleCondition{Field: field, Operator: models.RuleOpStartsWith, Value: models.RuleValue(key + "@")}, true
+		}
+	}
+
+	cond := &models.RuleCondition{Field: field, Value: models.RuleValue(key)}
+	if field == models.RuleFieldHeader {
+		cond.Header = header
+	}
+
+	switch match.matchType {
+	case ":is":
+		cond.Operator = models.RuleOpEquals
+	case ":contains":
+		cond.Operator = models.RuleOpContains
+	case ":regex":
+		if _, err := regexp.Compile(key); err != nil {
+			c.unsupported(test.line, construct+" :regex", "invalid regular expression")
+			return nil, false
+		}
+		cond.Operator = models.RuleOpMatches
+	case ":matches":
+		cond.Operator, cond.Value = sieveGlobCondition(key)
+	}
+
+	return cond, true
+}
+
+// hasflagTest - hasflag (imap4flags) : \Seen (lu) et \Flagged (étoilé ou suivi)
+func (c *sieveConverter) hasflagTest(test *sieveTest) (*models.RuleCondition, bool) {
+	if len(test.args) != 1 || len(test.args[0].strings) == 0 {
+		c.unsupported(test.line, "hasflag", "only a flag list without match type is supported")
+		return nil, false
+	}
+
+	children := []*models.RuleCondition{}
+	for _, list := range test.args[0].strings {
+		for _, flag := range strings.Fields(list) {
+			switch strings.ToLower(flag) {
+			case `\seen`:
+				children = append(children, &models.RuleCondition{Field: models.RuleFieldIsRead, Operator: models.RuleOpEquals, Value: "true"})
+			case `\flagged`:
+				children = append(children,
+					&models.RuleCondition{Field: models.RuleFieldLabel, Operator: models.RuleOpEquals, Value: "FLAGGED"},
+					&models.RuleCondition{Field: models.RuleFieldLabel, Operator: models.RuleOpEquals, Value: "STARRED"})
+			default:
+				c.unsupported(test.line, "hasflag "+flag, "only \\Seen and \\Flagged are supported")
+				return nil, false
+			}
+		}
+	}
+
+	return sieveGroup(children, false), true
+}
+
+// sieveGroup - Un seul nœud ou leur groupe ET (all) / OU
+func sieveGroup(children []*models.RuleCondition, all bool) *models.RuleCondition {
+	if len(children) == 1 {
+		return children[0]
+	}
+	if all {
+		return &models.RuleCondition{All: children}
+	}
+	return &models.RuleCondition{Any: children}
+}
+
+// sieveGlobCondition - Opérateur équivalent à un motif :matches ("*" et "?", "\" échappe)
+func sieveGlobCondition(glob string) (models.RuleOperator, models.RuleValue) {
+	if !strings.ContainsAny(glob, `?\`) {
+		inner := strings.Trim(glob, "*")
+		if !strings.Contains(inner, "*") {
+			switch {
+			case glob == inner:
+				return models.RuleOpEquals, models.RuleValue(glob)
+			case inner == "":
+				return models.RuleOpMatches, ""
+			case glob == "*"+inner+"*":
+				return models.RuleOpContains, models.RuleValue(inner)
+			case glob == inner+"*":
+				return models.RuleOpStartsWith, models.RuleValue(inner)
+			case glob == "*"+inner:
+				return models.RuleOpEndsWith, models.RuleValue(inner)
+			}
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("^")
+	runes := []rune(glob)
+	for i := 0; i < len(runes); i++ {
+		switch runes[i] {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		case '\\':
+			if i+1 < len(runes) {
+				i++
+			}
+			b.WriteString(regexp.QuoteMeta(string(runes[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(runes[i])))
+		}
+	}
+	b.WriteString("$")
+	return models.RuleOpMatches, models.RuleValue(b.String())
+}
+
+// sieveExporter - Traduction des règles Tamis en script Sieve
+type sieveExporter struct {
+	extensions map[string]bool
+}
+
+// exportSieve - Script Sieve des règles actives (par priorité) et règles non exportables
+func exportSieve(rules []*models.CleanupRule) *models.SieveExport {
+	exporter := &sieveExporter{extensions: map[string]bool{}}
+	export := &models.SieveExport{Unsupported: []*models.SieveIssue{}}
+
+	var body strings.Builder
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+
+		test, err := exporter.condition(rule.Conditions)
+		if err != nil {
+			export.Unsupported = append(export.Unsupported, &models.SieveIssue{RuleID: rule.ID, Construct: rule.Name, Message: err.Error()})
+			continue
+		}
+		action, err := exporter.action(rule.Action)
+		if err != nil {
+			export.Unsupported = append(export.Unsupported, &models.SieveIssue{RuleID: rule.ID, Construct: rule.Name, Message: err.Error()})
+			continue
+		}
+
+		fmt.Fprintf(&body, "\n# %s\nif %s {\n    %s\n", strings.ReplaceAll(rule.Name, "\n", " "), test, action)
+		if rule.StopProcessing {
+			body.WriteString("    stop;\n")
+		}
+		body.WriteString("}\n")
+		export.Exported++
+	}
+
+	var script strings.Builder
+	script.WriteString("# Tamis cleanup rules\n")
+	if len(exporter.extensions) > 0 {
+		names := []string{}
+		for _, name := range []string{"fileinto", "imap4flags", "regex"} {
+			if exporter.extensions[name] {
+				names = append(names, sieveQuote(name))
+			}
+		}
+		fmt.Fprintf(&script, "require [%s];\n", strings.Join(names, ", "))
+	}
+	script.WriteString(body.String())
+
+	export.Script = script.String()
+	return export
+}
+
+// action - Commande Sieve d'une action Tamis
+func (e *sieveExporter) action(action models.EmailAction) (string, error) {
+	switch action {
+	case models.ActionMarkRead:
+		e.extensions["imap4flags"] = true
+		return `addflag "\\Seen";`, nil
+	case models.ActionMarkUnread:
+		e.extensions["imap4flags"] = true
+		return `removeflag "\\Seen";`, nil
+	}
+
+	folder, ok := sieveActionFolders[action]
+	if !ok {
+		return "", fmt.Errorf("action %s has no sieve equivalent", action)
+	}
+	e.extensions["fileinto"] = true
+	return "fileinto " + sieveQuote(folder) + ";", nil
+}
+
+// condition - Test Sieve d'un nœud de conditions
+func (e *sieveExporter) condition(cond *models.RuleCondition) (string, error) {
+	if cond == nil {
+		return "", fmt.Errorf("rule has no conditions")
+	}
+
+	var test string
+	switch {
+	case len(cond.All) > 0 || len(cond.Any) > 0:
+		children := cond.All
+		name := "allof"
+		if len(cond.Any) > 0 {
+			children, name = cond.Any, "anyof"
+		}
+		parts := make([]string, 0, len(children))
+		for _, child := range children {
+			part, err := e.condition(child)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, part)
+		}
+		test = name + " (" + strings.Join(parts, ", ") + ")"
+	default:
+		var err error
+		if test, err = e.comparison(cond); err != nil {
+			return "", err
+		}
+	}
+
+	if cond.Negate {
+		return "not " + test, nil
+	}
+	return test, nil
+}
+
+// comparison - Test Sieve d'une comparaison sur un champ
+func (e *sieveExporter) comparison(cond *models.RuleCondition) (string, error) {
+	value := string(cond.Value)
+
+	switch cond.Field {
+	case models.RuleFieldSize:
+		switch cond.Operator {
+		case models.RuleOpGreater:
+			return "size :over " + value, nil
+		case models.RuleOpLess:
+			return "size :under " + value, nil
+		default:
+			return fmt.Sprintf("allof (not size :over %s, not size :under %s)", value, value), nil
+		}
+
+	case models.RuleFieldIsRead:
+		e.extensions["imap4flags"] = true
+		if read, _ := strconv.ParseBool(value); !read {
+			return `not hasflag "\\Seen"`, nil
+		}
+		return `hasflag "\\Seen"`, nil
+
+	case models.RuleFieldLabel:
+		if cond.Operator == models.RuleOpEquals && (strings.EqualFold(value, "FLAGGED") || strings.EqualFold(value, "STARRED")) {
+			e.extensions["imap4flags"] = true
+			return `hasflag "\\Flagged"`, nil
+		}
+		return "", fmt.Errorf("label conditions other than flagged have no sieve equivalent")
+
+	case models.RuleFieldHeader:
+		if cond.Operator == models.RuleOpExists {
+			return "exists " + sieveQuote(cond.Header), nil
+		}
+	}
+
+	var prefix string
+	switch cond.Field {
+	case models.RuleFieldSender:
+		prefix = `address :all %s "from"`
+	case models.RuleFieldDomain:
+		prefix = `address :domain %s "from"`
+	case models.RuleFieldTo:
+		prefix = `address :all %s ["to", "cc"]`
+	case models.RuleFieldSubject:
+		prefix = `header %s "subject"`
+	case models.RuleFieldHeader:
+		prefix = `header %s ` + strings.ReplaceAll(sieveQuote(cond.Header), "%", "%%")
+	default:
+		return "", fmt.Errorf("field %s has no sieve equivalent", cond.Field)
+	}
+
+	var matchType, key string
+	switch cond.Operator {
+	case models.RuleOpEquals:
+		matchType, key = ":is", value
+	case models.RuleOpContains:
+		matchType, key = ":contains", value
+	case models.RuleOpStartsWith:
+		matchType, key = ":matches", sieveEscapeGlob(value)+"*"
+	case models.RuleOpEndsWith:
+		matchType, key = ":matches", "*"+sieveEscapeGlob(value)
+	case models.RuleOpMatches:
+		e.extensions["regex"] = true
+		matchType, key = ":regex", value
+	default:
+		return "", fmt.Errorf("operator %s has no sieve equivalent", cond.Operator)
+	}
+
+	return fmt.Sprintf(prefix, matchType) + " " + sieveQuote(key), nil
+}
+
+// sieveQuote - Chaîne Sieve entre guillemets
+func sieveQuote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+// sieveEscapeGlob - Échapper les caractères spéciaux d'un motif :matches
+func sieveEscapeGlob(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`).Replace(value)
+}
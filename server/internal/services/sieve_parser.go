@@ -0,0 +1,450 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	// maxSieveScriptSize - Taille maximale d'un script Sieve importé
+	maxSieveScriptSize = 256 * 1024
+	// maxSieveNesting - Profondeur maximale des blocs et des tests imbriqués
+	maxSieveNesting = 32
+)
+
+// sieveTokenKind - Nature d'un lexème Sieve (RFC 5228 §8.1)
+type sieveTokenKind int
+
+const (
+	sieveEOF sieveTokenKind = iota
+	sieveIdentifier
+	sieveTag
+	sieveNumber
+	sieveString
+	sievePunct // [ ] ( ) { } , ;
+)
+
+type sieveToken struct {
+	kind  sieveTokenKind
+	text  string
+	value int64 // Nombres, multiplicateur K/M/G appliqué
+	line  int
+}
+
+// sieveArg - Argument d'une commande ou d'un test : tag (":is"), nombre ou liste de chaînes
+type sieveArg struct {
+	tag     string
+	number  int64
+	strings []string
+	isNum   bool
+}
+
+// sieveTest - Test Sieve et ses sous-tests (allof, anyof, not)
+type sieveTest struct {
+	name  string
+	args  []sieveArg
+	tests []*sieveTest
+	line  int
+}
+
+// sieveCommand - Commande Sieve, avec son test (if, elsif) et son bloc éventuels
+type sieveCommand struct {
+	name  string
+	args  []sieveArg
+	test  *sieveTest
+	block []*sieveCommand
+	line  int
+}
+
+// sieveLexer - Découpage d'un script en lexèmes (commentaires ignorés)
+type sieveLexer struct {
+	src  []rune
+	pos  int
+	line int
+}
+
+// tokenizeSieve - Tous les lexèmes du script
+func tokenizeSieve(script string) ([]sieveToken, error) {
+	lexer := &sieveLexer{src: []rune(script), line: 1}
+	tokens := []sieveToken{}
+	for {
+		token, err := lexer.next()
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+		if token.kind == sieveEOF {
+			return tokens, nil
+		}
+	}
+}
+
+func (l *sieveLexer) peek(offset int) rune {
+	if l.pos+offset < len(l.src) {
+		return l.src[l.pos+offset]
+	}
+	return 0
+}
+
+func (l *sieveLexer) next() (sieveToken, error) {
+	l.skipSpaceAndComments()
+	if l.pos >= len(l.src) {
+		return sieveToken{kind: sieveEOF, line: l.line}, nil
+	}
+
+	start := l.line
+	c := l.src[l.pos]
+
+	switch {
+	case strings.ContainsRune("[](){},;", c):
+		l.pos++
+		return sieveToken{kind: sievePunct, text: string(c), line: start}, nil
+
+	case c == '"':
+		text, err := l.quotedString()
+		return sieveToken{kind: sieveString, text: text, line: start}, err
+
+	case c == ':':
+		l.pos++
+		name := l.identifier()
+		if name == "" {
+			return sieveToken{}, fmt.Errorf("line %d: invalid tag", start)
+		}
+		return sieveToken{kind: sieveTag, text: ":" + strings.ToLower(name), line: start}, nil
+
+	case unicode.IsDigit(c):
+		return l.number()
+
+	case c == '_' || unicode.IsLetter(c):
+		name := l.identifier()
+		if strings.EqualFold(name, "text") && l.peek(0) == ':' {
+			l.pos++
+			text, err := l.multilineString()
+			return sieveToken{kind: sieveString, text: text, line: start}, err
+		}
+		return sieveToken{kind: sieveIdentifier, text: strings.ToLower(name), line: start}, nil
+	}
+
+	return sieveToken{}, fmt.Errorf("line %d: unexpected character %q", start, c)
+}
+
+// skipSpaceAndComments - Ignorer espaces, commentaires "#" et "/* */"
+func (l *sieveLexer) skipSpaceAndComments() {
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '\n':
+			l.line++
+			l.pos++
+		case unicode.IsSpace(c):
+			l.pos++
+		case c == '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+		case c == '/' && l.peek(1) == '*':
+			l.pos += 2
+			for l.pos < len(l.src) && !(l.src[l.pos] == '*' && l.peek(1) == '/') {
+				if l.src[l.pos] == '\n' {
+					l.line++
+				}
+				l.pos++
+			}
+			l.pos += 2
+		default:
+			return
+		}
+	}
+}
+
+func (l *sieveLexer) identifier() string {
+	start := l.pos
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		if c != '_' && !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+			break
+		}
+		l.pos++
+	}
+	return string(l.src[start:l.pos])
+}
+
+func (l *sieveLexer) number() (sieveToken, error) {
+	start := l.pos
+	for l.pos < len(l.src) && unicode.IsDigit(l.src[l.pos]) {
+		l.pos++
+	}
+	value, err := strconv.ParseInt(string(l.src[start:l.pos]), 10, 64)
+	if err != nil {
+		return sieveToken{}, fmt.Errorf("line %d: invalid number", l.line)
+	}
+
+	switch unicode.ToUpper(l.peek(0)) {
+	case 'K':
+		value <<= 10
+		l.pos++
+	case 'M':
+		value <<= 20
+		l.pos++
+	case 'G':
+		value <<= 30
+		l.pos++
+	}
+
+	return sieveToken{kind: sieveNumber, text: string(l.src[start:l.pos]), value: value, line: l.line}, nil
+}
+
+// quotedString - Chaîne entre guillemets ; seuls \" et \\ sont des échappements
+func (l *sieveLexer) quotedString() (string, error) {
+	start := l.line
+	l.pos++
+
+	var b strings.Builder
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '"':
+			l.pos++
+			return b.String(), nil
+		case c == '\\' && l.pos+1 < len(l.src):
+			b.WriteRune(l.src[l.pos+1])
+			l.pos += 2
+		default:
+			if c == '\n' {
+				l.line++
+			}
+			b.WriteRune(c)
+			l.pos++
+		}
+	}
+
+	return "", fmt.Errorf("line %d: unterminated string", start)
+}
+
+// multilineString - Chaîne "text:" terminée par une ligne ne contenant qu'un point
+func (l *sieveLexer) multilineString() (string, error) {
+	start := l.line
+
+	// Le reste de la ligne après "text:" ne peut contenir qu'espaces et commentaire
+	for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+		l.pos++
+	}
+
+	var lines []string
+	for l.pos < len(l.src) {
+		l.pos++ // '\n'
+		l.line++
+		lineStart := l.pos
+		for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+			l.pos++
+		}
+		line := strings.TrimSuffix(string(l.src[lineStart:l.pos]), "\r")
+		if line == "." {
+			return strings.Join(lines, "\n"), nil
+		}
+		lines = append(lines, strings.TrimPrefix(line, ".")) // ".." en début de ligne
+	}
+
+	return "", fmt.Errorf("line %d: unterminated multi-line string", start)
+}
+
+// sieveParser - Analyse syntaxique des lexèmes en commandes (RFC 5228 §8.2)
+type sieveParser struct {
+	tokens []sieveToken
+	pos    int
+	depth  int // Imbrication courante des blocs et des tests
+}
+
+// parseSieve - Arbre des commandes d'un script
+func parseSieve(script string) ([]*sieveCommand, error) {
+	if len(script) > maxSieveScriptSize {
+		return nil, fmt.Errorf("sieve script is larger than %d bytes", maxSieveScriptSize)
+	}
+
+	tokens, err := tokenizeSieve(script)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := &sieveParser{tokens: tokens}
+	commands, err := parser.commands()
+	if err != nil {
+		return nil, err
+	}
+	if token := parser.current(); token.kind != sieveEOF {
+		return nil, fmt.Errorf("line %d: unexpected %q", token.line, token.text)
+	}
+	return commands, nil
+}
+
+func (p *sieveParser) current() sieveToken {
+	return p.tokens[p.pos]
+}
+
+func (p *sieveParser) isPunct(text string) bool {
+	token := p.current()
+	return token.kind == sievePunct && token.text == text
+}
+
+func (p *sieveParser) expect(text string) error {
+	if !p.isPunct(text) {
+		token := p.current()
+		if token.kind == sieveEOF {
+			return fmt.Errorf("line %d: expected %q before end of script", token.line, text)
+		}
+		return fmt.Errorf("line %d: expected %q, found %q", token.line, text, token.text)
+	}
+	p.pos++
+	return nil
+}
+
+// enter - Descendre d'un niveau d'imbrication
+func (p *sieveParser) enter() error {
+	p.depth++
+	if p.depth > maxSieveNesting {
+		return fmt.Errorf("line %d: blocks and tests are nested more than %d levels deep", p.current().line, maxSieveNesting)
+	}
+	return nil
+}
+
+func (p *sieveParser) leave() {
+	p.depth--
+}
+
+// commands - Suite de commandes jusqu'à "}" ou la fin du script
+func (p *sieveParser) commands() ([]*sieveCommand, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
+	commands := []*sieveCommand{}
+	for {
+		token := p.current()
+		if token.kind == sieveEOF || p.isPunct("}") {
+			return commands, nil
+		}
+		if token.kind != sieveIdentifier {
+			return nil, fmt.Errorf("line %d: expected a command, found %q", token.line, token.text)
+		}
+		p.pos++
+
+		command := &sieveCommand{name: token.text, line: token.line}
+		args, err := p.arguments()
+		if err != nil {
+			return nil, err
+		}
+		command.args = args
+
+		// Test des structures de contrôle (if, elsif)
+		if p.current().kind == sieveIdentifier {
+			if command.test, err = p.test(); err != nil {
+				return nil, err
+			}
+		}
+
+		if p.isPunct("{") {
+			p.pos++
+			if command.block, err = p.commands(); err != nil {
+				return nil, err
+			}
+			if err := p.expect("}"); err != nil {
+				return nil, err
+			}
+		} else if err := p.expect(";"); err != nil {
+			return nil, err
+		}
+
+		commands = append(commands, command)
+	}
+}
+
+// arguments - Tags, nombres et chaînes (ou listes de chaînes) d'une commande ou d'un test
+func (p *sieveParser) arguments() ([]sieveArg, error) {
+	args := []sieveArg{}
+	for {
+		token := p.current()
+		switch {
+		case token.kind == sieveTag:
+			args = append(args, sieveArg{tag: token.text})
+			p.pos++
+		case token.kind == sieveNumber:
+			args = append(args, sieveArg{number: token.value, isNum: true})
+			p.pos++
+		case token.kind == sieveString:
+			args = append(args, sieveArg{strings: []string{token.text}})
+			p.pos++
+		case p.isPunct("["):
+			p.pos++
+			list := []string{}
+			for {
+				item := p.current()
+				if item.kind != sieveString {
+					return nil, fmt.Errorf("line %d: expected a string in list", item.line)
+				}
+				list = append(list, item.text)
+				p.pos++
+				if p.isPunct("]") {
+					p.pos++
+					break
+				}
+				if err := p.expect(","); err != nil {
+					return nil, err
+				}
+			}
+			args = append(args, sieveArg{strings: list})
+		default:
+			return args, nil
+		}
+	}
+}
+
+// test - Test et, pour allof/anyof/not, ses sous-tests
+func (p *sieveParser) test() (*sieveTest, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
+	token := p.current()
+	if token.kind != sieveIdentifier {
+		return nil, fmt.Errorf("line %d: expected a test, found %q", token.line, token.text)
+	}
+	p.pos++
+
+	test := &sieveTest{name: token.text, line: token.line}
+	args, err := p.arguments()
+	if err != nil {
+		return nil, err
+	}
+	test.args = args
+
+	switch {
+	case p.isPunct("("):
+		p.pos++
+		for {
+			child, err := p.test()
+			if err != nil {
+				return nil, err
+			}
+			test.tests = append(test.tests, child)
+			if p.isPunct(")") {
+				p.pos++
+				break
+			}
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+	case p.current().kind == sieveIdentifier:
+		child, err := p.test()
+		if err != nil {
+			return nil, err
+		}
+		test.tests = []*sieveTest{child}
+	}
+
+	return test, nil
+}
@@ -0,0 +1,293 @@
+package services
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"tamis-server/internal/models"
+)
+
+// conditionJSON - Forme comparable d'un arbre de conditions
+func conditionJSON(t *testing.T, cond *models.RuleCondition) string {
+	t.Helper()
+	data, err := json.Marshal(cond)
+	if err != nil {
+		t.Fatalf("marshal condition: %v", err)
+	}
+	return string(data)
+}
+
+func TestParseSieve(t *testing.T) {
+	script := `require ["fileinto", "imap4flags"];
+# Commentaire
+/* Commentaire
+   sur plusieurs lignes */
+if allof (header :contains "Subject" "promo", not size :under 10K) {
+    fileinto "Junk";
+    stop;
+} elsif exists "List-Id" {
+    addflag text:
+\Seen
+..
+.
+;
+}
+`
+	commands, err := parseSieve(script)
+	if err != nil {
+		t.Fatalf("parseSieve: %v", err)
+	}
+	if len(commands) != 3 {
+		t.Fatalf("commands = %d, want 3", len(commands))
+	}
+
+	require := commands[0]
+	if require.name != "require" || len(require.args) != 1 || strings.Join(require.args[0].strings, ",") != "fileinto,imap4flags" {
+		t.Errorf("require = %+v", require)
+	}
+
+	branch := commands[1]
+	if branch.name != "if" || branch.line != 5 || len(branch.block) != 2 {
+		t.Fatalf("if = %+v", branch)
+	}
+	if branch.test.name != "allof" || len(branch.test.tests) != 2 {
+		t.Fatalf("test = %+v", branch.test)
+	}
+	header := branch.test.tests[0]
+	if header.name != "header" || header.args[0].tag != ":contains" || header.args[1].strings[0] != "Subject" {
+		t.Errorf("header test = %+v", header)
+	}
+	size := branch.test.tests[1].tests[0]
+	if size.name != "size" || size.args[0].tag != ":under" || !size.args[1].isNum || size.args[1].number != 10*1024 {
+		t.Errorf("size test = %+v", size)
+	}
+
+	elsif := commands[2]
+	if elsif.name != "elsif" || elsif.line != 8 {
+		t.Fatalf("elsif = %+v", elsif)
+	}
+	if flags := elsif.block[0].args[0].strings[0]; flags != "\\Seen\n." {
+		t.Errorf("multi-line string = %q", flags)
+	}
+}
+
+func TestParseSieveErrors(t *testing.T) {
+	cases := map[string]string{
+		"unterminated string": `fileinto "Junk;`,
+		"missing semicolon":   `fileinto "Junk"`,
+		"unclosed block":      `if true { stop;`,
+		"unexpected brace":    `stop; }`,
+		"invalid list":        `require ["fileinto", 3];`,
+		"unterminated text":   "addflag text:\n\\Seen\n",
+		"nesting too deep":    strings.Repeat("if true {", maxSieveNesting+1) + strings.Repeat("}", maxSieveNesting+1),
+	}
+	for name, script := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := parseSieve(script); err == nil {
+				t.Errorf("parseSieve(%q) succeeded", script)
+			}
+		})
+	}
+}
+
+func TestConvertSieve(t *testing.T) {
+	cases := []struct {
+		name       string
+		script     string
+		conditions string
+		action     models.EmailAction
+		stop       bool
+	}{
+		{
+			name:       "address domain",
+			script:     `if address :domain :is "from" "example.com" { fileinto "INBOX.Trash"; }`,
+			conditions: `{"field":"domain","operator":"equals","value":"example.com"}`,
+			action:     models.ActionDelete,
+		},
+		{
+			name:       "header list becomes any",
+			script:     `if header :contains ["subject", "x-spam"] "offer" { discard; stop; }`,
+			conditions: `{"any":[{"field":"subject","operator":"contains","value":"offer"},{"field":"header","header":"x-spam","operator":"contains","value":"offer"}]}`,
+			action:     models.ActionDelete,
+			stop:       true,
+		},
+		{
+			name:       "matches glob",
+			script:     `if header :matches "subject" "[News]*" { fileinto "Archive"; }`,
+			conditions: `{"field":"subject","operator":"starts_with","value":"[News]"}`,
+			action:     models.ActionArchive,
+		},
+		{
+			name:       "matches wildcard in the middle",
+			script:     `if header :matches "subject" "Invoice ?*.pdf" { fileinto "Archive"; }`,
+			conditions: `{"field":"subject","operator":"matches","value":"^Invoice ..*\\.pdf$"}`,
+			action:     models.ActionArchive,
+		},
+		{
+			name:       "seen flag",
+			script:     `require "imap4flags"; if size :over 1M { addflag "\\Seen"; }`,
+			conditions: `{"field":"size","operator":"gt","value":"1048576"}`,
+			action:     models.ActionMarkRead,
+		},
+		{
+			name:       "unconditional",
+			script:     `fileinto "[Gmail]/Spam";`,
+			conditions: `{"field":"size","operator":"lt","value":"0","negate":true}`,
+			action:     models.ActionSpam,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			commands, err := parseSieve(tc.script)
+			if err != nil {
+				t.Fatalf("parseSieve: %v", err)
+			}
+			rules, issues := convertSieve(commands)
+			if len(issues) != 0 {
+				t.Errorf("issues = %+v", issues[0])
+			}
+			if len(rules) != 1 {
+				t.Fatalf("rules = %d, want 1", len(rules))
+			}
+			rule := rules[0].rule
+			if got := conditionJSON(t, rule.Conditions); got != tc.conditions {
+				t.Errorf("conditions = %s\nwant %s", got, tc.conditions)
+			}
+			if rule.Action != tc.action || rule.StopProcessing != tc.stop {
+				t.Errorf("action = %s, stop = %v", rule.Action, rule.StopProcessing)
+			}
+		})
+	}
+}
+
+func TestConvertSieveChain(t *testing.T) {
+	script := `if header :is "x-priority" "1" {
+    keep;
+} elsif address :is "from" "boss@example.com" {
+    addflag "\\Seen";
+} else {
+    fileinto "Archive";
+}`
+	commands, err := parseSieve(script)
+	if err != nil {
+		t.Fatalf("parseSieve: %v", err)
+	}
+	rules, issues := convertSieve(commands)
+	if len(issues) != 0 {
+		t.Errorf("issues = %+v", issues[0])
+	}
+	if len(rules) != 2 {
+		t.Fatalf("rules = %d, want 2", len(rules))
+	}
+
+	// Chaque branche porte la négation des tests qui la précèdent
+	priority := `{"field":"header","header":"x-priority","operator":"equals","value":"1","negate":true}`
+	sender := `{"field":"sender","operator":"equals","value":"boss@example.com"`
+	if got, want := conditionJSON(t, rules[0].rule.Conditions), `{"all":[`+priority+`,`+sender+`}]}`; got != want {
+		t.Errorf("elsif conditions = %s\nwant %s", got, want)
+	}
+	if got, want := conditionJSON(t, rules[1].rule.Conditions), `{"all":[`+priority+`,`+sender+`,"negate":true}]}`; got != want {
+		t.Errorf("else conditions = %s\nwant %s", got, want)
+	}
+	if rules[0].line != 4 || rules[1].line != 6 {
+		t.Errorf("lines = %d, %d", rules[0].line, rules[1].line)
+	}
+}
+
+func TestConvertSieveUnsupported(t *testing.T) {
+	script := `require ["fileinto", "vacation"];
+vacation "Absent";
+if header :is "subject" "x" { fileinto "Projects"; }
+if envelope :is "from" "a@example.com" { discard; } else { fileinto "Trash"; }
+if true { addflag "\\Flagged"; }
+`
+	commands, err := parseSieve(script)
+	if err != nil {
+		t.Fatalf("parseSieve: %v", err)
+	}
+	rules, issues := convertSieve(commands)
+	if len(rules) != 0 {
+		t.Errorf("rules = %d, want 0", len(rules))
+	}
+
+	constructs := []string{}
+	for _, issue := range issues {
+		constructs = append(constructs, issue.Construct)
+	}
+	want := []string{"require vacation", "vacation", `fileinto "Projects"`, "envelope", "else", `addflag \Flagged`}
+	if strings.Join(constructs, "|") != strings.Join(want, "|") {
+		t.Errorf("issues = %q\nwant %q", constructs, want)
+	}
+}
+
+func TestSieveExportRoundTrip(t *testing.T) {
+	rules := []*models.CleanupRule{
+		{
+			ID: 1, Name: "Newsletters", Enabled: true, Action: models.ActionArchive, StopProcessing: true,
+			Conditions: &models.RuleCondition{All: []*models.RuleCondition{
+				{Field: models.RuleFieldHeader, Header: "list-unsubscribe", Operator: models.RuleOpExists},
+				{Field: models.RuleFieldSubject, Operator: models.RuleOpStartsWith, Value: "Weekly"},
+				{Field: models.RuleFieldDomain, Operator: models.RuleOpEquals, Value: "example.com", Negate: true},
+			}},
+		},
+		{
+			ID: 2, Name: "Gros messages lus", Enabled: true, Action: models.ActionDelete,
+			Conditions: &models.RuleCondition{Any: []*models.RuleCondition{
+				{Field: models.RuleFieldSize, Operator: models.RuleOpGreater, Value: "5242880"},
+				{Field: models.RuleFieldIsRead, Operator: models.RuleOpEquals, Value: "true"},
+			}},
+		},
+		{
+			ID: 3, Name: "Promotions", Enabled: true, Action: models.ActionSpam,
+			Conditions: &models.RuleCondition{Field: models.RuleFieldSubject, Operator: models.RuleOpMatches, Value: `^\[promo\] "`},
+		},
+		{
+			ID: 4, Name: "Expéditeur", Enabled: true, Action: models.ActionMarkRead,
+			Conditions: &models.RuleCondition{Field: models.RuleFieldSender, Operator: models.RuleOpContains, Value: "noreply"},
+		},
+		{
+			ID: 5, Name: "Désactivée", Enabled: false, Action: models.ActionDelete,
+			Conditions: &models.RuleCondition{Field: models.RuleFieldSubject, Operator: models.RuleOpContains, Value: "x"},
+		},
+		{
+			ID: 6, Name: "Libellé", Enabled: true, Action: models.ActionDelete,
+			Conditions: &models.RuleCondition{Field: models.RuleFieldLabel, Operator: models.RuleOpEquals, Value: "Work"},
+		},
+	}
+
+	export := exportSieve(rules)
+	if export.Exported != 4 {
+		t.Errorf("exported = %d, want 4", export.Exported)
+	}
+	if len(export.Unsupported) != 1 || export.Unsupported[0].RuleID != 6 {
+		t.Errorf("unsupported = %+v", export.Unsupported)
+	}
+	if !strings.HasPrefix(export.Script, "# Tamis cleanup rules\nrequire [\"fileinto\", \"imap4flags\", \"regex\"];\n") {
+		t.Errorf("script header:\n%s", export.Script)
+	}
+
+	commands, err := parseSieve(export.Script)
+	if err != nil {
+		t.Fatalf("parseSieve(exported script): %v\n%s", err, export.Script)
+	}
+	imported, issues := convertSieve(commands)
+	if len(issues) != 0 {
+		t.Errorf("issues = %+v", issues[0])
+	}
+	if len(imported) != 4 {
+		t.Fatalf("imported = %d, want 4\n%s", len(imported), export.Script)
+	}
+
+	// Le script réimporté redonne les mêmes conditions, actions et arrêts
+	for i, original := range []*models.CleanupRule{rules[0], rules[1], rules[2], rules[3]} {
+		rule := imported[i].rule
+		if got, want := conditionJSON(t, rule.Conditions), conditionJSON(t, original.Conditions); got != want {
+			t.Errorf("rule %d conditions = %s\nwant %s", original.ID, got, want)
+		}
+		if rule.Action != original.Action || rule.StopProcessing != original.StopProcessing {
+			t.Errorf("rule %d action = %s, stop = %v", original.ID, rule.Action, rule.StopProcessing)
+		}
+	}
+}
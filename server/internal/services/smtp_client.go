@@ -0,0 +1,182 @@
+package services
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// OutgoingMail - Message texte simple envoyé au nom d'un compte (désinscription mailto...)
+type OutgoingMail struct {
+	From    string
+	To      string
+	Subject string
+	Body    string
+}
+
+// MailSender - Envoi d'un message depuis un compte (API du provider ou SMTP)
+type MailSender interface {
+	SendMail(msg *OutgoingMail) error
+}
+
+// SMTPConfig - Paramètres de connexion à un serveur SMTP de soumission
+type SMTPConfig struct {
+	Addr       string         // host:port ; le port 465 utilise TLS implicite, les autres STARTTLS
+	Username   string         // Adresse email du compte
+	Secret     string         // Access token OAuth2 ou mot de passe selon Auth
+	Auth       IMAPAuthMethod // Mêmes méthodes qu'en IMAP, XOAUTH2 par défaut
+	TLSConfig  *tls.Config    // Optionnel (certificats de test, ServerName...)
+	DisableTLS bool           // Connexion en clair, réservé aux serveurs de test locaux
+	Timeout    time.Duration
+}
+
+// SMTPClient - Client SMTP minimal pour l'envoi de messages texte
+type SMTPClient struct {
+	config SMTPConfig
+}
+
+func NewSMTPClient(config SMTPConfig) *SMTPClient {
+	if config.Auth == "" {
+		config.Auth = IMAPAuthXOAuth2
+	}
+	if config.Timeout == 0 {
+		config.Timeout = 30 * time.Second
+	}
+	return &SMTPClient{config: config}
+}
+
+// SendMail - Se connecter, s'authentifier et soumettre le message
+func (c *SMTPClient) SendMail(msg *OutgoingMail) error {
+	host, port, err := net.SplitHostPort(c.config.Addr)
+	if err != nil {
+		return fmt.Errorf("invalid smtp address %q: %w", c.config.Addr, err)
+	}
+
+	tlsConfig := c.config.TLSConfig
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{ServerName: host}
+	}
+
+	dialer := &net.Dialer{Timeout: c.config.Timeout}
+	implicitTLS := !c.config.DisableTLS && port == "465"
+
+	var conn net.Conn
+	if implicitTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", c.config.Addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", c.config.Addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to smtp server: %w", err)
+	}
+	conn.SetDeadline(time.Now().Add(c.config.Timeout))
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp handshake failed: %w", err)
+	}
+	defer client.Close()
+
+	if !implicitTLS && !c.config.DisableTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("smtp server does not support starttls")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("smtp starttls failed: %w", err)
+		}
+	}
+
+	var auth smtp.Auth
+	if c.config.Auth == IMAPAuthLogin {
+		auth = smtp.PlainAuth("", c.config.Username, c.config.Secret, host)
+	} else {
+		auth = &xoauth2SMTPAuth{username: c.config.Username, accessToken: c.config.Secret}
+	}
+	if err := client.Auth(auth); err != nil {
+		return fmt.Errorf("smtp authentication failed: %w", err)
+	}
+
+	from := msg.From
+	if from == "" {
+		from = c.config.Username
+	}
+	if err := client.Mail(from); err != nil {
+		return fmt.Errorf("smtp MAIL FROM rejected: %w", err)
+	}
+	if err := client.Rcpt(msg.To); err != nil {
+		return fmt.Errorf("smtp RCPT TO rejected: %w", err)
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp DATA rejected: %w", err)
+	}
+	if _, err := writer.Write(buildOutgoingMessage(from, msg)); err != nil {
+		writer.Close()
+		return fmt.Errorf("failed to write smtp message: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("smtp message rejected: %w", err)
+	}
+
+	return client.Quit()
+}
+
+// xoauth2SMTPAuth - Mécanisme SASL XOAUTH2 (Gmail, Outlook, Yahoo)
+type xoauth2SMTPAuth struct {
+	username    string
+	accessToken string
+}
+
+func (a *xoauth2SMTPAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	return "XOAUTH2", []byte("user=" + a.username + "\x01auth=Bearer " + a.accessToken + "\x01\x01"), nil
+}
+
+func (a *xoauth2SMTPAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	// En cas d'échec le serveur envoie un défi JSON : une réponse vide termine l'échange avec l'erreur finale
+	if more {
+		return []byte{}, nil
+	}
+	return nil, nil
+}
+
+// buildOutgoingMessage - Message RFC 5322 texte brut (UTF-8) ; From omis s'il est vide
+func buildOutgoingMessage(from string, msg *OutgoingMail) []byte {
+	var buf bytes.Buffer
+
+	if from != "" {
+		fmt.Fprintf(&buf, "From: %s\r\n", from)
+	}
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: base64\r\n")
+	buf.WriteString("\r\n")
+
+	encoded := base64.StdEncoding.EncodeToString([]byte(msg.Body))
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded + "\r\n")
+
+	return buf.Bytes()
+}
+
+// smtpAddrForIMAPHost - Serveur de soumission déduit du serveur IMAP (imap.example.com -> smtp.example.com:465)
+func smtpAddrForIMAPHost(imapHost string) string {
+	host := imapHost
+	if strings.HasPrefix(host, "imap.") {
+		host = "smtp." + strings.TrimPrefix(host, "imap.")
+	}
+	return net.JoinHostPort(host, "465")
+}
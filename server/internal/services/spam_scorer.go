@@ -0,0 +1,146 @@
+package services
+
+import (
+	"net/mail"
+	"regexp"
+	"strings"
+	"tamis-server/internal/models"
+)
+
+// Signaux de spam relevés dans les en-têtes et points associés
+const (
+	spamSignalDMARCFail       = "dmarc_fail"
+	spamSignalSPFFail         = "spf_fail"
+	spamSignalSPFSoftFail     = "spf_softfail"
+	spamSignalDKIMFail        = "dkim_fail"
+	spamSignalAuthPass        = "authenticated" // DKIM et DMARC valides : domaine expéditeur authentifié
+	spamSignalUpstreamFlag    = "upstream_flag" // X-Spam-Flag / X-Spam-Status posé par un filtre en amont
+	spamSignalDisplayName     = "display_name_mismatch"
+	spamSignalReplyTo         = "reply_to_mismatch"
+	spamSignalPrecedenceJunk  = "precedence_junk"
+	spamSignalBulkNoUnsubscri = "bulk_without_unsubscribe"
+)
+
+var spamSignalPoints = map[string]int{
+	spamSignalDMARCFail:       4,
+	spamSignalSPFFail:         3,
+	spamSignalSPFSoftFail:     1,
+	spamSignalDKIMFail:        2,
+	spamSignalAuthPass:        -2,
+	spamSignalUpstreamFlag:    models.SpamScoreThreshold,
+	spamSignalDisplayName:     3,
+	spamSignalReplyTo:         1,
+	spamSignalPrecedenceJunk:  2,
+	spamSignalBulkNoUnsubscri: 2,
+}
+
+// authResultPattern - Résultats "méthode=verdict" d'un en-tête Authentication-Results (RFC 8601)
+var authResultPattern = regexp.MustCompile(`(?i)\b(spf|dkim|dmarc)\s*=\s*([a-z]+)`)
+
+// displayNameDomainPattern - Adresse ou nom de domaine affiché dans le nom de l'expéditeur ("PayPal <...>" n'en contient pas)
+var displayNameDomainPattern = regexp.MustCompile(`(?i)(?:[a-z0-9._%+-]+@)?((?:[a-z0-9-]+\.)+[a-z]{2,})`)
+
+// scoreSpam - Score local d'un email à partir de ses en-têtes conservés, et signaux relevés
+func scoreSpam(email *models.Email) (int, []string) {
+	headers := email.Headers
+	signals := []string{}
+
+	signals = append(signals, authenticationSignals(headers["authentication-results"])...)
+
+	if strings.EqualFold(headers["x-spam-flag"], "yes") || strings.HasPrefix(strings.ToLower(headers["x-spam-status"]), "yes") {
+		signals = append(signals, spamSignalUpstreamFlag)
+	}
+
+	senderDomain := addressDomain(normalizeSender(email.From))
+	if senderDomain != "" {
+		if from, err := mail.ParseAddress(headers["from"]); err == nil {
+			for _, match := range displayNameDomainPattern.FindAllStringSubmatch(from.Name, -1) {
+				if !sameBaseDomain(match[1], senderDomain) {
+					signals = append(signals, spamSignalDisplayName)
+					break
+				}
+			}
+		}
+
+		if replyTo := addressDomain(normalizeSender(headers["reply-to"])); replyTo != "" && !sameBaseDomain(replyTo, senderDomain) {
+			signals = append(signals, spamSignalReplyTo)
+		}
+	}
+
+	precedence := strings.ToLower(headers["precedence"])
+	if precedence == "junk" {
+		signals = append(signals, spamSignalPrecedenceJunk)
+	}
+	if headers["list-unsubscribe"] == "" {
+		bulk := precedence == "bulk"
+		for _, name := range bulkMailerHeaders {
+			bulk = bulk || headers[name] != ""
+		}
+		if bulk {
+			signals = append(signals, spamSignalBulkNoUnsubscri)
+		}
+	}
+
+	score := 0
+	for _, signal := range signals {
+		score += spamSignalPoints[signal]
+	}
+	return score, signals
+}
+
+// authenticationSignals - Échecs SPF/DKIM/DMARC (ou authentification réussie) d'un en-tête Authentication-Results.
+// Une seule signature DKIM valide suffit quand le message en porte plusieurs.
+func authenticationSignals(value string) []string {
+	if value == "" {
+		return nil
+	}
+
+	results := map[string]map[string]bool{}
+	for _, match := range authResultPattern.FindAllStringSubmatch(value, -1) {
+		method, verdict := strings.ToLower(match[1]), strings.ToLower(match[2])
+		if results[method] == nil {
+			results[method] = map[string]bool{}
+		}
+		results[method][verdict] = true
+	}
+
+	signals := []string{}
+	if results["dmarc"]["fail"] {
+		signals = append(signals, spamSignalDMARCFail)
+	}
+	switch {
+	case results["spf"]["fail"]:
+		signals = append(signals, spamSignalSPFFail)
+	case results["spf"]["softfail"]:
+		signals = append(signals, spamSignalSPFSoftFail)
+	}
+	if results["dkim"]["fail"] && !results["dkim"]["pass"] {
+		signals = append(signals, spamSignalDKIMFail)
+	}
+	if results["dkim"]["pass"] && results["dmarc"]["pass"] {
+		signals = append(signals, spamSignalAuthPass)
+	}
+	return signals
+}
+
+// addressDomain - Domaine d'une adresse normalisée ("" si absent)
+func addressDomain(address string) string {
+	if at := strings.LastIndex(address, "@"); at >= 0 {
+		return address[at+1:]
+	}
+	return ""
+}
+
+// baseDomain - Deux derniers niveaux d'un domaine (news.example.com -> example.com)
+func baseDomain(domain string) string {
+	labels := strings.Split(strings.ToLower(strings.TrimSuffix(domain, ".")), ".")
+	if len(labels) > 2 {
+		labels = labels[len(labels)-2:]
+	}
+	return strings.Join(labels, ".")
+}
+
+// sameBaseDomain - Domaines de la même organisation (approximation par les deux derniers niveaux)
+func sameBaseDomain(a, b string) bool {
+	return baseDomain(a) == baseDomain(b)
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"tamis-server/internal/models"
+)
+
+func TestScoreSpam(t *testing.T) {
+	cases := []struct {
+		name    string
+		from    string
+		headers map[string]string
+		score   int
+		signals []string
+	}{
+		{
+			name: "failed authentication and impersonated display name",
+			from: "support@secure-login.example.net",
+			headers: map[string]string{
+				"from":                   `"paypal.com Support" <support@secure-login.example.net>`,
+				"authentication-results": "mx.example.org; spf=fail smtp.mailfrom=example.net; dkim=fail; dmarc=fail header.from=example.net",
+			},
+			score:   12,
+			signals: []string{spamSignalDMARCFail, spamSignalSPFFail, spamSignalDKIMFail, spamSignalDisplayName},
+		},
+		{
+			name: "authenticated sender",
+			from: "news@mail.example.com",
+			headers: map[string]string{
+				"from":                   "Example <news@mail.example.com>",
+				"authentication-results": "mx.example.org; spf=pass; dkim=pass header.d=example.com; dmarc=pass",
+				"reply-to":               "support@example.com",
+				"list-unsubscribe":       "<mailto:stop@example.com>",
+				"precedence":             "bulk",
+			},
+			score:   -2,
+			signals: []string{spamSignalAuthPass},
+		},
+		{
+			name: "upstream flag and bulk mail without unsubscribe",
+			from: "offers@example.com",
+			headers: map[string]string{
+				"x-spam-status": "Yes, score=9.1",
+				"x-mailgun-tag": "promo",
+			},
+			score:   7,
+			signals: []string{spamSignalUpstreamFlag, spamSignalBulkNoUnsubscri},
+		},
+		{
+			name: "reply-to on another domain and soft fail",
+			from: "billing@example.com",
+			headers: map[string]string{
+				"authentication-results": "mx.example.org; spf=softfail",
+				"reply-to":               "Billing <billing@example.org>",
+			},
+			score:   2,
+			signals: []string{spamSignalSPFSoftFail, spamSignalReplyTo},
+		},
+		{
+			name: "one valid dkim signature among several",
+			from: "team@example.com",
+			headers: map[string]string{
+				"authentication-results": "mx.example.org; dkim=fail header.d=old.example; dkim=pass header.d=example.com; dmarc=pass",
+			},
+			score:   -2,
+			signals: []string{spamSignalAuthPass},
+		},
+		{
+			name: "display name on the sender's own domain",
+			from: "alerts@bank.example.com",
+			headers: map[string]string{
+				"from":        "example.com alerts <alerts@bank.example.com>",
+				"x-spam-flag": "NO",
+				"precedence":  "junk",
+			},
+			score:   2,
+			signals: []string{spamSignalPrecedenceJunk},
+		},
+		{
+			name:    "no headers",
+			from:    "friend@example.org",
+			score:   0,
+			signals: []string{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			score, signals := scoreSpam(&models.Email{From: tc.from, Headers: tc.headers})
+			if score != tc.score || !reflect.DeepEqual(signals, tc.signals) {
+				t.Errorf("scoreSpam = %d %q, want %d %q", score, signals, tc.score, tc.signals)
+			}
+		})
+	}
+}
+
+func TestScoreSpamThreshold(t *testing.T) {
+	// Un filtre en amont suffit à classer l'email en spam
+	score, _ := scoreSpam(&models.Email{From: "a@example.com", Headers: map[string]string{"x-spam-flag": "YES"}})
+	if score < models.SpamScoreThreshold {
+		t.Errorf("score = %d, want at least %d", score, models.SpamScoreThreshold)
+	}
+}
+
+func TestBaseDomain(t *testing.T) {
+	cases := map[string]string{
+		"news.mail.example.com": "example.com",
+		"Example.COM.":          "example.com",
+		"localhost":             "localhost",
+	}
+	for domain, want := range cases {
+		if got := baseDomain(domain); got != want {
+			t.Errorf("baseDomain(%q) = %q, want %q", domain, got, want)
+		}
+	}
+}
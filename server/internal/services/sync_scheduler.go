@@ -0,0 +1,235 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"tamis-server/internal/config"
+	"tamis-server/internal/models"
+	"tamis-server/internal/repository"
+	"tamis-server/internal/utils"
+	"time"
+)
+
+// SyncScheduler - Synchronisation périodique de tous les comptes actifs
+type SyncScheduler struct {
+	mailService    *MailService
+	accountService *AccountService
+	settingsRepo   *repository.SettingsRepository
+	config         config.SchedulerConfig
+	logger         *utils.Logger
+
+	queue chan *models.EmailAccount
+
+	mu          sync.Mutex
+	pending     map[int]bool          // Comptes en file d'attente ou en cours de synchronisation
+	lastAttempt map[int]time.Time     // Dernière tentative, réussie ou non (évite de relancer un échec à chaque tick)
+	jitter      map[int]time.Duration // Décalage tiré après chaque tentative pour étaler la charge
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewSyncScheduler(mailService *MailService, accountService *AccountService, settingsRepo *repository.SettingsRepository, cfg config.SchedulerConfig, logger *utils.Logger) *SyncScheduler {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	if cfg.TickInterval <= 0 {
+		cfg.TickInterval = time.Minute
+	}
+
+	return &SyncScheduler{
+		mailService:    mailService,
+		accountService: accountService,
+		settingsRepo:   settingsRepo,
+		config:         cfg,
+		logger:         logger,
+		queue:          make(chan *models.EmailAccount, cfg.Workers),
+		pending:        make(map[int]bool),
+		lastAttempt:    make(map[int]time.Time),
+		jitter:         make(map[int]time.Duration),
+	}
+}
+
+// Start - Démarrer la boucle de planification et les workers
+func (s *SyncScheduler) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+
+	for i := 0; i < s.config.Workers; i++ {
+		s.wg.Add(1)
+		go s.worker(ctx)
+	}
+
+	s.wg.Add(1)
+	go s.loop(ctx)
+
+	s.logger.Info(fmt.Sprintf("Sync scheduler started - Workers: %d, Default interval: %s", s.config.Workers, s.config.DefaultInterval))
+}
+
+// Stop - Arrêter la planification et attendre la fin des synchronisations en cours
+func (s *SyncScheduler) Stop() {
+	if s.cancel == nil {
+		return
+	}
+
+	s.cancel()
+	s.wg.Wait()
+	s.logger.Info("Sync scheduler stopped")
+}
+
+// loop - Rechercher les comptes à synchroniser à chaque tick
+func (s *SyncScheduler) loop(ctx context.Context) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.config.TickInterval)
+	defer ticker.Stop()
+
+	for {
+		s.scheduleDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// scheduleDue - Mettre en file les comptes dont l'échéance est passée
+func (s *SyncScheduler) scheduleDue(ctx context.Context) {
+	accounts, err := s.accountService.GetActiveAccounts()
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Scheduler failed to list accounts: %v", err))
+		return
+	}
+
+	settings, err := s.settingsRepo.GetAll()
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Scheduler failed to load user settings: %v", err))
+		return
+	}
+
+	now := time.Now()
+	for _, account := range accounts {
+		userSettings := settings[account.UserID]
+		if userSettings != nil && !userSettings.AutoSyncEnabled {
+			continue
+		}
+
+		if !s.isDue(account, s.intervalFor(userSettings), now) {
+			continue
+		}
+
+		if !s.enqueue(ctx, account) {
+			// Pool saturé : les comptes restants seront repris au prochain tick
+			return
+		}
+	}
+}
+
+// intervalFor - Intervalle de synchronisation d'un utilisateur, borné par le minimum configuré
+func (s *SyncScheduler) intervalFor(settings *models.UserSettings) time.Duration {
+	interval := s.config.DefaultInterval
+	if settings != nil && settings.SyncIntervalMinutes > 0 {
+		interval = time.Duration(settings.SyncIntervalMinutes) * time.Minute
+	}
+	if interval < s.config.MinInterval {
+		interval = s.config.MinInterval
+	}
+	return interval
+}
+
+// isDue - Échéance = dernière synchronisation (ou tentative) + intervalle + jitter
+func (s *SyncScheduler) isDue(account *models.EmailAccount, interval time.Duration, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pending[account.ID] {
+		return false
+	}
+
+	last := s.lastAttempt[account.ID]
+	if account.LastSyncedAt != nil && account.LastSyncedAt.After(last) {
+		last = *account.LastSyncedAt
+	}
+	if last.IsZero() {
+		return true
+	}
+
+	jitter, ok := s.jitter[account.ID]
+	if !ok {
+		jitter = s.randomJitter()
+		s.jitter[account.ID] = jitter
+	}
+
+	return now.After(last.Add(interval + jitter))
+}
+
+// enqueue - Confier un compte à un worker sans bloquer (false si la file est pleine)
+func (s *SyncScheduler) enqueue(ctx context.Context, account *models.EmailAccount) bool {
+	s.mu.Lock()
+	s.pending[account.ID] = true
+	s.mu.Unlock()
+
+	select {
+	case s.queue <- account:
+		return true
+	case <-ctx.Done():
+	default:
+	}
+
+	s.mu.Lock()
+	delete(s.pending, account.ID)
+	s.mu.Unlock()
+	return false
+}
+
+// worker - Synchroniser les comptes reçus jusqu'à l'arrêt du scheduler
+func (s *SyncScheduler) worker(ctx context.Context) {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case account := <-s.queue:
+			s.syncAccount(account)
+		}
+	}
+}
+
+// syncAccount - Synchroniser un compte puis tirer un nouveau jitter
+func (s *SyncScheduler) syncAccount(account *models.EmailAccount) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.pending, account.ID)
+		s.lastAttempt[account.ID] = time.Now()
+		s.jitter[account.ID] = s.randomJitter()
+		s.mu.Unlock()
+	}()
+
+	result, err := s.mailService.SyncAccount(account, false)
+	if err != nil {
+		if errors.Is(err, ErrSyncInProgress) {
+			s.logger.Debug(fmt.Sprintf("Scheduled sync skipped for account %d: already running", account.ID))
+			return
+		}
+		s.logger.Error(fmt.Sprintf("Scheduled sync failed for account %d: %v", account.ID, err))
+		return
+	}
+
+	if result.NewEmails > 0 || result.UpdatedEmails > 0 || result.DeletedEmails > 0 {
+		s.logger.Info(fmt.Sprintf("Scheduled sync for account %d - New: %d, Updated: %d, Deleted: %d",
+			account.ID, result.NewEmails, result.UpdatedEmails, result.DeletedEmails))
+	}
+}
+
+// randomJitter - Décalage aléatoire dans [0, Jitter)
+func (s *SyncScheduler) randomJitter() time.Duration {
+	if s.config.Jitter <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(s.config.Jitter)))
+}
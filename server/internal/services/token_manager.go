@@ -0,0 +1,104 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"tamis-server/internal/models"
+	"tamis-server/internal/utils"
+	"time"
+)
+
+// tokenRefreshSkew - Marge avant expiration à partir de laquelle on rafraîchit le token
+const tokenRefreshSkew = 2 * time.Minute
+
+// TokenManager - Fournit des access tokens valides et les rafraîchit avant expiration
+type TokenManager struct {
+	accountService *AccountService
+	oauth2Service  *utils.OAuth2Service
+	logger         *utils.Logger
+
+	mu    sync.Mutex
+	locks map[int]*sync.Mutex // Un verrou par compte pour sérialiser les refresh concurrents
+}
+
+func NewTokenManager(accountService *AccountService, oauth2Service *utils.OAuth2Service, logger *utils.Logger) *TokenManager {
+	return &TokenManager{
+		accountService: accountService,
+		oauth2Service:  oauth2Service,
+		logger:         logger,
+		locks:          make(map[int]*sync.Mutex),
+	}
+}
+
+// GetValidToken - Récupérer les tokens déchiffrés, rafraîchis si nécessaire
+func (m *TokenManager) GetValidToken(account *models.EmailAccount) (*models.DecryptedTokens, error) {
+	// Les comptes IMAP par mot de passe n'ont pas de token à rafraîchir
+	if account.AuthType == models.AuthTypePassword {
+		return m.accountService.GetDecryptedToken(account.ID)
+	}
+
+	lock := m.accountLock(account.ID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	// Relire après acquisition du verrou : un autre worker a pu rafraîchir entre-temps
+	tokens, err := m.accountService.GetDecryptedToken(account.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tokens: %w", err)
+	}
+
+	if !needsRefresh(tokens) {
+		return tokens, nil
+	}
+
+	if tokens.RefreshToken == "" {
+		return nil, fmt.Errorf("access token expired and no refresh token available")
+	}
+
+	m.logger.Info(fmt.Sprintf("Refreshing expired token for account %d", account.ID))
+
+	newTokens, err := m.oauth2Service.RefreshProviderToken(account.Provider, tokens.RefreshToken)
+	if err != nil {
+		if errors.Is(err, utils.ErrTokenRevoked) {
+			reason := "OAuth2 access was revoked by the provider, please reconnect the account"
+			if deactivateErr := m.accountService.DeactivateAccount(account.ID, reason); deactivateErr != nil {
+				m.logger.Error(fmt.Sprintf("Failed to deactivate account %d: %v", account.ID, deactivateErr))
+			}
+			account.IsActive = false
+			account.InactiveReason = reason
+		}
+		return nil, fmt.Errorf("failed to refresh token: %w", err)
+	}
+
+	if err := m.accountService.UpdateTokens(account.ID, newTokens); err != nil {
+		return nil, fmt.Errorf("failed to save refreshed token: %w", err)
+	}
+
+	return &models.DecryptedTokens{
+		AccessToken:  newTokens.AccessToken,
+		RefreshToken: newTokens.RefreshToken,
+		ExpiresAt:    &newTokens.Expiry,
+	}, nil
+}
+
+// accountLock - Verrou dédié à un compte
+func (m *TokenManager) accountLock(accountID int) *sync.Mutex {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	lock, ok := m.locks[accountID]
+	if !ok {
+		lock = &sync.Mutex{}
+		m.locks[accountID] = lock
+	}
+	return lock
+}
+
+// needsRefresh - Token sans date d'expiration connue ou expirant bientôt
+func needsRefresh(tokens *models.DecryptedTokens) bool {
+	if tokens.ExpiresAt == nil {
+		return tokens.RefreshToken != ""
+	}
+	return time.Now().Add(tokenRefreshSkew).After(*tokens.ExpiresAt)
+}
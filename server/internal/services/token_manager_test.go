@@ -0,0 +1,176 @@
+package services
+
+import (
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"tamis-server/internal/config"
+	"tamis-server/internal/models"
+	"tamis-server/internal/repository"
+	"tamis-server/internal/utils"
+)
+
+// tokenTestEnv - TokenManager branché sur un compte Gmail en mémoire et un endpoint token de test
+type tokenTestEnv struct {
+	manager   *TokenManager
+	db        *fakeDB
+	refreshes []string // refresh_token reçus par l'endpoint
+}
+
+func newTokenTestEnv(t *testing.T, expiresAt *time.Time, refreshToken string, tokenHandler http.HandlerFunc) *tokenTestEnv {
+	t.Helper()
+
+	env := &tokenTestEnv{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "refresh_token" {
+			t.Errorf("token request form = %v (%v)", r.PostForm, err)
+		}
+		env.refreshes = append(env.refreshes, r.PostForm.Get("refresh_token"))
+		tokenHandler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	logger := utils.NewLogger()
+	now := time.Now()
+	account := &models.EmailAccount{
+		ID: 5, UserID: 1, Provider: models.ProviderGmail, Email: "me@gmail.com", AuthType: models.AuthTypeOAuth2,
+		TokenExpiresAt: expiresAt, IsActive: true, CreatedAt: now, UpdatedAt: now,
+	}
+
+	var accounts *AccountService
+	fake, db := newFakeDB(t, func(query string, args []driver.Value) ([][]driver.Value, error) {
+		if strings.Contains(query, "FROM email_accounts WHERE id = $1") {
+			return [][]driver.Value{fakeAccountRow(t, accounts, account, "old-access", refreshToken)}, nil
+		}
+		return nil, nil
+	})
+	accounts = NewAccountService(repository.NewAccountRepository(db), nil, logger, "test-key")
+
+	env.db = fake
+	env.manager = NewTokenManager(accounts, utils.NewOAuth2ServiceWithTokenURL(&config.Config{}, logger, server.URL), logger)
+	return env
+}
+
+// updates - Requêtes UPDATE envoyées à la base
+func (e *tokenTestEnv) updates() []string {
+	updates := []string{}
+	for _, query := range e.db.executed() {
+		if strings.HasPrefix(query, "UPDATE") {
+			updates = append(updates, query)
+		}
+	}
+	return updates
+}
+
+func refreshedToken(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"access_token":"new-access","expires_in":3600,"token_type":"Bearer"}`))
+}
+
+func TestGetValidTokenSkew(t *testing.T) {
+	cases := []struct {
+		name      string
+		expiresIn time.Duration
+		refresh   bool
+	}{
+		{"valid", time.Hour, false},
+		{"outside the skew", tokenRefreshSkew + time.Minute, false},
+		{"inside the skew", tokenRefreshSkew - time.Minute, true},
+		{"expired", -time.Minute, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			expiresAt := time.Now().Add(tc.expiresIn)
+			env := newTokenTestEnv(t, &expiresAt, "refresh-1", refreshedToken)
+
+			tokens, err := env.manager.GetValidToken(&models.EmailAccount{ID: 5, Provider: models.ProviderGmail})
+			if err != nil {
+				t.Fatalf("GetValidToken: %v", err)
+			}
+
+			if !tc.refresh {
+				if tokens.AccessToken != "old-access" || len(env.refreshes) != 0 {
+					t.Errorf("token = %q after %d refreshes", tokens.AccessToken, len(env.refreshes))
+				}
+				return
+			}
+			// Le refresh token est conservé quand le provider n'en renvoie pas de nouveau
+			if tokens.AccessToken != "new-access" || tokens.RefreshToken != "refresh-1" || time.Until(*tokens.ExpiresAt) < 59*time.Minute {
+				t.Errorf("tokens = %+v", tokens)
+			}
+			if len(env.refreshes) != 1 || env.refreshes[0] != "refresh-1" {
+				t.Errorf("refreshes = %v", env.refreshes)
+			}
+			if updates := env.updates(); len(updates) != 1 || !strings.Contains(updates[0], "SET access_token") {
+				t.Errorf("updates = %q", updates)
+			}
+		})
+	}
+}
+
+func TestGetValidTokenWithoutExpiry(t *testing.T) {
+	env := newTokenTestEnv(t, nil, "refresh-1", refreshedToken)
+
+	tokens, err := env.manager.GetValidToken(&models.EmailAccount{ID: 5, Provider: models.ProviderGmail})
+	if err != nil {
+		t.Fatalf("GetValidToken: %v", err)
+	}
+	if tokens.AccessToken != "new-access" || len(env.refreshes) != 1 {
+		t.Errorf("token = %q after %d refreshes", tokens.AccessToken, len(env.refreshes))
+	}
+}
+
+func TestGetValidTokenExpiredWithoutRefreshToken(t *testing.T) {
+	expiresAt := time.Now().Add(-time.Minute)
+	env := newTokenTestEnv(t, &expiresAt, "", refreshedToken)
+
+	if _, err := env.manager.GetValidToken(&models.EmailAccount{ID: 5, Provider: models.ProviderGmail}); err == nil {
+		t.Fatal("expired token returned without refresh token")
+	}
+	if len(env.refreshes) != 0 {
+		t.Errorf("refreshes = %v", env.refreshes)
+	}
+}
+
+func TestGetValidTokenRevokedDeactivatesAccount(t *testing.T) {
+	expiresAt := time.Now().Add(-time.Minute)
+	env := newTokenTestEnv(t, &expiresAt, "refresh-1", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"invalid_grant","error_description":"Token has been expired or revoked."}`))
+	})
+
+	account := &models.EmailAccount{ID: 5, Provider: models.ProviderGmail, IsActive: true}
+	_, err := env.manager.GetValidToken(account)
+	if !errors.Is(err, utils.ErrTokenRevoked) {
+		t.Fatalf("error = %v, want ErrTokenRevoked", err)
+	}
+
+	if account.IsActive || account.InactiveReason == "" {
+		t.Errorf("account = active %v, reason %q", account.IsActive, account.InactiveReason)
+	}
+	updates := env.updates()
+	if len(updates) != 1 || !strings.Contains(updates[0], "SET is_active") {
+		t.Errorf("updates = %q", updates)
+	}
+}
+
+func TestGetValidTokenTransientRefreshFailureKeepsAccount(t *testing.T) {
+	expiresAt := time.Now().Add(-time.Minute)
+	env := newTokenTestEnv(t, &expiresAt, "refresh-1", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"temporarily_unavailable"}`, http.StatusServiceUnavailable)
+	})
+
+	account := &models.EmailAccount{ID: 5, Provider: models.ProviderGmail, IsActive: true}
+	_, err := env.manager.GetValidToken(account)
+	if err == nil || errors.Is(err, utils.ErrTokenRevoked) {
+		t.Fatalf("error = %v", err)
+	}
+	if !account.IsActive || len(env.updates()) != 0 {
+		t.Errorf("account deactivated on a transient failure: %q", env.updates())
+	}
+}
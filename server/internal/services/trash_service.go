@@ -0,0 +1,254 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"tamis-server/internal/models"
+	"tamis-server/internal/repository"
+	"tamis-server/internal/utils"
+	"time"
+)
+
+const (
+	// defaultTrashRetentionDays - Rétention de la corbeille sans préférence enregistrée
+	defaultTrashRetentionDays = 30
+	// maxTrashRetentionDays - Rétention maximale configurable
+	maxTrashRetentionDays = 3650
+	// trashPurgeInterval - Fréquence de la purge automatique
+	trashPurgeInterval = time.Hour
+	// trashPageSize - Taille de page par défaut de la corbeille
+	trashPageSize = 50
+)
+
+// TrashService - Corbeille Tamis (liste, restauration, purge) et annulation des actions
+type TrashService struct {
+	emailRepo       *repository.EmailRepository
+	batchRepo       *repository.ActionBatchRepository
+	outboxRepo      *repository.OutboxRepository
+	accountService  *AccountService
+	settingsService *SettingsService
+	mailService     *MailService
+	logger          *utils.Logger
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewTrashService(emailRepo *repository.EmailRepository, batchRepo *repository.ActionBatchRepository, outboxRepo *repository.OutboxRepository, accountService *AccountService, settingsService *SettingsService, mailService *MailService, logger *utils.Logger) *TrashService {
+	return &TrashService{
+		emailRepo:       emailRepo,
+		batchRepo:       batchRepo,
+		outboxRepo:      outboxRepo,
+		accountService:  accountService,
+		settingsService: settingsService,
+		mailService:     mailService,
+		logger:          logger,
+	}
+}
+
+// ListTrash - Emails supprimés via Tamis, encore restaurables
+func (s *TrashService) ListTrash(userID, limit, offset int) (*models.TrashPage, error) {
+	settings, err := s.settingsService.GetSettings(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.TrashPage{Emails: []*models.Email{}, RetentionDays: settings.TrashRetentionDays}
+
+	accounts, err := s.accountService.GetUserAccounts(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user accounts: %w", err)
+	}
+
+	accountIDs := make([]int, 0, len(accounts))
+	for _, account := range accounts {
+		accountIDs = append(accountIDs, account.ID)
+	}
+	if len(accountIDs) == 0 {
+		return page, nil
+	}
+
+	if limit <= 0 {
+		limit = trashPageSize
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	page.Emails, page.TotalCount, err = s.emailRepo.GetTrash(accountIDs, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// Restore - Sortir des emails de la corbeille, chez le provider puis en base
+func (s *TrashService) Restore(userID int, emailIDs []string) (*models.EmailActionResult, error) {
+	return s.mailService.ExecuteEmailAction(userID, &models.EmailActionRequest{
+		EmailIDs: emailIDs,
+		Action:   models.ActionRestore,
+	})
+}
+
+// Undo - Annuler toute une action à partir de son handle
+func (s *TrashService) Undo(userID int, batchID string) (*models.EmailActionResult, error) {
+	batch, err := s.batchRepo.Get(userID, batchID)
+	if err != nil {
+		return nil, err
+	}
+	if batch == nil {
+		return nil, fmt.Errorf("undo handle not found")
+	}
+	if batch.UndoneAt != nil {
+		return nil, fmt.Errorf("action already undone")
+	}
+
+	now := time.Now()
+	if !now.Before(batch.ExpiresAt) {
+		return nil, fmt.Errorf("undo window expired")
+	}
+
+	inverse, ok := inverseAction(batch.Action)
+	if !ok {
+		return nil, fmt.Errorf("action %s cannot be undone", batch.Action)
+	}
+
+	items, err := s.batchRepo.GetItems(batch.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Réserver l'annulation : deux requêtes simultanées n'annulent pas deux fois (libérée en cas d'échec)
+	reserved, err := s.batchRepo.MarkUndone(batch.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !reserved {
+		return nil, fmt.Errorf("action already undone")
+	}
+
+	// Les opérations de ce lot pas encore appliquées chez le provider sont simplement annulées
+	outboxIDs := []int64{}
+	for _, item := range items {
+		if item.OutboxID != 0 {
+			outboxIDs = append(outboxIDs, item.OutboxID)
+		}
+	}
+	cancelledIDs := []string{}
+	if len(outboxIDs) > 0 {
+		cancelledIDs, err = s.outboxRepo.CancelOperations(userID, outboxIDs)
+		if err != nil {
+			s.releaseUndo(batch.ID, nil)
+			return nil, err
+		}
+	}
+	cancelled := make(map[string]bool, len(cancelledIDs))
+	for _, id := range cancelledIDs {
+		cancelled[id] = true
+	}
+
+	ids := []string{}
+	for _, item := range items {
+		if cancelled[item.EmailID] {
+			continue
+		}
+		// Lu/non lu, spam/non spam : ne rétablir que les emails dont l'état a réellement changé
+		if (batch.Action == models.ActionMarkRead && item.WasRead) || (batch.Action == models.ActionMarkUnread && !item.WasRead) {
+			continue
+		}
+		if (batch.Action == models.ActionSpam && item.WasSpam) || (batch.Action == models.ActionNotSpam && !item.WasSpam) {
+			continue
+		}
+		ids = append(ids, item.EmailID)
+	}
+
+	s.logger.Info(fmt.Sprintf("Undoing %s batch %s for user %d: %d emails, %d pending operations cancelled",
+		batch.Action, batch.ID, userID, len(ids), len(cancelledIDs)))
+
+	if len(ids) == 0 {
+		return &models.EmailActionResult{
+			Action:       inverse,
+			ProcessedIDs: []string{},
+			FailedIDs:    []string{},
+			PendingIDs:   []string{},
+		}, nil
+	}
+
+	result, err := s.mailService.executeEmailAction(userID, &models.EmailActionRequest{
+		EmailIDs: ids,
+		Action:   inverse,
+	}, actionOptions{confirmed: true})
+	if err != nil {
+		s.releaseUndo(batch.ID, cancelledIDs)
+		return nil, err
+	}
+
+	// Échec partiel : le handle reste utilisable pour les emails non rétablis
+	if len(result.FailedIDs) > 0 {
+		done := append(append(cancelledIDs, result.ProcessedIDs...), result.PendingIDs...)
+		s.releaseUndo(batch.ID, done)
+	}
+
+	return result, nil
+}
+
+// releaseUndo - Libérer la réservation d'un handle dont l'annulation a échoué
+func (s *TrashService) releaseUndo(batchID string, doneEmailIDs []string) {
+	if err := s.batchRepo.ReleaseUndo(batchID, doneEmailIDs); err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to release undo handle %s: %v", batchID, err))
+	}
+}
+
+// Start - Lancer la purge automatique de la corbeille et des handles expirés
+func (s *TrashService) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.wg.Add(1)
+	go s.worker(ctx)
+}
+
+// Stop - Arrêter la purge automatique
+func (s *TrashService) Stop() {
+	if s.cancel == nil {
+		return
+	}
+
+	s.cancel()
+	s.wg.Wait()
+	s.logger.Info("Trash purge stopped")
+}
+
+// worker - Purger au démarrage puis à intervalle régulier
+func (s *TrashService) worker(ctx context.Context) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(trashPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		s.purge()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purge - Supprimer définitivement les emails dont la rétention est dépassée
+func (s *TrashService) purge() {
+	now := time.Now()
+
+	count, err := s.emailRepo.PurgeTrash(now, defaultTrashRetentionDays)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to purge trash: %v", err))
+	} else if count > 0 {
+		s.logger.Info(fmt.Sprintf("Purged %d emails from trash", count))
+	}
+
+	if _, err := s.batchRepo.PurgeExpired(now); err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to purge undo handles: %v", err))
+	}
+}
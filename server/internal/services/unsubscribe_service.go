@@ -0,0 +1,385 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"tamis-server/internal/models"
+	"tamis-server/internal/repository"
+	"tamis-server/internal/utils"
+	"time"
+)
+
+// MailSenderFactory - Construit l'expéditeur d'un compte (remplaçable par un serveur SMTP/API de test)
+type MailSenderFactory func(account *models.EmailAccount, accessToken string) (MailSender, error)
+
+// UnsubscribeService - Désinscription des listes de diffusion (RFC 8058, mailto)
+type UnsubscribeService struct {
+	unsubscribeRepo *repository.UnsubscribeRepository
+	emailRepo       *repository.EmailRepository
+	mailService     *MailService
+	accountService  *AccountService
+	logger          *utils.Logger
+
+	httpClient *http.Client      // Requêtes One-Click
+	senders    MailSenderFactory // Envois mailto
+}
+
+func NewUnsubscribeService(unsubscribeRepo *repository.UnsubscribeRepository, emailRepo *repository.EmailRepository, mailService *MailService, accountService *AccountService, logger *utils.Logger) *UnsubscribeService {
+	return NewUnsubscribeServiceWithTransports(unsubscribeRepo, emailRepo, mailService, accountService, logger,
+		newUnsubscribeHTTPClient(), mailService.createMailSender)
+}
+
+// NewUnsubscribeServiceWithTransports - Service utilisant d'autres cibles HTTP et SMTP (serveurs de test locaux)
+func NewUnsubscribeServiceWithTransports(unsubscribeRepo *repository.UnsubscribeRepository, emailRepo *repository.EmailRepository, mailService *MailService, accountService *AccountService, logger *utils.Logger, httpClient *http.Client, senders MailSenderFactory) *UnsubscribeService {
+	return &UnsubscribeService{
+		unsubscribeRepo: unsubscribeRepo,
+		emailRepo:       emailRepo,
+		mailService:     mailService,
+		accountService:  accountService,
+		logger:          logger,
+		httpClient:      httpClient,
+		senders:         senders,
+	}
+}
+
+// listUnsubscribeTargets - Cibles annoncées par List-Unsubscribe (RFC 2369)
+type listUnsubscribeTargets struct {
+	HTTPS  string // Première URL https (seule utilisable en One-Click)
+	Link   string // Première URL http(s), à ouvrir manuellement
+	Mailto string
+}
+
+// Unsubscribe - Se désinscrire d'une liste : One-Click si possible, mailto sinon, puis nettoyage optionnel
+func (s *UnsubscribeService) Unsubscribe(userID int, req *models.UnsubscribeRequest) (*models.UnsubscribeResult, error) {
+	email, err := s.findListEmail(userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := parseListUnsubscribe(email.Headers["list-unsubscribe"])
+	oneClick := strings.EqualFold(strings.ReplaceAll(email.Headers["list-unsubscribe-post"], " ", ""), "List-Unsubscribe=One-Click")
+
+	record := &models.Unsubscription{
+		UserID:        userID,
+		AccountID:     email.AccountID,
+		Sender:        normalizeSender(email.From),
+		ListID:        normalizeListID(email.Headers["list-id"]),
+		ArchiveFuture: req.ArchiveFuture,
+	}
+
+	failures := []string{}
+	if oneClick && targets.HTTPS != "" {
+		record.Method = models.UnsubscribeOneClick
+		record.Target = targets.HTTPS
+		if err := s.postOneClick(targets.HTTPS); err != nil {
+			s.logger.Warn(fmt.Sprintf("One-click unsubscribe failed for %s: %v", record.Sender, err))
+			failures = append(failures, err.Error())
+		} else {
+			record.Status = models.UnsubscribeSucceeded
+		}
+	}
+
+	if record.Status == "" && targets.Mailto != "" {
+		record.Method = models.UnsubscribeMailto
+		record.Target = targets.Mailto
+		if err := s.sendMailto(email.AccountID, targets.Mailto); err != nil {
+			s.logger.Warn(fmt.Sprintf("Mailto unsubscribe failed for %s: %v", record.Sender, err))
+			failures = append(failures, err.Error())
+		} else {
+			record.Status = models.UnsubscribeSucceeded
+		}
+	}
+
+	if record.Status == "" {
+		switch {
+		case len(failures) > 0:
+			record.Status = models.UnsubscribeFailed
+			record.Error = strings.Join(failures, "; ")
+		case targets.Link != "":
+			// Lien sans One-Click : une requête automatique pourrait ne rien faire (ou confirmer autre chose)
+			record.Method = models.UnsubscribeLink
+			record.Target = targets.Link
+			record.Status = models.UnsubscribeManual
+		default:
+			return nil, fmt.Errorf("no supported unsubscribe method for %s", record.Sender)
+		}
+	}
+
+	if err := s.unsubscribeRepo.Save(record); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info(fmt.Sprintf("Unsubscribe from %s for user %d: %s (%s)", record.Sender, userID, record.Status, record.Method))
+
+	result := &models.UnsubscribeResult{Unsubscription: record}
+	if req.DeleteExisting && record.Status != models.UnsubscribeFailed {
+		ids, err := s.emailRepo.GetIDsFromList(userID, record.Sender, record.ListID)
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) > 0 {
+			cleanup, err := s.mailService.ExecuteEmailAction(userID, &models.EmailActionRequest{
+				EmailIDs: ids,
+				Action:   models.ActionDelete,
+			})
+			if err != nil {
+				return nil, err
+			}
+			result.Cleanup = cleanup
+		}
+	}
+
+	return result, nil
+}
+
+// GetUnsubscriptions - Historique des désinscriptions de l'utilisateur
+func (s *UnsubscribeService) GetUnsubscriptions(userID int) ([]*models.Unsubscription, error) {
+	return s.unsubscribeRepo.GetByUser(userID)
+}
+
+// ArchiveFromUnsubscribedLists - Archiver les nouveaux messages des listes marquées archive_future (NewMailHandler)
+func (s *UnsubscribeService) ArchiveFromUnsubscribedLists(account *models.EmailAccount, emails []*models.Email) {
+	lists, err := s.unsubscribeRepo.GetArchiveFuture(account.UserID)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to load auto-archive lists for user %d: %v", account.UserID, err))
+		return
+	}
+	if len(lists) == 0 {
+		return
+	}
+
+	senders := map[string]bool{}
+	listIDs := map[string]bool{}
+	for _, list := range lists {
+		if list.ListID != "" {
+			listIDs[list.ListID] = true
+		} else {
+			senders[list.Sender] = true
+		}
+	}
+
+	ids := []string{}
+	for _, email := range emails {
+		listID := normalizeListID(email.Headers["list-id"])
+		if (listID != "" && listIDs[listID]) || senders[normalizeSender(email.From)] {
+			ids = append(ids, email.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	if _, err := s.mailService.executeEmailAction(account.UserID, &models.EmailActionRequest{
+		EmailIDs: ids,
+		Action:   models.ActionArchive,
+	}, actionOptions{automated: true}); err != nil {
+		s.logger.Error(fmt.Sprintf("Failed to auto-archive %d emails for account %d: %v", len(ids), account.ID, err))
+		return
+	}
+
+	s.logger.Info(fmt.Sprintf("Auto-archived %d emails from unsubscribed lists for account %d", len(ids), account.ID))
+}
+
+// findListEmail - Email de référence portant l'en-tête List-Unsubscribe
+func (s *UnsubscribeService) findListEmail(userID int, req *models.UnsubscribeRequest) (*models.Email, error) {
+	var email *models.Email
+
+	switch {
+	case req.EmailID != "":
+		emails, err := s.emailRepo.GetByIDsForUser(userID, []string{req.EmailID})
+		if err != nil {
+			return nil, err
+		}
+		if len(emails) == 0 {
+			return nil, fmt.Errorf("email not found")
+		}
+		email = emails[0]
+	case req.Sender != "":
+		found, err := s.emailRepo.GetLatestListEmail(userID, normalizeSender(req.Sender))
+		if err != nil {
+			return nil, err
+		}
+		if found == nil {
+			return nil, fmt.Errorf("no mailing-list email found from %s", req.Sender)
+		}
+		email = found
+	default:
+		return nil, fmt.Errorf("email_id or sender is required")
+	}
+
+	if email.Headers["list-unsubscribe"] == "" {
+		return nil, fmt.Errorf("email has no List-Unsubscribe header")
+	}
+
+	return email, nil
+}
+
+// postOneClick - Requête RFC 8058 : POST sans cookies ni authentification
+func (s *UnsubscribeService) postOneClick(target string) error {
+	req, err := http.NewRequest(http.MethodPost, target, strings.NewReader("List-Unsubscribe=One-Click"))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("one-click request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("one-click request returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// sendMailto - Envoyer le message de désinscription depuis le compte qui a reçu la liste
+func (s *UnsubscribeService) sendMailto(accountID int, target string) error {
+	msg, err := parseMailto(target)
+	if err != nil {
+		return err
+	}
+
+	account, err := s.accountService.GetAccountByID(accountID)
+	if err != nil {
+		return err
+	}
+	if !account.IsActive {
+		return fmt.Errorf("account is inactive")
+	}
+	msg.From = account.Email
+
+	tokens, err := s.mailService.tokenManager.GetValidToken(account)
+	if err != nil {
+		return err
+	}
+
+	sender, err := s.senders(account, tokens.AccessToken)
+	if err != nil {
+		return err
+	}
+	if closer, ok := sender.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	return sender.SendMail(msg)
+}
+
+// parseListUnsubscribe - Extraire les URLs "<...>" d'un en-tête List-Unsubscribe
+func parseListUnsubscribe(header string) listUnsubscribeTargets {
+	targets := listUnsubscribeTargets{}
+
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if !strings.HasPrefix(part, "<") || !strings.HasSuffix(part, ">") {
+			continue
+		}
+		target := strings.TrimSpace(part[1 : len(part)-1])
+		lower := strings.ToLower(target)
+
+		switch {
+		case strings.HasPrefix(lower, "mailto:"):
+			if targets.Mailto == "" {
+				targets.Mailto = target
+			}
+		case strings.HasPrefix(lower, "https://"):
+			if targets.HTTPS == "" {
+				targets.HTTPS = target
+			}
+			if targets.Link == "" {
+				targets.Link = target
+			}
+		case strings.HasPrefix(lower, "http://"):
+			if targets.Link == "" {
+				targets.Link = target
+			}
+		}
+	}
+
+	return targets
+}
+
+// parseMailto - Destinataire, sujet et corps d'une URL mailto (RFC 6068)
+func parseMailto(target string) (*OutgoingMail, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mailto url: %w", err)
+	}
+
+	to, err := url.PathUnescape(u.Opaque)
+	if err != nil || to == "" {
+		to = u.Query().Get("to")
+	}
+	if to == "" {
+		return nil, fmt.Errorf("mailto url has no recipient")
+	}
+
+	query := u.Query()
+	msg := &OutgoingMail{
+		To:      to,
+		Subject: query.Get("subject"),
+		Body:    query.Get("body"),
+	}
+	if msg.Subject == "" {
+		msg.Subject = "unsubscribe"
+	}
+	if msg.Body == "" {
+		msg.Body = "unsubscribe"
+	}
+
+	return msg, nil
+}
+
+// normalizeListID - Identifiant de liste sans libellé ni chevrons ("Nom <a.b.c>" -> "a.b.c")
+func normalizeListID(listID string) string {
+	if start := strings.LastIndex(listID, "<"); start >= 0 {
+		if end := strings.Index(listID[start:], ">"); end > 0 {
+			listID = listID[start+1 : start+end]
+		}
+	}
+	return strings.ToLower(strings.TrimSpace(listID))
+}
+
+// newUnsubscribeHTTPClient - Client One-Click limité aux adresses publiques (les URLs viennent des emails reçus)
+func newUnsubscribeHTTPClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: publicAddressOnly,
+	}
+
+	return &http.Client{
+		Timeout: 20 * time.Second,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 3 {
+				return fmt.Errorf("too many redirects")
+			}
+			return nil
+		},
+	}
+}
+
+// publicAddressOnly - Refuser les connexions vers le réseau local (loopback, privé, link-local...)
+func publicAddressOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+		return fmt.Errorf("refusing to connect to non-public address %s", host)
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"tamis-server/internal/models"
+	"tamis-server/internal/repository"
+	"tamis-server/internal/utils"
+)
+
+// recordingSender - Expéditeur qui conserve les messages envoyés
+type recordingSender struct {
+	sent []*OutgoingMail
+}
+
+func (s *recordingSender) SendMail(msg *OutgoingMail) error {
+	s.sent = append(s.sent, msg)
+	return nil
+}
+
+// newUnsubscribeTestService - Service dont la base ne contient qu'un email de liste (headers) et son compte IMAP
+func newUnsubscribeTestService(t *testing.T, headers map[string]string, httpClient *http.Client, sender *recordingSender) *UnsubscribeService {
+	t.Helper()
+
+	logger := utils.NewLogger()
+	now := time.Now()
+	account := &models.EmailAccount{
+		ID: 3, UserID: 1, Provider: models.ProviderOther, Email: "me@example.org",
+		AuthType: models.AuthTypePassword, IMAPHost: "imap.example.org", IMAPPort: 993, IsActive: true,
+		CreatedAt: now, UpdatedAt: now,
+	}
+
+	var accountService *AccountService
+	encodedHeaders, err := json.Marshal(headers)
+	if err != nil {
+		t.Fatalf("marshal headers: %v", err)
+	}
+	_, db := newFakeDB(t, func(query string, args []driver.Value) ([][]driver.Value, error) {
+		switch {
+		case strings.Contains(query, "FROM emails WHERE id = ANY"):
+			return [][]driver.Value{{
+				"3-42", int64(3), "<42@lists.example.com>", "42", "Weekly news", "news@lists.example.com", "{me@example.org}",
+				now, int64(2048), false, false, false, "{INBOX}", nil, string(encodedHeaders), "newsletter", false,
+				nil, int64(0), "{}", nil, now, now,
+			}}, nil
+		case strings.Contains(query, "FROM email_accounts WHERE id = $1"):
+			return [][]driver.Value{fakeAccountRow(t, accountService, account, "app-password", "")}, nil
+		case strings.Contains(query, "INSERT INTO unsubscriptions"):
+			return [][]driver.Value{{int64(1), now, now}}, nil
+		}
+		t.Errorf("unexpected query %s", query)
+		return nil, nil
+	})
+
+	accountService = NewAccountService(repository.NewAccountRepository(db), nil, logger, "test-key")
+	mailService := &MailService{tokenManager: NewTokenManager(accountService, nil, logger)}
+	senders := func(account *models.EmailAccount, accessToken string) (MailSender, error) {
+		if account.Email != "me@example.org" || accessToken != "app-password" {
+			t.Errorf("sender for %s with token %q", account.Email, accessToken)
+		}
+		return sender, nil
+	}
+
+	return NewUnsubscribeServiceWithTransports(repository.NewUnsubscribeRepository(db), repository.NewEmailRepository(db),
+		mailService, accountService, logger, httpClient, senders)
+}
+
+func TestUnsubscribeOneClick(t *testing.T) {
+	var method, contentType, body string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		method, contentType, body = r.Method, r.Header.Get("Content-Type"), string(data)
+	}))
+	defer server.Close()
+
+	sender := &recordingSender{}
+	service := newUnsubscribeTestService(t, map[string]string{
+		"list-unsubscribe":      "<mailto:leave@lists.example.com>, <" + server.URL + "/u/42>",
+		"list-unsubscribe-post": "List-Unsubscribe=One-Click",
+	}, server.Client(), sender)
+
+	result, err := service.Unsubscribe(1, &models.UnsubscribeRequest{EmailID: "3-42"})
+	if err != nil {
+		t.Fatalf("Unsubscribe: %v", err)
+	}
+
+	if method != http.MethodPost || contentType != "application/x-www-form-urlencoded" || body != "List-Unsubscribe=One-Click" {
+		t.Errorf("request = %s %q %q", method, contentType, body)
+	}
+	record := result.Unsubscription
+	if record.Method != models.UnsubscribeOneClick || record.Status != models.UnsubscribeSucceeded || record.Target != server.URL+"/u/42" {
+		t.Errorf("record = %+v", record)
+	}
+	if len(sender.sent) != 0 {
+		t.Errorf("mailto sent after a successful one-click: %+v", sender.sent[0])
+	}
+}
+
+func TestUnsubscribeOneClickFailureFallsBackToMailto(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "gone", http.StatusGone)
+	}))
+	defer server.Close()
+
+	sender := &recordingSender{}
+	service := newUnsubscribeTestService(t, map[string]string{
+		"list-unsubscribe":      "<" + server.URL + "/u/42>, <mailto:leave@lists.example.com>",
+		"list-unsubscribe-post": "List-Unsubscribe=One-Click",
+	}, server.Client(), sender)
+
+	result, err := service.Unsubscribe(1, &models.UnsubscribeRequest{EmailID: "3-42"})
+	if err != nil {
+		t.Fatalf("Unsubscribe: %v", err)
+	}
+
+	record := result.Unsubscription
+	if record.Method != models.UnsubscribeMailto || record.Status != models.UnsubscribeSucceeded || record.Target != "mailto:leave@lists.example.com" {
+		t.Errorf("record = %+v", record)
+	}
+	// Sans sujet ni corps dans l'URL, le message porte "unsubscribe" et part du compte destinataire
+	want := []*OutgoingMail{{From: "me@example.org", To: "leave@lists.example.com", Subject: "unsubscribe", Body: "unsubscribe"}}
+	if !reflect.DeepEqual(sender.sent, want) {
+		t.Errorf("sent = %+v", sender.sent)
+	}
+}
+
+func TestUnsubscribeDefaultClientRefusesLoopback(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	service := NewUnsubscribeServiceWithTransports(nil, nil, nil, nil, utils.NewLogger(), newUnsubscribeHTTPClient(), nil)
+	err := service.postOneClick(server.URL + "/u/42")
+	if err == nil || !strings.Contains(err.Error(), "non-public address") {
+		t.Errorf("error = %v, want a non-public address refusal", err)
+	}
+	if requests != 0 {
+		t.Errorf("requests = %d, want 0", requests)
+	}
+}
+
+func TestParseMailto(t *testing.T) {
+	cases := []struct {
+		target string
+		want   OutgoingMail
+	}{
+		{"mailto:leave@example.com", OutgoingMail{To: "leave@example.com", Subject: "unsubscribe", Body: "unsubscribe"}},
+		{"mailto:leave@example.com?subject=Remove%20me", OutgoingMail{To: "leave@example.com", Subject: "Remove me", Body: "unsubscribe"}},
+		{"mailto:leave%2Blist@example.com?body=stop&subject=", OutgoingMail{To: "leave+list@example.com", Subject: "unsubscribe", Body: "stop"}},
+		{"mailto:?to=leave@example.com", OutgoingMail{To: "leave@example.com", Subject: "unsubscribe", Body: "unsubscribe"}},
+	}
+	for _, tc := range cases {
+		msg, err := parseMailto(tc.target)
+		if err != nil {
+			t.Errorf("parseMailto(%q): %v", tc.target, err)
+			continue
+		}
+		if *msg != tc.want {
+			t.Errorf("parseMailto(%q) = %+v, want %+v", tc.target, *msg, tc.want)
+		}
+	}
+
+	if _, err := parseMailto("mailto:?subject=x"); err == nil {
+		t.Error("mailto without recipient accepted")
+	}
+}
@@ -0,0 +1,29 @@
+package services
+
+const (
+	yahooIMAPAddr = "imap.mail.yahoo.com:993"
+	yahooSMTPAddr = "smtp.mail.yahoo.com:465"
+)
+
+// YahooClient - Client IMAP Yahoo Mail (XOAUTH2) avec les dossiers propres à Yahoo
+type YahooClient struct {
+	*GenericIMAPClient
+}
+
+func NewYahooClient(email, accessToken string) *YahooClient {
+	return NewYahooClientWithConfig(IMAPConfig{
+		Addr:     yahooIMAPAddr,
+		Username: email,
+		Secret:   accessToken,
+	})
+}
+
+// NewYahooClientWithConfig - Client Yahoo avec une configuration IMAP personnalisée (serveur de test)
+func NewYahooClientWithConfig(config IMAPConfig) *YahooClient {
+	config.Auth = IMAPAuthXOAuth2
+	config.Mailbox = "INBOX"
+	config.ArchiveMailbox = "Archive"
+	config.TrashMailbox = "Trash"
+	config.SpamMailbox = "Bulk"
+	return &YahooClient{GenericIMAPClient: NewGenericIMAPClient(config)}
+}
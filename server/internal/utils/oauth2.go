@@ -2,6 +2,7 @@ package utils
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -11,9 +12,28 @@ import (
 	"time"
 )
 
+const (
+	googleAuthURL  = "https://accounts.google.com/o/oauth2/v2/auth"
+	googleTokenURL = "https://oauth2.googleapis.com/token"
+
+	microsoftAuthURL  = "https://login.microsoftonline.com/common/oauth2/v2.0/authorize"
+	microsoftTokenURL = "https://login.microsoftonline.com/common/oauth2/v2.0/token"
+	microsoftMeURL    = "https://graph.microsoft.com/v1.0/me"
+	microsoftScopes   = "offline_access openid email profile https://graph.microsoft.com/Mail.ReadWrite https://graph.microsoft.com/User.Read"
+
+	yahooAuthURL     = "https://api.login.yahoo.com/oauth2/request_auth"
+	yahooTokenURL    = "https://api.login.yahoo.com/oauth2/get_token"
+	yahooUserInfoURL = "https://api.login.yahoo.com/openid/v1/userinfo"
+	yahooScopes      = "openid email profile mail-w"
+)
+
+// ErrTokenRevoked - Le refresh token a été révoqué ou a expiré (invalid_grant)
+var ErrTokenRevoked = errors.New("oauth2 refresh token revoked")
+
 type OAuth2Service struct {
-	config *config.Config
-	logger *Logger
+	config   *config.Config
+	logger   *Logger
+	tokenURL string // Endpoint token commun à tous les providers ; vide = endpoint de chaque provider
 }
 
 func NewOAuth2Service(config *config.Config, logger *Logger) *OAuth2Service {
@@ -23,14 +43,21 @@ func NewOAuth2Service(config *config.Config, logger *Logger) *OAuth2Service {
 	}
 }
 
+// NewOAuth2ServiceWithTokenURL - Service dont les échanges et refresh de tokens visent tokenURL (serveur de test)
+func NewOAuth2ServiceWithTokenURL(config *config.Config, logger *Logger, tokenURL string) *OAuth2Service {
+	service := NewOAuth2Service(config, logger)
+	service.tokenURL = tokenURL
+	return service
+}
+
 // GetGoogleAuthURL - Générer l'URL d'autorisation Google
 func (s *OAuth2Service) GetGoogleAuthURL(state string) string {
-	baseURL := "https://accounts.google.com/o/oauth2/v2/auth"
+	baseURL := googleAuthURL
 	params := url.Values{}
 	params.Add("client_id", s.config.OAuth2.Gmail.ClientID)
 	params.Add("redirect_uri", s.config.OAuth2.Gmail.RedirectURL)
 	params.Add("response_type", "code")
-	params.Add("scope", "https://www.googleapis.com/auth/gmail.readonly https://www.googleapis.com/auth/userinfo.email")
+	params.Add("scope", "https://www.googleapis.com/auth/gmail.modify https://www.googleapis.com/auth/userinfo.email https://www.googleapis.com/auth/userinfo.profile")
 	params.Add("access_type", "offline")
 	params.Add("prompt", "consent")
 	params.Add("state", state)
@@ -47,84 +74,189 @@ func (s *OAuth2Service) ExchangeCodeForTokens(code string) (*models.OAuth2Token,
 	data.Set("grant_type", "authorization_code")
 	data.Set("redirect_uri", s.config.OAuth2.Gmail.RedirectURL)
 
-	resp, err := http.PostForm("https://oauth2.googleapis.com/token", data)
+	return s.requestToken(googleTokenURL, data, "")
+}
+
+// RefreshGoogleToken - Rafraîchir un token Google
+func (s *OAuth2Service) RefreshGoogleToken(refreshToken string) (*models.OAuth2Token, error) {
+	data := url.Values{}
+	data.Set("client_id", s.config.OAuth2.Gmail.ClientID)
+	data.Set("client_secret", s.config.OAuth2.Gmail.ClientSecret)
+	data.Set("refresh_token", refreshToken)
+	data.Set("grant_type", "refresh_token")
+
+	return s.requestToken(googleTokenURL, data, refreshToken)
+}
+
+// RefreshProviderToken - Rafraîchir un token via l'endpoint du provider
+func (s *OAuth2Service) RefreshProviderToken(provider models.EmailProvider, refreshToken string) (*models.OAuth2Token, error) {
+	switch provider {
+	case models.ProviderGmail:
+		return s.RefreshGoogleToken(refreshToken)
+	case models.ProviderOutlook:
+		return s.RefreshOutlookToken(refreshToken)
+	case models.ProviderYahoo:
+		return s.RefreshYahooToken(refreshToken)
+	default:
+		return nil, fmt.Errorf("token refresh not supported for provider %s", provider)
+	}
+}
+
+// GetUserInfo - Récupérer les informations utilisateur depuis Google
+func (s *OAuth2Service) GetUserInfo(accessToken string) (*GoogleUserInfo, error) {
+	req, err := http.NewRequest("GET", "https://www.googleapis.com/oauth2/v2/userinfo", nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to exchange code: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, fmt.Errorf("failed to get user info: %w", err)
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("oauth2 error: %s", string(body))
+		return nil, fmt.Errorf("failed to get user info, status: %d", resp.StatusCode)
 	}
 
-	var tokenResponse struct {
-		AccessToken  string `json:"access_token"`
-		RefreshToken string `json:"refresh_token"`
-		ExpiresIn    int    `json:"expires_in"`
-		TokenType    string `json:"token_type"`
+	var userInfo GoogleUserInfo
+	if err := json.NewDecoder(resp.Body).Decode(&userInfo); err != nil {
+		return nil, fmt.Errorf("failed to decode user info: %w", err)
 	}
 
-	if err := json.Unmarshal(body, &tokenResponse); err != nil {
-		return nil, fmt.Errorf("failed to parse token response: %w", err)
-	}
+	return &userInfo, nil
+}
 
-	return &models.OAuth2Token{
-		AccessToken:  tokenResponse.AccessToken,
-		RefreshToken: tokenResponse.RefreshToken,
-		TokenType:    tokenResponse.TokenType,
-		Expiry:       time.Now().Add(time.Duration(tokenResponse.ExpiresIn) * time.Second),
-	}, nil
+type GoogleUserInfo struct {
+	ID    string `json:"id"`
+	Email string `json:"email"`
+	Name  string `json:"name"`
 }
 
-// RefreshGoogleToken - Rafraîchir un token Google
-func (s *OAuth2Service) RefreshGoogleToken(refreshToken string) (*models.OAuth2Token, error) {
+// GetOutlookAuthURL - Générer l'URL d'autorisation Microsoft (Outlook / Microsoft 365)
+func (s *OAuth2Service) GetOutlookAuthURL(state string) string {
+	params := url.Values{}
+	params.Add("client_id", s.config.OAuth2.Outlook.ClientID)
+	params.Add("redirect_uri", s.config.OAuth2.Outlook.RedirectURL)
+	params.Add("response_type", "code")
+	params.Add("response_mode", "query")
+	params.Add("scope", microsoftScopes)
+	params.Add("prompt", "select_account")
+	params.Add("state", state)
+
+	return fmt.Sprintf("%s?%s", microsoftAuthURL, params.Encode())
+}
+
+// ExchangeOutlookCode - Échanger le code d'autorisation Microsoft contre des tokens
+func (s *OAuth2Service) ExchangeOutlookCode(code string) (*models.OAuth2Token, error) {
 	data := url.Values{}
-	data.Set("client_id", s.config.OAuth2.Gmail.ClientID)
-	data.Set("client_secret", s.config.OAuth2.Gmail.ClientSecret)
+	data.Set("client_id", s.config.OAuth2.Outlook.ClientID)
+	data.Set("client_secret", s.config.OAuth2.Outlook.ClientSecret)
+	data.Set("code", code)
+	data.Set("grant_type", "authorization_code")
+	data.Set("redirect_uri", s.config.OAuth2.Outlook.RedirectURL)
+	data.Set("scope", microsoftScopes)
+
+	return s.requestToken(microsoftTokenURL, data, "")
+}
+
+// RefreshOutlookToken - Rafraîchir un token Microsoft (le refresh token peut être renouvelé)
+func (s *OAuth2Service) RefreshOutlookToken(refreshToken string) (*models.OAuth2Token, error) {
+	data := url.Values{}
+	data.Set("client_id", s.config.OAuth2.Outlook.ClientID)
+	data.Set("client_secret", s.config.OAuth2.Outlook.ClientSecret)
 	data.Set("refresh_token", refreshToken)
 	data.Set("grant_type", "refresh_token")
+	data.Set("scope", microsoftScopes)
+
+	return s.requestToken(microsoftTokenURL, data, refreshToken)
+}
 
-	resp, err := http.PostForm("https://oauth2.googleapis.com/token", data)
+// GetOutlookUserInfo - Récupérer le profil Microsoft Graph (/me)
+func (s *OAuth2Service) GetOutlookUserInfo(accessToken string) (*OutlookUserInfo, error) {
+	req, err := http.NewRequest("GET", microsoftMeURL, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to refresh token: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, fmt.Errorf("failed to get user info: %w", err)
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("refresh token error: %s", string(body))
+		return nil, fmt.Errorf("failed to get user info, status: %d", resp.StatusCode)
 	}
 
-	var tokenResponse struct {
-		AccessToken string `json:"access_token"`
-		ExpiresIn   int    `json:"expires_in"`
-		TokenType   string `json:"token_type"`
+	var userInfo OutlookUserInfo
+	if err := json.NewDecoder(resp.Body).Decode(&userInfo); err != nil {
+		return nil, fmt.Errorf("failed to decode user info: %w", err)
 	}
 
-	if err := json.Unmarshal(body, &tokenResponse); err != nil {
-		return nil, fmt.Errorf("failed to parse refresh response: %w", err)
+	return &userInfo, nil
+}
+
+type OutlookUserInfo struct {
+	ID                string `json:"id"`
+	Mail              string `json:"mail"`
+	UserPrincipalName string `json:"userPrincipalName"`
+	DisplayName       string `json:"displayName"`
+}
+
+// Email - Adresse principale (mail est vide pour certains comptes personnels)
+func (u *OutlookUserInfo) Email() string {
+	if u.Mail != "" {
+		return u.Mail
 	}
+	return u.UserPrincipalName
+}
 
-	return &models.OAuth2Token{
-		AccessToken:  tokenResponse.AccessToken,
-		RefreshToken: refreshToken,
-		TokenType:    tokenResponse.TokenType,
-		Expiry:       time.Now().Add(time.Duration(tokenResponse.ExpiresIn) * time.Second),
-	}, nil
+// GetYahooAuthURL - Générer l'URL d'autorisation Yahoo
+func (s *OAuth2Service) GetYahooAuthURL(state string) string {
+	params := url.Values{}
+	params.Add("client_id", s.config.OAuth2.Yahoo.ClientID)
+	params.Add("redirect_uri", s.config.OAuth2.Yahoo.RedirectURL)
+	params.Add("response_type", "code")
+	params.Add("scope", yahooScopes)
+	params.Add("state", state)
+
+	return fmt.Sprintf("%s?%s", yahooAuthURL, params.Encode())
 }
 
-// GetUserInfo - Récupérer les informations utilisateur depuis Google
-func (s *OAuth2Service) GetUserInfo(accessToken string) (*GoogleUserInfo, error) {
-	req, err := http.NewRequest("GET", "https://www.googleapis.com/oauth2/v2/userinfo", nil)
+// ExchangeYahooCode - Échanger le code d'autorisation Yahoo contre des tokens
+func (s *OAuth2Service) ExchangeYahooCode(code string) (*models.OAuth2Token, error) {
+	data := url.Values{}
+	data.Set("client_id", s.config.OAuth2.Yahoo.ClientID)
+	data.Set("client_secret", s.config.OAuth2.Yahoo.ClientSecret)
+	data.Set("code", code)
+	data.Set("grant_type", "authorization_code")
+	data.Set("redirect_uri", s.config.OAuth2.Yahoo.RedirectURL)
+
+	return s.requestToken(yahooTokenURL, data, "")
+}
+
+// RefreshYahooToken - Rafraîchir un token Yahoo
+func (s *OAuth2Service) RefreshYahooToken(refreshToken string) (*models.OAuth2Token, error) {
+	data := url.Values{}
+	data.Set("client_id", s.config.OAuth2.Yahoo.ClientID)
+	data.Set("client_secret", s.config.OAuth2.Yahoo.ClientSecret)
+	data.Set("refresh_token", refreshToken)
+	data.Set("grant_type", "refresh_token")
+	data.Set("redirect_uri", s.config.OAuth2.Yahoo.RedirectURL)
+
+	return s.requestToken(yahooTokenURL, data, refreshToken)
+}
+
+// GetYahooUserInfo - Récupérer le profil OpenID Yahoo
+func (s *OAuth2Service) GetYahooUserInfo(accessToken string) (*YahooUserInfo, error) {
+	req, err := http.NewRequest("GET", yahooUserInfoURL, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -142,7 +274,7 @@ func (s *OAuth2Service) GetUserInfo(accessToken string) (*GoogleUserInfo, error)
 		return nil, fmt.Errorf("failed to get user info, status: %d", resp.StatusCode)
 	}
 
-	var userInfo GoogleUserInfo
+	var userInfo YahooUserInfo
 	if err := json.NewDecoder(resp.Body).Decode(&userInfo); err != nil {
 		return nil, fmt.Errorf("failed to decode user info: %w", err)
 	}
@@ -150,8 +282,59 @@ func (s *OAuth2Service) GetUserInfo(accessToken string) (*GoogleUserInfo, error)
 	return &userInfo, nil
 }
 
-type GoogleUserInfo struct {
-	ID    string `json:"id"`
+type YahooUserInfo struct {
+	Sub   string `json:"sub"`
 	Email string `json:"email"`
 	Name  string `json:"name"`
 }
+
+// requestToken - Appeler un endpoint token OAuth2 (code ou refresh)
+func (s *OAuth2Service) requestToken(tokenURL string, data url.Values, currentRefreshToken string) (*models.OAuth2Token, error) {
+	if s.tokenURL != "" {
+		tokenURL = s.tokenURL
+	}
+	resp, err := http.PostForm(tokenURL, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errorResponse struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(body, &errorResponse) == nil && errorResponse.Error == "invalid_grant" {
+			return nil, fmt.Errorf("%w: %s", ErrTokenRevoked, string(body))
+		}
+		return nil, fmt.Errorf("oauth2 error: %s", string(body))
+	}
+
+	var tokenResponse struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int    `json:"expires_in"`
+		TokenType    string `json:"token_type"`
+	}
+
+	if err := json.Unmarshal(body, &tokenResponse); err != nil {
+		return nil, fmt.Errorf("failed to parse token response: %w", err)
+	}
+
+	// Certains providers ne renvoient pas de nouveau refresh token lors d'un refresh
+	refreshToken := tokenResponse.RefreshToken
+	if refreshToken == "" {
+		refreshToken = currentRefreshToken
+	}
+
+	return &models.OAuth2Token{
+		AccessToken:  tokenResponse.AccessToken,
+		RefreshToken: refreshToken,
+		TokenType:    tokenResponse.TokenType,
+		Expiry:       time.Now().Add(time.Duration(tokenResponse.ExpiresIn) * time.Second),
+	}, nil
+}